@@ -0,0 +1,323 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2015
+//------------------------------------------------------------
+// command line driver (pmsim)
+// - usage: pmsim <command> [flags]
+// -- commands: run, simulate, check, replay, latex, validate
+// -- replay: reproduces the path of a replay trace file (-replay_file), eg the counterexample found by check
+// -- snapshots: -snapshot_file and -snapshot_time write the status of the first run to a file, and
+//    -restore_file lets the runs start from it (see framework: snapshot)
+// -- debugger: -debugger stdin or -debugger <host>:<port> pauses the controller before the critical
+//    sections and reads commands (see framework: debugger)
+// -- http api: -http_api localhost:<port> serves json views of the running simulation and lets entries be
+//    injected into PICs (see framework: httpApi)
+// -- metrics: -metrics_file <base> writes per wiring and per container metrics of every run as csv time series
+//    and in prometheus text format (see pmModel: metrics)
+// -- simulate: the observables of the model are aggregated over the runs; -simulation_precision stops the runs
+//    as soon as their confidence intervals are narrow enough (see framework: runStatistics)
+// -- the use case is either a model file (-model) or a registered use case (-usecase, see RegisterUseCase)
+// -- runtime settings: -config file, environment (PM_...) and one flag per config key (see config.BindFlags);
+//    the command determines the verification mode
+// - own drivers with compiled in use cases: register them and call Main, eg:
+// -- RegisterUseCase("myUseCase", InitMyUseCase)
+// -- os.Exit(cli.Main(os.Args[1:]))
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package cli
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/framework"
+	. "github.com/peermodel/simulator/latex"
+	. "github.com/peermodel/simulator/pmAutomata"
+	. "github.com/peermodel/simulator/pmModel"
+	. "github.com/peermodel/simulator/runtime"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////
+// consts
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// exit codes
+// - nb: a model, eval or internal error of a run (see debug: errors.go) stops the run and ends with EXIT_ERROR
+const (
+	EXIT_OK                  int = 0
+	EXIT_ERROR               int = 1
+	EXIT_INVARIANT_VIOLATION int = 3
+	EXIT_DEADLOCK            int = 4
+	EXIT_LIVELOCK            int = 5
+	EXIT_PROPERTY_VIOLATION  int = 6
+)
+
+//------------------------------------------------------------
+// commands
+const (
+	RUN_CMD      string = "run"
+	SIMULATE_CMD string = "simulate"
+	CHECK_CMD    string = "check"
+	REPLAY_CMD   string = "replay"
+	LATEX_CMD    string = "latex"
+	VALIDATE_CMD string = "validate"
+)
+
+//////////////////////////////////////////////////////////////
+// vars
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// output of the driver's own messages
+var STDOUT io.Writer = os.Stdout
+var STDERR io.Writer = os.Stderr
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// run the command given by args (without program name) and return the exit code
+func Main(args []string) int {
+	//------------------------------------------------------------
+	// command
+	if 0 == len(args) || "-h" == args[0] || "-help" == args[0] || "help" == args[0] {
+		usage()
+		return EXIT_ERROR
+	}
+	cmd := args[0]
+	switch cmd {
+	case RUN_CMD, SIMULATE_CMD, CHECK_CMD, REPLAY_CMD, LATEX_CMD, VALIDATE_CMD:
+	default:
+		fmt.Fprintf(STDERR, "pmsim: unknown command \"%s\"\n", cmd)
+		usage()
+		return EXIT_ERROR
+	}
+	//------------------------------------------------------------
+	// flags
+	// - config flags are bound to a scratch config; only the ones that were set are applied after the config file
+	fs := flag.NewFlagSet("pmsim "+cmd, flag.ContinueOnError)
+	fs.SetOutput(STDERR)
+	modelPath := fs.String("model", "", "model file (.json, .yaml, .yml)")
+	useCaseName := fs.String("usecase", "", "name of a registered use case")
+	configPath := fs.String("config", "", "config file (.json, .yaml, .yml)")
+	testCaseName := fs.String("name", "", "test case name (default: model name, use case name or file name)")
+	flagCfg := NewConfig()
+	flagCfg.BindFlags(fs)
+	if err := fs.Parse(args[1:]); nil != err {
+		return EXIT_ERROR
+	}
+	if 0 < fs.NArg() {
+		fmt.Fprintf(STDERR, "pmsim: unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return EXIT_ERROR
+	}
+	//------------------------------------------------------------
+	// config: defaults < file < environment < flags < command
+	cfg, err := LoadConfig(*configPath)
+	if nil != err {
+		fmt.Fprintf(STDERR, "pmsim: %s\n", err)
+		return EXIT_ERROR
+	}
+	// - every config flag given is applied as given, also an empty value, eg to clear a list of the file
+	fs.Visit(func(f *flag.Flag) {
+		if flagCfg.IsSet(f.Name) {
+			// nb: the value has already been checked by the flag parser; the other flags are no config keys
+			cfg.Set(f.Name, flagCfg.Get(f.Name))
+		}
+	})
+	switch cmd {
+	case RUN_CMD:
+		cfg.VerificationMode = ONE_RUN
+	case SIMULATE_CMD:
+		cfg.VerificationMode = SIMULATION
+	case CHECK_CMD:
+		cfg.VerificationMode = MODEL_CHECKING
+	case REPLAY_CMD:
+		cfg.VerificationMode = REPLAY
+	}
+	if err := cfg.Validate(); nil != err {
+		fmt.Fprintf(STDERR, "pmsim: %s\n", err)
+		return EXIT_ERROR
+	}
+	// - the replay trace is checked before the use case is initialized, like the other config values
+	if REPLAY == cfg.VerificationMode {
+		if _, err := LoadReplayTrace(cfg.ReplayFile); nil != err {
+			fmt.Fprintf(STDERR, "pmsim: %s\n", err)
+			return EXIT_ERROR
+		}
+	}
+	// - the status is created with the configured system ttl
+	cfg.Apply()
+	//------------------------------------------------------------
+	// use case
+	if ("" == *modelPath) == ("" == *useCaseName) {
+		fmt.Fprintf(STDERR, "pmsim: exactly one of -model and -usecase must be given\n")
+		return EXIT_ERROR
+	}
+	var s *Status
+	name := *testCaseName
+	if "" != *modelPath {
+		mf, err := LoadModelFile(*modelPath)
+		if nil != err {
+			fmt.Fprintf(STDERR, "pmsim: %s\n", err)
+			return EXIT_ERROR
+		}
+		if "" == name {
+			name = mf.Name
+		}
+		if "" == name {
+			name = strings.TrimSuffix(filepath.Base(*modelPath), filepath.Ext(*modelPath))
+		}
+		if s, err = NewPeerModelAutomataGenerator().InitModelFileUseCase(mf); nil != err {
+			fmt.Fprintf(STDERR, "pmsim: %s: %s\n", *modelPath, err)
+			return EXIT_ERROR
+		}
+	} else {
+		initAppUseCaseFu := USE_CASES[*useCaseName]
+		if nil == initAppUseCaseFu {
+			fmt.Fprintf(STDERR, "pmsim: use case \"%s\" is not registered (registered: %s)\n", *useCaseName, strings.Join(useCaseNames(), ", "))
+			return EXIT_ERROR
+		}
+		if "" == name {
+			name = *useCaseName
+		}
+		s = initAppUseCaseFu()
+	}
+	//------------------------------------------------------------
+	// meta model check
+	// - validate reports all results; the other commands that run report the errors only and do not run then
+	var results CheckResults
+	if metaCtx, ok := s.MetaContext.(*MetaContext); ok {
+		results = metaCtx.PeerSpace.CheckMetaModel()
+	}
+	//------------------------------------------------------------
+	// execute command
+	switch cmd {
+	case LATEX_CMD:
+		DebugInit()
+		latexConfig := NewLatexConfig()
+		s.MetaContext.MetaModel2Latex(name, &latexConfig)
+		fmt.Fprintf(STDOUT, "pmsim: %s: latex written to %s\n", name, LATEX_FILE_NAME)
+		return EXIT_OK
+	case VALIDATE_CMD:
+		for _, r := range results {
+			fmt.Fprintf(STDERR, "pmsim: %s: %s\n", name, r)
+		}
+		if results.HasErrors() {
+			return EXIT_ERROR
+		}
+		fmt.Fprintf(STDOUT, "pmsim: %s: ok (%s)\n", name, cfg)
+		return EXIT_OK
+	}
+	if results.HasErrors() {
+		for _, r := range results {
+			if CHECK_ERROR == r.Severity {
+				fmt.Fprintf(STDERR, "pmsim: %s: %s\n", name, r)
+			}
+		}
+		return EXIT_ERROR
+	}
+	verdict, err := RunWithConfig(s, name, NewLatexConfig(), cfg)
+	if nil != err {
+		fmt.Fprintf(STDERR, "pmsim: %s: %s\n", name, err)
+		return EXIT_ERROR
+	}
+	if CHECK_CMD == cmd {
+		fmt.Fprintf(STDOUT, "pmsim: %s: %d runs, %d states explored, %d states pruned\n", name, RUN_COUNT, MC_VARS.StatesExplored, MC_VARS.StatesPruned)
+	}
+	return verdict2ExitCode(name, verdict)
+}
+
+//------------------------------------------------------------
+// map verdict to exit code and report it
+func verdict2ExitCode(name string, verdict VerdictTypeEnum) int {
+	switch verdict {
+	case NO_VIOLATION:
+		fmt.Fprintf(STDOUT, "pmsim: %s: %s\n", name, verdict)
+		return EXIT_OK
+	case INVARIANT_VIOLATION:
+		fmt.Fprintf(STDOUT, "pmsim: %s: %s: %s\n", name, verdict, VERDICT_MSG)
+		printCounterexample()
+		return EXIT_INVARIANT_VIOLATION
+	case DEADLOCK:
+		fmt.Fprintf(STDOUT, "pmsim: %s: %s: %s\n", name, verdict, VERDICT_MSG)
+		printCounterexample()
+		return EXIT_DEADLOCK
+	case LIVELOCK:
+		fmt.Fprintf(STDOUT, "pmsim: %s: %s: %s\n", name, verdict, VERDICT_MSG)
+		printCounterexample()
+		return EXIT_LIVELOCK
+	case PROPERTY_VIOLATION:
+		fmt.Fprintf(STDOUT, "pmsim: %s: %s: %s\n", name, verdict, VERDICT_MSG)
+		printCounterexample()
+		return EXIT_PROPERTY_VIOLATION
+	case REPLAY_DIVERGENCE:
+		fmt.Fprintf(STDOUT, "pmsim: %s: %s: %s\n", name, verdict, VERDICT_MSG)
+		return EXIT_ERROR
+	default:
+		fmt.Fprintf(STDOUT, "pmsim: %s: %s\n", name, verdict)
+		return EXIT_ERROR
+	}
+}
+
+//------------------------------------------------------------
+// path that led to the violation
+func printCounterexample() {
+	fmt.Fprintf(STDOUT, "counterexample (%d steps, * = choice, loop = lasso):\n%s", len(COUNTEREXAMPLE), COUNTEREXAMPLE.ToString(2))
+	fmt.Fprintf(STDOUT, "replay trace written to %s (reproduce with: pmsim %s -%s <copy of it>)\n", REPLAY_TRACE_FILE_NAME, REPLAY_CMD, REPLAY_FILE_KEY)
+}
+
+//------------------------------------------------------------
+func useCaseNames() []string {
+	names := []string{}
+	for name := range USE_CASES {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//------------------------------------------------------------
+func usage() {
+	fmt.Fprintf(STDERR, "usage: pmsim <command> (-model <file> | -usecase <name>) [-config <file>] [-name <name>] [config flags]\n")
+	fmt.Fprintf(STDERR, "commands:\n")
+	fmt.Fprintf(STDERR, "  %-9s run the use case once\n", RUN_CMD)
+	fmt.Fprintf(STDERR, "  %-9s run %s simulation runs\n", SIMULATE_CMD, SIMULATION_COUNT_KEY)
+	fmt.Fprintf(STDERR, "  %-9s model check the use case (bounded by %s)\n", CHECK_CMD, MC_BOUND_KEY)
+	fmt.Fprintf(STDERR, "  %-9s run the use case once along the path in -%s\n", REPLAY_CMD, REPLAY_FILE_KEY)
+	fmt.Fprintf(STDERR, "  %-9s write the latex documentation of the meta model\n", LATEX_CMD)
+	fmt.Fprintf(STDERR, "  %-9s check config and meta model without running\n", VALIDATE_CMD)
+	fmt.Fprintf(STDERR, "config flags: -%s\n", strings.Join(CONFIG_KEYS, ", -"))
+	fmt.Fprintf(STDERR, "exit codes: %d ok, %d error, %d invariant violation, %d deadlock, %d livelock, %d property violation\n", EXIT_OK, EXIT_ERROR, EXIT_INVARIANT_VIOLATION, EXIT_DEADLOCK, EXIT_LIVELOCK, EXIT_PROPERTY_VIOLATION)
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
@@ -0,0 +1,641 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// end to end tests of the command line driver with the models in testdata
+// - every command runs in a fresh temp dir, because the runtime writes its trace files into the working dir
+// - the applied config is restored after every command
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package cli
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/framework"
+	. "github.com/peermodel/simulator/pmModel"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// result of a command
+type pmsimResult struct {
+	exitCode int
+	// messages of the driver (see STDOUT and STDERR)
+	out string
+	// the working dir of the command
+	dir string
+}
+
+//------------------------------------------------------------
+// absolute path of a model in testdata
+func testModel(t *testing.T, name string) string {
+	path, err := filepath.Abs(filepath.Join("testdata", name))
+	if nil != err {
+		t.Fatal(err)
+	}
+	return path
+}
+
+//------------------------------------------------------------
+// run pmsim with the args in a fresh temp dir
+// - the trace output of the runtime (on stdout, see debug: TRACE_FILE) goes to a file in that dir
+// - the ids are counted from 0 as in a fresh process
+func runPmsim(t *testing.T, args ...string) pmsimResult {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "pmsim_test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	wd, err := os.Getwd()
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); nil != err {
+		t.Fatal(err)
+	}
+	stdout, err := os.Create(filepath.Join(dir, "stdout.log"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	prevCfg, prevStdout, prevTraceFile, prevSTDOUT, prevSTDERR := CurrentConfig(), os.Stdout, TRACE_FILE, STDOUT, STDERR
+	var out bytes.Buffer
+	os.Stdout, TRACE_FILE, STDOUT, STDERR = stdout, stdout, &out, &out
+	defer func() {
+		os.Stdout, TRACE_FILE, STDOUT, STDERR = prevStdout, prevTraceFile, prevSTDOUT, prevSTDERR
+		stdout.Close()
+		prevCfg.Apply()
+		os.Chdir(wd)
+	}()
+	MACHINE_ID, UUID, ENTRY_VERSION, FID_CNT, UUID_CNT = 0, 0, 0, 0, 0
+	exitCode := Main(args)
+	return pmsimResult{exitCode: exitCode, out: out.String(), dir: dir}
+}
+
+//------------------------------------------------------------
+// the command must end with the exit code and its messages must match all patterns
+func expectPmsim(t *testing.T, r pmsimResult, exitCode int, patterns ...string) {
+	t.Helper()
+	if exitCode != r.exitCode {
+		t.Fatalf("exit code %d expected, got %d:\n%s", exitCode, r.exitCode, r.out)
+	}
+	for _, pattern := range patterns {
+		if !regexp.MustCompile(pattern).MatchString(r.out) {
+			t.Fatalf("%q expected in:\n%s", pattern, r.out)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// number of explored and pruned states reported by check
+func checkStatistics(t *testing.T, r pmsimResult) (int, int) {
+	t.Helper()
+	match := regexp.MustCompile(`(\d+) states explored, (\d+) states pruned`).FindStringSubmatch(r.out)
+	if nil == match {
+		t.Fatalf("state statistics expected in:\n%s", r.out)
+	}
+	explored, _ := strconv.Atoi(match[1])
+	pruned, _ := strconv.Atoi(match[2])
+	return explored, pruned
+}
+
+//------------------------------------------------------------
+// content of a file written by a command into its working dir
+func readResultFile(t *testing.T, r pmsimResult, name string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join(r.dir, name))
+	if nil != err {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+//------------------------------------------------------------
+// the records of an event log without their sequence numbers
+var eventSeqPattern = regexp.MustCompile(`"seq":\d+,`)
+
+func eventRecords(log string) []string {
+	return strings.Split(strings.TrimSpace(eventSeqPattern.ReplaceAllString(log, "")), "\n")
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// wrong command lines are refused with their reason before anything runs
+func TestCommandLineErrors(t *testing.T) {
+	model := testModel(t, "loop.yaml")
+	tests := []struct {
+		args    []string
+		pattern string
+	}{
+		{[]string{}, "usage: pmsim <command>"},
+		{[]string{"walk", "-model", model}, `unknown command "walk"`},
+		{[]string{"run"}, "exactly one of -model and -usecase must be given"},
+		{[]string{"run", "-model", model, "-usecase", "loop"}, "exactly one of -model and -usecase must be given"},
+		{[]string{"run", "-usecase", "noSuchUseCase"}, `use case "noSuchUseCase" is not registered`},
+		{[]string{"run", "-model", model, "loop"}, "unexpected arguments: loop"},
+		{[]string{"run", "-model", model, "-executor", "THREADS"}, "executor"},
+		{[]string{"run", "-model", model, "-livelock_bound", "3"}, "livelock_bound > 0 requires goal_containers"},
+		{[]string{"replay", "-model", model}, "REPLAY requires replay_file"},
+		{[]string{"run", "-model", testModel(t, "noSuchModel.yaml")}, "noSuchModel.yaml"},
+	}
+	for _, test := range tests {
+		r := runPmsim(t, test.args...)
+		expectPmsim(t, r, EXIT_ERROR, test.pattern)
+	}
+}
+
+//------------------------------------------------------------
+// config: defaults < file < environment < flags < command; a flag is applied as given, also if it is empty
+func TestConfigPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pmsim_test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	configPath := filepath.Join(dir, "c.yaml")
+	if err := ioutil.WriteFile(configPath, []byte("system_ttl: 5\nseed: 3\nevent_log_file: events.log\nverification_mode: SIMULATION\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	args := []string{"validate", "-model", testModel(t, "loop.yaml"), "-config", configPath}
+	r := runPmsim(t, args...)
+	expectPmsim(t, r, EXIT_OK, "system_ttl=5,", "seed=3,", "event_log_file=events.log,", "verification_mode=SIMULATION,")
+	os.Setenv(ENV_PREFIX+"SYSTEM_TTL", "6")
+	defer os.Unsetenv(ENV_PREFIX + "SYSTEM_TTL")
+	r = runPmsim(t, args...)
+	expectPmsim(t, r, EXIT_OK, "system_ttl=6,", "seed=3,")
+	r = runPmsim(t, append(args, "-system_ttl", "7", "-event_log_file", "")...)
+	expectPmsim(t, r, EXIT_OK, "system_ttl=7,", "seed=3,", "event_log_file=,")
+	// - the command determines the verification mode
+	r = runPmsim(t, "check", "-model", testModel(t, "loop.yaml"), "-config", configPath)
+	expectPmsim(t, r, EXIT_OK, "^pmsim: loop: \\d+ runs, \\d+ states explored")
+	// - the cleared event log file is not written
+	r = runPmsim(t, "run", "-model", testModel(t, "loop.yaml"), "-config", configPath, "-event_log_file", "")
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+	if _, err := os.Stat(filepath.Join(r.dir, "events.log")); !os.IsNotExist(err) {
+		t.Fatalf("no event log expected: %v", err)
+	}
+}
+
+//------------------------------------------------------------
+// latex writes the documentation of the meta model without running it
+func TestLatex(t *testing.T) {
+	r := runPmsim(t, "latex", "-model", testModel(t, "loop.yaml"), "-name", "myLoop")
+	expectPmsim(t, r, EXIT_OK, "^pmsim: myLoop: latex written to "+LATEX_FILE_NAME)
+	if tex := readResultFile(t, r, LATEX_FILE_NAME); !strings.Contains(tex, "W1") {
+		t.Fatalf("latex of the wirings expected:\n%s", tex)
+	}
+}
+
+//------------------------------------------------------------
+// the two entries move between PIC and POC forever: the states repeat and are pruned
+func TestCheckPrunesVisitedStates(t *testing.T) {
+	r := runPmsim(t, "check", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30")
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+	explored, pruned := checkStatistics(t, r)
+	if 0 == explored || 0 == pruned {
+		t.Fatalf("explored and pruned states expected, got %d explored, %d pruned", explored, pruned)
+	}
+}
+
+//------------------------------------------------------------
+// several commands in one process: a run leaves no clocks behind that the next status is created with
+func TestConsecutiveRuns(t *testing.T) {
+	expected := "DEADLOCK: no machine is enabled and no time event is pending \\(t=2, et=2\\)"
+	r := runPmsim(t, "run", "-model", testModel(t, "lock.yaml"), "-deadlock_detection", "true")
+	expectPmsim(t, r, EXIT_DEADLOCK, expected)
+	r = runPmsim(t, "run", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30")
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+	r = runPmsim(t, "run", "-model", testModel(t, "lock.yaml"), "-deadlock_detection", "true")
+	expectPmsim(t, r, EXIT_DEADLOCK, expected)
+}
+
+//------------------------------------------------------------
+// an eval error of a run is returned with its context; it stops the run, but not the process, ie the next run works
+func TestErrorInRun(t *testing.T) {
+	for _, executor := range []string{"SEQUENTIAL", "GOROUTINES"} {
+		r := runPmsim(t, "run", "-model", testModel(t, "evalerror.yaml"), "-executor", executor)
+		expectPmsim(t, r, EXIT_ERROR, `^pmsim: evalerror: eval error: peer P1: wiring P1_W1: link 0: machine PccRead__\S+: Eval: LABEL "x": undefined entry property = x;`)
+		if strings.Contains(r.out, "internal error") {
+			t.Fatalf("%s: no internal error expected:\n%s", executor, r.out)
+		}
+		r = runPmsim(t, "run", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30", "-executor", executor)
+		expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+	}
+}
+
+//------------------------------------------------------------
+// the entry that P1 waits for is never written: no machine is enabled and no time event is pending
+func TestDeadlockVerdict(t *testing.T) {
+	for _, command := range []string{"run", "check"} {
+		r := runPmsim(t, command, "-model", testModel(t, "lock.yaml"), "-deadlock_detection", "true")
+		expectPmsim(t, r, EXIT_DEADLOCK, "DEADLOCK: no machine is enabled")
+	}
+	// - without the detection the run just ends
+	r := runPmsim(t, "run", "-model", testModel(t, "lock.yaml"))
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+}
+
+//------------------------------------------------------------
+// P1 moves both entries into its POC, which may hold only one: the run stops with the name of the invariant
+// and the clock in all modes
+// - sequential: the clock of the violation depends on the order of the machines
+func TestInvariantVerdict(t *testing.T) {
+	for _, command := range []string{"run", "simulate", "check"} {
+		r := runPmsim(t, command, "-model", testModel(t, "invariant.yaml"), "-system_ttl", "10", "-simulation_count", "3", "-executor", "SEQUENTIAL")
+		expectPmsim(t, r, EXIT_INVARIANT_VIOLATION,
+			`INVARIANT_VIOLATION: invariant oneInPoc violated: 2 entries in P1_POC of type A, allowed: 0\.\.1 \(t=3\)`)
+		// - the trace shows the space of the violation
+		dump := regexp.MustCompile(`INVARIANT VIOLATION: invariant oneInPoc violated: .*, t=3, et=\d+\n\s*-+ SPACE at CLOCK=3 -+\n` +
+			`\s*P1_PIC updateEvtTime=\d+ = \{\}\n\s*P1_POC updateEvtTime=\d+ = \{\n\s*<Id=e1, type=A>, \n\s*<Id=e2, type=A, tts=4>\}`)
+		if out := readResultFile(t, r, "stdout.log"); !dump.MatchString(out) {
+			t.Fatalf("%s: space dump of the violation expected in:\n%s", command, out)
+		}
+	}
+	// - before the 2nd entry arrives the invariants hold
+	r := runPmsim(t, "run", "-model", testModel(t, "invariant.yaml"), "-system_ttl", "2", "-executor", "SEQUENTIAL")
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+}
+
+//------------------------------------------------------------
+// the entries move between PIC and POC forever, but never reach the goal container
+// - the livelock bound counts the wiring firings, not the critical sections: W1 commits into the POC whenever it
+//   fires, which is progress with the POC as goal container, also with a small bound
+func TestLivelockVerdict(t *testing.T) {
+	r := runPmsim(t, "run", "-model", testModel(t, "loop.yaml"), "-livelock_bound", "1000", "-goal_containers", "P9_PIC")
+	expectPmsim(t, r, EXIT_LIVELOCK, `LIVELOCK: cycle of \d+ critical sections without progress in goal containers \[P9_PIC\]`)
+	for _, bound := range []string{"2", "3", "5", "20", "100"} {
+		r = runPmsim(t, "run", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30", "-livelock_bound", bound, "-goal_containers", "P1_POC")
+		expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+	}
+	// - but a bound of 1 firing: W2 takes from the POC and commits into the PIC
+	r = runPmsim(t, "run", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30", "-livelock_bound", "1", "-goal_containers", "P1_POC")
+	expectPmsim(t, r, EXIT_LIVELOCK, `LIVELOCK: 1 wiring firings without progress in goal containers \[P1_POC\]`)
+}
+
+//------------------------------------------------------------
+// the A leaves the PIC of P1: always violated, eventually in its POC holds
+func TestPropertyVerdict(t *testing.T) {
+	r := runPmsim(t, "check", "-model", testModel(t, "prop.yaml"))
+	expectPmsim(t, r, EXIT_PROPERTY_VIOLATION, `PROPERTY_VIOLATION: property alwaysInPic violated: always\(A_in_P1_PIC\)`)
+	r = runPmsim(t, "check", "-model", testModel(t, "propok.yaml"))
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+}
+
+//------------------------------------------------------------
+// both executors give the same verdicts
+// - the sequential executor is deterministic: its messages and event logs do not change from run to run
+// - nb: with goroutines, the order in which the machines reach the controller may differ
+func TestExecutorEquivalence(t *testing.T) {
+	tests := [][]string{
+		{"run", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30", "-event_log_file", "events.log"},
+		{"run", "-model", testModel(t, "lock.yaml"), "-deadlock_detection", "true", "-event_log_file", "events.log"},
+		{"check", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30", "-event_log_file", "events.log"},
+		{"check", "-model", testModel(t, "prop.yaml"), "-event_log_file", "events.log"},
+	}
+	for _, args := range tests {
+		seq1 := runPmsim(t, append(args, "-executor", "SEQUENTIAL")...)
+		seq2 := runPmsim(t, append(args, "-executor", "SEQUENTIAL")...)
+		if seq1.exitCode != seq2.exitCode || seq1.out != seq2.out {
+			t.Errorf("%v: equal results expected:\n%d: %s\n%d: %s", args, seq1.exitCode, seq1.out, seq2.exitCode, seq2.out)
+		} else if readResultFile(t, seq1, "events.log") != readResultFile(t, seq2, "events.log") {
+			t.Errorf("%v: equal event logs expected", args)
+		}
+		if gor := runPmsim(t, append(args, "-executor", "GOROUTINES")...); seq1.exitCode != gor.exitCode {
+			t.Errorf("%v: equal verdicts expected:\n%d: %s\n%d: %s", args, seq1.exitCode, seq1.out, gor.exitCode, gor.out)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// the metrics of a tiny model: the time series (csv) and the final values (prometheus) are as in the golden files
+// - P1 moves the two entries from PIC to POC at t=2 and t=3 (tts of the 2nd entry)
+func TestMetricsGolden(t *testing.T) {
+	r := runPmsim(t, "run", "-model", testModel(t, "metrics.yaml"), "-system_ttl", "4", "-executor", "SEQUENTIAL", "-metrics_file", "m")
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+	for _, ext := range []string{"csv", "prom"} {
+		golden, err := ioutil.ReadFile(filepath.Join("testdata", "metrics."+ext+".golden"))
+		if nil != err {
+			t.Fatal(err)
+		}
+		if got := readResultFile(t, r, "m_1."+ext); string(golden) != got {
+			t.Errorf("%s differs from testdata/metrics.%s.golden:\n%s", ext, ext, got)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// a run restored from a snapshot continues like the run of the snapshot
+func TestSnapshotRoundTrip(t *testing.T) {
+	args := []string{"run", "-model", testModel(t, "loop.yaml"), "-system_ttl", "30", "-executor", "SEQUENTIAL", "-event_log_file", "events.log"}
+	r1 := runPmsim(t, append(args, "-snapshot_file", "snap.json", "-snapshot_time", "5")...)
+	expectPmsim(t, r1, EXIT_OK, "NO_VIOLATION")
+	snapFile := filepath.Join(r1.dir, "snap.json")
+	snap, err := LoadSnapshot(snapFile)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 5 != snap.Clock || 0 == len(snap.Machines) || 0 == len(snap.Slots) {
+		t.Fatalf("snapshot at t=5 with machines and slots expected, got t=%d, %d machines, %d slots", snap.Clock, len(snap.Machines), len(snap.Slots))
+	}
+	// - written again, it is the same file
+	snapFile2 := filepath.Join(r1.dir, "snap2.json")
+	if err := snap.Write(snapFile2); nil != err {
+		t.Fatal(err)
+	}
+	if readResultFile(t, r1, "snap.json") != readResultFile(t, r1, "snap2.json") {
+		t.Fatalf("equal snapshot files expected")
+	}
+	// - the restored run logs the same events as the run of the snapshot from the snapshot on
+	// -- nb: except its start record
+	r2 := runPmsim(t, append(args, "-restore_file", snapFile)...)
+	expectPmsim(t, r2, EXIT_OK, "NO_VIOLATION")
+	events1, events2 := eventRecords(readResultFile(t, r1, "events.log")), eventRecords(readResultFile(t, r2, "events.log"))
+	if 2 > len(events2) || len(events2) > len(events1) {
+		t.Fatalf("%d events of the restored run, %d of the run of the snapshot", len(events2), len(events1))
+	}
+	events1 = events1[len(events1)-len(events2)+1:]
+	for i, event := range events2[1:] {
+		if events1[i] != event {
+			t.Fatalf("event %d of the restored run differs:\n%s\n%s", i+2, events1[i], event)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// the times are drawn from distributions: the same seed gives the same runs, and the replay trace of the
+// counterexample reproduces it
+func TestStochasticReplay(t *testing.T) {
+	args := []string{"-model", testModel(t, "stoch.yaml"), "-system_ttl", "60", "-executor", "SEQUENTIAL"}
+	simulate := append([]string{"simulate", "-simulation_count", "5", "-seed", "7"}, args...)
+	r1 := runPmsim(t, simulate...)
+	expectPmsim(t, r1, EXIT_INVARIANT_VIOLATION, "INVARIANT_VIOLATION: invariant bothInPoc violated", "counterexample")
+	if r := runPmsim(t, simulate...); r1.exitCode != r.exitCode || r1.out != r.out {
+		t.Fatalf("equal results of the same seed expected:\n%s\n%s", r1.out, r.out)
+	}
+	r2 := runPmsim(t, append([]string{"replay", "-replay_file", filepath.Join(r1.dir, "replay.log")}, args...)...)
+	if r1.exitCode != r2.exitCode || r1.out != r2.out {
+		t.Fatalf("the replay must reproduce the counterexample:\n%s\n%s", r1.out, r2.out)
+	}
+}
+
+//------------------------------------------------------------
+// the replay of the counterexample found by check ends with the same verdict and records the same path again;
+// a trace that the run cannot follow is reported as divergence
+func TestReplayCounterexample(t *testing.T) {
+	for _, name := range []string{"invariant.yaml", "prop.yaml"} {
+		args := []string{"-model", testModel(t, name), "-system_ttl", "10", "-executor", "SEQUENTIAL"}
+		r1 := runPmsim(t, append([]string{"check"}, args...)...)
+		if EXIT_OK == r1.exitCode {
+			t.Fatalf("%s: violation expected:\n%s", name, r1.out)
+		}
+		trace := readResultFile(t, r1, "replay.log")
+		r2 := runPmsim(t, append([]string{"replay", "-replay_file", filepath.Join(r1.dir, "replay.log")}, args...)...)
+		verdict := regexp.MustCompile(`(?m)^pmsim: \w+: [A-Z_]+: .*$`)
+		if v := verdict.FindString(r1.out); "" == v || r1.exitCode != r2.exitCode || v != verdict.FindString(r2.out) {
+			t.Fatalf("%s: the replay must end with the verdict of check:\n%s\n%s", name, r1.out, r2.out)
+		}
+		if got := readResultFile(t, r2, "replay.log"); trace != got {
+			t.Fatalf("%s: the replay must record the path of check:\n%s\n%s", name, trace, got)
+		}
+	}
+	// - a machine of the trace that the model does not have
+	model := testModel(t, "invariant.yaml")
+	trace := "RUN 1\nSTEP 0 0 0 Wiring__M1__Stop__Stop_W1\nSTEP 1 1 0 Wiring__M0__P1__P1_W9\nEND\n"
+	dir, err := ioutil.TempDir("", "pmsim_test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	replayFile := filepath.Join(dir, "replay.log")
+	if err := ioutil.WriteFile(replayFile, []byte(trace), 0644); nil != err {
+		t.Fatal(err)
+	}
+	r := runPmsim(t, "replay", "-replay_file", replayFile, "-model", model, "-executor", "SEQUENTIAL")
+	expectPmsim(t, r, EXIT_ERROR, `REPLAY_DIVERGENCE: step 2: machine Wiring__M0__P1__P1_W9 does not exist \(t=1\)`)
+	// - an incomplete trace is refused before the run
+	if err := ioutil.WriteFile(replayFile, []byte("RUN 1\n"), 0644); nil != err {
+		t.Fatal(err)
+	}
+	r = runPmsim(t, "replay", "-replay_file", replayFile, "-model", model)
+	expectPmsim(t, r, EXIT_ERROR, "replay trace: .*: no complete run found")
+}
+
+//------------------------------------------------------------
+// in execution mode RANDOM every simulation run is another interleaving, which only depends on the seed
+// - the interleaving of a run: its takes, tx records and exceptions by time and wiring (the ids depend on the
+//   machines created by the runs before)
+func TestRandomExecutionMode(t *testing.T) {
+	interleaving := func(r pmsimResult, run int) string {
+		t.Helper()
+		steps := []string{}
+		for _, line := range eventRecords(readResultFile(t, r, "events.log")) {
+			var rec struct {
+				Type  string
+				Run   int
+				Clock int
+				Wid   string
+			}
+			if err := json.Unmarshal([]byte(line), &rec); nil != err {
+				t.Fatalf("%s: %s", err, line)
+			}
+			if run == rec.Run && ("take" == rec.Type || "exception" == rec.Type || strings.HasPrefix(rec.Type, "tx_")) {
+				steps = append(steps, fmt.Sprintf("%s %d %s", rec.Type, rec.Clock, rec.Wid))
+			}
+		}
+		return strings.Join(steps, "\n")
+	}
+	args := []string{"-model", testModel(t, "race.yaml"), "-system_ttl", "12", "-executor", "SEQUENTIAL", "-execution_mode", "RANDOM", "-event_log_file", "events.log"}
+	simulate := append([]string{"simulate", "-simulation_count", "6"}, args...)
+	r1 := runPmsim(t, append(simulate, "-seed", "3")...)
+	expectPmsim(t, r1, EXIT_OK, "NO_VIOLATION")
+	// - the same seed gives the same runs, another seed other ones
+	if r := runPmsim(t, append(simulate, "-seed", "3")...); readResultFile(t, r1, "events.log") != readResultFile(t, r, "events.log") {
+		t.Fatalf("equal event logs of the same seed expected")
+	}
+	if r := runPmsim(t, append(simulate, "-seed", "1")...); readResultFile(t, r1, "events.log") == readResultFile(t, r, "events.log") {
+		t.Fatalf("different event logs of different seeds expected")
+	}
+	// - the runs differ from each other
+	different := false
+	for run := 2; run <= 6; run++ {
+		different = different || interleaving(r1, 1) != interleaving(r1, run)
+	}
+	if !different {
+		t.Fatalf("different interleavings of the runs expected:\n%s", interleaving(r1, 1))
+	}
+	// - every run reports its seed, which is derived from the seed
+	trace := readResultFile(t, r1, "stdout.log")
+	for run := 1; run <= 6; run++ {
+		if banner := fmt.Sprintf("%d. MODEL SIMULATION RUN (seed=%d):", run, RunSeed(3, run)); !strings.Contains(trace, banner) {
+			t.Fatalf("%q expected in the trace", banner)
+		}
+	}
+	if seed := fmt.Sprintf("- seed: 3 (run seed: %d, ", RunSeed(3, 2)); !strings.Contains(trace, seed) {
+		t.Fatalf("%q expected in the statistics", seed)
+	}
+	// - a single run with the seed of the 2nd run repeats it
+	r2 := runPmsim(t, append([]string{"run", "-seed", strconv.FormatInt(RunSeed(3, 2), 10)}, args...)...)
+	expectPmsim(t, r2, EXIT_OK, "NO_VIOLATION")
+	if interleaving(r1, 2) != interleaving(r2, 1) {
+		t.Fatalf("the interleaving of run 2 expected:\n%s\ngot:\n%s", interleaving(r1, 2), interleaving(r2, 1))
+	}
+}
+
+//------------------------------------------------------------
+// all type errors are reported before the run starts
+func TestTypeErrorsBeforeRun(t *testing.T) {
+	r := runPmsim(t, "run", "-model", testModel(t, "illtyped.yaml"), "-event_log_file", "events.log")
+	expectPmsim(t, r, EXIT_ERROR, "6 type errors:", "wiring W1: wprops: tts", "wiring W2: link 0: count")
+	if _, err := os.Stat(filepath.Join(r.dir, "events.log")); !os.IsNotExist(err) {
+		t.Fatalf("no event log expected: %v", err)
+	}
+}
+
+//------------------------------------------------------------
+// validate reports all results of the meta model check in order; only errors fail
+func TestValidate(t *testing.T) {
+	r := runPmsim(t, "validate", "-model", testModel(t, "unchecked.yaml"))
+	expected := strings.Join([]string{
+		"pmsim: unchecked: error: entry e1 in P1_PIC: dest P8 is not a peer",
+		"pmsim: unchecked: error: peer P1: wiring W1: link 1: lprops: dest P9 is not a peer",
+		"pmsim: unchecked: warning: peer P1: wiring W1: link 1: last link does not commit",
+		"pmsim: unchecked: error: peer P1: wiring W2: link 1: sub peer S does not exist",
+		"pmsim: unchecked: error: peer P1: wiring W3: link 1: service S1 has no service wrapper",
+		"pmsim: unchecked: error: peer P1: wiring W3: no guard link",
+		"pmsim: unchecked: warning: peer P1: wiring W2: wiring is unreachable: entry type C of its guard is never produced",
+	}, "\n") + "\n"
+	if EXIT_ERROR != r.exitCode || expected != r.out {
+		t.Fatalf("exit code %d and results expected:\n%s\ngot %d:\n%s", EXIT_ERROR, expected, r.exitCode, r.out)
+	}
+	// - warnings only
+	r = runPmsim(t, "validate", "-model", testModel(t, "lock.yaml"))
+	expectPmsim(t, r, EXIT_OK, "warning: peer P1: wiring W1: link 1: entry type B is read, but never written",
+		"warning: peer P1: wiring W1: wiring is unreachable: entry type B of its guard is never produced", "lock: ok")
+	r = runPmsim(t, "validate", "-model", testModel(t, "loop.yaml"))
+	expectPmsim(t, r, EXIT_OK, "^pmsim: loop: ok")
+}
+
+//------------------------------------------------------------
+// the other commands report the errors of the meta model check and do not start the machines
+func TestMetaModelCheckBeforeRun(t *testing.T) {
+	for _, cmd := range []string{RUN_CMD, SIMULATE_CMD, CHECK_CMD} {
+		r := runPmsim(t, cmd, "-model", testModel(t, "unchecked.yaml"), "-event_log_file", "events.log")
+		expected := strings.Join([]string{
+			"pmsim: unchecked: error: entry e1 in P1_PIC: dest P8 is not a peer",
+			"pmsim: unchecked: error: peer P1: wiring W1: link 1: lprops: dest P9 is not a peer",
+			"pmsim: unchecked: error: peer P1: wiring W2: link 1: sub peer S does not exist",
+			"pmsim: unchecked: error: peer P1: wiring W3: link 1: service S1 has no service wrapper",
+			"pmsim: unchecked: error: peer P1: wiring W3: no guard link",
+		}, "\n") + "\n"
+		if EXIT_ERROR != r.exitCode || expected != r.out {
+			t.Fatalf("%s: exit code %d and errors expected:\n%s\ngot %d:\n%s", cmd, EXIT_ERROR, expected, r.exitCode, r.out)
+		}
+		if _, err := os.Stat(filepath.Join(r.dir, "events.log")); !os.IsNotExist(err) {
+			t.Fatalf("%s: no event log expected: %v", cmd, err)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// two occ txs take the same entry: the first to commit wins, the other one gets a tx conflict, is rolled back
+// and retries with a new tx
+// - the action links are delayed, so that both txs have taken the entry before either commits
+func TestOccRace(t *testing.T) {
+	r := runPmsim(t, "run", "-model", testModel(t, "race.yaml"), "-system_ttl", "12", "-executor", "SEQUENTIAL", "-event_log_file", "events.log")
+	expectPmsim(t, r, EXIT_OK, "NO_VIOLATION")
+	type record struct {
+		Type  string
+		Clock int
+		Wid   string
+		Cid   string
+		Eids  []string
+		Txid  string
+		Msg   string
+	}
+	takes, commits, rollbacks, exceptions := []record{}, []record{}, []record{}, []record{}
+	txCreates := map[string][]record{}
+	for _, line := range eventRecords(readResultFile(t, r, "events.log")) {
+		var rec record
+		if err := json.Unmarshal([]byte(line), &rec); nil != err {
+			t.Fatalf("%s: %s", err, line)
+		}
+		if !strings.HasPrefix(rec.Wid, "P1_") {
+			continue
+		}
+		switch {
+		case "take" == rec.Type && "P1_PIC" == rec.Cid:
+			takes = append(takes, rec)
+		case "tx_commit" == rec.Type:
+			commits = append(commits, rec)
+		case "tx_rollback" == rec.Type:
+			rollbacks = append(rollbacks, rec)
+		case "exception" == rec.Type:
+			exceptions = append(exceptions, rec)
+		case "tx_create" == rec.Type:
+			txCreates[rec.Wid] = append(txCreates[rec.Wid], rec)
+		}
+	}
+	if 2 != len(takes) || takes[0].Wid == takes[1].Wid || "[e1]" != fmt.Sprint(takes[0].Eids) || "[e1]" != fmt.Sprint(takes[1].Eids) {
+		t.Fatalf("both wirings must take e1: %v", takes)
+	}
+	if 1 != len(commits) || 1 != len(rollbacks) || commits[0].Wid == rollbacks[0].Wid {
+		t.Fatalf("one commit and one rollback of the other wiring expected: %v, %v", commits, rollbacks)
+	}
+	winner, loser := commits[0], rollbacks[0]
+	if loser.Clock < winner.Clock {
+		t.Fatalf("the rollback must follow the commit: %v, %v", winner, loser)
+	}
+	if 1 != len(exceptions) || loser.Wid != exceptions[0].Wid ||
+		"TX-CONFLICT: OCC: entry e1 in P1_PIC has been taken or changed by another tx" != exceptions[0].Msg {
+		t.Fatalf("tx conflict of %s expected: %v", loser.Txid, exceptions)
+	}
+	// - the loser retries with a new tx
+	retried := false
+	for _, rec := range txCreates[loser.Wid] {
+		retried = retried || (rec.Clock >= loser.Clock && rec.Txid != loser.Txid)
+	}
+	if !retried {
+		t.Fatalf("new tx of %s after the rollback expected: %v", loser.Wid, txCreates[loser.Wid])
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
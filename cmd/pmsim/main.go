@@ -0,0 +1,42 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2015
+//------------------------------------------------------------
+// pmsim command line tool; see package cli
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package main
+
+import (
+	"github.com/peermodel/simulator/cli"
+	"os"
+)
+
+//------------------------------------------------------------
+func main() {
+	os.Exit(cli.Main(os.Args[1:]))
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
@@ -0,0 +1,726 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2015, 2016
+//------------------------------------------------------------
+// run time configuration
+// - holds all knobs that were formerly compile time consts (see defines.go)
+// - sources (in the order in which they are usually applied):
+// -- defaults (NewConfig)
+// -- file (LoadFile): json (*.json) or yaml (*.yaml, *.yml) with one flat map of keys
+// -- environment (LoadEnv): ENV_PREFIX + key in upper case, eg PM_SYSTEM_TTL
+// -- command line flags (BindFlags): -system_ttl etc.
+// - keys are the same for all sources, eg: system_ttl, verification_mode
+// - Apply sets the config vars of defines.go; several configs can be applied one after the other
+// - a config knows which keys were set by a source (see IsSet), eg to tell a default from a configured value
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//////////////////////////////////////////////////////////////
+// consts
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// prefix of environment variables
+const ENV_PREFIX string = "PM_"
+
+//------------------------------------------------------------
+// config keys
+const (
+	SYSTEM_TTL_KEY                     string = "system_ttl"
+	TICK_FREQUENCY_KEY                 string = "tick_frequency"
+	SIMULATION_COUNT_KEY               string = "simulation_count"
+	MC_BOUND_KEY                       string = "mc_bound"
+	VERIFICATION_MODE_KEY              string = "verification_mode"
+	EXECUTION_MODE_KEY                 string = "execution_mode"
+	MC_CP_KEY_SELECTION_CRITERION_KEY  string = "mc_cp_key_selection_criterion"
+	MC_CP_TIME_SELECTION_CRITERION_KEY string = "mc_cp_time_selection_criterion"
+	DEADLOCK_DETECTION_KEY             string = "deadlock_detection"
+	LIVELOCK_BOUND_KEY                 string = "livelock_bound"
+	GOAL_CONTAINERS_KEY                string = "goal_containers"
+	SEED_KEY                           string = "seed"
+	REPLAY_FILE_KEY                    string = "replay_file"
+	EVENT_LOG_FILE_KEY                 string = "event_log_file"
+	INDEX_LABELS_KEY                   string = "index_labels"
+	EXECUTOR_KEY                       string = "executor"
+	SNAPSHOT_FILE_KEY                  string = "snapshot_file"
+	SNAPSHOT_TIME_KEY                  string = "snapshot_time"
+	RESTORE_FILE_KEY                   string = "restore_file"
+	DEBUGGER_KEY                       string = "debugger"
+	HTTP_API_KEY                       string = "http_api"
+	METRICS_FILE_KEY                   string = "metrics_file"
+	SIMULATION_PRECISION_KEY           string = "simulation_precision"
+)
+
+//------------------------------------------------------------
+// all config keys in a fixed order
+var CONFIG_KEYS = []string{
+	SYSTEM_TTL_KEY,
+	TICK_FREQUENCY_KEY,
+	SIMULATION_COUNT_KEY,
+	MC_BOUND_KEY,
+	VERIFICATION_MODE_KEY,
+	EXECUTION_MODE_KEY,
+	MC_CP_KEY_SELECTION_CRITERION_KEY,
+	MC_CP_TIME_SELECTION_CRITERION_KEY,
+	DEADLOCK_DETECTION_KEY,
+	LIVELOCK_BOUND_KEY,
+	GOAL_CONTAINERS_KEY,
+	SEED_KEY,
+	REPLAY_FILE_KEY,
+	EVENT_LOG_FILE_KEY,
+	INDEX_LABELS_KEY,
+	EXECUTOR_KEY,
+	SNAPSHOT_FILE_KEY,
+	SNAPSHOT_TIME_KEY,
+	RESTORE_FILE_KEY,
+	DEBUGGER_KEY,
+	HTTP_API_KEY,
+	METRICS_FILE_KEY,
+	SIMULATION_PRECISION_KEY,
+}
+
+//////////////////////////////////////////////////////////////
+// data type
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// caution: keep up to date with the config vars in defines.go
+type Config struct {
+	SystemTtl                  int
+	TickFrequency              int
+	SimulationCount            int
+	McBound                    int
+	VerificationMode           VerificationTypeEnum
+	ExecutionMode              ExecutionTypeEnum
+	McCpKeySelectionCriterion  ChoiceSelectionCriterionTypeEnum
+	McCpTimeSelectionCriterion ChoiceSelectionCriterionTypeEnum
+	DeadlockDetection          bool
+	LivelockBound              int
+	GoalContainers             []string // comma separated in yaml, environment and flags; list in json
+	Seed                       int64
+	ReplayFile                 string
+	EventLogFile               string
+	IndexLabels                []string // comma separated in yaml, environment and flags; list in json
+	Executor                   ExecutorTypeEnum
+	SnapshotFile               string
+	SnapshotTime               int
+	RestoreFile                string
+	Debugger                   string
+	HttpApi                    string
+	MetricsFile                string
+	SimulationPrecision        float64
+	// keys set by a source (file, environment or flag; see Set), also if the value equals the default
+	setKeys map[string]bool
+}
+
+//////////////////////////////////////////////////////////////
+// constructors
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// create new config with default values
+func NewConfig() *Config {
+	//------------------------------------------------------------
+	// alloc
+	c := new(Config)
+	//------------------------------------------------------------
+	// init
+	c.SystemTtl = DEFAULT_SYSTEM_TTL
+	c.TickFrequency = DEFAULT_TICK_FREQUENCY
+	c.SimulationCount = DEFAULT_SIMULATION_COUNT
+	c.McBound = DEFAULT_MC_BOUND
+	c.VerificationMode = DEFAULT_VERIFICATION_MODE
+	c.ExecutionMode = DEFAULT_EXECUTION_MODE
+	c.McCpKeySelectionCriterion = DEFAULT_MC_CP_KEY_SELECTION_CRITERION
+	c.McCpTimeSelectionCriterion = DEFAULT_MC_CP_TIME_SELECTION_CRITERION
+	c.DeadlockDetection = DEFAULT_DEADLOCK_DETECTION
+	c.LivelockBound = DEFAULT_LIVELOCK_BOUND
+	c.GoalContainers = []string{}
+	c.Seed = DEFAULT_SEED
+	c.ReplayFile = DEFAULT_REPLAY_FILE
+	c.EventLogFile = DEFAULT_EVENT_LOG_FILE
+	c.IndexLabels = []string{}
+	c.Executor = DEFAULT_EXECUTOR
+	c.SnapshotFile = DEFAULT_SNAPSHOT_FILE
+	c.SnapshotTime = DEFAULT_SNAPSHOT_TIME
+	c.RestoreFile = DEFAULT_RESTORE_FILE
+	c.Debugger = DEFAULT_DEBUGGER
+	c.HttpApi = DEFAULT_HTTP_API
+	c.MetricsFile = DEFAULT_METRICS_FILE
+	c.SimulationPrecision = DEFAULT_SIMULATION_PRECISION
+	//------------------------------------------------------------
+	// return
+	return c
+}
+
+//------------------------------------------------------------
+// create new config from the currently applied config vars
+func CurrentConfig() *Config {
+	//------------------------------------------------------------
+	// alloc
+	c := new(Config)
+	//------------------------------------------------------------
+	// init
+	c.SystemTtl = SYSTEM_TTL
+	c.TickFrequency = TICK_FREQUENCY
+	c.SimulationCount = SIMULATION_COUNT
+	c.McBound = MC_BOUND
+	c.VerificationMode = VERIFICATION_MODE
+	c.ExecutionMode = EXECUTION_MODE
+	c.McCpKeySelectionCriterion = MC_CP_KEY_SELECTION_CRITERION
+	c.McCpTimeSelectionCriterion = MC_CP_TIME_SELECTION_CRITERION
+	c.DeadlockDetection = DEADLOCK_DETECTION
+	c.LivelockBound = LIVELOCK_BOUND
+	c.GoalContainers = append([]string{}, GOAL_CONTAINERS...)
+	c.Seed = SEED
+	c.ReplayFile = REPLAY_FILE
+	c.EventLogFile = EVENT_LOG_FILE
+	c.IndexLabels = append([]string{}, INDEX_LABELS...)
+	c.Executor = EXECUTOR
+	c.SnapshotFile = SNAPSHOT_FILE
+	c.SnapshotTime = SNAPSHOT_TIME
+	c.RestoreFile = RESTORE_FILE
+	c.Debugger = DEBUGGER
+	c.HttpApi = HTTP_API
+	c.MetricsFile = METRICS_FILE
+	c.SimulationPrecision = SIMULATION_PRECISION
+	c.setKeys = copySetKeys(SET_CONFIG_KEYS)
+	//------------------------------------------------------------
+	// return
+	return c
+}
+
+//------------------------------------------------------------
+// create new config: defaults, overwritten by file (if path is not empty), then by environment
+func LoadConfig(path string) (*Config, error) {
+	c := NewConfig()
+	if "" != path {
+		if err := c.LoadFile(path); nil != err {
+			return nil, err
+		}
+	}
+	if err := c.LoadEnv(); nil != err {
+		return nil, err
+	}
+	return c, nil
+}
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// copy
+func (c *Config) Copy() *Config {
+	newC := *c
+	newC.GoalContainers = append([]string{}, c.GoalContainers...)
+	newC.IndexLabels = append([]string{}, c.IndexLabels...)
+	newC.setKeys = copySetKeys(c.setKeys)
+	return &newC
+}
+
+//------------------------------------------------------------
+// set the config vars (see defines.go) to the values of this config
+// - the config must be valid
+func (c *Config) Apply() error {
+	if err := c.Validate(); nil != err {
+		return err
+	}
+	SYSTEM_TTL = c.SystemTtl
+	TICK_FREQUENCY = c.TickFrequency
+	SIMULATION_COUNT = c.SimulationCount
+	MC_BOUND = c.McBound
+	VERIFICATION_MODE = c.VerificationMode
+	EXECUTION_MODE = c.ExecutionMode
+	MC_CP_KEY_SELECTION_CRITERION = c.McCpKeySelectionCriterion
+	MC_CP_TIME_SELECTION_CRITERION = c.McCpTimeSelectionCriterion
+	DEADLOCK_DETECTION = c.DeadlockDetection
+	LIVELOCK_BOUND = c.LivelockBound
+	GOAL_CONTAINERS = append([]string{}, c.GoalContainers...)
+	SEED = c.Seed
+	REPLAY_FILE = c.ReplayFile
+	EVENT_LOG_FILE = c.EventLogFile
+	INDEX_LABELS = append([]string{}, c.IndexLabels...)
+	EXECUTOR = c.Executor
+	SNAPSHOT_FILE = c.SnapshotFile
+	SNAPSHOT_TIME = c.SnapshotTime
+	RESTORE_FILE = c.RestoreFile
+	DEBUGGER = c.Debugger
+	HTTP_API = c.HttpApi
+	METRICS_FILE = c.MetricsFile
+	SIMULATION_PRECISION = c.SimulationPrecision
+	SET_CONFIG_KEYS = copySetKeys(c.setKeys)
+	SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT = 0
+	GOAL_PROGRESS_COUNT = 0
+	WIRING_FIRING_COUNT = 0
+	return nil
+}
+
+//------------------------------------------------------------
+// check values and their combinations
+// - returns the first problem found, or nil
+func (c *Config) Validate() error {
+	//------------------------------------------------------------
+	// single values
+	if 0 >= c.SystemTtl || MAX_SYSTEM_TTL < c.SystemTtl {
+		return fmt.Errorf("config: %s = %d must be in [1, %d]", SYSTEM_TTL_KEY, c.SystemTtl, MAX_SYSTEM_TTL)
+	}
+	if 0 >= c.TickFrequency {
+		return fmt.Errorf("config: %s = %d must be > 0", TICK_FREQUENCY_KEY, c.TickFrequency)
+	}
+	if 0 > c.SimulationCount {
+		return fmt.Errorf("config: %s = %d must not be negative", SIMULATION_COUNT_KEY, c.SimulationCount)
+	}
+	if 0 > c.McBound {
+		return fmt.Errorf("config: %s = %d must not be negative", MC_BOUND_KEY, c.McBound)
+	}
+	if _, err := ParseVerificationType(c.VerificationMode.String()); nil != err {
+		return fmt.Errorf("config: %s: %s", VERIFICATION_MODE_KEY, err)
+	}
+	if _, err := ParseExecutionType(c.ExecutionMode.String()); nil != err {
+		return fmt.Errorf("config: %s: %s", EXECUTION_MODE_KEY, err)
+	}
+	if FIRST_KEY != c.McCpKeySelectionCriterion && RANDOM_KEY != c.McCpKeySelectionCriterion {
+		return fmt.Errorf("config: %s = %s must be FIRST_KEY or RANDOM_KEY", MC_CP_KEY_SELECTION_CRITERION_KEY, c.McCpKeySelectionCriterion)
+	}
+	if FIRST_TIME != c.McCpTimeSelectionCriterion && RANDOM_TIME != c.McCpTimeSelectionCriterion {
+		return fmt.Errorf("config: %s = %s must be FIRST_TIME or RANDOM_TIME", MC_CP_TIME_SELECTION_CRITERION_KEY, c.McCpTimeSelectionCriterion)
+	}
+	if _, err := ParseExecutorType(c.Executor.String()); nil != err {
+		return fmt.Errorf("config: %s: %s", EXECUTOR_KEY, err)
+	}
+	if 0 > c.LivelockBound {
+		return fmt.Errorf("config: %s = %d must not be negative", LIVELOCK_BOUND_KEY, c.LivelockBound)
+	}
+	if 0 > c.SnapshotTime {
+		return fmt.Errorf("config: %s = %d must not be negative", SNAPSHOT_TIME_KEY, c.SnapshotTime)
+	}
+	if 0 > c.SimulationPrecision {
+		return fmt.Errorf("config: %s = %g must not be negative", SIMULATION_PRECISION_KEY, c.SimulationPrecision)
+	}
+	if "" != c.HttpApi {
+		if err := checkLocalhostAddress(HTTP_API_KEY, c.HttpApi); nil != err {
+			return err
+		}
+	}
+	if "" != c.Debugger && "stdin" != c.Debugger {
+		if err := checkLocalhostAddress(DEBUGGER_KEY, c.Debugger); nil != err {
+			return err
+		}
+	}
+	//------------------------------------------------------------
+	// combinations
+	if 0 < c.LivelockBound && 0 == len(c.GoalContainers) {
+		return fmt.Errorf("config: %s > 0 requires %s", LIVELOCK_BOUND_KEY, GOAL_CONTAINERS_KEY)
+	}
+	switch c.VerificationMode {
+	case SIMULATION:
+		if 0 == c.SimulationCount {
+			return fmt.Errorf("config: %s requires %s > 0", SIMULATION, SIMULATION_COUNT_KEY)
+		}
+	case MODEL_CHECKING:
+		if 0 == c.McBound {
+			return fmt.Errorf("config: %s requires %s > 0", MODEL_CHECKING, MC_BOUND_KEY)
+		}
+		// MIN_ISSUE_TIME does not check the wait4 condition, which contradicts the choice point creation
+		if MIN_ISSUE_TIME == c.ExecutionMode {
+			return fmt.Errorf("config: %s cannot be combined with %s = %s", MODEL_CHECKING, EXECUTION_MODE_KEY, MIN_ISSUE_TIME)
+		}
+		// changes made in the debugger would not be part of the choice points
+		if "" != c.Debugger {
+			return fmt.Errorf("config: %s cannot be combined with %s", MODEL_CHECKING, DEBUGGER_KEY)
+		}
+	case REPLAY:
+		if "" == c.ReplayFile {
+			return fmt.Errorf("config: %s requires %s", REPLAY, REPLAY_FILE_KEY)
+		}
+		// the replay trace starts at the beginning, not at the snapshot
+		if "" != c.RestoreFile {
+			return fmt.Errorf("config: %s cannot be combined with %s", REPLAY, RESTORE_FILE_KEY)
+		}
+	}
+	//------------------------------------------------------------
+	return nil
+}
+
+//------------------------------------------------------------
+// set one value given as string
+// - key is one of CONFIG_KEYS (case insensitive)
+func (c *Config) Set(key string, value string) error {
+	var err error
+	value = strings.TrimSpace(value)
+	normKey := strings.ToLower(strings.TrimSpace(key))
+	switch normKey {
+	case SYSTEM_TTL_KEY:
+		c.SystemTtl, err = strconv.Atoi(value)
+	case TICK_FREQUENCY_KEY:
+		c.TickFrequency, err = strconv.Atoi(value)
+	case SIMULATION_COUNT_KEY:
+		c.SimulationCount, err = strconv.Atoi(value)
+	case MC_BOUND_KEY:
+		c.McBound, err = strconv.Atoi(value)
+	case VERIFICATION_MODE_KEY:
+		c.VerificationMode, err = ParseVerificationType(value)
+	case EXECUTION_MODE_KEY:
+		c.ExecutionMode, err = ParseExecutionType(value)
+	case MC_CP_KEY_SELECTION_CRITERION_KEY:
+		c.McCpKeySelectionCriterion, err = ParseChoiceSelectionCriterionType(value)
+	case MC_CP_TIME_SELECTION_CRITERION_KEY:
+		c.McCpTimeSelectionCriterion, err = ParseChoiceSelectionCriterionType(value)
+	case DEADLOCK_DETECTION_KEY:
+		c.DeadlockDetection, err = strconv.ParseBool(value)
+	case LIVELOCK_BOUND_KEY:
+		c.LivelockBound, err = strconv.Atoi(value)
+	case GOAL_CONTAINERS_KEY:
+		c.GoalContainers = splitList(value)
+	case SEED_KEY:
+		c.Seed, err = strconv.ParseInt(value, 10, 64)
+	case REPLAY_FILE_KEY:
+		c.ReplayFile = value
+	case EVENT_LOG_FILE_KEY:
+		c.EventLogFile = value
+	case INDEX_LABELS_KEY:
+		c.IndexLabels = splitList(value)
+	case EXECUTOR_KEY:
+		c.Executor, err = ParseExecutorType(value)
+	case SNAPSHOT_FILE_KEY:
+		c.SnapshotFile = value
+	case SNAPSHOT_TIME_KEY:
+		c.SnapshotTime, err = strconv.Atoi(value)
+	case RESTORE_FILE_KEY:
+		c.RestoreFile = value
+	case DEBUGGER_KEY:
+		c.Debugger = value
+	case HTTP_API_KEY:
+		c.HttpApi = value
+	case METRICS_FILE_KEY:
+		c.MetricsFile = value
+	case SIMULATION_PRECISION_KEY:
+		c.SimulationPrecision, err = strconv.ParseFloat(value, 64)
+	default:
+		return fmt.Errorf("config: unknown key \"%s\"", key)
+	}
+	if nil != err {
+		return fmt.Errorf("config: %s: %s", key, err)
+	}
+	if nil == c.setKeys {
+		c.setKeys = map[string]bool{}
+	}
+	c.setKeys[normKey] = true
+	return nil
+}
+
+//------------------------------------------------------------
+// was the value of the key set by a source (see Set), and not only by default or by assignment?
+// - key is one of CONFIG_KEYS (case insensitive)
+func (c *Config) IsSet(key string) bool {
+	return c.setKeys[strings.ToLower(strings.TrimSpace(key))]
+}
+
+//------------------------------------------------------------
+// get one value as string
+func (c *Config) Get(key string) string {
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case SYSTEM_TTL_KEY:
+		return strconv.Itoa(c.SystemTtl)
+	case TICK_FREQUENCY_KEY:
+		return strconv.Itoa(c.TickFrequency)
+	case SIMULATION_COUNT_KEY:
+		return strconv.Itoa(c.SimulationCount)
+	case MC_BOUND_KEY:
+		return strconv.Itoa(c.McBound)
+	case VERIFICATION_MODE_KEY:
+		return c.VerificationMode.String()
+	case EXECUTION_MODE_KEY:
+		return c.ExecutionMode.String()
+	case MC_CP_KEY_SELECTION_CRITERION_KEY:
+		return c.McCpKeySelectionCriterion.String()
+	case MC_CP_TIME_SELECTION_CRITERION_KEY:
+		return c.McCpTimeSelectionCriterion.String()
+	case DEADLOCK_DETECTION_KEY:
+		return strconv.FormatBool(c.DeadlockDetection)
+	case LIVELOCK_BOUND_KEY:
+		return strconv.Itoa(c.LivelockBound)
+	case GOAL_CONTAINERS_KEY:
+		return strings.Join(c.GoalContainers, ",")
+	case SEED_KEY:
+		return strconv.FormatInt(c.Seed, 10)
+	case REPLAY_FILE_KEY:
+		return c.ReplayFile
+	case EVENT_LOG_FILE_KEY:
+		return c.EventLogFile
+	case INDEX_LABELS_KEY:
+		return strings.Join(c.IndexLabels, ",")
+	case EXECUTOR_KEY:
+		return c.Executor.String()
+	case SNAPSHOT_FILE_KEY:
+		return c.SnapshotFile
+	case SNAPSHOT_TIME_KEY:
+		return strconv.Itoa(c.SnapshotTime)
+	case RESTORE_FILE_KEY:
+		return c.RestoreFile
+	case DEBUGGER_KEY:
+		return c.Debugger
+	case HTTP_API_KEY:
+		return c.HttpApi
+	case METRICS_FILE_KEY:
+		return c.MetricsFile
+	case SIMULATION_PRECISION_KEY:
+		return strconv.FormatFloat(c.SimulationPrecision, 'g', -1, 64)
+	default:
+		return ""
+	}
+}
+
+//============================================================
+// sources
+//============================================================
+
+//------------------------------------------------------------
+// overwrite values by those found in the file
+// - the format is selected by the file extension: .json, or .yaml/.yml
+// - keys not contained in the file keep their value
+func (c *Config) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return fmt.Errorf("config: %s", err)
+	}
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		values, err = parseJsonConfig(data)
+	case ".yaml", ".yml":
+		values, err = parseYamlConfig(data)
+	default:
+		return fmt.Errorf("config: %s: unknown file type (use .json, .yaml or .yml)", path)
+	}
+	if nil != err {
+		return fmt.Errorf("config: %s: %s", path, err)
+	}
+	for _, key := range CONFIG_KEYS {
+		if value, ok := values[key]; ok {
+			if err := c.Set(key, value); nil != err {
+				return err
+			}
+			delete(values, key)
+		}
+	}
+	for key := range values {
+		return fmt.Errorf("config: %s: unknown key \"%s\"", path, key)
+	}
+	return nil
+}
+
+//------------------------------------------------------------
+// overwrite values by environment variables (if set)
+// - eg: PM_VERIFICATION_MODE=MODEL_CHECKING
+func (c *Config) LoadEnv() error {
+	for _, key := range CONFIG_KEYS {
+		if value, ok := os.LookupEnv(ENV_PREFIX + strings.ToUpper(key)); ok {
+			if err := c.Set(key, value); nil != err {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------
+// define one flag per key in the flag set; flags overwrite the config when they are parsed
+// - eg: -verification_mode=SIMULATION -simulation_count=10
+func (c *Config) BindFlags(fs *flag.FlagSet) {
+	for _, key := range CONFIG_KEYS {
+		fs.Var(&configFlag{c: c, key: key}, key, fmt.Sprintf("config %s", key))
+	}
+}
+
+//------------------------------------------------------------
+func (c *Config) String() string {
+	s := ""
+	for i, key := range CONFIG_KEYS {
+		if 0 < i {
+			s = fmt.Sprintf("%s, ", s)
+		}
+		s = fmt.Sprintf("%s%s=%s", s, key, c.Get(key))
+	}
+	return s
+}
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// flag.Value for one config key
+type configFlag struct {
+	c   *Config
+	key string
+}
+
+//------------------------------------------------------------
+func (f *configFlag) String() string {
+	if nil == f.c {
+		return ""
+	}
+	return f.c.Get(f.key)
+}
+
+//------------------------------------------------------------
+func (f *configFlag) Set(value string) error {
+	return f.c.Set(f.key, value)
+}
+
+//------------------------------------------------------------
+// comma separated list; empty items are skipped
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); "" != item {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+//------------------------------------------------------------
+// the tcp address (of the http api or the debugger) must be a localhost address: they are not authenticated
+func checkLocalhostAddress(key string, address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if nil != err {
+		return fmt.Errorf("config: %s: %s", key, err)
+	}
+	if "localhost" != host && "127.0.0.1" != host && "::1" != host {
+		return fmt.Errorf("config: %s = %s must be a localhost address", key, address)
+	}
+	return nil
+}
+
+//------------------------------------------------------------
+// copy of the set keys (see Config.IsSet)
+func copySetKeys(setKeys map[string]bool) map[string]bool {
+	newSetKeys := map[string]bool{}
+	for key := range setKeys {
+		newSetKeys[key] = true
+	}
+	return newSetKeys
+}
+
+//------------------------------------------------------------
+// json object with basic values (or lists of strings)
+// - numbers are taken as written, eg an int64 seed is not rounded to a float64
+func parseJsonConfig(data []byte) (map[string]string, error) {
+	raw := map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); nil != err {
+		return nil, err
+	}
+	values := map[string]string{}
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[strings.ToLower(key)] = v
+		case json.Number:
+			values[strings.ToLower(key)] = v.String()
+		case bool:
+			values[strings.ToLower(key)] = strconv.FormatBool(v)
+		case []interface{}:
+			// - list of strings, eg goal containers or index labels
+			items := []string{}
+			for _, item := range v {
+				itemS, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("ill. list item for key \"%s\"", key)
+				}
+				items = append(items, itemS)
+			}
+			values[strings.ToLower(key)] = strings.Join(items, ",")
+		default:
+			return nil, fmt.Errorf("ill. value for key \"%s\"", key)
+		}
+	}
+	return values, nil
+}
+
+//------------------------------------------------------------
+// yaml map with scalar values (or lists of scalars)
+// - the scalars are taken as written, eg quoted strings may contain a "#"; null is the empty string
+func parseYamlConfig(data []byte) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); nil != err {
+		return nil, err
+	}
+	values := map[string]string{}
+	// - empty file
+	if 0 == len(doc.Content) {
+		return values, nil
+	}
+	m := doc.Content[0]
+	if yaml.MappingNode != m.Kind {
+		return nil, fmt.Errorf("line %d: map of \"key: value\" expected", m.Line)
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		key, value := m.Content[i], m.Content[i+1]
+		switch value.Kind {
+		case yaml.ScalarNode:
+			if "!!null" == value.Tag {
+				values[strings.ToLower(key.Value)] = ""
+			} else {
+				values[strings.ToLower(key.Value)] = value.Value
+			}
+		case yaml.SequenceNode:
+			// - list, eg of goal containers or index labels
+			items := []string{}
+			for _, item := range value.Content {
+				if yaml.ScalarNode != item.Kind {
+					return nil, fmt.Errorf("line %d: ill. list item for key \"%s\"", item.Line, key.Value)
+				}
+				items = append(items, item.Value)
+			}
+			values[strings.ToLower(key.Value)] = strings.Join(items, ",")
+		default:
+			return nil, fmt.Errorf("line %d: ill. value for key \"%s\"", value.Line, key.Value)
+		}
+	}
+	return values, nil
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
@@ -0,0 +1,340 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the run time configuration: sources, flags, validation and apply
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// write the file into a fresh temp dir and return its path
+func writeTempFile(t *testing.T, name string, data string) string {
+	dir, err := ioutil.TempDir("", "config_test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0644); nil != err {
+		t.Fatal(err)
+	}
+	return path
+}
+
+//------------------------------------------------------------
+// the error must mention all parts
+func expectError(t *testing.T, err error, parts ...string) {
+	t.Helper()
+	if nil == err {
+		t.Fatalf("error expected (%s)", strings.Join(parts, ", "))
+	}
+	for _, part := range parts {
+		if !strings.Contains(err.Error(), part) {
+			t.Fatalf("error %q does not mention %q", err, part)
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+func TestNewConfigIsValid(t *testing.T) {
+	c := NewConfig()
+	if err := c.Validate(); nil != err {
+		t.Fatalf("default config is invalid: %s", err)
+	}
+	if DEFAULT_SYSTEM_TTL != c.SystemTtl || DEFAULT_SEED != c.Seed || DEFAULT_VERIFICATION_MODE != c.VerificationMode {
+		t.Fatalf("default config expected, got %s", c)
+	}
+}
+
+//------------------------------------------------------------
+func TestSetGet(t *testing.T) {
+	c := NewConfig()
+	values := map[string]string{
+		SYSTEM_TTL_KEY:                    "30",
+		SIMULATION_COUNT_KEY:              "7",
+		VERIFICATION_MODE_KEY:             "MODEL_CHECKING",
+		EXECUTION_MODE_KEY:                "RANDOM",
+		MC_CP_KEY_SELECTION_CRITERION_KEY: "FIRST_KEY",
+		DEADLOCK_DETECTION_KEY:            "true",
+		GOAL_CONTAINERS_KEY:               "P1_POC,P2_POC",
+		SEED_KEY:                          "-5",
+		INDEX_LABELS_KEY:                  "prio",
+		EXECUTOR_KEY:                      "SEQUENTIAL",
+		SIMULATION_PRECISION_KEY:          "0.25",
+	}
+	for key, value := range values {
+		if err := c.Set(key, value); nil != err {
+			t.Fatalf("set %s = %s: %s", key, value, err)
+		}
+	}
+	for key, value := range values {
+		if got := c.Get(key); value != got {
+			t.Errorf("get %s: %s expected, got %s", key, value, got)
+		}
+	}
+	// - keys are case insensitive, enum values too
+	if err := c.Set(" System_TTL ", " 40 "); nil != err || 40 != c.SystemTtl {
+		t.Fatalf("case insensitive key: %v, system ttl = %d", err, c.SystemTtl)
+	}
+	if err := c.Set(EXECUTION_MODE_KEY, "fairness"); nil != err || FAIRNESS != c.ExecutionMode {
+		t.Fatalf("case insensitive value: %v, execution mode = %s", err, c.ExecutionMode)
+	}
+	// - empty list items are skipped
+	c.Set(GOAL_CONTAINERS_KEY, " P1_POC, ,P2_POC,")
+	if 2 != len(c.GoalContainers) {
+		t.Fatalf("2 goal containers expected, got %v", c.GoalContainers)
+	}
+}
+
+//------------------------------------------------------------
+func TestSetErrors(t *testing.T) {
+	c := NewConfig()
+	expectError(t, c.Set("no_such_key", "1"), "unknown key", "no_such_key")
+	expectError(t, c.Set(SYSTEM_TTL_KEY, "ten"), SYSTEM_TTL_KEY)
+	expectError(t, c.Set(VERIFICATION_MODE_KEY, "SOMETIMES"), VERIFICATION_MODE_KEY, "SOMETIMES")
+	expectError(t, c.Set(DEADLOCK_DETECTION_KEY, "maybe"), DEADLOCK_DETECTION_KEY)
+	// - a failed set keeps the value
+	if DEFAULT_VERIFICATION_MODE != c.VerificationMode {
+		t.Fatalf("verification mode changed by a failed set: %s", c.VerificationMode)
+	}
+}
+
+//------------------------------------------------------------
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   map[string]string
+		parts []string
+	}{
+		{"system ttl 0", map[string]string{SYSTEM_TTL_KEY: "0"}, []string{SYSTEM_TTL_KEY}},
+		{"system ttl too big", map[string]string{SYSTEM_TTL_KEY: "3000004"}, []string{SYSTEM_TTL_KEY}},
+		{"tick frequency", map[string]string{TICK_FREQUENCY_KEY: "0"}, []string{TICK_FREQUENCY_KEY}},
+		{"livelock without goals", map[string]string{LIVELOCK_BOUND_KEY: "10"}, []string{LIVELOCK_BOUND_KEY, GOAL_CONTAINERS_KEY}},
+		{"simulation without runs", map[string]string{VERIFICATION_MODE_KEY: "SIMULATION", SIMULATION_COUNT_KEY: "0"}, []string{SIMULATION_COUNT_KEY}},
+		{"model checking with min issue time", map[string]string{VERIFICATION_MODE_KEY: "MODEL_CHECKING", EXECUTION_MODE_KEY: "MIN_ISSUE_TIME"}, []string{EXECUTION_MODE_KEY}},
+		{"model checking with debugger", map[string]string{VERIFICATION_MODE_KEY: "MODEL_CHECKING", DEBUGGER_KEY: "stdin"}, []string{DEBUGGER_KEY}},
+		{"replay without file", map[string]string{VERIFICATION_MODE_KEY: "REPLAY"}, []string{REPLAY_FILE_KEY}},
+		{"replay from snapshot", map[string]string{VERIFICATION_MODE_KEY: "REPLAY", REPLAY_FILE_KEY: "r.log", RESTORE_FILE_KEY: "s.json"}, []string{RESTORE_FILE_KEY}},
+		{"http api not local", map[string]string{HTTP_API_KEY: "0.0.0.0:8080"}, []string{HTTP_API_KEY, "localhost"}},
+	}
+	for _, test := range tests {
+		c := NewConfig()
+		for key, value := range test.set {
+			if err := c.Set(key, value); nil != err {
+				t.Fatalf("%s: set %s = %s: %s", test.name, key, value, err)
+			}
+		}
+		err := c.Validate()
+		if nil == err {
+			t.Errorf("%s: error expected", test.name)
+			continue
+		}
+		for _, part := range test.parts {
+			if !strings.Contains(err.Error(), part) {
+				t.Errorf("%s: error %q does not mention %q", test.name, err, part)
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------
+func TestLoadFile(t *testing.T) {
+	yamlPath := writeTempFile(t, "c.yaml", "---\n# comment\nsystem_ttl: 30 # inline\nverification_mode: \"SIMULATION\"\ngoal_containers: P1_POC, P2_POC\n")
+	c := NewConfig()
+	if err := c.LoadFile(yamlPath); nil != err {
+		t.Fatal(err)
+	}
+	if 30 != c.SystemTtl || SIMULATION != c.VerificationMode || 2 != len(c.GoalContainers) {
+		t.Fatalf("yaml not loaded: %s", c)
+	}
+	// - keys not in the file keep their value
+	if DEFAULT_SEED != c.Seed {
+		t.Fatalf("seed changed: %d", c.Seed)
+	}
+	jsonPath := writeTempFile(t, "c.json", `{"SYSTEM_TTL": 50, "deadlock_detection": true, "index_labels": ["prio", "key"]}`)
+	c = NewConfig()
+	if err := c.LoadFile(jsonPath); nil != err {
+		t.Fatal(err)
+	}
+	if 50 != c.SystemTtl || !c.DeadlockDetection || "prio,key" != c.Get(INDEX_LABELS_KEY) {
+		t.Fatalf("json not loaded: %s", c)
+	}
+	expectError(t, NewConfig().LoadFile(writeTempFile(t, "c.yaml", "sytem_ttl: 30\n")), "unknown key", "sytem_ttl")
+	expectError(t, NewConfig().LoadFile(writeTempFile(t, "c.toml", "system_ttl = 30\n")), "unknown file type")
+	expectError(t, NewConfig().LoadFile(writeTempFile(t, "c.yaml", "system_ttl\n")), "line 1")
+	expectError(t, NewConfig().LoadFile(writeTempFile(t, "c.yaml", "system_ttl:\n  value: 30\n")), "line 2", SYSTEM_TTL_KEY)
+}
+
+//------------------------------------------------------------
+// values are taken as written
+func TestLoadFileValues(t *testing.T) {
+	// - a "#" in a quoted yaml value is no comment; lists may also be yaml lists
+	yamlPath := writeTempFile(t, "c.yaml", "event_log_file: \"a#b.log\" # comment\nreplay_file: 'c # d'\ngoal_containers: [P1_POC, P2_POC]\n")
+	c := NewConfig()
+	if err := c.LoadFile(yamlPath); nil != err {
+		t.Fatal(err)
+	}
+	if "a#b.log" != c.EventLogFile || "c # d" != c.ReplayFile || "P1_POC,P2_POC" != c.Get(GOAL_CONTAINERS_KEY) {
+		t.Fatalf("quoted values and list expected, got %s", c)
+	}
+	// - an int64 seed beyond 2^53 is not rounded, neither in json nor in yaml
+	for _, path := range []string{
+		writeTempFile(t, "c.json", `{"seed": 9007199254740993}`),
+		writeTempFile(t, "c.yaml", "seed: 9007199254740993\n"),
+	} {
+		c = NewConfig()
+		if err := c.LoadFile(path); nil != err {
+			t.Fatal(err)
+		}
+		if 9007199254740993 != c.Seed {
+			t.Fatalf("%s: seed 9007199254740993 expected, got %d", filepath.Base(path), c.Seed)
+		}
+	}
+}
+
+//------------------------------------------------------------
+func TestLoadEnv(t *testing.T) {
+	os.Setenv(ENV_PREFIX+"SYSTEM_TTL", "60")
+	defer os.Unsetenv(ENV_PREFIX + "SYSTEM_TTL")
+	c, err := LoadConfig(writeTempFile(t, "c.yaml", "system_ttl: 30\nseed: 7\n"))
+	if nil != err {
+		t.Fatal(err)
+	}
+	// - the environment overwrites the file
+	if 60 != c.SystemTtl || 7 != c.Seed {
+		t.Fatalf("system ttl 60 and seed 7 expected, got %s", c)
+	}
+	os.Setenv(ENV_PREFIX+"SYSTEM_TTL", "sixty")
+	if _, err := LoadConfig(""); nil == err {
+		t.Fatal("error expected for an ill. environment value")
+	}
+}
+
+//------------------------------------------------------------
+func TestBindFlags(t *testing.T) {
+	c := NewConfig()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	c.BindFlags(fs)
+	if err := fs.Parse([]string{"-system_ttl", "30", "-execution_mode=RANDOM", "-goal_containers", "P1_POC"}); nil != err {
+		t.Fatal(err)
+	}
+	if 30 != c.SystemTtl || RANDOM != c.ExecutionMode || 1 != len(c.GoalContainers) {
+		t.Fatalf("flags not applied: %s", c)
+	}
+	// - an ill. value is refused by the flag parser
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	NewConfig().BindFlags(fs)
+	if err := fs.Parse([]string{"-executor", "THREADS"}); nil == err {
+		t.Fatal("error expected for an ill. executor")
+	}
+}
+
+//------------------------------------------------------------
+// a config knows the keys that a source set, also if the value is the default one
+func TestIsSet(t *testing.T) {
+	prev := CurrentConfig()
+	defer prev.Apply()
+	c, err := LoadConfig(writeTempFile(t, "c.yaml", fmt.Sprintf("system_ttl: %d\n", DEFAULT_SYSTEM_TTL)))
+	if nil != err {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	c.BindFlags(fs)
+	if err := fs.Parse([]string{"-seed", "7"}); nil != err {
+		t.Fatal(err)
+	}
+	if !c.IsSet(SYSTEM_TTL_KEY) || !c.IsSet(" SEED ") || c.IsSet(TICK_FREQUENCY_KEY) {
+		t.Fatalf("system ttl and seed set expected, got %v", c.setKeys)
+	}
+	// - a failed set sets nothing; an assignment is not tracked
+	c.Set(MC_BOUND_KEY, "many")
+	c.TickFrequency = 2
+	if c.IsSet(MC_BOUND_KEY) || c.IsSet(TICK_FREQUENCY_KEY) || NewConfig().IsSet(SYSTEM_TTL_KEY) {
+		t.Fatalf("mc bound and tick frequency not set expected, got %v", c.setKeys)
+	}
+	// - copied and applied with the config
+	if !c.Copy().IsSet(SYSTEM_TTL_KEY) {
+		t.Fatal("system ttl set expected in the copy")
+	}
+	if err := c.Apply(); nil != err {
+		t.Fatal(err)
+	}
+	if cur := CurrentConfig(); !cur.IsSet(SYSTEM_TTL_KEY) || cur.IsSet(TICK_FREQUENCY_KEY) {
+		t.Fatalf("system ttl set expected in the current config, got %v", cur.setKeys)
+	}
+}
+
+//------------------------------------------------------------
+func TestApply(t *testing.T) {
+	prev := CurrentConfig()
+	defer prev.Apply()
+	c := NewConfig()
+	c.SystemTtl = 30
+	c.GoalContainers = []string{"P1_POC"}
+	if err := c.Apply(); nil != err {
+		t.Fatal(err)
+	}
+	if 30 != SYSTEM_TTL || !IsGoalContainer("P1_POC") || IsGoalContainer("P2_POC") {
+		t.Fatalf("config vars not set: SYSTEM_TTL = %d, GOAL_CONTAINERS = %v", SYSTEM_TTL, GOAL_CONTAINERS)
+	}
+	// - the applied config vars are independent of the config
+	c.GoalContainers[0] = "P2_POC"
+	if !IsGoalContainer("P1_POC") {
+		t.Fatal("goal containers share the list of the config")
+	}
+	if cur := CurrentConfig(); 30 != cur.SystemTtl || 1 != len(cur.GoalContainers) {
+		t.Fatalf("current config does not reflect the applied one: %s", cur)
+	}
+	// - an invalid config is not applied
+	c = NewConfig()
+	c.SystemTtl = 0
+	if err := c.Apply(); nil == err || 30 != SYSTEM_TTL {
+		t.Fatalf("invalid config: err = %v, SYSTEM_TTL = %d", err, SYSTEM_TTL)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
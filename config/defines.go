@@ -28,27 +28,39 @@
 
 package config
 
+import (
+	"fmt"
+	"strings"
+)
+
 //////////////////////////////////////////////////////////////
-// set (default) values of configuration consts
+// default values of the configuration
+// - nb: they are only defaults; the values actually used are the vars below,
+// -- which are set from a Config (see config.go) before a run
 //////////////////////////////////////////////////////////////
 
+//------------------------------------------------------------
+// maximal system ttl
+// - nb: it is also used as the INFINITE time (see scheduler), so a configured system ttl must not exceed it
+const MAX_SYSTEM_TTL int = 3000003
+
 //------------------------------------------------------------
 // overall system time aka "system ttl (time to live)"
 // - default = 100000
-const SYSTEM_TTL int = 3000003
+const DEFAULT_SYSTEM_TTL int = MAX_SYSTEM_TTL
 
 //------------------------------------------------------------
 // increment time after N times; or if no condition is fulfilled
 // - default = 1
-const TICK_FREQUENCY int = 1
+const DEFAULT_TICK_FREQUENCY int = 1
 
 //------------------------------------------------------------
 // determines number of simulation runs
-const SIMULATION_COUNT int = 3
+const DEFAULT_SIMULATION_COUNT int = 3
 
 //------------------------------------------------------------
 // limits the number of model checking iterations runs
-const MC_BOUND int = 1000
+const DEFAULT_MC_BOUND int = 1000
 
 //------------------------------------------------------------
 // verification mode
@@ -62,7 +74,10 @@ const MC_BOUND int = 1000
 // MODEL_CHECKING
 // - still under construction
 //............................................................
-const VERIFICATION_MODE VerificationTypeEnum = ONE_RUN // <<<<<<<<<<<<<<<<<<<<<<<<
+// REPLAY
+// - one run that follows the replay trace in the replay file (eg a counterexample found by model checking)
+//............................................................
+const DEFAULT_VERIFICATION_MODE VerificationTypeEnum = ONE_RUN // <<<<<<<<<<<<<<<<<<<<<<<<
 
 //------------------------------------------------------------
 // execution mode
@@ -79,23 +94,225 @@ const VERIFICATION_MODE VerificationTypeEnum = ONE_RUN // <<<<<<<<<<<<<<<<<<<<<<
 // - select the one whose condition is fulfilled and whose last try to execute is furthest behind;
 // - faster and fairer than the above ones -- simply the best!
 //............................................................
-const EXECUTION_MODE ExecutionTypeEnum = FAIRNESS // <<<<<<<<<<<<<<<<<<<<<<<<
+// RANDOM
+// - select one of the machines whose condition is fulfilled at random (see SEED)
+// - every simulation run is another interleaving, which is reproducible with the seed
+//............................................................
+const DEFAULT_EXECUTION_MODE ExecutionTypeEnum = FAIRNESS // <<<<<<<<<<<<<<<<<<<<<<<<
 
 //------------------------------------------------------------
 // criterion of model checker: how to select choice point
 //............................................................
 // - FIRST_KEY / RANDOM_KEY
-const MC_CP_KEY_SELECTION_CRITERION ChoiceSelectionCriterionTypeEnum = RANDOM_KEY
+const DEFAULT_MC_CP_KEY_SELECTION_CRITERION ChoiceSelectionCriterionTypeEnum = RANDOM_KEY
 
 //............................................................
 // - FIRST_TIME / RANDOM_TIME
-const MC_CP_TIME_SELECTION_CRITERION ChoiceSelectionCriterionTypeEnum = FIRST_TIME
+const DEFAULT_MC_CP_TIME_SELECTION_CRITERION ChoiceSelectionCriterionTypeEnum = FIRST_TIME
+
+//------------------------------------------------------------
+// stop a run with verdict DEADLOCK if no machine is enabled, no future time event exists and
+// the goal has not been reached, ie none of the goal containers holds a committed entry
+// - without goal containers, every such stop is a deadlock
+// - if the goal has been reached, the run stops as terminated
+const DEFAULT_DEADLOCK_DETECTION bool = false
+
+//------------------------------------------------------------
+// livelock (non-progress) detection:
+// - stop a run with verdict LIVELOCK if wirings fired this number of times in a row, ie their instances
+//   ended with the commit or rollback of their tx, without any progress in one of the goal containers
+// - nb: it is counted in firings, not in critical sections, because a wiring needs several critical sections
+//   for one firing
+// - 0 ... off
+const DEFAULT_LIVELOCK_BOUND int = 0
+
+//------------------------------------------------------------
+// seed of the random generator
+// - each run gets its own seed derived from it (see framework: SeedRun); the first run uses the seed itself
+const DEFAULT_SEED int64 = 99
+
+//------------------------------------------------------------
+// replay trace file read in verification mode REPLAY
+// - nb: the replay trace file written by a run (see debug: REPLAY_TRACE_FILE_NAME) is overwritten by the next run
+// -- -> copy it first
+const DEFAULT_REPLAY_FILE string = ""
+
+//------------------------------------------------------------
+// structured event log in JSON Lines (see eventLog), eg "events.jsonl" or "stdout"
+// - "" ... off
+const DEFAULT_EVENT_LOG_FILE string = ""
+
+//------------------------------------------------------------
+// executor of the machines
+//............................................................
+// GOROUTINES
+// - every async machine runs in its own go routine; the critical section is handed over via channels
+//............................................................
+// SEQUENTIAL
+// - the controller executes the machines itself, ie no go routine per machine
+// - every run is deterministic (also the machine numbers and the order of ties in the selection of the next machine)
+//............................................................
+const DEFAULT_EXECUTOR ExecutorTypeEnum = GOROUTINES
+
+//------------------------------------------------------------
+// snapshot of the status (see framework: snapshot)
+// - written to the snapshot file at the first step of the first run at or after the snapshot time
+// - "" ... off
+const DEFAULT_SNAPSHOT_FILE string = ""
+const DEFAULT_SNAPSHOT_TIME int = 0
+
+//------------------------------------------------------------
+// snapshot file the runs start from, instead of the initial status of the use case
+// - the use case must be the one of the snapshot
+// - "" ... off
+const DEFAULT_RESTORE_FILE string = ""
+
+//------------------------------------------------------------
+// interactive debugger of the controller (see framework: debugger)
+// - "stdin" ... commands from stdin, output to stdout
+// - "<host>:<port>" ... commands and output via the first tcp connection to this address, which must be a
+//   localhost address, eg "localhost:7070"
+// - "" ... off
+const DEFAULT_DEBUGGER string = ""
+
+//------------------------------------------------------------
+// http api to observe and drive the running simulation (see framework: httpApi)
+// - "<host>:<port>" ... listen on this address, which must be a localhost address, eg "localhost:8080"
+// - "" ... off
+const DEFAULT_HTTP_API string = ""
+
+//------------------------------------------------------------
+// base name of the metrics files written at the end of every run (see pmModel: metrics)
+// - "<base>_<run>.csv" ... time series, "<base>_<run>.prom" ... prometheus text format
+// - "" ... off
+const DEFAULT_METRICS_FILE string = ""
+
+//------------------------------------------------------------
+// SIMULATION: target precision of the observables (see framework: runStatistics)
+// - > 0 ... stop the runs as soon as the 95% confidence interval of every observable is within +/- this fraction of
+//   its mean (absolute, if the mean is below 1, eg near 0); SIMULATION_COUNT is then the max. number of runs
+// - 0 ... off: exactly SIMULATION_COUNT runs
+const DEFAULT_SIMULATION_PRECISION float64 = 0
+
+//////////////////////////////////////////////////////////////
+// configuration vars
+// - caution: do not set them directly, but via Config.Apply
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+var SYSTEM_TTL int = DEFAULT_SYSTEM_TTL
+
+//------------------------------------------------------------
+var TICK_FREQUENCY int = DEFAULT_TICK_FREQUENCY
+
+//------------------------------------------------------------
+var SIMULATION_COUNT int = DEFAULT_SIMULATION_COUNT
+
+//------------------------------------------------------------
+var MC_BOUND int = DEFAULT_MC_BOUND
+
+//------------------------------------------------------------
+var VERIFICATION_MODE VerificationTypeEnum = DEFAULT_VERIFICATION_MODE
+
+//------------------------------------------------------------
+var EXECUTION_MODE ExecutionTypeEnum = DEFAULT_EXECUTION_MODE
+
+//------------------------------------------------------------
+var MC_CP_KEY_SELECTION_CRITERION ChoiceSelectionCriterionTypeEnum = DEFAULT_MC_CP_KEY_SELECTION_CRITERION
+
+//------------------------------------------------------------
+var MC_CP_TIME_SELECTION_CRITERION ChoiceSelectionCriterionTypeEnum = DEFAULT_MC_CP_TIME_SELECTION_CRITERION
+
+//------------------------------------------------------------
+var DEADLOCK_DETECTION bool = DEFAULT_DEADLOCK_DETECTION
+
+//------------------------------------------------------------
+var LIVELOCK_BOUND int = DEFAULT_LIVELOCK_BOUND
+
+//------------------------------------------------------------
+// ids of the goal containers for livelock detection (eg "P1_POC")
+// - default: none
+var GOAL_CONTAINERS []string
+
+//------------------------------------------------------------
+// entry property labels that the containers index (eg "id"), in addition to the entry type
+// - selectors "label == value" with such a label are answered from the index
+// - default: none
+var INDEX_LABELS []string
+
+//------------------------------------------------------------
+var SEED int64 = DEFAULT_SEED
+
+//------------------------------------------------------------
+var REPLAY_FILE string = DEFAULT_REPLAY_FILE
+
+//------------------------------------------------------------
+var EVENT_LOG_FILE string = DEFAULT_EVENT_LOG_FILE
+
+//------------------------------------------------------------
+var EXECUTOR ExecutorTypeEnum = DEFAULT_EXECUTOR
+
+//------------------------------------------------------------
+var SNAPSHOT_FILE string = DEFAULT_SNAPSHOT_FILE
+
+//------------------------------------------------------------
+var SNAPSHOT_TIME int = DEFAULT_SNAPSHOT_TIME
+
+//------------------------------------------------------------
+var RESTORE_FILE string = DEFAULT_RESTORE_FILE
+
+//------------------------------------------------------------
+var DEBUGGER string = DEFAULT_DEBUGGER
+
+//------------------------------------------------------------
+var HTTP_API string = DEFAULT_HTTP_API
+
+//------------------------------------------------------------
+var METRICS_FILE string = DEFAULT_METRICS_FILE
+
+//------------------------------------------------------------
+var SIMULATION_PRECISION float64 = DEFAULT_SIMULATION_PRECISION
+
+//------------------------------------------------------------
+// keys of the config vars that a source set explicitly (see Config.IsSet)
+// - eg the system ttl of a use case applies only if no system ttl is set (see runtime: Run)
+var SET_CONFIG_KEYS map[string]bool
+
+//////////////////////////////////////////////////////////////
+// other vars
+//////////////////////////////////////////////////////////////
 
 //------------------------------------------------------------
 // number of space updates made by this run
 // - TBD: create interface for this var
 var SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT int = 0
 
+//------------------------------------------------------------
+// number of entries committed into goal containers by this run
+// - progress for livelock detection; counted by the model (see IsGoalContainer)
+// - nb: takes and not yet committed writes are no progress
+var GOAL_PROGRESS_COUNT int = 0
+
+//------------------------------------------------------------
+// number of wiring instances that ended with the commit or rollback of their tx in this run
+// - steps for livelock detection; counted by the model (see pmModel: Tx.End)
+var WIRING_FIRING_COUNT int = 0
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// is the container with the given id one of the configured goal containers?
+func IsGoalContainer(cid string) bool {
+	for _, goalCid := range GOAL_CONTAINERS {
+		if cid == goalCid {
+			return true
+		}
+	}
+	return false
+}
+
 //////////////////////////////////////////////////////////////
 // enums
 //////////////////////////////////////////////////////////////
@@ -112,6 +329,7 @@ const (
 	ONE_RUN VerificationTypeEnum = iota
 	SIMULATION
 	MODEL_CHECKING
+	REPLAY
 )
 
 //------------------------------------------------------------
@@ -123,11 +341,24 @@ func (t VerificationTypeEnum) String() string {
 		return "SIMULATION"
 	case MODEL_CHECKING:
 		return "MODEL_CHECKING"
+	case REPLAY:
+		return "REPLAY"
 	default:
 		return "ill. verification type"
 	}
 }
 
+//------------------------------------------------------------
+// inverse of String
+func ParseVerificationType(name string) (VerificationTypeEnum, error) {
+	for _, t := range []VerificationTypeEnum{ONE_RUN, SIMULATION, MODEL_CHECKING, REPLAY} {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return ONE_RUN, fmt.Errorf("ill. verification type = \"%s\"", name)
+}
+
 //============================================================
 // execution type
 //============================================================
@@ -140,6 +371,7 @@ const (
 	MIN_ISSUE_TIME ExecutionTypeEnum = iota
 	MIN_ISSUE_TIME_AND_CONDITION_FULFILLED
 	FAIRNESS
+	RANDOM
 )
 
 //------------------------------------------------------------
@@ -151,11 +383,60 @@ func (t ExecutionTypeEnum) String() string {
 		return "MIN_ISSUE_TIME_AND_CONDITION_FULFILLED"
 	case FAIRNESS:
 		return "FAIRNESS"
+	case RANDOM:
+		return "RANDOM"
 	default:
 		return "ill. execution type"
 	}
 }
 
+//------------------------------------------------------------
+// inverse of String
+func ParseExecutionType(name string) (ExecutionTypeEnum, error) {
+	for _, t := range []ExecutionTypeEnum{MIN_ISSUE_TIME, MIN_ISSUE_TIME_AND_CONDITION_FULFILLED, FAIRNESS, RANDOM} {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return FAIRNESS, fmt.Errorf("ill. execution type = \"%s\"", name)
+}
+
+//============================================================
+// executor type
+//============================================================
+
+//------------------------------------------------------------
+type ExecutorTypeEnum int
+
+//------------------------------------------------------------
+const (
+	GOROUTINES ExecutorTypeEnum = iota
+	SEQUENTIAL
+)
+
+//------------------------------------------------------------
+func (t ExecutorTypeEnum) String() string {
+	switch t {
+	case GOROUTINES:
+		return "GOROUTINES"
+	case SEQUENTIAL:
+		return "SEQUENTIAL"
+	default:
+		return "ill. executor type"
+	}
+}
+
+//------------------------------------------------------------
+// inverse of String
+func ParseExecutorType(name string) (ExecutorTypeEnum, error) {
+	for _, t := range []ExecutorTypeEnum{GOROUTINES, SEQUENTIAL} {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return GOROUTINES, fmt.Errorf("ill. executor type = \"%s\"", name)
+}
+
 //============================================================
 // choice selection criterion type
 //============================================================
@@ -187,6 +468,17 @@ func (t ChoiceSelectionCriterionTypeEnum) String() string {
 	}
 }
 
+//------------------------------------------------------------
+// inverse of String
+func ParseChoiceSelectionCriterionType(name string) (ChoiceSelectionCriterionTypeEnum, error) {
+	for _, t := range []ChoiceSelectionCriterionTypeEnum{FIRST_KEY, RANDOM_KEY, FIRST_TIME, RANDOM_TIME} {
+		if strings.EqualFold(name, t.String()) {
+			return t, nil
+		}
+	}
+	return FIRST_KEY, fmt.Errorf("ill. choice selection criterion type = \"%s\"", name)
+}
+
 //////////////////////////////////////////////////////////////
 // EOF
 //////////////////////////////////////////////////////////////
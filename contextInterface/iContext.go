@@ -38,6 +38,8 @@ type IContext interface {
 	Copy() interface{}
 	// machine identifier used as suffix
 	MachineKeySuffix() string
+	// for the snapshot of the status (see IMetaContext)
+	Snapshot() ([]byte, error)
 	// require also the IPrint interface ...
 	IsEmpty() bool
 	Print(ind int)
@@ -65,6 +65,10 @@ const (
 	// machine to controller:
 	// - info that machine has terminated
 	TERMINATED
+	//------------------------------------------------------------
+	// machine to controller:
+	// - machine raised an error (see Status.Err) -> controller shall stop the entire system
+	FAILED
 )
 
 //------------------------------------------------------------
@@ -80,6 +84,8 @@ func (t SignalTypeEnum) String() string {
 		return "STOP"
 	case TERMINATED:
 		return "TERMINATED"
+	case FAILED:
+		return "FAILED"
 	default:
 		return "ill. signal type"
 	}
@@ -86,6 +86,12 @@ var TRACES = map[TraceLevelEnum]bool{
 // @@@ istate number comments are not correct... dependent on profiles...
 ////////////////////////////////////////
 var MACHINE_TRACE_FLAGS = map[string]TraceFlags{
+	"OccRead": {false,
+		STF{}},
+	"OccTxCommit": {false,
+		STF{}},
+	"OccWrite": {false,
+		STF{}},
 	"PccRead": {false,
 		STF{"init": false,
 			"8":    false,
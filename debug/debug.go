@@ -230,19 +230,39 @@ func BorderLine2TraceFile(char byte, lineLen int) {
 ////////////////////////////////////////
 
 // ----------------------------------------
+// raise an internal error (see errors.go)
 func Panic(s string) {
+	RaiseError(NewInternalError(s))
+}
+
+// ----------------------------------------
+// raise a model error (see errors.go)
+func ModelPanic(s string) {
+	RaiseError(NewModelError(s))
+}
+
+// ----------------------------------------
+// raise an eval error (see errors.go)
+func EvalPanic(s string) {
+	RaiseError(NewEvalError(s))
+}
+
+// ----------------------------------------
+// trace the error and panic with it
+// - nb: recovered by the machine (see framework) or by the runtime
+func RaiseError(err IContextError) {
 	// ----------------------------------------
 	String2TraceFile("\n\n\n")
 	StarBorderLine2TraceFile()
 	StarBorderLine2TraceFile()
 	// ----------------------------------------
-	String2TraceFile(fmt.Sprintf("PANIC: %s\n", s))
+	String2TraceFile(fmt.Sprintf("PANIC: %s\n", err))
 	// ----------------------------------------
 	StarBorderLine2TraceFile()
 	StarBorderLine2TraceFile()
 	String2TraceFile("\n\n\n")
 	// ----------------------------------------
-	panic("-- THE END --")
+	panic(err)
 }
 
 ////////////////////////////////////////
@@ -0,0 +1,169 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// typed errors of the runtime, instead of terminating the process
+// - ModelError: the model is wrong, eg an ill. link or a wrong use of a container
+// - EvalError: an arg cannot be evaluated, eg an undefined var or entry property
+// - InternalError: should not occur, eg a failed assertion of the framework
+// - all have a context: pid, wid and link no (if raised in a wiring), machine and its state
+// - they are raised as panic (see Panic, ModelPanic, EvalPanic) and recovered by the machine
+//   that raised them, which adds its context; the run then stops and runtime.Run returns the error
+// - nb: any other panic of a machine is recovered as InternalError
+////////////////////////////////////////
+
+package debug
+
+import (
+	"fmt"
+	"strings"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+// where the error was raised; empty fields are unknown
+// - LinkNo is -1 if unknown
+type ErrorContext struct {
+	Pid     string
+	Wid     string
+	LinkNo  int
+	Machine string
+	State   string
+}
+
+// ----------------------------------------
+type ModelError struct {
+	ErrorContext
+	Msg string
+}
+
+// ----------------------------------------
+type EvalError struct {
+	ErrorContext
+	Msg string
+}
+
+// ----------------------------------------
+type InternalError struct {
+	ErrorContext
+	Msg string
+}
+
+// ----------------------------------------
+// interface of the typed errors
+type IContextError interface {
+	error
+	GetErrorContext() *ErrorContext
+}
+
+// ----------------------------------------
+// implemented by machine contexts that know the peer, wiring and link of the machine
+// - fills the context of the errors raised by the machine
+type IErrorPosition interface {
+	ErrorPosition() (pid string, wid string, linkNo int)
+}
+
+////////////////////////////////////////
+// constructors
+////////////////////////////////////////
+
+// ----------------------------------------
+func NewModelError(msg string) *ModelError {
+	return &ModelError{ErrorContext: ErrorContext{LinkNo: -1}, Msg: msg}
+}
+
+// ----------------------------------------
+func NewEvalError(msg string) *EvalError {
+	return &EvalError{ErrorContext: ErrorContext{LinkNo: -1}, Msg: msg}
+}
+
+// ----------------------------------------
+func NewInternalError(msg string) *InternalError {
+	return &InternalError{ErrorContext: ErrorContext{LinkNo: -1}, Msg: msg}
+}
+
+// ----------------------------------------
+// convert a recovered panic value into a typed error
+func RecoveredError(r interface{}) IContextError {
+	switch err := r.(type) {
+	case IContextError:
+		return err
+	case error:
+		return NewInternalError(err.Error())
+	default:
+		return NewInternalError(fmt.Sprint(r))
+	}
+}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+func (ec *ErrorContext) GetErrorContext() *ErrorContext {
+	return ec
+}
+
+// ----------------------------------------
+// eg "peer P1: wiring P1_W1: link 2: machine Wiring__M3[21]: "
+func (ec *ErrorContext) String() string {
+	pos := []string{}
+	if "" != ec.Pid {
+		pos = append(pos, "peer "+ec.Pid)
+	}
+	if "" != ec.Wid {
+		pos = append(pos, "wiring "+ec.Wid)
+	}
+	if 0 <= ec.LinkNo {
+		pos = append(pos, fmt.Sprintf("link %d", ec.LinkNo))
+	}
+	if "" != ec.Machine {
+		pos = append(pos, fmt.Sprintf("machine %s[%s]", ec.Machine, ec.State))
+	}
+	if 0 == len(pos) {
+		return ""
+	}
+	return strings.Join(pos, ": ") + ": "
+}
+
+// ----------------------------------------
+func (err *ModelError) Error() string {
+	return fmt.Sprintf("model error: %s%s", err.ErrorContext.String(), err.Msg)
+}
+
+// ----------------------------------------
+func (err *EvalError) Error() string {
+	return fmt.Sprintf("eval error: %s%s", err.ErrorContext.String(), err.Msg)
+}
+
+// ----------------------------------------
+func (err *InternalError) Error() string {
+	return fmt.Sprintf("internal error: %s%s", err.ErrorContext.String(), err.Msg)
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -39,6 +39,8 @@ type IEvent interface {
 	Copy() interface{}
 	// for debug
 	String() string
+	// for the snapshot of the status (see IMetaContext)
+	Snapshot() ([]byte, error)
 	// require also the IPrint interface ...
 	IsEmpty() bool
 	Print(ind int)
@@ -0,0 +1,302 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// structured event log
+// - machine readable stream of everything the simulator does; in contrast to the traces (see debug),
+//   which are meant for humans
+// - written to the event log file (see config: EVENT_LOG_FILE), "" = off, "stdout" = standard output
+// - format: JSON Lines, ie one json object per line (see EventRecord)
+// - every record carries: sequence number, run, type, clock and event clock, and the machine key
+// -- machine records (enter, leave, state): the machine itself
+// -- all other records: the machine in the critical section ("" if it was the controller)
+// - records are only written within a run (see EventLogStartRun/EventLogEndRun), ie not while
+//   the use case is initialized
+// - the records can also be passed to a sink (see SetEventSink), eg for metrics; independent of the file
+//------------------------------------------------------------
+// caution: written by machines and controller -> LogEvent is synchronized
+//////////////////////////////////////////////////////////////
+
+package eventLog
+
+import (
+	. "github.com/peermodel/simulator/scheduler"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+type EventRecordTypeEnum int
+
+//------------------------------------------------------------
+// record types:
+// - run start and end (msg = reason of the stop)
+// - a machine enters/leaves the critical section
+// - a machine changes its state (from, to)
+// - space operations (cid, eids): read (also test), take (also delete), write, emit (service output)
+// - transactions (txid)
+// - exceptions (msg)
+// - a ripe scheduler slot is processed (msg = slot)
+// - the clock advances (from, to)
+const (
+	LOG_RUN_START EventRecordTypeEnum = iota
+	LOG_RUN_END
+	LOG_ENTER
+	LOG_LEAVE
+	LOG_STATE
+	LOG_READ
+	LOG_TAKE
+	LOG_WRITE
+	LOG_EMIT
+	LOG_TX_CREATE
+	LOG_TX_COMMIT
+	LOG_TX_ROLLBACK
+	LOG_EXCEPTION
+	LOG_SLOT
+	LOG_CLOCK
+)
+
+//------------------------------------------------------------
+// one line of the event log
+// - empty fields are omitted
+type EventRecord struct {
+	Seq        int                 `json:"seq"`
+	Run        int                 `json:"run"`
+	Type       EventRecordTypeEnum `json:"type"`
+	Clock      int                 `json:"clock"`
+	EventClock int                 `json:"et"`
+	MachineKey string              `json:"machine,omitempty"`
+	Pid        string              `json:"pid,omitempty"`
+	Wid        string              `json:"wid,omitempty"`
+	Wiid       string              `json:"wiid,omitempty"`
+	Cid        string              `json:"cid,omitempty"`
+	Eids       []string            `json:"eids,omitempty"`
+	Txid       string              `json:"txid,omitempty"`
+	From       string              `json:"from,omitempty"`
+	To         string              `json:"to,omitempty"`
+	Msg        string              `json:"msg,omitempty"`
+}
+
+//------------------------------------------------------------
+// gets every record of a run
+// - nb: called synchronized (see LogEvent)
+type EventSinkFu func(rec EventRecord)
+
+//------------------------------------------------------------
+// implemented by machine contexts that know the peer and wiring of the machine
+// - fills pid, wid and wiid of the machine records
+type IEventLogIds interface {
+	EventLogIds() (pid string, wid string, wiid string)
+}
+
+//////////////////////////////////////////////////////////////
+// vars
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// run that is currently logged
+var EVENT_LOG_RUN int
+
+//------------------------------------------------------------
+// key of the machine in the critical section; "" = controller
+// - set by the framework on enter and leave
+var EVENT_LOG_MACHINE_KEY string
+
+//------------------------------------------------------------
+// private vars
+// - event log file; nil = off
+var eventLogFile *os.File
+var eventLogMutex sync.Mutex
+var eventLogSeq int
+var eventLogActiveFlag bool
+var eventSink EventSinkFu
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+func (t EventRecordTypeEnum) String() string {
+	switch t {
+	case LOG_RUN_START:
+		return "run_start"
+	case LOG_RUN_END:
+		return "run_end"
+	case LOG_ENTER:
+		return "enter"
+	case LOG_LEAVE:
+		return "leave"
+	case LOG_STATE:
+		return "state"
+	case LOG_READ:
+		return "read"
+	case LOG_TAKE:
+		return "take"
+	case LOG_WRITE:
+		return "write"
+	case LOG_EMIT:
+		return "emit"
+	case LOG_TX_CREATE:
+		return "tx_create"
+	case LOG_TX_COMMIT:
+		return "tx_commit"
+	case LOG_TX_ROLLBACK:
+		return "tx_rollback"
+	case LOG_EXCEPTION:
+		return "exception"
+	case LOG_SLOT:
+		return "slot"
+	case LOG_CLOCK:
+		return "clock"
+	default:
+		return "ill. event record type"
+	}
+}
+
+//------------------------------------------------------------
+// the type is written by its name
+func (t EventRecordTypeEnum) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// open the event log file; "" = off
+// - trick: "stdout" = standard output
+func OpenEventLog(fileName string) error {
+	CloseEventLog()
+	eventLogMutex.Lock()
+	defer eventLogMutex.Unlock()
+	eventLogSeq = 0
+	switch fileName {
+	case "":
+		return nil
+	case "stdout":
+		eventLogFile = os.Stdout
+	default:
+		f, err := os.Create(fileName)
+		if nil != err {
+			return fmt.Errorf("event log: %s", err)
+		}
+		eventLogFile = f
+	}
+	return nil
+}
+
+//------------------------------------------------------------
+func CloseEventLog() {
+	eventLogMutex.Lock()
+	defer eventLogMutex.Unlock()
+	if nil != eventLogFile && os.Stdout != eventLogFile {
+		eventLogFile.Close()
+	}
+	eventLogFile = nil
+	eventLogActiveFlag = false
+}
+
+//------------------------------------------------------------
+// set the sink of the records; nil = none
+// - nb: set it before the run starts
+func SetEventSink(sink EventSinkFu) {
+	eventLogMutex.Lock()
+	defer eventLogMutex.Unlock()
+	eventSink = sink
+}
+
+//------------------------------------------------------------
+// shall events be logged?
+// - nb: callers check it before they collect the data of a record
+func EventLogIsOn() bool {
+	return (nil != eventLogFile || nil != eventSink) && eventLogActiveFlag
+}
+
+//------------------------------------------------------------
+// a run starts: log its records from now on
+func EventLogStartRun(run int) {
+	if nil == eventLogFile && nil == eventSink {
+		return
+	}
+	EVENT_LOG_RUN = run
+	EVENT_LOG_MACHINE_KEY = ""
+	eventLogActiveFlag = true
+	LogEvent(EventRecord{Type: LOG_RUN_START})
+}
+
+//------------------------------------------------------------
+// a run ends; msg = reason of the stop
+// - nb: machines that are still being stopped are not logged any more
+func EventLogEndRun(msg string) {
+	if !EventLogIsOn() {
+		return
+	}
+	EVENT_LOG_MACHINE_KEY = ""
+	LogEvent(EventRecord{Type: LOG_RUN_END, Msg: msg})
+	eventLogActiveFlag = false
+}
+
+//------------------------------------------------------------
+// write one record
+// - sequence number, run and clocks are set here; an empty machine key is set to the machine in the critical section
+func LogEvent(rec EventRecord) {
+	if !EventLogIsOn() {
+		return
+	}
+	eventLogMutex.Lock()
+	defer eventLogMutex.Unlock()
+	//------------------------------------------------------------
+	// the log might have been closed meanwhile
+	if nil == eventLogFile && nil == eventSink {
+		return
+	}
+	eventLogSeq++
+	rec.Seq = eventLogSeq
+	rec.Run = EVENT_LOG_RUN
+	rec.Clock = CLOCK
+	rec.EventClock = EVENT_CLOCK
+	if "" == rec.MachineKey {
+		rec.MachineKey = EVENT_LOG_MACHINE_KEY
+	}
+	if nil != eventSink {
+		eventSink(rec)
+	}
+	if nil == eventLogFile {
+		return
+	}
+	data, err := json.Marshal(rec)
+	if nil != err {
+		//------------------------------------------------------------
+		// cannot happen: all fields are basic values
+		data, _ = json.Marshal(EventRecord{Seq: rec.Seq, Run: rec.Run, Type: rec.Type, Clock: rec.Clock, EventClock: rec.EventClock, Msg: err.Error()})
+	}
+	eventLogFile.Write(append(data, '\n'))
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
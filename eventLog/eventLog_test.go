@@ -0,0 +1,185 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the event log
+// - a short run is logged "by hand", ie as the framework would do it
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package eventLog
+
+import (
+	. "github.com/peermodel/simulator/scheduler"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// test data
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// the expected lines of the short run (see logShortRun)
+var SHORT_RUN_LINES = []string{
+	`{"seq":1,"run":2,"type":"run_start","clock":0,"et":0}`,
+	`{"seq":2,"run":2,"type":"enter","clock":0,"et":0,"machine":"m1","pid":"P1","wid":"W1","wiid":"1"}`,
+	`{"seq":3,"run":2,"type":"take","clock":0,"et":0,"machine":"m1","cid":"P1_PIC","eids":["e1","e2"]}`,
+	`{"seq":4,"run":2,"type":"write","clock":0,"et":0,"machine":"m1","cid":"P1_POC","eids":["e3"],"txid":"tx1"}`,
+	`{"seq":5,"run":2,"type":"state","clock":0,"et":0,"machine":"m1","from":"1","to":"2"}`,
+	`{"seq":6,"run":2,"type":"leave","clock":0,"et":0,"machine":"m1"}`,
+	`{"seq":7,"run":2,"type":"clock","clock":5,"et":3,"from":"0","to":"5"}`,
+	`{"seq":8,"run":2,"type":"run_end","clock":5,"et":3,"msg":"no more events"}`,
+}
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// restore the clocks and close the log at the end of the test
+func resetEventLog(t *testing.T) {
+	clock, eventClock := CLOCK, EVENT_CLOCK
+	t.Cleanup(func() {
+		CloseEventLog()
+		SetEventSink(nil)
+		CLOCK, EVENT_CLOCK = clock, eventClock
+	})
+	CLOCK, EVENT_CLOCK = 0, 0
+}
+
+//------------------------------------------------------------
+// log the records of a short run
+// - the enter and leave of the machine as well as the clock are set as the framework would do it
+func logShortRun() {
+	// - before the run: not logged
+	LogEvent(EventRecord{Type: LOG_WRITE, Cid: "P1_PIC", Eids: []string{"e0"}})
+	EventLogStartRun(2)
+	EVENT_LOG_MACHINE_KEY = "m1"
+	LogEvent(EventRecord{Type: LOG_ENTER, Pid: "P1", Wid: "W1", Wiid: "1"})
+	LogEvent(EventRecord{Type: LOG_TAKE, Cid: "P1_PIC", Eids: []string{"e1", "e2"}})
+	LogEvent(EventRecord{Type: LOG_WRITE, Cid: "P1_POC", Eids: []string{"e3"}, Txid: "tx1"})
+	LogEvent(EventRecord{Type: LOG_STATE, From: "1", To: "2"})
+	LogEvent(EventRecord{Type: LOG_LEAVE})
+	EVENT_LOG_MACHINE_KEY = ""
+	CLOCK, EVENT_CLOCK = 5, 3
+	LogEvent(EventRecord{Type: LOG_CLOCK, From: "0", To: "5"})
+	EventLogEndRun("no more events")
+	// - after the run: not logged
+	LogEvent(EventRecord{Type: LOG_EXCEPTION, Msg: "late"})
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// the log file has one json line per record of the run, in sequence
+func TestEventLogFile(t *testing.T) {
+	resetEventLog(t)
+	dir, err := ioutil.TempDir("", "eventLog")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	fileName := filepath.Join(dir, "events.jsonl")
+	if err := OpenEventLog(fileName); nil != err {
+		t.Fatal(err)
+	}
+	logShortRun()
+	CloseEventLog()
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(SHORT_RUN_LINES) != len(lines) {
+		t.Fatalf("%d lines expected, got %d:\n%s", len(SHORT_RUN_LINES), len(lines), data)
+	}
+	for i, line := range lines {
+		if SHORT_RUN_LINES[i] != line {
+			t.Errorf("line %d:\nexpected %s\ngot      %s", i+1, SHORT_RUN_LINES[i], line)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// the sink gets the same records as the file, also if the file is off
+func TestEventLogSink(t *testing.T) {
+	resetEventLog(t)
+	if err := OpenEventLog(""); nil != err {
+		t.Fatal(err)
+	}
+	var recs []EventRecord
+	SetEventSink(func(rec EventRecord) { recs = append(recs, rec) })
+	if EventLogIsOn() {
+		t.Fatal("event log must be off before the run")
+	}
+	logShortRun()
+	if EventLogIsOn() {
+		t.Error("event log must be off after the run")
+	}
+	if len(SHORT_RUN_LINES) != len(recs) {
+		t.Fatalf("%d records expected, got %d", len(SHORT_RUN_LINES), len(recs))
+	}
+	for i, rec := range recs {
+		data, err := json.Marshal(rec)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if SHORT_RUN_LINES[i] != string(data) {
+			t.Errorf("record %d:\nexpected %s\ngot      %s", i+1, SHORT_RUN_LINES[i], data)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// without file and sink nothing is logged
+func TestEventLogOff(t *testing.T) {
+	resetEventLog(t)
+	if err := OpenEventLog(""); nil != err {
+		t.Fatal(err)
+	}
+	EventLogStartRun(1)
+	if EventLogIsOn() {
+		t.Error("event log must be off without file and sink")
+	}
+	EventLogEndRun("")
+}
+
+//------------------------------------------------------------
+// a file that cannot be created is an error
+func TestEventLogOpenError(t *testing.T) {
+	resetEventLog(t)
+	err := OpenEventLog(filepath.Join("does", "not", "exist", "events.jsonl"))
+	if nil == err || !strings.HasPrefix(err.Error(), "event log: ") {
+		t.Fatalf("event log error expected, got %v", err)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
@@ -48,6 +48,13 @@ type Automaton struct {
 	LocalVariablesCopyFunction LVSCopyHandler
 	// - function that resolves alias vars contained in LVS
 	CompleteLocalVariablesAliasFunction LVSAliasHandler
+	// - functions that encode and decode all local vars for a snapshot (see snapshot)
+	// -- nil ... machines of the automaton cannot be in a snapshot
+	LocalVariablesSnapshotFunction LVSSnapshotHandler
+	LocalVariablesRestoreFunction  LVSRestoreHandler
+	// - function that describes the LVS for model checking, if some local vars are irrelevant for the state
+	// -- nil ... all local vars are described (see LocalVariablesFingerprint)
+	LocalVariablesFingerprintFunction LVSFingerprintHandler
 	//------------------------------------------------------------
 	// code to be performed per state
 	// - key = state id
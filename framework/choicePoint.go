@@ -23,13 +23,12 @@
 // - abbreviation: CP ... choice point
 //------------------------------------------------------------
 // Code Review: 2021 Apr, Eva Maria Kuehn
-// - TBD: enhance FetchChoice: specify a selection criterion
 //////////////////////////////////////////////////////////////
 
 package framework
 
 import (
-	//	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/config"
 	. "github.com/peermodel/simulator/debug"
 	"fmt"
 	//	"math/rand"
@@ -160,20 +159,35 @@ func (cp *ChoicePoint) ContainsKey(key string) bool {
 //------------------------------------------------------------
 // fetch a choice from any choice point and remove it; i.e.the respective  CP is changed;
 // returns the selected machine key and "" if no choice was found;
-func (cp *ChoicePoint) EasyFetchChoice() string {
+// - the key is selected by MC_CP_KEY_SELECTION_CRITERION:
+// -- FIRST_KEY: the first one in the order of SortMachineKeys
+// -- RANDOM_KEY: drawn from randomIntn, ie the seeded and recorded random source of a status (see RandomIntn)
+// - nb: the choices have no times (any more) -> the time criterion is not relevant
+func (cp *ChoicePoint) EasyFetchChoice(randomIntn func(n int) int) string {
 	//------------------------------------------------------------
-	// is there any CP? if so just take the first one
-	for key, _ := range cp.Choices {
-		//------------------------------------------------------------
-		// remove the choice
-		delete(cp.Choices, key)
-		//------------------------------------------------------------
-		// return
-		return key
+	// is there any choice?
+	if 0 == len(cp.Choices) {
+		return ""
+	}
+	//------------------------------------------------------------
+	// sort the keys; map order is random
+	keys := make([]string, 0, len(cp.Choices))
+	for key := range cp.Choices {
+		keys = append(keys, key)
 	}
+	SortMachineKeys(keys)
+	//------------------------------------------------------------
+	// apply the key criterion
+	key := keys[0]
+	if RANDOM_KEY == MC_CP_KEY_SELECTION_CRITERION {
+		key = keys[randomIntn(len(keys))]
+	}
+	//------------------------------------------------------------
+	// remove the choice
+	delete(cp.Choices, key)
 	//------------------------------------------------------------
 	// return
-	return ""
+	return key
 }
 
 ////------------------------------------------------------------
@@ -0,0 +1,173 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the seeded random source and of the selection of the choices
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/config"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// restore the seeds, the random generator and the choice criterion after the test
+func resetRandom(t *testing.T) {
+	seed, runSeed, generator, seededFlag, criterion := SEED, RUN_SEED, RANDOM_GENERATOR, RANDOM_GENERATOR_WAS_SEEDED_FLAG, MC_CP_KEY_SELECTION_CRITERION
+	t.Cleanup(func() {
+		SEED, RUN_SEED, RANDOM_GENERATOR, RANDOM_GENERATOR_WAS_SEEDED_FLAG, MC_CP_KEY_SELECTION_CRITERION = seed, runSeed, generator, seededFlag, criterion
+	})
+}
+
+//------------------------------------------------------------
+// n draws in [0, 1000) of the random generator
+func draws(n int) []int {
+	values := make([]int, n)
+	for i := range values {
+		values[i] = RANDOM_GENERATOR.Intn(1000)
+	}
+	return values
+}
+
+//------------------------------------------------------------
+func newTestChoicePoint(keys ...string) *ChoicePoint {
+	cp := NewChoicePoint()
+	for _, key := range keys {
+		cp.Choices[key] = 0
+	}
+	return cp
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// the first run uses the seed itself, the others get different seeds
+func TestRunSeed(t *testing.T) {
+	seeds := map[int64]int{}
+	for run := 1; run <= 100; run++ {
+		seed := RunSeed(7, run)
+		if other, found := seeds[seed]; found {
+			t.Fatalf("runs %d and %d have the same seed %d", other, run, seed)
+		}
+		seeds[seed] = run
+	}
+	if 7 != RunSeed(7, 1) || RunSeed(7, 2) != RunSeed(7, 2) || RunSeed(7, 2) == RunSeed(8, 2) {
+		t.Fatalf("run 1 with seed 7 and reproducible seeds expected")
+	}
+}
+
+//------------------------------------------------------------
+// the draws of a run depend only on the seed and the run
+func TestSeedRun(t *testing.T) {
+	resetRandom(t)
+	SEED = 7
+	SeedRun(2)
+	if RunSeed(7, 2) != RUN_SEED || !RANDOM_GENERATOR_WAS_SEEDED_FLAG {
+		t.Fatalf("run seed %d expected, got %d", RunSeed(7, 2), RUN_SEED)
+	}
+	run2 := draws(10)
+	SeedRun(1)
+	run1 := draws(10)
+	SeedRun(2)
+	if again := draws(10); !reflect.DeepEqual(run2, again) {
+		t.Fatalf("equal draws of run 2 expected: %v, %v", run2, again)
+	}
+	if reflect.DeepEqual(run1, run2) {
+		t.Fatalf("different draws of the runs expected: %v", run1)
+	}
+	// - a single run with the run seed as seed repeats the run
+	SEED = RunSeed(7, 2)
+	SeedRun(1)
+	if again := draws(10); !reflect.DeepEqual(run2, again) {
+		t.Fatalf("equal draws of run 2 expected: %v, %v", run2, again)
+	}
+}
+
+//------------------------------------------------------------
+// the order depends on the names and contexts and on the order of creation, not on the machine numbers
+func TestSortMachineKeys(t *testing.T) {
+	keys := []string{"Wiring__M12__P1__P1_W2", "Wiring__M3__P1__P1_W1", "Read__M2__P1", "Wiring__M10__P1__P1_W1"}
+	SortMachineKeys(keys)
+	expected := "Read__M2__P1 Wiring__M3__P1__P1_W1 Wiring__M10__P1__P1_W1 Wiring__M12__P1__P1_W2"
+	if got := strings.Join(keys, " "); expected != got {
+		t.Fatalf("%s expected, got %s", expected, got)
+	}
+	if got := MachineKeyWithoutNumber("Wiring__M10__P1__P1_W1"); "Wiring__P1__P1_W1" != got {
+		t.Fatalf("Wiring__P1__P1_W1 expected, got %s", got)
+	}
+}
+
+//------------------------------------------------------------
+// FIRST_KEY takes the choices in the order of SortMachineKeys, RANDOM_KEY draws them from the given source
+func TestEasyFetchChoice(t *testing.T) {
+	resetRandom(t)
+	keys := []string{"Wiring__M12__P1__P1_W2", "Wiring__M3__P1__P1_W1", "Wiring__M10__P1__P1_W1"}
+	noDraw := func(n int) int {
+		t.Fatalf("no draw expected")
+		return 0
+	}
+	MC_CP_KEY_SELECTION_CRITERION = FIRST_KEY
+	cp := newTestChoicePoint(keys...)
+	got := []string{}
+	for key := cp.EasyFetchChoice(noDraw); "" != key; key = cp.EasyFetchChoice(noDraw) {
+		got = append(got, key)
+	}
+	if expected := "Wiring__M3__P1__P1_W1 Wiring__M10__P1__P1_W1 Wiring__M12__P1__P1_W2"; expected != strings.Join(got, " ") {
+		t.Fatalf("%s expected, got %v", expected, got)
+	}
+	// - the last one of the sorted keys, then the first of the rest
+	MC_CP_KEY_SELECTION_CRITERION = RANDOM_KEY
+	cp = newTestChoicePoint(keys...)
+	ns := []int{}
+	last := func(n int) int {
+		ns = append(ns, n)
+		return n - 1
+	}
+	first := func(n int) int {
+		ns = append(ns, n)
+		return 0
+	}
+	if key1, key2 := cp.EasyFetchChoice(last), cp.EasyFetchChoice(first); "Wiring__M12__P1__P1_W2" != key1 || "Wiring__M3__P1__P1_W1" != key2 {
+		t.Fatalf("Wiring__M12__P1__P1_W2 and Wiring__M3__P1__P1_W1 expected, got %s and %s", key1, key2)
+	}
+	if !reflect.DeepEqual([]int{3, 2}, ns) || 1 != len(cp.Choices) {
+		t.Fatalf("draws in [0, 3) and [0, 2) and 1 choice left expected, got %v, %d", ns, len(cp.Choices))
+	}
+	if key := newTestChoicePoint().EasyFetchChoice(noDraw); "" != key {
+		t.Fatalf("no choice expected, got %s", key)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
@@ -67,8 +67,9 @@ func NewChoicePoints() *ChoicePoints {
 // - key  ... the selected machine key
 // - cp   ... the selected & updated choice point (ie the selected choice is removed);
 //            is needed by caller; caution might be removed from choice points by this function (and empty)
+// - randomIntn ... random source for the key selection (see ChoicePoint.EasyFetchChoice)
 // CAUTION: caller must assure that there are still choices when calling this fu
-func (cpsPtr *ChoicePoints) EasyFetchChoice() (string, *ChoicePoint) {
+func (cpsPtr *ChoicePoints) EasyFetchChoice(randomIntn func(n int) int) (string, *ChoicePoint) {
 	//------------------------------------------------------------
 	// local vars
 	theCpNo := -1
@@ -83,7 +84,7 @@ func (cpsPtr *ChoicePoints) EasyFetchChoice() (string, *ChoicePoint) {
 	// - try to fetch a choice
 	for i := 0; i < len(cps); i++ {
 		// nb: fetch updates the CP, i.e. removes the choice and possibly also its key in the CP's map, if found:
-		retKey = cps[i].EasyFetchChoice()
+		retKey = cps[i].EasyFetchChoice(randomIntn)
 		if retKey != "" {
 			// set return vals
 			theCpNo = i
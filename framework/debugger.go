@@ -0,0 +1,465 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// interactive debugger of the controller
+// - on if configured (see config: DEBUGGER): commands are read from stdin or from a tcp connection
+// - the controller asks the debugger before it lets the selected machine enter the critical section
+//   (see Controller); the debugger pauses there:
+// -- at the first step of the first run
+// -- after a step command, ie after one critical section
+// -- at the first step at or after the clock given by the until command
+// -- at a breakpoint: the selected machine is in the given state of the given automaton (state id or
+//    comment), or the given wiring id is a part of its machine key
+// - while paused, there is no machine in the critical section; the commands (see help) can:
+// -- print the space, the machines and the context of a machine
+// -- write and take entries by hand (see IMetaContext: DebugWriteEntry, DebugTakeEntry); every such
+//    operation is an event of its own (see EVENT_CLOCK); nb: it is not contained in the replay trace
+// -- nb: the selected machine is resumed anyway; if its condition does not hold any more, it waits again
+// - the end of the input detaches the debugger, ie the runs go on without it
+// - quit stops the run, and all further runs
+// - output of the space and of contexts is captured from the trace file (see TRACE_FILE)
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/helpers"
+	. "github.com/peermodel/simulator/scheduler"
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////
+// vars
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// private:
+// the debugger; nil if off
+var debugger *Debugger
+
+//------------------------------------------------------------
+// private:
+// help text
+const debuggerHelp = `commands:
+  step | s                      execute the next critical section and pause again
+  continue | c                  continue until a breakpoint
+  until | u <clock>             continue until the clock is reached
+  break | b                     list the breakpoints
+  break | b state <automaton> <state>
+                                pause before a machine of the automaton executes the state (id or comment)
+  break | b wiring <wid>        pause before a machine of the wiring executes
+  delete | d                    delete all breakpoints
+  machines | m                  print all machines
+  space [all]                   print the space (all: also empty containers)
+  context <machine key>         print the context of the machine (a unique part of its key is enough)
+  write <entry spec>            write an entry, eg: write {peer: P1, container: PIC, type: A}
+  take <cid> [<entry type>]     take an entry, eg: take P1_PIC A
+  quit | q                      stop the run
+  help | h                      this text
+`
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+type Debugger struct {
+	//------------------------------------------------------------
+	// commands and output
+	in  *bufio.Scanner
+	out io.Writer
+	// - tcp only
+	listener net.Listener
+	conn     net.Conn
+	//------------------------------------------------------------
+	// pause at the next step
+	stepFlag bool
+	// pause at the first step at or after this clock (-1 ... none)
+	untilClock int
+	//------------------------------------------------------------
+	// breakpoints
+	// - key = "<automaton> <state>"
+	stateBreakpoints map[string]bool
+	// - key = wiring id
+	wiringBreakpoints map[string]bool
+	//------------------------------------------------------------
+	// end of the input
+	detachedFlag bool
+	// quit command
+	quitFlag bool
+}
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// open the debugger (see config: DEBUGGER); "" = off
+// - tcp: blocks until the first connection
+func OpenDebugger(address string) error {
+	CloseDebugger()
+	if "" == address {
+		return nil
+	}
+	d := &Debugger{
+		stepFlag:          true,
+		untilClock:        -1,
+		stateBreakpoints:  map[string]bool{},
+		wiringBreakpoints: map[string]bool{},
+	}
+	if "stdin" == address {
+		d.in = bufio.NewScanner(os.Stdin)
+		d.out = os.Stdout
+	} else {
+		var err error
+		if d.listener, err = net.Listen("tcp", address); nil != err {
+			return fmt.Errorf("debugger: %s", err)
+		}
+		SystemInfo(fmt.Sprintf("DEBUGGER: waiting for a connection on %s", d.listener.Addr()))
+		if d.conn, err = d.listener.Accept(); nil != err {
+			d.listener.Close()
+			return fmt.Errorf("debugger: %s", err)
+		}
+		d.in = bufio.NewScanner(d.conn)
+		d.out = d.conn
+	}
+	debugger = d
+	d.printf("pmsim debugger (help: h)\n")
+	return nil
+}
+
+//------------------------------------------------------------
+func CloseDebugger() {
+	if nil == debugger {
+		return
+	}
+	if nil != debugger.conn {
+		debugger.conn.Close()
+	}
+	if nil != debugger.listener {
+		debugger.listener.Close()
+	}
+	debugger = nil
+}
+
+//------------------------------------------------------------
+// has the debugger stopped the runs?
+func DebuggerQuitted() bool {
+	return nil != debugger && debugger.quitFlag
+}
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// private fu:
+// called by the controller before the selected machine enters the critical section
+// - pauses and executes commands, if due
+// - returns false if the run shall be stopped
+func (d *Debugger) beforeStep(s *Status, mc *MachineControl) bool {
+	if d.quitFlag {
+		return false
+	}
+	if d.detachedFlag {
+		return true
+	}
+	//------------------------------------------------------------
+	// pause?
+	reason := d.pauseReason(mc)
+	if "" == reason {
+		return true
+	}
+	d.stepFlag = false
+	d.untilClock = -1
+	d.printf("PAUSE (%s): run=%d, t=%d, et=%d, next: %s (state %s)\n", reason, RUN_COUNT, CLOCK, EVENT_CLOCK, mc.M.Key(), d.stateName(mc.M))
+	//------------------------------------------------------------
+	// command loop
+	for {
+		d.printf("(pmdbg) ")
+		if !d.in.Scan() {
+			d.detachedFlag = true
+			return true
+		}
+		line := strings.TrimSpace(d.in.Text())
+		fields := strings.Fields(line)
+		if 0 == len(fields) {
+			continue
+		}
+		args := fields[1:]
+		switch fields[0] {
+		case "step", "s":
+			d.stepFlag = true
+			return true
+		case "continue", "c":
+			return true
+		case "until", "u":
+			if 1 != len(args) {
+				d.printf("usage: until <clock>\n")
+				continue
+			}
+			t, err := strconv.Atoi(args[0])
+			if nil != err || t < CLOCK {
+				d.printf("ill. clock %s (t=%d)\n", args[0], CLOCK)
+				continue
+			}
+			d.untilClock = t
+			return true
+		case "break", "b":
+			d.breakCmd(s, args)
+		case "delete", "d":
+			d.stateBreakpoints = map[string]bool{}
+			d.wiringBreakpoints = map[string]bool{}
+		case "machines", "m":
+			d.printMachines(s)
+		case "space":
+			allFlag := 1 == len(args) && "all" == args[0]
+			d.printf("%s", captureTrace(func() {
+				s.MetaContext.SpacePrint(TRACE0, 0 /* nBlanks */, allFlag)
+			}))
+		case "context":
+			if 1 != len(args) {
+				d.printf("usage: context <machine key>\n")
+				continue
+			}
+			if m := d.findMachine(s, args[0]); nil != m {
+				d.printf("%s:\n%s", m.Key(), captureTrace(func() {
+					m.Context.Println(TAB)
+				}))
+			}
+		case "write":
+			if 0 == len(args) {
+				d.printf("usage: write <entry spec>\n")
+				continue
+			}
+			EVENT_CLOCK++
+			eid, err := s.MetaContext.DebugWriteEntry(strings.TrimSpace(strings.TrimPrefix(line, fields[0])), &s.Scheduler)
+			d.printResult("written", eid, err)
+		case "take":
+			if 1 != len(args) && 2 != len(args) {
+				d.printf("usage: take <cid> [<entry type>]\n")
+				continue
+			}
+			entryType := ""
+			if 2 == len(args) {
+				entryType = args[1]
+			}
+			EVENT_CLOCK++
+			eid, err := s.MetaContext.DebugTakeEntry(args[0], entryType)
+			d.printResult("taken", eid, err)
+		case "quit", "q":
+			d.quitFlag = true
+			return false
+		case "help", "h":
+			d.printf("%s", debuggerHelp)
+		default:
+			d.printf("unknown command %s (help: h)\n", fields[0])
+		}
+	}
+}
+
+//------------------------------------------------------------
+// private fu:
+// why to pause before the machine executes; "" = do not pause
+func (d *Debugger) pauseReason(mc *MachineControl) string {
+	m := mc.M
+	switch {
+	case d.stepFlag:
+		return "step"
+	case 0 <= d.untilClock && CLOCK >= d.untilClock:
+		return fmt.Sprintf("until %d", d.untilClock)
+	case d.stateBreakpoints[fmt.Sprintf("%s %s", m.A.Name, m.CurrentState)]:
+		return fmt.Sprintf("break state %s %s", m.A.Name, m.CurrentState)
+	case d.stateBreakpoints[fmt.Sprintf("%s %s", m.A.Name, m.A.StateComments[m.CurrentState])]:
+		return fmt.Sprintf("break state %s %s", m.A.Name, m.A.StateComments[m.CurrentState])
+	}
+	for _, part := range strings.Split(m.Context.MachineKeySuffix(), "__") {
+		if d.wiringBreakpoints[part] {
+			return fmt.Sprintf("break wiring %s", part)
+		}
+	}
+	return ""
+}
+
+//------------------------------------------------------------
+// private fu:
+// set or list breakpoints
+// - a state breakpoint needs an automaton whose machines are controlled by the controller, ie started ASYNC
+//   (eg Wiring); a SYNC sub-automaton runs within the critical section of its caller and is never selected
+func (d *Debugger) breakCmd(s *Status, args []string) {
+	switch {
+	case 0 == len(args):
+		keys := []string{}
+		for key := range d.stateBreakpoints {
+			keys = append(keys, fmt.Sprintf("state %s", key))
+		}
+		for wid := range d.wiringBreakpoints {
+			keys = append(keys, fmt.Sprintf("wiring %s", wid))
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			d.printf("  %s\n", key)
+		}
+	case 3 == len(args) && "state" == args[0]:
+		a := s.controlledAutomaton(args[1])
+		if nil == a {
+			d.printf("automaton %s has no machine controlled by the controller (sync sub-automaton?)\n", args[1])
+			return
+		}
+		if _, ok := a.StateComments[args[2]]; !ok && !a.hasStateComment(args[2]) {
+			d.printf("automaton %s has no state %s\n", args[1], args[2])
+			return
+		}
+		d.stateBreakpoints[fmt.Sprintf("%s %s", args[1], args[2])] = true
+	case 2 == len(args) && "wiring" == args[0]:
+		d.wiringBreakpoints[args[1]] = true
+	default:
+		d.printf("usage: break [state <automaton> <state> | wiring <wid>]\n")
+	}
+}
+
+//------------------------------------------------------------
+// private fu:
+// the automaton of the given name, if a machine controlled by the controller executes it; nil otherwise
+func (s *Status) controlledAutomaton(name string) *Automaton {
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	defer s.StatusMutex.RUnlock()
+	for _, mc := range s.MachineControls {
+		if name == mc.M.A.Name && SYNC != mc.M.StartType {
+			return mc.M.A
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------
+// private fu:
+// is the comment the comment of a state of the automaton?
+func (a *Automaton) hasStateComment(comment string) bool {
+	for _, c := range a.StateComments {
+		if comment == c {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------
+// private fu:
+// print all machines in the order of their keys
+func (d *Debugger) printMachines(s *Status) {
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	defer s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	keys := s.machineKeys()
+	SortMachineKeys(keys)
+	for _, key := range keys {
+		mc := s.MachineControls[key]
+		d.printf("  %s (state %s): %s\n", key, d.stateName(mc.M), mc.Condition.String())
+	}
+}
+
+//------------------------------------------------------------
+// private fu:
+// machine whose key is or contains the given string (if unique); nil if none
+func (d *Debugger) findMachine(s *Status, key string) *Machine {
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	defer s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	if mc := s.MachineControls[key]; nil != mc {
+		return mc.M
+	}
+	found := []*Machine{}
+	for k, mc := range s.MachineControls {
+		if strings.Contains(k, key) {
+			found = append(found, mc.M)
+		}
+	}
+	switch len(found) {
+	case 0:
+		d.printf("no machine %s\n", key)
+		return nil
+	case 1:
+		return found[0]
+	}
+	d.printf("%d machines contain %s\n", len(found), key)
+	return nil
+}
+
+//------------------------------------------------------------
+// private fu:
+// state id and comment
+func (d *Debugger) stateName(m *Machine) string {
+	if comment := m.A.StateComments[m.CurrentState]; "" != comment {
+		return fmt.Sprintf("%s: %s", m.CurrentState, comment)
+	}
+	return m.CurrentState
+}
+
+//------------------------------------------------------------
+// private fu:
+func (d *Debugger) printResult(what string, eid string, err error) {
+	if nil != err {
+		d.printf("%s\n", err)
+		return
+	}
+	d.printf("%s %s (et=%d)\n", what, eid, EVENT_CLOCK)
+}
+
+//------------------------------------------------------------
+// private fu:
+func (d *Debugger) printf(format string, a ...interface{}) {
+	fmt.Fprintf(d.out, format, a...)
+}
+
+//------------------------------------------------------------
+// private fu:
+// output written to the trace file by the fu
+// - nb: the trace file is redirected meanwhile
+func captureTrace(fu func()) string {
+	r, w, err := os.Pipe()
+	if nil != err {
+		return fmt.Sprintf("%s\n", err)
+	}
+	done := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		r.Close()
+		done <- string(data)
+	}()
+	origTraceFile := TRACE_FILE
+	TRACE_FILE = w
+	fu()
+	TRACE_FILE = origTraceFile
+	w.Close()
+	return <-done
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
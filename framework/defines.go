@@ -27,6 +27,7 @@
 package framework
 
 import (
+	. "github.com/peermodel/simulator/config"
 	"math/rand"
 )
 
@@ -45,11 +46,33 @@ var RUN_COUNT int
 // - Use the Seed function to initialize the default Source if different behavior is required for each run.
 // - Typically a non-fixed seed should be used, such as time.Now().UnixNano().
 // - https://golang.org/pkg/time/#Time.UnixNano
-var RANDOM_GENERATOR *rand.Rand = rand.New(rand.NewSource(99))
+// - the runtime seeds it for every run (see SeedRun)
+var RANDOM_GENERATOR *rand.Rand = rand.New(rand.NewSource(DEFAULT_SEED))
 
 // static var that signals if the random generator has already been "seeded"
 var RANDOM_GENERATOR_WAS_SEEDED_FLAG = false
 
+//------------------------------------------------------------
+// seed of the random generator for the current run
+var RUN_SEED int64 = DEFAULT_SEED
+
+//------------------------------------------------------------
+// verdict of the verification
+// - reset by the runtime before the test case is run; set when a violation is detected
+var VERDICT VerdictTypeEnum = NO_VIOLATION
+
+//------------------------------------------------------------
+// description of the violation (empty if none)
+var VERDICT_MSG string
+
+//------------------------------------------------------------
+// path that led to the violation (empty if none or if not known)
+var COUNTEREXAMPLE Path
+
+//------------------------------------------------------------
+// random draws made on the path of the counterexample
+var COUNTEREXAMPLE_DRAWS RandomDraws
+
 //////////////////////////////////////////////////////////////
 // consts
 //////////////////////////////////////////////////////////////
@@ -101,6 +124,72 @@ func (t MachineMessageTypeEnum) String() string {
 	}
 }
 
+//============================================================
+// verdict types
+//============================================================
+
+//------------------------------------------------------------
+type VerdictTypeEnum int
+
+//------------------------------------------------------------
+const (
+	NO_VIOLATION VerdictTypeEnum = iota
+	INVARIANT_VIOLATION
+	DEADLOCK
+	LIVELOCK
+	PROPERTY_VIOLATION
+	REPLAY_DIVERGENCE
+)
+
+//------------------------------------------------------------
+func (t VerdictTypeEnum) String() string {
+	switch t {
+	case NO_VIOLATION:
+		return "NO_VIOLATION"
+	case INVARIANT_VIOLATION:
+		return "INVARIANT_VIOLATION"
+	case DEADLOCK:
+		return "DEADLOCK"
+	case LIVELOCK:
+		return "LIVELOCK"
+	case PROPERTY_VIOLATION:
+		return "PROPERTY_VIOLATION"
+	case REPLAY_DIVERGENCE:
+		return "REPLAY_DIVERGENCE"
+	default:
+		return "ill. verdict type"
+	}
+}
+
+//------------------------------------------------------------
+// set the verdict and its counterexample, unless a violation has already been found
+func SetVerdict(verdict VerdictTypeEnum, msg string, path Path, draws RandomDraws) {
+	if NO_VIOLATION == VERDICT {
+		VERDICT = verdict
+		VERDICT_MSG = msg
+		COUNTEREXAMPLE = path.Copy()
+		COUNTEREXAMPLE_DRAWS = append(RandomDraws{}, draws...)
+	}
+}
+
+//------------------------------------------------------------
+// seed the random generator for the given run (1, 2, ...) with a seed derived from the configured SEED
+// - so every simulation run gets another, but reproducible random order
+func SeedRun(run int) {
+	RUN_SEED = RunSeed(SEED, run)
+	RANDOM_GENERATOR = rand.New(rand.NewSource(RUN_SEED))
+	RANDOM_GENERATOR_WAS_SEEDED_FLAG = true
+}
+
+//------------------------------------------------------------
+// seed of the given run
+// - the first run uses the seed itself; ie a single run can be repeated by configuring its run seed as seed
+func RunSeed(seed int64, run int) int64 {
+	// - golden ratio increment (as in splitmix64) spreads the seeds of consecutive runs
+	const gamma uint64 = 0x9E3779B97F4A7C15
+	return int64(uint64(seed) + uint64(run-1)*gamma)
+}
+
 //============================================================
 // machine start types
 //============================================================
@@ -0,0 +1,432 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// http api to observe and drive the running simulation
+// - on if configured (see config: HTTP_API); listens on a localhost address only
+// - json endpoints:
+// -- GET  /clock                 run, clock and event clock
+// -- GET  /containers            all containers with their entries
+// -- GET  /machines              all machine controls with their wait conditions
+// -- GET  /slots                 the slots of the scheduler, in the order of their time
+// -- GET  /statistics            counters of the run (see PrintStatistics)
+// -- GET  /transactions          the running transactions
+// -- POST /peers/<pid>/pic       write the entry given in the body into the PIC of the peer (see IMetaContext: InjectEntry);
+//                                returns its id; nb: not possible in model checking and replay, as it is not part of the path
+// - the requests are served by the controller before it lets the next machine enter the critical section,
+//   ie when no machine is in the critical section (see Controller)
+// -- when no run is active (before the first run, between the runs, after the last run), a request is
+//    refused with 503 service unavailable; nb: the status must not be accessed besides its controller
+// -- an injected entry is an event of its own (see EVENT_CLOCK); nb: it is not contained in the replay trace
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/helpers"
+	. "github.com/peermodel/simulator/scheduler"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//////////////////////////////////////////////////////////////
+// consts
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// max. number of requests that wait for the controller
+const HTTP_API_QUEUE_SIZE int = 64
+
+//////////////////////////////////////////////////////////////
+// vars
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// private:
+// the http api; nil if off
+var httpApi *HttpApi
+
+//------------------------------------------------------------
+// private:
+// errors of a request that was not served -> 503
+var errHttpApiNoRun = errors.New("no run is active")
+var errHttpApiQueueFull = errors.New("too many pending requests")
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+type HttpApi struct {
+	listener net.Listener
+	server   *http.Server
+	//------------------------------------------------------------
+	// requests to be served by the controller
+	requests chan *httpApiRequest
+	//------------------------------------------------------------
+	// protects the fields below
+	mutex sync.Mutex
+	// is the controller of a run running? only then requests are accepted
+	runningFlag bool
+}
+
+//------------------------------------------------------------
+// private:
+// request to be served on the status
+type httpApiRequest struct {
+	fu    func(s *Status) (interface{}, error)
+	reply chan httpApiReply
+}
+
+//------------------------------------------------------------
+// private:
+type httpApiReply struct {
+	data interface{}
+	err  error
+}
+
+//------------------------------------------------------------
+// json views
+type HttpApiClock struct {
+	Run        int
+	Clock      int
+	EventClock int
+}
+
+//------------------------------------------------------------
+type HttpApiMachine struct {
+	Key          string
+	Automaton    string
+	State        string
+	StateComment string `json:",omitempty"`
+	// wait condition
+	Condition         string
+	ConditionType     string
+	Wait4Time         int
+	IssueEventTime    int
+	Waiting           bool
+	LastExecutionTime int
+	NCriticalSections int
+}
+
+//------------------------------------------------------------
+type HttpApiSlot struct {
+	Key  string
+	Type string
+	Time int
+	Info string
+}
+
+//------------------------------------------------------------
+type HttpApiStatistics struct {
+	Run                int
+	Clock              int
+	EventClock         int
+	CriticalSections   int
+	TerminatedMachines int
+	Machines           int
+	Slots              int
+	ExecutionMode      string
+	VerificationMode   string
+	Executor           string
+	Seed               int64
+	RunSeed            int64
+	RandomDraws        int
+	Txs                string `json:",omitempty"`
+}
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// open the http api (see config: HTTP_API); "" = off
+// - nb: the address is checked to be a localhost one (see Config.Validate)
+func OpenHttpApi(address string) error {
+	CloseHttpApi()
+	if "" == address {
+		return nil
+	}
+	a := &HttpApi{requests: make(chan *httpApiRequest, HTTP_API_QUEUE_SIZE)}
+	var err error
+	if a.listener, err = net.Listen("tcp", address); nil != err {
+		return fmt.Errorf("http api: %s", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/clock", a.handleGet(a.clock))
+	mux.HandleFunc("/containers", a.handleGet(a.containers))
+	mux.HandleFunc("/machines", a.handleGet(a.machines))
+	mux.HandleFunc("/slots", a.handleGet(a.slots))
+	mux.HandleFunc("/statistics", a.handleGet(a.statistics))
+	mux.HandleFunc("/transactions", a.handleGet(a.transactions))
+	mux.HandleFunc("/peers/", a.handleInject)
+	a.server = &http.Server{Handler: mux}
+	go a.server.Serve(a.listener)
+	SystemInfo(fmt.Sprintf("HTTP API: listening on http://%s", a.listener.Addr()))
+	httpApi = a
+	return nil
+}
+
+//------------------------------------------------------------
+func CloseHttpApi() {
+	if nil == httpApi {
+		return
+	}
+	httpApi.server.Close()
+	httpApi = nil
+}
+
+//////////////////////////////////////////////////////////////
+// methods: controller side
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// private fu:
+// the controller of the status starts
+func (a *HttpApi) attach(s *Status) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.runningFlag = true
+}
+
+//------------------------------------------------------------
+// private fu:
+// the controller of the status exits
+// - serves the pending requests, as they are not served by the controller any more; later requests are refused
+func (a *HttpApi) detach(s *Status) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.runningFlag = false
+	a.serve(s)
+}
+
+//------------------------------------------------------------
+// private fu:
+// serve all pending requests
+// - caution: no machine must be in the critical section
+func (a *HttpApi) serve(s *Status) {
+	for {
+		select {
+		case r := <-a.requests:
+			data, err := r.fu(s)
+			r.reply <- httpApiReply{data: data, err: err}
+		default:
+			return
+		}
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// methods: http side
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// private fu:
+// let the controller of the running run serve the fu on its status, and wait for its result
+// - error if no run is active (see errHttpApiNoRun), or if the queue is full
+func (a *HttpApi) do(fu func(s *Status) (interface{}, error)) (interface{}, error) {
+	a.mutex.Lock()
+	if !a.runningFlag {
+		a.mutex.Unlock()
+		return nil, errHttpApiNoRun
+	}
+	r := &httpApiRequest{fu: fu, reply: make(chan httpApiReply, 1)}
+	select {
+	case a.requests <- r:
+	default:
+		a.mutex.Unlock()
+		return nil, errHttpApiQueueFull
+	}
+	a.mutex.Unlock()
+	reply := <-r.reply
+	return reply.data, reply.err
+}
+
+//------------------------------------------------------------
+// private fu:
+func (a *HttpApi) handleGet(fu func(s *Status) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if http.MethodGet != req.Method {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := a.do(fu)
+		if nil != err {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJson(w, data)
+	}
+}
+
+//------------------------------------------------------------
+// private fu:
+// POST /peers/<pid>/pic
+func (a *HttpApi) handleInject(w http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if 3 != len(parts) || "pic" != parts[2] {
+		http.NotFound(w, req)
+		return
+	}
+	if http.MethodPost != req.Method {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if MODEL_CHECKING == VERIFICATION_MODE || REPLAY == VERIFICATION_MODE {
+		http.Error(w, fmt.Sprintf("entries cannot be injected in %s", VERIFICATION_MODE), http.StatusConflict)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if nil != err {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pid := parts[1]
+	data, err := a.do(func(s *Status) (interface{}, error) {
+		EVENT_CLOCK++
+		eid, err := s.MetaContext.InjectEntry(pid, string(body), &s.Scheduler)
+		if nil != err {
+			return nil, err
+		}
+		return map[string]string{"Eid": eid}, nil
+	})
+	if nil != err {
+		http.Error(w, err.Error(), httpStatusOf(err, http.StatusBadRequest))
+		return
+	}
+	writeJson(w, data)
+}
+
+//------------------------------------------------------------
+// private fu:
+// http status of the error of a request: 503 if it was not served, otherwise the given one
+func httpStatusOf(err error, status int) int {
+	if errHttpApiNoRun == err || errHttpApiQueueFull == err {
+		return http.StatusServiceUnavailable
+	}
+	return status
+}
+
+//------------------------------------------------------------
+// private fu:
+func writeJson(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); nil != err {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// methods: views
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// private fu:
+func (a *HttpApi) clock(s *Status) (interface{}, error) {
+	return HttpApiClock{Run: RUN_COUNT, Clock: CLOCK, EventClock: EVENT_CLOCK}, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+func (a *HttpApi) containers(s *Status) (interface{}, error) {
+	data, err := s.MetaContext.ContainersJson()
+	return json.RawMessage(data), err
+}
+
+//------------------------------------------------------------
+// private fu:
+// in the order of the machine keys
+func (a *HttpApi) machines(s *Status) (interface{}, error) {
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	defer s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	keys := s.machineKeys()
+	SortMachineKeys(keys)
+	machines := []HttpApiMachine{}
+	for _, key := range keys {
+		mc := s.MachineControls[key]
+		m := mc.M
+		machines = append(machines, HttpApiMachine{
+			Key:               key,
+			Automaton:         m.A.Name,
+			State:             m.CurrentState,
+			StateComment:      m.A.StateComments[m.CurrentState],
+			Condition:         mc.Condition.String(),
+			ConditionType:     mc.Condition.Type.String(),
+			Wait4Time:         mc.Condition.Wait4Time,
+			IssueEventTime:    mc.Condition.IssueEventTime,
+			Waiting:           mc.Waiting,
+			LastExecutionTime: mc.LastExecutionTime,
+			NCriticalSections: mc.NCriticalSections,
+		})
+	}
+	return machines, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+func (a *HttpApi) slots(s *Status) (interface{}, error) {
+	slots := []HttpApiSlot{}
+	for _, slot := range s.Scheduler.Slots() {
+		slots = append(slots, HttpApiSlot{Key: slot.Key(), Type: slot.Type.String(), Time: slot.Time, Info: slot.ToString(0)})
+	}
+	return slots, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+func (a *HttpApi) statistics(s *Status) (interface{}, error) {
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	nMachines := len(s.MachineControls)
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	return HttpApiStatistics{
+		Run:                RUN_COUNT,
+		Clock:              CLOCK,
+		EventClock:         EVENT_CLOCK,
+		CriticalSections:   s.CriticalSectionCounter,
+		TerminatedMachines: s.MachineTerminationCounter,
+		Machines:           nMachines,
+		Slots:              s.Scheduler.Len(),
+		ExecutionMode:      fmt.Sprintf("%s", EXECUTION_MODE),
+		VerificationMode:   fmt.Sprintf("%s", VERIFICATION_MODE),
+		Executor:           EXECUTOR.String(),
+		Seed:               SEED,
+		RunSeed:            RUN_SEED,
+		RandomDraws:        len(s.Draws),
+		Txs:                s.MetaContext.TxStatistics(),
+	}, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+func (a *HttpApi) transactions(s *Status) (interface{}, error) {
+	data, err := s.MetaContext.RunningTransactionsJson()
+	return json.RawMessage(data), err
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
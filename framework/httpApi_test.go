@@ -0,0 +1,156 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the http api: requests are only served by the controller of a running run
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/scheduler"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// open the http api on a free port; it is closed at the end of the test
+func openTestHttpApi(t *testing.T) string {
+	if err := OpenHttpApi("localhost:0"); nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(CloseHttpApi)
+	return "http://" + httpApi.listener.Addr().String()
+}
+
+//------------------------------------------------------------
+// status code and body of the response
+func fetchHttpApi(method string, url string) (int, string, error) {
+	req, err := http.NewRequest(method, url, strings.NewReader(""))
+	if nil != err {
+		return 0, "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if nil != err {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+//------------------------------------------------------------
+func mustFetchHttpApi(t *testing.T, method string, url string) (int, string) {
+	t.Helper()
+	code, body, err := fetchHttpApi(method, url)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return code, body
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+func TestHttpApiNoRun(t *testing.T) {
+	url := openTestHttpApi(t)
+	for _, path := range []string{"/clock", "/containers", "/machines", "/slots", "/statistics", "/transactions"} {
+		if code, body := mustFetchHttpApi(t, http.MethodGet, url+path); http.StatusServiceUnavailable != code || !strings.Contains(body, errHttpApiNoRun.Error()) {
+			t.Errorf("%s: 503 expected, got %d: %s", path, code, body)
+		}
+	}
+	if code, body := mustFetchHttpApi(t, http.MethodPost, url+"/peers/P1/pic"); http.StatusServiceUnavailable != code {
+		t.Errorf("inject: 503 expected, got %d: %s", code, body)
+	}
+	if code, _ := mustFetchHttpApi(t, http.MethodPost, url+"/clock"); http.StatusMethodNotAllowed != code {
+		t.Errorf("405 expected, got %d", code)
+	}
+}
+
+//------------------------------------------------------------
+// a request is served by the controller; after the run it is refused again
+func TestHttpApiServedByController(t *testing.T) {
+	url := openTestHttpApi(t)
+	prevClock := CLOCK
+	defer func() { CLOCK = prevClock }()
+	CLOCK = 42
+	httpApi.attach(nil)
+	type result struct {
+		code int
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		code, body, err := fetchHttpApi(http.MethodGet, url+"/clock")
+		results <- result{code, body, err}
+	}()
+	// - the controller serves the pending requests between the steps
+	var r result
+	for served := false; !served; {
+		httpApi.serve(nil)
+		select {
+		case r = <-results:
+			served = true
+		default:
+		}
+	}
+	var clock HttpApiClock
+	if nil != r.err {
+		t.Fatal(r.err)
+	}
+	if http.StatusOK != r.code || nil != json.Unmarshal([]byte(r.body), &clock) || 42 != clock.Clock {
+		t.Fatalf("clock 42 expected, got %d: %s", r.code, r.body)
+	}
+	httpApi.detach(nil)
+	if code, _ := mustFetchHttpApi(t, http.MethodGet, url+"/clock"); http.StatusServiceUnavailable != code {
+		t.Fatalf("503 after the run expected, got %d", code)
+	}
+}
+
+//------------------------------------------------------------
+// requests that do not fit into the queue are refused
+func TestHttpApiQueueFull(t *testing.T) {
+	url := openTestHttpApi(t)
+	httpApi.attach(nil)
+	defer httpApi.detach(nil)
+	for i := 0; i < HTTP_API_QUEUE_SIZE; i++ {
+		httpApi.requests <- &httpApiRequest{fu: httpApi.clock, reply: make(chan httpApiReply, 1)}
+	}
+	if code, body := mustFetchHttpApi(t, http.MethodGet, url+"/clock"); http.StatusServiceUnavailable != code || !strings.Contains(body, errHttpApiQueueFull.Error()) {
+		t.Fatalf("503 expected, got %d: %s", code, body)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
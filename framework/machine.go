@@ -29,13 +29,18 @@
 package framework
 
 import (
+	. "github.com/peermodel/simulator/config"
 	. "github.com/peermodel/simulator/contextInterface"
 	. "github.com/peermodel/simulator/controller"
 	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/eventLog"
 	. "github.com/peermodel/simulator/helpers"
 	. "github.com/peermodel/simulator/scheduler"
 	"fmt"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 )
 
 //////////////////////////////////////////////////////////////
@@ -46,6 +51,11 @@ import (
 // counts number of machines
 var MACHINE_ID int
 
+//------------------------------------------------------------
+// private:
+// machine number within a machine key (see Key)
+var machineNumberRegexp = regexp.MustCompile(`__M([0-9]+)__`)
+
 //////////////////////////////////////////////////////////////
 // data types
 //////////////////////////////////////////////////////////////
@@ -61,6 +71,20 @@ type LVSCopyHandler func(*Machine, interface{}) interface{}
 // - TBD: is there any more elegant solution for that?
 type LVSAliasHandler func(*Status, *Machine, interface{}) interface{}
 
+//------------------------------------------------------------
+// encode second arg (= LVS) for a snapshot (see snapshot) and return result
+// - nb: without the alias vars, which are recomputed on restore (see LVSAliasHandler)
+type LVSSnapshotHandler func(*Machine, interface{}) ([]byte, error)
+
+//------------------------------------------------------------
+// decode the LVS written by the LVSSnapshotHandler and return result
+// - caution: caller must complete the alias vars
+type LVSRestoreHandler func(*Machine, []byte) (interface{}, error)
+
+//------------------------------------------------------------
+// canonical description of the second arg (= LVS) for model checking (see LocalVariablesFingerprint)
+type LVSFingerprintHandler func(*Machine, interface{}) string
+
 //------------------------------------------------------------
 // data of one machine
 // - including an own context;
@@ -225,6 +249,41 @@ func (m *Machine) Key() string {
 	return (fmt.Sprintf("%s__M%d__%s", m.Name, m.Number, m.Context.MachineKeySuffix()))
 }
 
+//------------------------------------------------------------
+// name (ie comment) of the state with the given id in the automaton of the machine; "" if there is none
+// - eg for state hooks that must not depend on the generated state ids
+func (m *Machine) StateName(stateId string) string {
+	return m.A.StateComments[stateId]
+}
+
+//------------------------------------------------------------
+// machine key without the machine number, ie "<machine-name>__<machine-key-suffix>"
+// - nb: machine numbers depend on how many machines were created before (eg by previous runs)
+func MachineKeyWithoutNumber(key string) string {
+	return machineNumberRegexp.ReplaceAllString(key, "__")
+}
+
+//------------------------------------------------------------
+// sort machine keys by name and context, and then by machine number
+// - ie the order does not depend on the absolute machine numbers, but only on the order in which the machines were created
+func SortMachineKeys(keys []string) {
+	number := func(key string) int {
+		if match := machineNumberRegexp.FindStringSubmatch(key); nil != match {
+			n, _ := strconv.Atoi(match[1])
+			return n
+		}
+		return 0
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ki := MachineKeyWithoutNumber(keys[i])
+		kj := MachineKeyWithoutNumber(keys[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return number(keys[i]) < number(keys[j])
+	})
+}
+
 //------------------------------------------------------------
 // execute the machine
 // - if start type is SYNC the machine runs in caller's thread who must be in the critical section now
@@ -232,6 +291,9 @@ func (m *Machine) Key() string {
 // -- ie machine must enter the critical section before its execution and leave it on exit
 // nb: inbetween the critical section could be left & entered again by the handler code when calling a wait4 fu
 func (m *Machine) Execute(s *Status, mc *MachineControl) {
+	//------------------------------------------------------------
+	// errors raised by the machine stop the run (see recoverError)
+	defer m.recoverError(s)
 	//------------------------------------------------------------
 	// assertions:
 	// - automaton must exist
@@ -241,9 +303,6 @@ func (m *Machine) Execute(s *Status, mc *MachineControl) {
 	//------------------------------------------------------------
 	// debug:
 	//............................................................
-	// - init debug flag: default = no trace
-	stateTraceFlag := false
-	//............................................................
 	// - set my go routine id
 	mc.Gid = GetGoRoutineID()
 	//............................................................
@@ -312,36 +371,10 @@ func (m *Machine) Execute(s *Status, mc *MachineControl) {
 				// debug:
 				// - fmt.Println(fmt.Sprintf("%s: next current state = %s", m.Key(), m.CurrentState)) // DEBUG
 				//------------------------------------------------------------
-				// - get & set trace flag for this state:
-				stateTraceFlag = MACHINE_TRACE_FLAGS[m.Name].SFlags[m.CurrentState]
-				m.ThisStateTraceFlag = stateTraceFlag
-				//------------------------------------------------------------
-				// get state handler
-				h := m.A.StateHandlers[m.CurrentState]
-				//------------------------------------------------------------
-				// assertion: state handler must exist
-				if nil == h {
-					m.SystemError(fmt.Sprintf("no handler function for state %s found", m.CurrentState))
-				}
-				//------------------------------------------------------------
-				// debug
-				// - String2TraceFile(fmt.Sprintf("m.TraceFlag %s ThisStateTraceFlag of machine %s for state = %s = %s\n", m.TraceFlag, m.Name, m.CurrentState,stateTraceFlag)
-				if m.DoTrace() { // DEBUG
-					/**/ m.PrintNumberAndNameAndState() // DEBUG
-					/**/ String2TraceFile(fmt.Sprintf(" -- CLOCK=%d:\n", CLOCK)) // DEBUG
-					/**/ m.Context.Println(IND + TAB) // DEBUG
-				} // DEBUG
-				//------------------------------------------------------------
-				// TBD: assert that is me or a sync submachine of mine in the critical section
-				// - not so easy...
-				//------------------------------------------------------------
-				// !!! HERE THE CODE OF THE AUTOMATON IS EXECUTED !!!
-				//------------------------------------------------------------
-				// execute the state handler
-				// - nb: advances current state to next state
+				// execute the current state
 				// - nb: may call a wait4 function, ie leave & enter the critical section
 				// -- if machine gets stop signal while waiting, its returns STOPPED
-				retval := h(s, m)
+				retval := m.executeState(s)
 				//------------------------------------------------------------
 				// check ret val
 				switch retval {
@@ -395,31 +428,8 @@ func (m *Machine) Execute(s *Status, mc *MachineControl) {
 	//------------------------------------------------------------
 
 	//------------------------------------------------------------
-	// debug
-	if m.DoTrace() { // DEBUG
-		/**/ m.PrintNumberAndName() // DEBUG
-		/**/ String2TraceFile(fmt.Sprintf("machine %s end (life time was %d-%d)\n", m.Key(), m.StartTime, CLOCK)) // DEBUG
-	} // DEBUG
-	//------------------------------------------------------------
-	// debug: statistics
-	// - how many machines were used for this automaton
-	m.A.nMachinesUsedCount++
-	//------------------------------------------------------------
-	// leave the critical section, if still in the CS
-	// - if ASYNC (or fire-and-forget)
-	if SYNC != m.StartType && mIsInCsFlag {
-		//............................................................
-		// debug
-		// m.SystemInfo(fmt.Sprintf("M %s calls leave critical section before exiting, GID=%d", m.Key(), GetGoRoutineID())) // DEBUG
-		//............................................................
-		// leave
-		s.LeaveCriticalSection(m)
-	}
-	//------------------------------------------------------------
-	// clean up
-	// - do it for every machine
-	// caution: locks machine controls in status
-	s.cleanUpTerminatedMachine(m.Key(), m.StartType)
+	// leave the critical section (if still in it) and clean up
+	m.terminate(s, mIsInCsFlag)
 	//------------------------------------------------------------
 	// if machine was stopped, ie not in the CS
 	// - inform controller about TERMINATION and stop my go routine explicitly
@@ -447,6 +457,91 @@ func (m *Machine) Execute(s *Status, mc *MachineControl) {
 	}
 }
 
+//------------------------------------------------------------
+// execute the current state of the machine, ie call its state handler
+// - nb: the machine is in the critical section
+// - returns the ret val of the state handler
+func (m *Machine) executeState(s *Status) StateRetEnum {
+	//------------------------------------------------------------
+	// - get & set trace flag for this state:
+	stateTraceFlag := MACHINE_TRACE_FLAGS[m.Name].SFlags[m.CurrentState]
+	m.ThisStateTraceFlag = stateTraceFlag
+	//------------------------------------------------------------
+	// get state handler
+	h := m.A.StateHandlers[m.CurrentState]
+	//------------------------------------------------------------
+	// assertion: state handler must exist
+	if nil == h {
+		m.SystemError(fmt.Sprintf("no handler function for state %s found", m.CurrentState))
+	}
+	//------------------------------------------------------------
+	// debug
+	// - String2TraceFile(fmt.Sprintf("m.TraceFlag %s ThisStateTraceFlag of machine %s for state = %s = %s\n", m.TraceFlag, m.Name, m.CurrentState,stateTraceFlag)
+	if m.DoTrace() { // DEBUG
+		/**/ m.PrintNumberAndNameAndState() // DEBUG
+		/**/ String2TraceFile(fmt.Sprintf(" -- CLOCK=%d:\n", CLOCK)) // DEBUG
+		/**/ m.Context.Println(IND + TAB) // DEBUG
+	} // DEBUG
+	//------------------------------------------------------------
+	// TBD: assert that is me or a sync submachine of mine in the critical section
+	// - not so easy...
+	//------------------------------------------------------------
+	// !!! HERE THE CODE OF THE AUTOMATON IS EXECUTED !!!
+	//------------------------------------------------------------
+	// execute the state handler
+	// - nb: advances current state to next state
+	// - nb: may call a wait4 function, ie leave & enter the critical section
+	// -- if machine gets stop signal while waiting, its returns STOPPED
+	// -- sequential executor: it returns STOPPED when the machine has left the critical section (see wait)
+	prevState := m.CurrentState
+	retval := h(s, m)
+	//------------------------------------------------------------
+	// event log: state transition and whatever the state hook of the automaton logs
+	if EventLogIsOn() && STOPPED != retval {
+		if prevState != m.CurrentState {
+			m.logEvent(EventRecord{Type: LOG_STATE, From: prevState, To: m.CurrentState})
+		}
+		if hook := STATE_HOOKS[m.Name]; nil != hook {
+			hook(s, m, prevState)
+		}
+	}
+	//------------------------------------------------------------
+	// return
+	return retval
+}
+
+//------------------------------------------------------------
+// end of the machine execution
+// - leave the critical section, if still in it (and ASYNC), and clean up the machine
+func (m *Machine) terminate(s *Status, mIsInCsFlag bool) {
+	//------------------------------------------------------------
+	// debug
+	if m.DoTrace() { // DEBUG
+		/**/ m.PrintNumberAndName() // DEBUG
+		/**/ String2TraceFile(fmt.Sprintf("machine %s end (life time was %d-%d)\n", m.Key(), m.StartTime, CLOCK)) // DEBUG
+	} // DEBUG
+	//------------------------------------------------------------
+	// debug: statistics
+	// - how many machines were used for this automaton
+	m.A.nMachinesUsedCount++
+	//------------------------------------------------------------
+	// leave the critical section, if still in the CS
+	// - if ASYNC (or fire-and-forget)
+	if SYNC != m.StartType && mIsInCsFlag {
+		//............................................................
+		// debug
+		// m.SystemInfo(fmt.Sprintf("M %s calls leave critical section before exiting, GID=%d", m.Key(), GetGoRoutineID())) // DEBUG
+		//............................................................
+		// leave
+		s.LeaveCriticalSection(m)
+	}
+	//------------------------------------------------------------
+	// clean up
+	// - do it for every machine
+	// caution: locks machine controls in status
+	s.cleanUpTerminatedMachine(m.Key(), m.StartType)
+}
+
 //------------------------------------------------------------
 // generic preparartion of starting a machine
 // - private
@@ -502,6 +597,11 @@ func (m *Machine) StartAsync(s *Status, ctx IContext) {
 	// set context
 	m.Context = ctx
 	//------------------------------------------------------------
+	// sequential executor: the controller executes the machine when it resumes it (see step)
+	if SEQUENTIAL == EXECUTOR {
+		return
+	}
+	//------------------------------------------------------------
 	// start and execute machine in parallel
 	// - nb: this will set the machine's new gid correctly
 	go m.Execute(s, mc)
@@ -643,9 +743,52 @@ func (m *Machine) PrintlnNotYet(tl TraceLevelEnum, tab int, a0 string) {
 }
 
 //------------------------------------------------------------
-// @@@ wozu?
+// write a record about this machine to the event log
+// - machine key and, if the context knows them, pid, wid and wiid are set
+func (m *Machine) logEvent(rec EventRecord) {
+	rec.MachineKey = m.Key()
+	if ids, ok := m.Context.(IEventLogIds); ok {
+		rec.Pid, rec.Wid, rec.Wiid = ids.EventLogIds()
+	}
+	LogEvent(rec)
+}
+
+//------------------------------------------------------------
+// raise an internal error with the context of the machine
 func (m *Machine) Panic(msg string) {
-	/**/ Panic(msg)
+	/**/ RaiseError(m.addErrorContext(NewInternalError(msg)))
+}
+
+//------------------------------------------------------------
+// set the context of the error to this machine, unless it is set already (eg by a sync sub machine)
+func (m *Machine) addErrorContext(err IContextError) IContextError {
+	ec := err.GetErrorContext()
+	if nil == m || "" != ec.Machine {
+		return err
+	}
+	ec.Machine = m.Key()
+	ec.State = m.CurrentState
+	if pos, ok := m.Context.(IErrorPosition); ok && "" == ec.Pid {
+		ec.Pid, ec.Wid, ec.LinkNo = pos.ErrorPosition()
+	}
+	return err
+}
+
+//------------------------------------------------------------
+// recover an error raised by the machine (see debug: errors.go)
+// - sync machine: pass it on to its caller
+// - otherwise: the machine is cleaned up and the controller stops the run (see Status.failed)
+// - caution: must be deferred directly
+func (m *Machine) recoverError(s *Status) {
+	r := recover()
+	if nil == r {
+		return
+	}
+	err := m.addErrorContext(RecoveredError(r))
+	if SYNC == m.StartType {
+		panic(err)
+	}
+	s.failed(m, err)
 }
 
 //------------------------------------------------------------
@@ -678,7 +821,7 @@ func (m *Machine) PrintlnStarMessage(msgType MachineMessageTypeEnum, msgText str
 // should not occur, eg wrong model
 func (m *Machine) UserError(err string) {
 	/**/ m.PrintlnStarMessage(USER_ERROR, err)
-	m.Panic(err)
+	RaiseError(m.addErrorContext(NewModelError(err)))
 }
 
 //------------------------------------------------------------
@@ -68,6 +68,13 @@ type MachineControl struct {
 	// - go routine id
 	// - nb: sync sub machines share Gid with caller
 	Gid uint64
+	//------------------------------------------------------------
+	// the machine waits in its current state, which is executed again when the machine is resumed (see wait):
+	// - sequential executor: the machine has left the critical section in a wait4 fu of its current state
+	// -- it enters it again, when the wait state is executed next time
+	// - restored machine: it was waiting when the snapshot was taken (see snapshot)
+	// - nb: not cloned; a machine of a choice point is restarted in its wait state (see WaitInterruptedByCP_Flag)
+	Waiting bool
 	//============================================================
 	// for model checking (complicated...):
 	//============================================================
@@ -157,6 +164,8 @@ func (mc *MachineControl) Clone4NewRun() *MachineControl {
 	//------------------------------------------------------------
 	// Gid (new; must be set by caller)
 	//------------------------------------------------------------
+	// Waiting (not copied)
+	//------------------------------------------------------------
 	// return
 	return newMc
 }
@@ -59,6 +59,16 @@ type ModelCheckingVars struct {
 	// - counter for CP ids
 	// - nb: CPs are numbered with 1, 2, 3, ...
 	ChoicePointUuid int
+	//------------------------------------------------------------
+	// hashes of all visited states (see Status.StateFingerprint)
+	// - value: number of the run that reached the state first (debug)
+	VisitedStates map[StateHash]int
+	//------------------------------------------------------------
+	// statistics:
+	// - number of distinct states explored
+	StatesExplored int
+	// - number of times a path reached an already visited state and was pruned
+	StatesPruned int
 }
 
 //------------------------------------------------------------
@@ -77,6 +87,9 @@ func InitModelCheckingVars() {
 	MC_VARS.CurChoice = ""
 	MC_VARS.UseCurChoiceAsNextMachineFlag = false
 	MC_VARS.ChoicePointUuid = 1
+	MC_VARS.VisitedStates = make(map[StateHash]int)
+	MC_VARS.StatesExplored = 0
+	MC_VARS.StatesPruned = 0
 }
 
 //////////////////////////////////////////////////////////////
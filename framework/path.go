@@ -0,0 +1,233 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// path of a run and progress checks
+// - the controller records every machine that it lets enter the critical section
+// - deadlock: no machine is enabled and no future time event exists, but the goal is not reached
+//   (see config: DEADLOCK_DETECTION); otherwise the run has terminated normally
+// - livelock (non-progress): machines keep on executing, but no entry is committed into a goal container;
+//   either the path runs into a cycle without progress (lasso), or the wirings fired as often as the livelock
+//   bound without progress
+// - if one is detected, the path that led there is the counterexample (see SetVerdict)
+// - model checking of temporal properties: the states of the path are recorded, too;
+//   if the path runs into a loop, the counterexample is a lasso (prefix and loop)
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/scheduler"
+	"fmt"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// one step of a path
+type PathStep struct {
+	//------------------------------------------------------------
+	// clocks when the machine was let in
+	Clock      int
+	EventClock int
+	//------------------------------------------------------------
+	// key of the machine that was let in
+	MachineKey string
+	//------------------------------------------------------------
+	// model checking: was this step a choice, ie were there alternatives?
+	ChoiceFlag bool
+	//------------------------------------------------------------
+	// lasso: the loop starts with this step
+	LoopStartFlag bool
+}
+
+//------------------------------------------------------------
+// steps in the order of their execution
+type Path []PathStep
+
+//------------------------------------------------------------
+// state of a path (only recorded when model checking temporal properties)
+type PathState struct {
+	//------------------------------------------------------------
+	// number of steps of the path before this state
+	Step int
+	//------------------------------------------------------------
+	// hash of the state (see StateFingerprint)
+	Hash StateHash
+	//------------------------------------------------------------
+	// values of the atoms of the temporal properties
+	Valuation []bool
+}
+
+//------------------------------------------------------------
+type PathStates []PathState
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+func (path Path) Copy() Path {
+	newPath := make(Path, len(path))
+	copy(newPath, path)
+	return newPath
+}
+
+//------------------------------------------------------------
+// one line per step; choices are marked with "*"
+func (path Path) ToString(tab int) string {
+	var sb strings.Builder
+	for i, step := range path {
+		choiceMark := " "
+		if step.ChoiceFlag {
+			choiceMark = "*"
+		}
+		if step.LoopStartFlag {
+			sb.WriteString(fmt.Sprintf("%s  loop:\n", NBlanksToString("", tab)))
+		}
+		sb.WriteString(fmt.Sprintf("%s%4d%s t=%d, et=%d: %s\n", NBlanksToString("", tab), i+1, choiceMark, step.Clock, step.EventClock, step.MachineKey))
+	}
+	return sb.String()
+}
+
+//------------------------------------------------------------
+// record the current state in the path states
+// - returns the index of an earlier state with the same hash, ie where the path loops back to; -1 otherwise
+// - nb: then the state is not recorded again; neither is a state that equals the last one (stuttering)
+func (s *Status) recordPathState() int {
+	hash := s.StateHash()
+	n := len(s.States)
+	if 0 < n && s.States[n-1].Hash == hash {
+		return -1
+	}
+	for i, state := range s.States {
+		if state.Hash == hash {
+			return i
+		}
+	}
+	s.States = append(s.States, PathState{Step: len(s.Path), Hash: hash, Valuation: s.MetaContext.PropertyValuation()})
+	return -1
+}
+
+//------------------------------------------------------------
+// check the temporal properties along the recorded path states
+// - loopStart: index of the state that follows the last one; -1 = path might go on
+// - sets the verdict with the (lasso shaped) path as counterexample
+func (s *Status) checkProperties(loopStart int) {
+	word := make([][]bool, len(s.States))
+	for i, state := range s.States {
+		word[i] = state.Valuation
+	}
+	err := s.MetaContext.CheckProperties(word, loopStart)
+	if nil == err {
+		return
+	}
+	path := s.Path.Copy()
+	msg := fmt.Sprintf("%s (t=%d)", err, CLOCK)
+	if 0 <= loopStart {
+		if loopStep := s.States[loopStart].Step; loopStep < len(path) {
+			path[loopStep].LoopStartFlag = true
+		} else {
+			msg = fmt.Sprintf("%s, the path stays in its last state forever", msg)
+		}
+	}
+	SetVerdict(PROPERTY_VIOLATION, msg, path, s.Draws)
+}
+
+//------------------------------------------------------------
+// no machine is enabled and nothing can change in the future?
+// - ie all machines wait for a user event (that is not fulfilled) and no time event is pending,
+//   neither in the scheduler nor in a machine's wait condition
+// - nb: terminated machines are not in the machine controls; if there are none at all, the system is done
+// - must only be called by the controller
+func (s *Status) isQuiescent() bool {
+	//------------------------------------------------------------
+	// pending time events in the scheduler?
+	// - nb: slots at or after the system ttl will never be processed
+	for _, slot := range s.Scheduler.Slots() {
+		if USER_SLOT == slot.Type && slot.Time < SYSTEM_TTL {
+			return false
+		}
+	}
+	//------------------------------------------------------------
+	// enabled machines or machines waiting for a time before the system ttl?
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	defer s.StatusMutex.RUnlock()
+	if 0 == len(s.MachineControls) {
+		return false
+	}
+	for _, mc := range s.MachineControls {
+		if nil == mc.Condition || mc.Condition.Wait4Time < SYSTEM_TTL || s.ConditionIsFulfilled(mc) {
+			return false
+		}
+	}
+	return true
+}
+
+//------------------------------------------------------------
+// the system is quiescent (see isQuiescent): is it a deadlock?
+// - with goal containers: if none of them holds a committed entry
+// - without: always
+func (s *Status) isDeadlocked() bool {
+	return 0 == len(GOAL_CONTAINERS) || !s.MetaContext.GoalReached()
+}
+
+//------------------------------------------------------------
+// update the livelock detection after a machine was let in
+// - returns the index of the step where a cycle without progress starts, ie the state before the
+//   current step was already reached before that step since the last progress; the current step is
+//   not part of the cycle
+// - returns len(path) if the livelock bound has been reached, ie the wirings fired that often without progress;
+//   -1 otherwise
+// - progress and firings are measured by the global goal progress and wiring firing counts (see config)
+func (s *Status) noProgress(lastGoalProgressCount *int, lastWiringFiringCount *int) int {
+	if 0 >= LIVELOCK_BOUND {
+		return -1
+	}
+	firings := WIRING_FIRING_COUNT - *lastWiringFiringCount
+	*lastWiringFiringCount = WIRING_FIRING_COUNT
+	if GOAL_PROGRESS_COUNT > *lastGoalProgressCount {
+		*lastGoalProgressCount = GOAL_PROGRESS_COUNT
+		s.StepsWithoutProgress = 0
+		s.NoProgressStates = PathStates{}
+		return -1
+	}
+	hash := s.StateHash()
+	for _, state := range s.NoProgressStates {
+		if state.Hash == hash {
+			return state.Step
+		}
+	}
+	s.NoProgressStates = append(s.NoProgressStates, PathState{Step: len(s.Path) - 1, Hash: hash})
+	s.StepsWithoutProgress += firings
+	if s.StepsWithoutProgress >= LIVELOCK_BOUND {
+		return len(s.Path)
+	}
+	return -1
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
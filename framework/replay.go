@@ -0,0 +1,334 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// replay traces
+// - a replay trace is the machine readable form of a path (see Path) plus the random draws made on it
+// - written to the replay trace file (see debug: REPLAY_TRACE_FILE_NAME):
+// -- the counterexample, if a violation was found
+// -- if REPLAY_TRACE is on: the path of every run (in the order of the runs)
+// -- ie the last run in the file is the counterexample
+// - read by the runtime in verification mode REPLAY (see config: REPLAY_FILE), which lets exactly
+//   the machines of the trace enter the critical section and returns the recorded random draws
+// -- also the draws made when the status is created, eg for the initial entries (see NewStatus)
+// - format: one record per line, fields separated by blanks:
+// -- RUN <run count>
+// -- SELECT                          (the next machine was selected at random, ie every step begins with the
+//                                    draw of its selection; see SelectsAtRandom)
+// -- STEP <clock> <event clock> <choice flag 0/1> <machine key>
+// -- LOOP                            (the loop of a lasso starts with the next step)
+// -- RAND <n> <value>                (random number in [0, n) drawn before the next step)
+// -- END
+// - lines starting with "#" are comments
+//------------------------------------------------------------
+// caution: machine numbers are volatile -> if a recorded machine key is not found, the machine with
+// - the same name and context is taken (see replayMachineKey)
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/scheduler"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// one random draw
+type RandomDraw struct {
+	//------------------------------------------------------------
+	// number of steps of the path before the draw
+	Step int
+	//------------------------------------------------------------
+	// range [0, N) and drawn value
+	N     int
+	Value int
+}
+
+//------------------------------------------------------------
+// draws in the order in which they were made
+type RandomDraws []RandomDraw
+
+//------------------------------------------------------------
+// a path to be replayed
+type ReplayTrace struct {
+	//------------------------------------------------------------
+	// run in which the path was recorded
+	Run int
+	//------------------------------------------------------------
+	Path  Path
+	Draws RandomDraws
+	//------------------------------------------------------------
+	// was the next machine selected at random (see SelectsAtRandom)?
+	SelectFlag bool
+}
+
+//////////////////////////////////////////////////////////////
+// vars
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// the replay trace of the runtime in verification mode REPLAY; nil otherwise
+// - a new status follows it from its creation on (see NewStatus)
+var CUR_REPLAY_TRACE *ReplayTrace
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// machine readable form of the path and its draws (see format above)
+func (path Path) ToReplayTrace(run int, draws RandomDraws, selectFlag bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("RUN %d\n", run))
+	if selectFlag {
+		sb.WriteString("SELECT\n")
+	}
+	d := 0
+	for i, step := range path {
+		for ; d < len(draws) && draws[d].Step <= i; d++ {
+			sb.WriteString(fmt.Sprintf("RAND %d %d\n", draws[d].N, draws[d].Value))
+		}
+		if step.LoopStartFlag {
+			sb.WriteString("LOOP\n")
+		}
+		choice := 0
+		if step.ChoiceFlag {
+			choice = 1
+		}
+		sb.WriteString(fmt.Sprintf("STEP %d %d %d %s\n", step.Clock, step.EventClock, choice, step.MachineKey))
+	}
+	for ; d < len(draws); d++ {
+		sb.WriteString(fmt.Sprintf("RAND %d %d\n", draws[d].N, draws[d].Value))
+	}
+	sb.WriteString("END\n")
+	return sb.String()
+}
+
+//------------------------------------------------------------
+// random number in [0, n)
+// - recorded in the draws of the path; in verification mode REPLAY, the recorded draw is returned
+// - must only be called by the machine in the critical section or by the controller
+// - caution: n must be > 0
+func (s *Status) RandomIntn(n int) int {
+	var value int
+	if nil != s.Replay {
+		i := len(s.Draws)
+		if i < len(s.Replay.Draws) && n == s.Replay.Draws[i].N {
+			value = s.Replay.Draws[i].Value
+		} else {
+			//------------------------------------------------------------
+			// the run does not follow the trace any more -> draw a fresh value; the controller stops the run
+			value = RANDOM_GENERATOR.Intn(n)
+			SetVerdict(REPLAY_DIVERGENCE, fmt.Sprintf("random draw %d in [0, %d) is not in the replay trace (t=%d)", i+1, n, CLOCK), s.Path, s.Draws)
+		}
+	} else {
+		value = RANDOM_GENERATOR.Intn(n)
+	}
+	s.Draws = append(s.Draws, RandomDraw{Step: len(s.Path), N: n, Value: value})
+	return value
+}
+
+//------------------------------------------------------------
+// is the next machine selected by a random draw (see selectNextMachine)?
+// - in a replay: was it in the recorded run?
+func (s *Status) SelectsAtRandom() bool {
+	if nil != s.Replay {
+		return s.Replay.SelectFlag
+	}
+	return MODEL_CHECKING != VERIFICATION_MODE && RANDOM == EXECUTION_MODE
+}
+
+//------------------------------------------------------------
+// private fu:
+// next machine of the replay trace
+// - "" if the trace is exhausted, or if its next machine is not yet enabled (ie time must go on)
+// - returns an error if the run does not follow the trace any more
+func (s *Status) replayNextMachine() (string, error) {
+	if REPLAY_DIVERGENCE == VERDICT {
+		return "", fmt.Errorf("%s", VERDICT_MSG)
+	}
+	i := len(s.Path)
+	if i >= len(s.Replay.Path) {
+		return "", nil
+	}
+	step := s.Replay.Path[i]
+	if CLOCK > step.Clock {
+		return "", fmt.Errorf("step %d: machine %s was not enabled at t=%d", i+1, step.MachineKey, step.Clock)
+	}
+	key := s.replayMachineKey(step.MachineKey)
+	if "" == key {
+		if CLOCK < step.Clock {
+			return "", nil
+		}
+		return "", fmt.Errorf("step %d: machine %s does not exist (t=%d)", i+1, step.MachineKey, CLOCK)
+	}
+	//------------------------------------------------------------
+	// nb: at the recorded time the machine is let in anyhow, eg also a machine whose user condition was
+	//   resetted by the model checker (see UserConditionResettedFlag)
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	mc := s.MachineControls[key]
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	if CLOCK < step.Clock && !s.ConditionIsFulfilled(mc) {
+		return "", nil
+	}
+	return key, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+// make the draw of the selection of the next machine like the recorded run (see SelectsAtRandom), although the
+// machine is given by the trace, so that the following draws, eg of the distributions of the model, are the recorded ones
+// - nb: the draw is taken from the recorded ones like every draw (see RandomIntn); a different number of
+//   candidates is a divergence
+func (s *Status) replaySelectionDraw() {
+	if n := len(s.randomCandidates()); 0 < n {
+		s.RandomIntn(n)
+	} else {
+		SetVerdict(REPLAY_DIVERGENCE, fmt.Sprintf("step %d: no machine is enabled (t=%d)", len(s.Path)+1, CLOCK), s.Path, s.Draws)
+	}
+}
+
+//------------------------------------------------------------
+// private fu:
+// has the replay trace been executed completely?
+func (s *Status) replayEnded() bool {
+	return nil != s.Replay && len(s.Path) >= len(s.Replay.Path)
+}
+
+//------------------------------------------------------------
+// private fu:
+// key of the recorded machine in the machine controls; "" if there is no such machine
+// - the machine number of the recorded key might differ -> take the machine with the same
+//   name and context (the first one created, if there are several)
+func (s *Status) replayMachineKey(recordedKey string) string {
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	defer s.StatusMutex.RUnlock()
+	if _, ok := s.MachineControls[recordedKey]; ok {
+		return recordedKey
+	}
+	candidates := []string{}
+	for key := range s.MachineControls {
+		if MachineKeyWithoutNumber(key) == MachineKeyWithoutNumber(recordedKey) {
+			candidates = append(candidates, key)
+		}
+	}
+	if 0 == len(candidates) {
+		return ""
+	}
+	SortMachineKeys(candidates)
+	return candidates[0]
+}
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// write the path of a run to the replay trace file
+func WriteReplayTrace(run int, path Path, draws RandomDraws, selectFlag bool) {
+	String2ReplayTraceFile(path.ToReplayTrace(run, draws, selectFlag))
+}
+
+//------------------------------------------------------------
+// read the replay trace from a file
+// - if the file contains several runs, the last one is taken (see above)
+func LoadReplayTrace(fileName string) (*ReplayTrace, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		return nil, fmt.Errorf("replay trace: %s", err)
+	}
+	trace, err := ParseReplayTrace(string(data))
+	if nil != err {
+		return nil, fmt.Errorf("replay trace: %s: %s", fileName, err)
+	}
+	return trace, nil
+}
+
+//------------------------------------------------------------
+// parse a replay trace (see format above); the last run is returned
+func ParseReplayTrace(data string) (*ReplayTrace, error) {
+	var trace, cur *ReplayTrace
+	loopFlag := false
+	for i, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if 0 == len(fields) || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		//------------------------------------------------------------
+		// all numbers of the record
+		nums := []int{}
+		nNums := map[string]int{"RUN": 1, "STEP": 3, "RAND": 2}[fields[0]]
+		if len(fields) < 1+nNums {
+			return nil, fmt.Errorf("line %d: incomplete record", i+1)
+		}
+		for _, field := range fields[1 : 1+nNums] {
+			num, err := strconv.Atoi(field)
+			if nil != err {
+				return nil, fmt.Errorf("line %d: %s", i+1, err)
+			}
+			nums = append(nums, num)
+		}
+		if "RUN" != fields[0] && nil == cur {
+			return nil, fmt.Errorf("line %d: %s outside of a run", i+1, fields[0])
+		}
+		switch fields[0] {
+		case "RUN":
+			cur = &ReplayTrace{Run: nums[0], Path: Path{}, Draws: RandomDraws{}}
+			loopFlag = false
+		case "STEP":
+			if 5 != len(fields) {
+				return nil, fmt.Errorf("line %d: STEP <clock> <event clock> <choice flag> <machine key> expected", i+1)
+			}
+			cur.Path = append(cur.Path, PathStep{Clock: nums[0], EventClock: nums[1], ChoiceFlag: 1 == nums[2], MachineKey: fields[4], LoopStartFlag: loopFlag})
+			loopFlag = false
+		case "LOOP":
+			loopFlag = true
+		case "SELECT":
+			cur.SelectFlag = true
+		case "RAND":
+			if 0 >= nums[0] || 0 > nums[1] || nums[1] >= nums[0] {
+				return nil, fmt.Errorf("line %d: ill. random draw", i+1)
+			}
+			cur.Draws = append(cur.Draws, RandomDraw{Step: len(cur.Path), N: nums[0], Value: nums[1]})
+		case "END":
+			trace = cur
+			cur = nil
+		default:
+			return nil, fmt.Errorf("line %d: unknown record \"%s\"", i+1, fields[0])
+		}
+	}
+	if nil == trace {
+		return nil, fmt.Errorf("no complete run found")
+	}
+	return trace, nil
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
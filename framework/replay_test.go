@@ -0,0 +1,163 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the replay traces: their format and the recorded random draws
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// test data
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// a lasso with draws before the first step, between the steps and after the last one
+var TEST_REPLAY_PATH = Path{
+	{Clock: 0, EventClock: 0, MachineKey: "Wiring__M1__Stop__Stop_W1"},
+	{Clock: 1, EventClock: 1, MachineKey: "Wiring__M0__P1__P1_W1", ChoiceFlag: true},
+	{Clock: 3, EventClock: 2, MachineKey: "Wiring__M2__P1__P1_W2", LoopStartFlag: true},
+}
+
+var TEST_REPLAY_DRAWS = RandomDraws{{Step: 0, N: 2, Value: 1}, {Step: 2, N: 5, Value: 4}, {Step: 2, N: 3, Value: 0}, {Step: 3, N: 7, Value: 6}}
+
+const TEST_REPLAY_TRACE = `RUN 4
+SELECT
+RAND 2 1
+STEP 0 0 0 Wiring__M1__Stop__Stop_W1
+STEP 1 1 1 Wiring__M0__P1__P1_W1
+RAND 5 4
+RAND 3 0
+LOOP
+STEP 3 2 0 Wiring__M2__P1__P1_W2
+RAND 7 6
+END
+`
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// restore the verdict after the test
+func resetVerdict(t *testing.T) {
+	t.Cleanup(func() {
+		VERDICT, VERDICT_MSG, COUNTEREXAMPLE, COUNTEREXAMPLE_DRAWS = NO_VIOLATION, "", nil, nil
+	})
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// the written trace is parsed into the same path and draws
+func TestReplayTraceRoundTrip(t *testing.T) {
+	data := TEST_REPLAY_PATH.ToReplayTrace(4, TEST_REPLAY_DRAWS, true)
+	if TEST_REPLAY_TRACE != data {
+		t.Fatalf("trace expected:\n%s\ngot:\n%s", TEST_REPLAY_TRACE, data)
+	}
+	trace, err := ParseReplayTrace(data)
+	if nil != err {
+		t.Fatal(err)
+	}
+	expected := &ReplayTrace{Run: 4, Path: TEST_REPLAY_PATH, Draws: TEST_REPLAY_DRAWS, SelectFlag: true}
+	if !reflect.DeepEqual(expected, trace) {
+		t.Fatalf("%+v expected, got %+v", expected, trace)
+	}
+}
+
+//------------------------------------------------------------
+// the last complete run is taken; comments and empty lines are skipped
+func TestParseReplayTraceLastRun(t *testing.T) {
+	data := "# counterexample\n\n" + Path{}.ToReplayTrace(1, nil, false) + TEST_REPLAY_TRACE + "RUN 5\nSTEP 0 0 0 M\n"
+	trace, err := ParseReplayTrace(data)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 4 != trace.Run || 3 != len(trace.Path) || 4 != len(trace.Draws) {
+		t.Fatalf("run 4 with 3 steps and 4 draws expected, got %+v", trace)
+	}
+}
+
+//------------------------------------------------------------
+func TestParseReplayTraceErrors(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"", "no complete run found"},
+		{"RUN 1\nSTEP 0 0 0 M\n", "no complete run found"},
+		{"STEP 0 0 0 M\nEND\n", "line 1: STEP outside of a run"},
+		{"RUN\nEND\n", "line 1: incomplete record"},
+		{"RUN x\nEND\n", "line 1: strconv.Atoi: parsing \"x\": invalid syntax"},
+		{"RUN 1\nSTEP 0 0 0\nEND\n", "line 2: STEP <clock> <event clock> <choice flag> <machine key> expected"},
+		{"RUN 1\nRAND 3 3\nEND\n", "line 2: ill. random draw"},
+		{"RUN 1\nRAND 0 0\nEND\n", "line 2: ill. random draw"},
+		{"RUN 1\nJUMP\nEND\n", "line 2: unknown record \"JUMP\""},
+	}
+	for _, test := range tests {
+		if _, err := ParseReplayTrace(test.data); nil == err || test.expected != err.Error() {
+			t.Errorf("%q: %q expected, got %v", test.data, test.expected, err)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// in a replay the recorded draws are returned and recorded again; a draw that is not in the trace is a divergence
+func TestRandomIntnReplay(t *testing.T) {
+	resetVerdict(t)
+	s := new(Status)
+	s.Replay = &ReplayTrace{Path: Path{}, Draws: RandomDraws{{N: 5, Value: 3}, {N: 2, Value: 1}}}
+	if v1, v2 := s.RandomIntn(5), s.RandomIntn(2); 3 != v1 || 1 != v2 {
+		t.Fatalf("the recorded draws 3 and 1 expected, got %d and %d", v1, v2)
+	}
+	if !reflect.DeepEqual(s.Replay.Draws, s.Draws) || NO_VIOLATION != VERDICT {
+		t.Fatalf("the draws %v expected, got %v, %s", s.Replay.Draws, s.Draws, VERDICT)
+	}
+	// - the trace is exhausted
+	if v := s.RandomIntn(4); 0 > v || 4 <= v {
+		t.Fatalf("draw in [0, 4) expected, got %d", v)
+	}
+	if REPLAY_DIVERGENCE != VERDICT || !strings.HasPrefix(VERDICT_MSG, "random draw 3 in [0, 4) is not in the replay trace") {
+		t.Fatalf("replay divergence expected, got %s: %s", VERDICT, VERDICT_MSG)
+	}
+	// - another range than the recorded one
+	VERDICT = NO_VIOLATION
+	s = new(Status)
+	s.Replay = &ReplayTrace{Path: Path{}, Draws: RandomDraws{{N: 5, Value: 3}}}
+	s.RandomIntn(6)
+	if REPLAY_DIVERGENCE != VERDICT || !strings.HasPrefix(VERDICT_MSG, "random draw 1 in [0, 6) is not in the replay trace") {
+		t.Fatalf("replay divergence expected, got %s: %s", VERDICT, VERDICT_MSG)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
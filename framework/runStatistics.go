@@ -0,0 +1,226 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// statistics of the observables over the simulation runs
+// - the observables are declared by the use case (see IMetaContext: ObservableNames), eg in its model file;
+//   each run yields one value per observable, undefined values (NaN) are left out
+// - per observable: number of values, mean, variance (of the sample), min, max, percentiles (5, 25, 50, 75, 95;
+//   linear interpolation) and the 95% confidence interval of the mean (student t)
+// - target precision (see config: SIMULATION_PRECISION): the runs stop as soon as, for every observable,
+//   at least PRECISION_MIN_RUNS values exist and the half width of the confidence interval is
+//   within the precision times the absolute mean
+// -- the mean is at least PRECISION_MIN_SCALE here: for a mean near 0 (eg values around 0) the relative bound
+//    could never be reached; the precision is absolute then, ie in units of the observable
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	"fmt"
+	"math"
+	"sort"
+)
+
+//////////////////////////////////////////////////////////////
+// consts
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// min. number of values of an observable before the precision is checked
+const PRECISION_MIN_RUNS int = 10
+
+//------------------------------------------------------------
+// min. absolute mean the precision is relative to
+const PRECISION_MIN_SCALE float64 = 1
+
+//------------------------------------------------------------
+// private:
+// 0.975 quantiles of the student t distribution for 1..30 degrees of freedom
+var tQuantiles975 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+type RunStatistics struct {
+	// names of the observables
+	Names []string
+	// defined values of the observables; index = index of the name
+	Values [][]float64
+	// number of runs
+	NRuns int
+}
+
+//------------------------------------------------------------
+type ObservableSummary struct {
+	N        int
+	Mean     float64
+	Variance float64
+	Min      float64
+	Max      float64
+	P5       float64
+	P25      float64
+	P50      float64
+	P75      float64
+	P95      float64
+	// 95% confidence interval of the mean; NaN for less than 2 values
+	CiLow  float64
+	CiHigh float64
+}
+
+//////////////////////////////////////////////////////////////
+// constructor
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+func NewRunStatistics(names []string) *RunStatistics {
+	rs := new(RunStatistics)
+	rs.Names = names
+	rs.Values = make([][]float64, len(names))
+	return rs
+}
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// add the values of a run (see IMetaContext: ObservableValues)
+func (rs *RunStatistics) Add(values []float64) {
+	rs.NRuns++
+	for i, v := range values {
+		if i < len(rs.Values) && !math.IsNaN(v) {
+			rs.Values[i] = append(rs.Values[i], v)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// is the target precision reached for all observables?
+// - false if there are no observables
+// - nb: relative to the absolute mean, but at least to PRECISION_MIN_SCALE
+func (rs *RunStatistics) PrecisionReached(precision float64) bool {
+	if 0 == len(rs.Names) {
+		return false
+	}
+	for _, values := range rs.Values {
+		if len(values) < PRECISION_MIN_RUNS {
+			return false
+		}
+		sum := Summarize(values)
+		if (sum.CiHigh-sum.CiLow)/2 > precision*math.Max(math.Abs(sum.Mean), PRECISION_MIN_SCALE) {
+			return false
+		}
+	}
+	return true
+}
+
+//------------------------------------------------------------
+// print the summaries of all observables
+func (s *Status) PrintRunStatistics(rs *RunStatistics) {
+	if 0 == len(rs.Names) || !STATISTICS_TRACE.DoTrace() {
+		return
+	}
+	s.SystemInfo(fmt.Sprintf("OBSERVABLES (%d RUNS):", rs.NRuns))
+	for i, name := range rs.Names {
+		sum := Summarize(rs.Values[i])
+		if 0 == sum.N {
+			s.SystemInfo(fmt.Sprintf("- %s: no values", name))
+			continue
+		}
+		s.SystemInfo(fmt.Sprintf("- %s: n=%d, mean=%.4g, var=%.4g, min=%.4g, max=%.4g", name, sum.N, sum.Mean, sum.Variance, sum.Min, sum.Max))
+		s.SystemInfo(fmt.Sprintf("  p5=%.4g, p25=%.4g, p50=%.4g, p75=%.4g, p95=%.4g, 95%% ci=[%.4g, %.4g]", sum.P5, sum.P25, sum.P50, sum.P75, sum.P95, sum.CiLow, sum.CiHigh))
+	}
+	String2TraceFile("\n")
+}
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// summary of the values
+func Summarize(values []float64) ObservableSummary {
+	sum := ObservableSummary{N: len(values), CiLow: math.NaN(), CiHigh: math.NaN()}
+	if 0 == sum.N {
+		return sum
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	total := 0.0
+	for _, v := range sorted {
+		total += v
+	}
+	sum.Mean = total / float64(sum.N)
+	sum.Min = sorted[0]
+	sum.Max = sorted[sum.N-1]
+	sum.P5 = percentile(sorted, 0.05)
+	sum.P25 = percentile(sorted, 0.25)
+	sum.P50 = percentile(sorted, 0.5)
+	sum.P75 = percentile(sorted, 0.75)
+	sum.P95 = percentile(sorted, 0.95)
+	if 2 > sum.N {
+		return sum
+	}
+	sq := 0.0
+	for _, v := range sorted {
+		sq += (v - sum.Mean) * (v - sum.Mean)
+	}
+	sum.Variance = sq / float64(sum.N-1)
+	halfWidth := tQuantile975(sum.N-1) * math.Sqrt(sum.Variance/float64(sum.N))
+	sum.CiLow = sum.Mean - halfWidth
+	sum.CiHigh = sum.Mean + halfWidth
+	return sum
+}
+
+//------------------------------------------------------------
+// private fu:
+// percentile p (0..1) of sorted values, linearly interpolated
+func percentile(sorted []float64, p float64) float64 {
+	h := float64(len(sorted)-1) * p
+	i := int(math.Floor(h))
+	if i+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+//------------------------------------------------------------
+// private fu:
+// 0.975 quantile of the student t distribution
+// - more than 30 degrees of freedom: approximated by the normal quantile with the first correction term
+func tQuantile975(df int) float64 {
+	if df <= len(tQuantiles975) {
+		return tQuantiles975[df-1]
+	}
+	z := 1.959964
+	return z + (z*z*z+z)/(4*float64(df))
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
@@ -0,0 +1,225 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the statistics of the observables
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	"math"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// equal within eps; NaN equals NaN
+func almostEqual(a float64, b float64, eps float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) <= eps
+}
+
+//------------------------------------------------------------
+// n values, alternating a and b
+func alternating(n int, a float64, b float64) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = a
+		if 1 == i%2 {
+			values[i] = b
+		}
+	}
+	return values
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+func TestSummarize(t *testing.T) {
+	nan := math.NaN()
+	// - half width of 1..5: t(4) * sqrt(2.5 / 5)
+	hw := 2.776 * math.Sqrt(0.5)
+	tests := []struct {
+		name     string
+		values   []float64
+		expected ObservableSummary
+	}{
+		{"no values", nil, ObservableSummary{CiLow: nan, CiHigh: nan}},
+		{"one value", []float64{4}, ObservableSummary{N: 1, Mean: 4, Min: 4, Max: 4, P5: 4, P25: 4, P50: 4, P75: 4, P95: 4, CiLow: nan, CiHigh: nan}},
+		{"unsorted", []float64{5, 1, 4, 2, 3}, ObservableSummary{N: 5, Mean: 3, Variance: 2.5, Min: 1, Max: 5,
+			P5: 1.2, P25: 2, P50: 3, P75: 4, P95: 4.8, CiLow: 3 - hw, CiHigh: 3 + hw}},
+		{"equal values", []float64{2, 2, 2}, ObservableSummary{N: 3, Mean: 2, Min: 2, Max: 2, P5: 2, P25: 2, P50: 2, P75: 2, P95: 2, CiLow: 2, CiHigh: 2}},
+		{"negative values", []float64{-1, 1}, ObservableSummary{N: 2, Mean: 0, Variance: 2, Min: -1, Max: 1,
+			P5: -0.9, P25: -0.5, P50: 0, P75: 0.5, P95: 0.9, CiLow: -12.706, CiHigh: 12.706}},
+	}
+	for _, test := range tests {
+		values := append([]float64{}, test.values...)
+		got := Summarize(values)
+		e := test.expected
+		fields := []struct {
+			name     string
+			expected float64
+			got      float64
+		}{
+			{"mean", e.Mean, got.Mean}, {"variance", e.Variance, got.Variance}, {"min", e.Min, got.Min}, {"max", e.Max, got.Max},
+			{"p5", e.P5, got.P5}, {"p25", e.P25, got.P25}, {"p50", e.P50, got.P50}, {"p75", e.P75, got.P75}, {"p95", e.P95, got.P95},
+			{"ci low", e.CiLow, got.CiLow}, {"ci high", e.CiHigh, got.CiHigh},
+		}
+		if e.N != got.N {
+			t.Errorf("%s: n %d expected, got %d", test.name, e.N, got.N)
+		}
+		for _, f := range fields {
+			if !almostEqual(f.expected, f.got, 1e-9) {
+				t.Errorf("%s: %s %g expected, got %g", test.name, f.name, f.expected, f.got)
+			}
+		}
+		// - the values are not sorted in place
+		for i := range values {
+			if values[i] != test.values[i] {
+				t.Errorf("%s: values changed: %v", test.name, values)
+				break
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		sorted   []float64
+		p        float64
+		expected float64
+	}{
+		{[]float64{7}, 0.5, 7},
+		{[]float64{10, 20, 30, 40}, 0, 10},
+		{[]float64{10, 20, 30, 40}, 1, 40},
+		{[]float64{10, 20, 30, 40}, 0.5, 25},
+		{[]float64{10, 20, 30, 40}, 1.0 / 3, 20},
+		{[]float64{10, 20, 30, 40}, 0.95, 38.5},
+		{[]float64{-4, 0, 4}, 0.25, -2},
+	}
+	for _, test := range tests {
+		if got := percentile(test.sorted, test.p); !almostEqual(test.expected, got, 1e-9) {
+			t.Errorf("percentile(%v, %g): %g expected, got %g", test.sorted, test.p, test.expected, got)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// the table up to 30 degrees of freedom, then the approximation, which is compared to the exact quantiles
+func TestTQuantile975(t *testing.T) {
+	tests := []struct {
+		df       int
+		expected float64
+		eps      float64
+	}{
+		{1, 12.706, 0},
+		{2, 4.303, 0},
+		{10, 2.228, 0},
+		{30, 2.042, 0},
+		{31, 2.0395, 0.005},
+		{60, 2.0003, 0.005},
+		{120, 1.9799, 0.005},
+		{1000000, 1.96, 0.001},
+	}
+	for _, test := range tests {
+		if got := tQuantile975(test.df); !almostEqual(test.expected, got, test.eps) {
+			t.Errorf("tQuantile975(%d): %g expected, got %g", test.df, test.expected, got)
+		}
+	}
+	// - decreasing towards the normal quantile
+	for df := 2; df < 200; df++ {
+		if tQuantile975(df) > tQuantile975(df-1) {
+			t.Fatalf("tQuantile975(%d) = %g > tQuantile975(%d) = %g", df, tQuantile975(df), df-1, tQuantile975(df-1))
+		}
+	}
+}
+
+//------------------------------------------------------------
+// nb: the half width of 10 values alternating m-1 and m+1 is t(9) * sqrt(10/9 / 10) = 0.754
+func TestPrecisionReached(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    [][]float64
+		precision float64
+		expected  bool
+	}{
+		{"no observables", nil, 1, false},
+		{"too few values", [][]float64{alternating(PRECISION_MIN_RUNS-1, 5, 5)}, 1, false},
+		{"equal values", [][]float64{alternating(PRECISION_MIN_RUNS, 5, 5)}, 0.01, true},
+		{"within precision", [][]float64{alternating(10, 9, 11)}, 0.1, true},
+		{"not within precision", [][]float64{alternating(10, 9, 11)}, 0.05, false},
+		{"mean 0: absolute precision", [][]float64{alternating(10, -1, 1)}, 1, true},
+		{"mean 0: not within absolute precision", [][]float64{alternating(10, -1, 1)}, 0.5, false},
+		{"small mean: absolute precision", [][]float64{alternating(10, 0.1, 0.3)}, 0.1, true},
+		{"one observable not reached", [][]float64{alternating(10, 5, 5), alternating(10, 9, 11)}, 0.05, false},
+		{"one observable without values", [][]float64{alternating(10, 5, 5), nil}, 0.05, false},
+	}
+	for _, test := range tests {
+		names := make([]string, len(test.values))
+		rs := NewRunStatistics(names)
+		for run := 0; ; run++ {
+			more := false
+			values := make([]float64, len(test.values))
+			for i := range test.values {
+				values[i] = math.NaN()
+				if run < len(test.values[i]) {
+					values[i] = test.values[i][run]
+					more = true
+				}
+			}
+			if !more {
+				break
+			}
+			rs.Add(values)
+		}
+		if got := rs.PrecisionReached(test.precision); test.expected != got {
+			t.Errorf("%s: %t expected, got %t", test.name, test.expected, got)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// undefined values are left out
+func TestRunStatisticsAdd(t *testing.T) {
+	rs := NewRunStatistics([]string{"a", "b"})
+	rs.Add([]float64{1, math.NaN()})
+	rs.Add([]float64{2, 3})
+	// - values of undeclared observables are ignored
+	rs.Add([]float64{math.NaN(), 4, 5})
+	if 3 != rs.NRuns || 2 != len(rs.Values[0]) || 2 != len(rs.Values[1]) {
+		t.Fatalf("3 runs with 2 values each expected, got %d runs: %v", rs.NRuns, rs.Values)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
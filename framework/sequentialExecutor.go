@@ -0,0 +1,148 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// sequential executor (see config: EXECUTOR)
+// - there is no go routine per machine: the controller executes the machines itself
+// -- resume only remembers the machine; the controller executes it before it waits for the next signal,
+//    ie at the same point where the machine's go routine would run
+// -- the machine executes its states until it leaves the critical section: it exits or calls a wait4 fu
+// -- it sends LEAVE to the controller channel like a machine in its own go routine
+// - a waiting machine ends its wait state and gets the wait state again as current state;
+//   when it is resumed, the wait state is executed again, and the wait4 fu enters the critical section
+//   and returns true (see wait) -> the event log is the same as for go routines
+// - the machines that still exist when the run is stopped are just cleaned up
+// - sync machines are executed by their callers as usual
+// the ties in the selection of the next machine are broken in the order of the machine keys (see
+// machineKeys), so every run is deterministic
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/helpers"
+	"fmt"
+)
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// execute the machine that was resumed by the controller (if any)
+// - caution: the controller must call it before it waits for the next signal
+func (s *Status) executeResumedMachine() {
+	//------------------------------------------------------------
+	// any machine resumed?
+	machineKey := s.resumedMachineKey
+	if "" == machineKey {
+		return
+	}
+	s.resumedMachineKey = ""
+	//------------------------------------------------------------
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	mc := s.MachineControls[machineKey]
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	//------------------------------------------------------------
+	// assertion
+	if nil == mc || nil == mc.M {
+		s.SystemError(fmt.Sprintf("resumed machine %s not in MCS", machineKey))
+	}
+	//------------------------------------------------------------
+	// execute it until it leaves the critical section
+	mc.M.step(s, mc)
+}
+
+//------------------------------------------------------------
+// execute the machine until it leaves the critical section
+// - ie until it exits or waits
+// - private
+func (m *Machine) step(s *Status, mc *MachineControl) {
+	//------------------------------------------------------------
+	// errors raised by the machine stop the run (see recoverError)
+	defer m.recoverError(s)
+	//------------------------------------------------------------
+	// assertion
+	if ASYNC != m.StartType {
+		m.SystemError(fmt.Sprintf("sequential executor: machine %s is not async", m.Key()))
+	}
+	//------------------------------------------------------------
+	// enter the critical section
+	// - nb: a waiting machine enters it in its wait state (see wait)
+	if !mc.Waiting {
+		//------------------------------------------------------------
+		// debug
+		mc.Gid = GetGoRoutineID()
+		if MACHINE_START_TRACE.DoTrace() { // DEBUG
+			/**/ String2TraceFile(fmt.Sprintf("Machine START %s M%d, m.StartTime=%d\n", m.Name, m.Number, m.StartTime)) // DEBUG
+		} // DEBUG
+		//------------------------------------------------------------
+		s.enter(m)
+	}
+	//------------------------------------------------------------
+	// execute all states until the machine exits or waits
+	for {
+		prevState := m.CurrentState
+		retval := m.executeState(s)
+		switch retval {
+		case OK:
+			//------------------------------------------------------------
+			// OK: continue with the next state
+		case EXIT:
+			//------------------------------------------------------------
+			// EXIT: leave the critical section and clean up
+			m.terminate(s, true /* mIsInCsFlag */)
+			return
+		case STOPPED:
+			//------------------------------------------------------------
+			// STOPPED: the machine has left the critical section in a wait4 fu
+			// - assertion: nobody else can stop it
+			if !mc.Waiting {
+				m.SystemError(fmt.Sprintf("sequential executor: machine %s stopped without waiting", m.Key()))
+			}
+			//------------------------------------------------------------
+			// execute the wait state again, when the machine is resumed
+			m.CurrentState = prevState
+			return
+		default:
+			s.SystemError(fmt.Sprintf("ill. ret of state handle = %s", retval))
+		}
+	}
+}
+
+//------------------------------------------------------------
+// terminate the machines that are stopped at the end of the run
+// - ie what their go routines would do: count and clean up
+// - in the order of the keys
+func (s *Status) terminateStoppedMachines(keys []string) {
+	SortMachineKeys(keys)
+	for _, key := range keys {
+		s.StatusMutex.RLock() // LOCK FOR READ //
+		mc := s.MachineControls[key]
+		s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+		mc.M.A.nMachinesUsedCount++
+		s.cleanUpTerminatedMachine(key, mc.M.StartType)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
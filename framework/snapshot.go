@@ -0,0 +1,436 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// snapshot of the status
+// - written to the snapshot file (see config: SNAPSHOT_FILE), in json, by the controller of the first run:
+// -- at the first step at or after the snapshot time (see config: SNAPSHOT_TIME)
+// -- ie when no machine is in the critical section: every machine is either not yet started (= it has
+//    not yet entered the critical section) or waits for its condition
+// -- a replay run takes no snapshot: its random draws are not made by the random generator
+// - contents:
+// -- clocks, machine counter and seed of the run
+// -- the model data (see IMetaContext: Snapshot)
+// -- all machines: state, context, local variables (see Automaton: LocalVariablesSnapshotFunction) and condition
+// -- the scheduler
+// -- path, random draws and states of the run so far
+// - not contained:
+// -- the automata and the rest of the model, which do not change at runtime
+// -- the model checking flags of the conditions (see Event) and the choice points
+// -- the statistics counters, which restart with every run (see Run)
+// - restored (see config: RESTORE_FILE) on the status of the same use case, before its runs start:
+// -- the machines are newly created with their old numbers; a waiting machine is resumed in its wait
+//    state (see wait), like a machine of the sequential executor
+// -- the first run continues with the random generator of the run of the snapshot, ie it is
+//    the same as the run of the snapshot from the snapshot on
+// -- simulation runs: every further run starts at the snapshot again, with its own seed
+// - caution: a machine that exits in the step of the snapshot is not in it (go routines only)
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/helpers"
+	. "github.com/peermodel/simulator/scheduler"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+)
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// snapshot of the status
+type Snapshot struct {
+	//------------------------------------------------------------
+	// clocks, machine counter and seed of the run
+	Clock      int
+	EventClock int
+	MachineId  int
+	RunSeed    int64
+	//------------------------------------------------------------
+	// model data (see IMetaContext)
+	MetaContext json.RawMessage
+	//------------------------------------------------------------
+	// all machines, in the order of their keys
+	Machines []*MachineSnapshot
+	//------------------------------------------------------------
+	// all slots of the scheduler, in the order in which they will be treated
+	Slots []*SlotSnapshot
+	//------------------------------------------------------------
+	// the run so far
+	Path                 Path
+	Draws                RandomDraws
+	States               PathStates
+	StepsWithoutProgress int
+	NoProgressStates     PathStates
+	DoneMachines         Strings
+}
+
+//------------------------------------------------------------
+// snapshot of a machine and its machine control
+type MachineSnapshot struct {
+	Key          string
+	Automaton    string
+	Number       int
+	CurrentState string
+	StartTime    int
+	TraceFlag    bool
+	//------------------------------------------------------------
+	// model specific (see IContext) and automaton specific (see LVSSnapshotHandler)
+	Context        json.RawMessage
+	LocalVariables json.RawMessage
+	//------------------------------------------------------------
+	// wait-for condition
+	// - EMPTY_CONDITION if the machine has not yet been started
+	ConditionType  EventTypeEnum
+	IssueEventTime int
+	Wait4Time      int
+	UserEvent      json.RawMessage `json:",omitempty"`
+	//------------------------------------------------------------
+	// statistics
+	LastExecutionTime int
+	NCriticalSections int
+}
+
+//------------------------------------------------------------
+// snapshot of a slot
+type SlotSnapshot struct {
+	Type SlotTypeEnum
+	Time int
+	// - model specific (see ISlot); empty for the sttl slot
+	UserSlot json.RawMessage `json:",omitempty"`
+}
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// take the snapshot of the status
+// - caution: no machine must be in the critical section
+func (s *Status) Snapshot() (*Snapshot, error) {
+	//------------------------------------------------------------
+	// assertion
+	if "" != s.CurMachineKey {
+		return nil, fmt.Errorf("snapshot: machine %s is in the critical section", s.CurMachineKey)
+	}
+	//------------------------------------------------------------
+	snap := &Snapshot{
+		Clock:                CLOCK,
+		EventClock:           EVENT_CLOCK,
+		MachineId:            MACHINE_ID,
+		RunSeed:              RUN_SEED,
+		Machines:             []*MachineSnapshot{},
+		Slots:                []*SlotSnapshot{},
+		Path:                 s.Path,
+		Draws:                s.Draws,
+		States:               s.States,
+		StepsWithoutProgress: s.StepsWithoutProgress,
+		NoProgressStates:     s.NoProgressStates,
+		DoneMachines:         s.DoneMachines,
+	}
+	var err error
+	//------------------------------------------------------------
+	// model data
+	if snap.MetaContext, err = s.MetaContext.Snapshot(); nil != err {
+		return nil, fmt.Errorf("snapshot: %s", err)
+	}
+	//------------------------------------------------------------
+	// machines
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	defer s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	keys := s.machineKeys()
+	SortMachineKeys(keys)
+	for _, key := range keys {
+		mc := s.MachineControls[key]
+		m := mc.M
+		//------------------------------------------------------------
+		// skip a machine that is exiting (see terminate)
+		if "exit" == m.CurrentState {
+			continue
+		}
+		//------------------------------------------------------------
+		// assertions
+		if ASYNC != m.StartType {
+			return nil, fmt.Errorf("snapshot: machine %s is not async", key)
+		}
+		if EMPTY_CONDITION == mc.Condition.Type && "init" != m.CurrentState {
+			return nil, fmt.Errorf("snapshot: machine %s neither waits nor is new (state %s)", key, m.CurrentState)
+		}
+		if nil == m.A.LocalVariablesSnapshotFunction {
+			return nil, fmt.Errorf("snapshot: automaton %s does not support snapshots", m.A.Name)
+		}
+		//------------------------------------------------------------
+		ms := &MachineSnapshot{
+			Key:               key,
+			Automaton:         m.A.Name,
+			Number:            m.Number,
+			CurrentState:      m.CurrentState,
+			StartTime:         m.StartTime,
+			TraceFlag:         m.TraceFlag,
+			ConditionType:     mc.Condition.Type,
+			IssueEventTime:    mc.Condition.IssueEventTime,
+			Wait4Time:         mc.Condition.Wait4Time,
+			LastExecutionTime: mc.LastExecutionTime,
+			NCriticalSections: mc.NCriticalSections,
+		}
+		if ms.Context, err = m.Context.Snapshot(); nil != err {
+			return nil, fmt.Errorf("snapshot: machine %s: %s", key, err)
+		}
+		if ms.LocalVariables, err = m.A.LocalVariablesSnapshotFunction(m, m.LocalVariables); nil != err {
+			return nil, fmt.Errorf("snapshot: machine %s: %s", key, err)
+		}
+		if nil != mc.Condition.UserEvent {
+			if ms.UserEvent, err = mc.Condition.UserEvent.Snapshot(); nil != err {
+				return nil, fmt.Errorf("snapshot: machine %s: %s", key, err)
+			}
+		}
+		snap.Machines = append(snap.Machines, ms)
+	}
+	//------------------------------------------------------------
+	// scheduler
+	for _, slot := range s.Scheduler.Slots() {
+		ss := &SlotSnapshot{Type: slot.Type, Time: slot.Time}
+		if nil != slot.UserSlot {
+			if ss.UserSlot, err = slot.UserSlot.Snapshot(); nil != err {
+				return nil, fmt.Errorf("snapshot: scheduler: %s", err)
+			}
+		}
+		snap.Slots = append(snap.Slots, ss)
+	}
+	//------------------------------------------------------------
+	return snap, nil
+}
+
+//------------------------------------------------------------
+// restore the snapshot on the given status of the use case (eg freshly initialized by InitAppUseCaseFu)
+// - returns a new status that shares the automata and the meta context with the given one
+// - the machines of the given status are dropped (its go routines are never resumed)
+// - the machines of the snapshot are started (go routines only), ie the status is ready to run
+// - caution: sets the clocks, the machine counter and the model counters
+// - nb: the random generator is not touched (see FastForwardRandomGenerator)
+func (s *Status) Restore(snap *Snapshot) (*Status, error) {
+	//------------------------------------------------------------
+	// create new status
+	// - the sttl slot is restored from the snapshot
+	newS := NewStatus(SYSTEM_TTL, s.MetaContext)
+	newS.InitAppUseCaseFu = s.InitAppUseCaseFu
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	for aName, a := range s.Automata {
+		newS.Automata[aName] = a
+	}
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	//------------------------------------------------------------
+	// model data
+	if err := newS.MetaContext.Restore(snap.MetaContext); nil != err {
+		return nil, err
+	}
+	//------------------------------------------------------------
+	// clocks
+	// - nb: before the machines are created, which take the clock as their start time
+	CLOCK = snap.Clock
+	EVENT_CLOCK = snap.EventClock
+	//------------------------------------------------------------
+	// machines
+	for _, ms := range snap.Machines {
+		m, cond, err := newS.restoreMachine(ms)
+		if nil != err {
+			return nil, fmt.Errorf("snapshot: machine %s: %s", ms.Key, err)
+		}
+		mc := newS.CreateAndAddMachineControl(m, cond)
+		mc.LastExecutionTime = ms.LastExecutionTime
+		mc.NCriticalSections = ms.NCriticalSections
+		// - a waiting machine is resumed in its wait state (see wait)
+		mc.Waiting = EMPTY_CONDITION != cond.Type
+	}
+	// - nb: after the machines were created, which increments it
+	MACHINE_ID = snap.MachineId
+	//------------------------------------------------------------
+	// scheduler
+	// - nb: a slot comes before all slots with the same time that were inserted before it
+	newS.Scheduler = NewScheduler()
+	for i := len(snap.Slots) - 1; i >= 0; i-- {
+		ss := snap.Slots[i]
+		if STTL == ss.Type {
+			newS.Scheduler = newS.Scheduler.SortedInsert(NewSttlSlot(ss.Time))
+			continue
+		}
+		userSlot, err := newS.MetaContext.RestoreUserSlot(ss.UserSlot)
+		if nil != err {
+			return nil, err
+		}
+		newS.Scheduler = newS.Scheduler.SortedInsert(NewUserSlot(ss.Time, userSlot))
+	}
+	// - the system ttl might have been configured otherwise
+	if first := newS.Scheduler.First(); nil == first || STTL != first.Type || SYSTEM_TTL != first.Time {
+		newS.Scheduler = newS.Scheduler.ResetSttlSlot(SYSTEM_TTL)
+	}
+	//------------------------------------------------------------
+	// the run so far
+	newS.Path = append(Path{}, snap.Path...)
+	newS.Draws = append(RandomDraws{}, snap.Draws...)
+	newS.States = append(PathStates{}, snap.States...)
+	newS.StepsWithoutProgress = snap.StepsWithoutProgress
+	newS.NoProgressStates = append(PathStates{}, snap.NoProgressStates...)
+	newS.DoneMachines = snap.DoneMachines.Copy()
+	//------------------------------------------------------------
+	// start the machines
+	newS.startRestoredMachines()
+	//------------------------------------------------------------
+	return newS, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+// create the machine of the snapshot and its condition
+func (s *Status) restoreMachine(ms *MachineSnapshot) (*Machine, *Event, error) {
+	//------------------------------------------------------------
+	a := s.Automata[ms.Automaton]
+	if nil == a {
+		return nil, nil, fmt.Errorf("automaton %s not found", ms.Automaton)
+	}
+	if nil == a.LocalVariablesRestoreFunction {
+		return nil, nil, fmt.Errorf("automaton %s does not support snapshots", a.Name)
+	}
+	//------------------------------------------------------------
+	// machine
+	m := NewMachine(a)
+	m.Number = ms.Number
+	m.CurrentState = ms.CurrentState
+	m.StartType = ASYNC
+	m.StartTime = ms.StartTime
+	m.TraceFlag = ms.TraceFlag
+	var err error
+	if m.Context, err = s.MetaContext.RestoreContext(ms.Context); nil != err {
+		return nil, nil, err
+	}
+	// - the key must not have changed
+	if m.Key() != ms.Key {
+		return nil, nil, fmt.Errorf("restored as %s", m.Key())
+	}
+	if m.LocalVariables, err = a.LocalVariablesRestoreFunction(m, ms.LocalVariables); nil != err {
+		return nil, nil, err
+	}
+	// - nb: after the context and the model data were restored
+	m.LocalVariables = a.CompleteLocalVariablesAliasFunction(s, m, m.LocalVariables)
+	//------------------------------------------------------------
+	// condition
+	// - nb: no choice is generated for it any more (see GenerateChoiceFlag)
+	cond := NewEvent(ms.ConditionType)
+	cond.IssueEventTime = ms.IssueEventTime
+	cond.Wait4Time = ms.Wait4Time
+	cond.GenerateChoiceFlag = false
+	if 0 < len(ms.UserEvent) {
+		if cond.UserEvent, err = s.MetaContext.RestoreUserEvent(ms.UserEvent); nil != err {
+			return nil, nil, err
+		}
+	}
+	//------------------------------------------------------------
+	return m, cond, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+// start the async execution of all machines in the order of their keys
+// - sequential executor: nothing to start; the controller executes a machine when it resumes it
+func (s *Status) startRestoredMachines() {
+	if SEQUENTIAL == EXECUTOR {
+		return
+	}
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	keys := s.machineKeys()
+	SortMachineKeys(keys)
+	for _, key := range keys {
+		mc := s.MachineControls[key]
+		go mc.M.Execute(s, mc)
+	}
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+}
+
+//------------------------------------------------------------
+// private fu:
+// write the snapshot at the first step of the first run at or after the snapshot time (see Controller)
+// - a failure is only a warning, the run goes on
+func (s *Status) takeSnapshot() {
+	//------------------------------------------------------------
+	// due?
+	if "" == SNAPSHOT_FILE || 1 != RUN_COUNT || s.snapshotTakenFlag || nil != s.Replay || CLOCK < SNAPSHOT_TIME {
+		return
+	}
+	s.snapshotTakenFlag = true
+	//------------------------------------------------------------
+	snap, err := s.Snapshot()
+	if nil == err {
+		err = snap.Write(SNAPSHOT_FILE)
+	}
+	if nil != err {
+		s.SystemWarning(err.Error())
+		return
+	}
+	s.SystemInfo(fmt.Sprintf("SNAPSHOT: %d machines at t=%d, et=%d written to %s", len(snap.Machines), CLOCK, EVENT_CLOCK, SNAPSHOT_FILE))
+}
+
+//------------------------------------------------------------
+// write the snapshot to a file
+func (snap *Snapshot) Write(fileName string) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if nil != err {
+		return fmt.Errorf("snapshot: %s", err)
+	}
+	if err := ioutil.WriteFile(fileName, append(data, '\n'), 0644); nil != err {
+		return fmt.Errorf("snapshot: %s", err)
+	}
+	return nil
+}
+
+//------------------------------------------------------------
+// read a snapshot from a file
+func LoadSnapshot(fileName string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if nil != err {
+		return nil, fmt.Errorf("snapshot: %s", err)
+	}
+	snap := new(Snapshot)
+	if err := json.Unmarshal(data, snap); nil != err {
+		return nil, fmt.Errorf("snapshot: %s: %s", fileName, err)
+	}
+	return snap, nil
+}
+
+//------------------------------------------------------------
+// set the random generator to where it was at the snapshot
+// - ie reseed it with the seed of the run of the snapshot, and draw the random numbers of the run again
+func (snap *Snapshot) FastForwardRandomGenerator() {
+	RUN_SEED = snap.RunSeed
+	RANDOM_GENERATOR = rand.New(rand.NewSource(RUN_SEED))
+	RANDOM_GENERATOR_WAS_SEEDED_FLAG = true
+	for _, draw := range snap.Draws {
+		RANDOM_GENERATOR.Intn(draw.N)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
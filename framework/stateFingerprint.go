@@ -0,0 +1,278 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// state fingerprint for model checking
+// - canonical description of the global state: model data (via meta context),
+//   machines (name, context, state, condition, LVS), scheduler slots and clocks
+// - volatile ids (uuids, machine numbers) are not part of the fingerprint, so that
+//   two paths that reach the same state get the same fingerprint
+// - times are relative to the current clocks (see TimeFingerprint and RankEventTimes), so that
+//   a state is recognized also at a later time
+// -- nb: the remaining time up to the system ttl is not part of the state
+// - also used to recognize that a path runs into a loop (see recordPathState and noProgress)
+// - the model checker keeps the hashes of all visited states and prunes a path
+//   as soon as it reaches an already visited state (see Controller); not while temporal properties are checked
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/scheduler"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+//////////////////////////////////////////////////////////////
+// data types
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// hash of a state fingerprint
+type StateHash [sha256.Size]byte
+
+//------------------------------------------------------------
+// implemented by model data types that occur in LVS
+// - must return a canonical description without volatile ids
+// - eg entries (without eid), or aliases into the meta model (which are recomputed anyhow)
+// - caution: is also called for nil receivers
+type IFingerprint interface {
+	Fingerprint() string
+}
+
+//////////////////////////////////////////////////////////////
+// methods
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// canonical description of the current global state
+// - caution: must only be called by the controller, ie when no machine is in the critical section
+func (s *Status) StateFingerprint() string {
+	RELATIVE_TIME_FINGERPRINT_FLAG = true
+	defer func() { RELATIVE_TIME_FINGERPRINT_FLAG = false }()
+	var sb strings.Builder
+	//------------------------------------------------------------
+	// clocks
+	sb.WriteString(fmt.Sprintf("t=%s, et=%s\n", TimeFingerprint(CLOCK), EventTimeFingerprint(EVENT_CLOCK)))
+	//------------------------------------------------------------
+	// model data
+	sb.WriteString(s.MetaContext.StateFingerprint())
+	//------------------------------------------------------------
+	// machines
+	// - the key contains the volatile machine number -> sort the machine fingerprints instead
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	mFps := make([]string, 0, len(s.MachineControls))
+	for _, mc := range s.MachineControls {
+		mFps = append(mFps, mc.Fingerprint())
+	}
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	sort.Strings(mFps)
+	for _, mFp := range mFps {
+		sb.WriteString(mFp)
+		sb.WriteString("\n")
+	}
+	//------------------------------------------------------------
+	// scheduler
+	sb.WriteString(s.Scheduler.Fingerprint())
+	//------------------------------------------------------------
+	// return
+	return RankEventTimes(sb.String())
+}
+
+//------------------------------------------------------------
+// hash of the state fingerprint
+func (s *Status) StateHash() StateHash {
+	return sha256.Sum256([]byte(s.StateFingerprint()))
+}
+
+//------------------------------------------------------------
+// canonical description of a machine control
+// - machine name and context, but not its number
+func (mc *MachineControl) Fingerprint() string {
+	if fu := mc.M.A.LocalVariablesFingerprintFunction; nil != fu {
+		return fmt.Sprintf("%s, lvs=%s", mc.controlFingerprint(), fu(mc.M, mc.M.LocalVariables))
+	}
+	return fmt.Sprintf("%s, lvs=%s", mc.controlFingerprint(), LocalVariablesFingerprint(mc.M.LocalVariables))
+}
+
+//------------------------------------------------------------
+// private fu:
+// machine control without LVS
+func (mc *MachineControl) controlFingerprint() string {
+	m := mc.M
+	res := fmt.Sprintf("%s__%s: %s", m.Name, m.Context.MachineKeySuffix(), m.CurrentState)
+	if nil != mc.Condition {
+		res = fmt.Sprintf("%s, cond=%s/%s/%s/%t", res, mc.Condition.Type, EventTimeFingerprint(mc.Condition.IssueEventTime), TimeFingerprint(mc.Condition.Wait4Time), mc.Condition.GenerateChoiceFlag)
+		if nil != mc.Condition.UserEvent {
+			res = fmt.Sprintf("%s/%s", res, mc.Condition.UserEvent)
+		}
+	}
+	if mc.UserConditionResettedFlag {
+		res = fmt.Sprintf("%s, resetted", res)
+	}
+	return res
+}
+
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// canonical description of the local variables of a machine
+// - the LVS struct is generated per automaton -> use reflection
+// - values implementing IFingerprint describe themselves; funcs and channels are skipped
+// - int fields whose name ends with ttl, tts or time are system times (eg wTtl, startTime) -> see TimeFingerprint
+// - the complete value is described; a pointer back to an enclosing value (cyclic data structure) is
+//   written as its distance "^n"
+func LocalVariablesFingerprint(lvs interface{}) string {
+	var sb strings.Builder
+	writeValueFingerprint(&sb, reflect.ValueOf(lvs), nil)
+	return sb.String()
+}
+
+//------------------------------------------------------------
+// private fu
+// - enclosing: the pointers (and maps) that are currently described
+func writeValueFingerprint(sb *strings.Builder, v reflect.Value, enclosing []uintptr) {
+	//------------------------------------------------------------
+	// nothing there?
+	if !v.IsValid() {
+		sb.WriteString("nil")
+		return
+	}
+	//------------------------------------------------------------
+	// unexported fields of the LVS: make them accessible (read only)
+	if !v.CanInterface() && v.CanAddr() {
+		v = reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+	}
+	//------------------------------------------------------------
+	// does the value describe itself?
+	// - nb: also nil pointers and nil slices are asked, ie implementations must cope with a nil receiver
+	if v.CanInterface() {
+		switch val := v.Interface().(type) {
+		case IFingerprint:
+			sb.WriteString(val.Fingerprint())
+			return
+		case error:
+			sb.WriteString(val.Error())
+			return
+		}
+	}
+	//------------------------------------------------------------
+	// generic
+	switch v.Kind() {
+	case reflect.Bool:
+		sb.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sb.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		sb.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		sb.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.String:
+		sb.WriteString(strconv.Quote(v.String()))
+	case reflect.Ptr:
+		if v.IsNil() {
+			sb.WriteString("nil")
+		} else if n := enclosingDistance(enclosing, v.Pointer()); 0 < n {
+			sb.WriteString(fmt.Sprintf("^%d", n))
+		} else {
+			writeValueFingerprint(sb, v.Elem(), append(enclosing, v.Pointer()))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			sb.WriteString("nil")
+		} else {
+			writeValueFingerprint(sb, v.Elem(), enclosing)
+		}
+	case reflect.Struct:
+		sb.WriteString("{")
+		for i := 0; i < v.NumField(); i++ {
+			if 0 < i {
+				sb.WriteString(", ")
+			}
+			if f := v.Field(i); isTimeField(v.Type().Field(i).Name) && reflect.Int == f.Kind() {
+				sb.WriteString(TimeFingerprint(int(f.Int())))
+			} else {
+				writeValueFingerprint(sb, f, enclosing)
+			}
+		}
+		sb.WriteString("}")
+	case reflect.Slice, reflect.Array:
+		sb.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if 0 < i {
+				sb.WriteString(", ")
+			}
+			writeValueFingerprint(sb, v.Index(i), enclosing)
+		}
+		sb.WriteString("]")
+	case reflect.Map:
+		if n := enclosingDistance(enclosing, v.Pointer()); 0 < n {
+			sb.WriteString(fmt.Sprintf("^%d", n))
+			return
+		}
+		enclosing = append(enclosing, v.Pointer())
+		// - map order is random -> sort the key/value pairs
+		kvs := []string{}
+		iter := v.MapRange()
+		for iter.Next() {
+			var kv strings.Builder
+			writeValueFingerprint(&kv, iter.Key(), enclosing)
+			kv.WriteString(":")
+			writeValueFingerprint(&kv, iter.Value(), enclosing)
+			kvs = append(kvs, kv.String())
+		}
+		sort.Strings(kvs)
+		sb.WriteString(fmt.Sprintf("{%s}", strings.Join(kvs, ", ")))
+	default:
+		// - func, chan, unsafe pointer: not part of the state
+		sb.WriteString("-")
+	}
+}
+
+//------------------------------------------------------------
+// private fu:
+// distance of the pointer to the innermost enclosing value with the same address; 0 if there is none
+func enclosingDistance(enclosing []uintptr, ptr uintptr) int {
+	for i := len(enclosing) - 1; 0 <= i; i-- {
+		if enclosing[i] == ptr {
+			return len(enclosing) - i
+		}
+	}
+	return 0
+}
+
+//------------------------------------------------------------
+// private fu:
+// is the LVS field a system time?
+func isTimeField(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, "ttl") || strings.HasSuffix(name, "tts") || strings.HasSuffix(name, "time")
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
@@ -0,0 +1,144 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the state fingerprint of the local variables
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package framework
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/scheduler"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// test data
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// describes itself
+type testFingerprinter struct {
+	id  string
+	val int
+}
+
+func (f *testFingerprinter) Fingerprint() string {
+	if nil == f {
+		return "none"
+	}
+	// - the id is volatile
+	return "val=" + strconv.Itoa(f.val)
+}
+
+//------------------------------------------------------------
+// like a generated LVS struct: unexported fields
+type testLvs struct {
+	name      string
+	count     int
+	wTtl      int
+	startTime int
+	vals      map[string]int
+	list      []*testFingerprinter
+	self      *testLvs
+	fu        func()
+	ch        chan int
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// equal LVS have equal fingerprints, independent of map order and volatile ids
+func TestLocalVariablesFingerprintEquality(t *testing.T) {
+	newLvs := func(id string) *testLvs {
+		lvs := &testLvs{name: "a", count: 2, vals: map[string]int{}}
+		for _, k := range []string{"x", "y", "z", "u", "v", "w"} {
+			lvs.vals[k] = len(k)
+		}
+		lvs.list = []*testFingerprinter{{id: id, val: 1}, nil}
+		lvs.fu = func() {}
+		lvs.ch = make(chan int)
+		return lvs
+	}
+	fp1 := LocalVariablesFingerprint(newLvs("id1"))
+	for i := 0; i < 10; i++ {
+		if fp2 := LocalVariablesFingerprint(newLvs("id2")); fp1 != fp2 {
+			t.Fatalf("equal fingerprints expected:\n%s\n%s", fp1, fp2)
+		}
+	}
+	if !strings.Contains(fp1, "val=1") || !strings.Contains(fp1, "none") {
+		t.Fatalf("self describing values expected in %s", fp1)
+	}
+	// - a changed value changes the fingerprint
+	lvs := newLvs("id1")
+	lvs.vals["x"] = 7
+	if fp2 := LocalVariablesFingerprint(lvs); fp1 == fp2 {
+		t.Fatalf("different fingerprints expected: %s", fp1)
+	}
+}
+
+//------------------------------------------------------------
+// a pointer back to an enclosing value ends the description
+func TestLocalVariablesFingerprintCycle(t *testing.T) {
+	lvs := &testLvs{name: "a"}
+	lvs.self = lvs
+	if fp := LocalVariablesFingerprint(lvs); !strings.Contains(fp, "^1") {
+		t.Fatalf("cycle mark expected in %s", fp)
+	}
+}
+
+//------------------------------------------------------------
+// system times are relative to the clock while a state fingerprint is taken
+func TestLocalVariablesFingerprintTimes(t *testing.T) {
+	prevClock, prevTtl := CLOCK, SYSTEM_TTL
+	defer func() {
+		CLOCK, SYSTEM_TTL = prevClock, prevTtl
+		RELATIVE_TIME_FINGERPRINT_FLAG = false
+	}()
+	SYSTEM_TTL = 100
+	RELATIVE_TIME_FINGERPRINT_FLAG = true
+	CLOCK = 10
+	fp1 := LocalVariablesFingerprint(&testLvs{count: 5, wTtl: 15, startTime: 3})
+	CLOCK = 20
+	fp2 := LocalVariablesFingerprint(&testLvs{count: 5, wTtl: 25, startTime: 13})
+	if fp1 != fp2 {
+		t.Fatalf("equal relative fingerprints expected:\n%s\n%s", fp1, fp2)
+	}
+	// - but the count is no time
+	if fp3 := LocalVariablesFingerprint(&testLvs{count: 15, wTtl: 25, startTime: 13}); fp2 == fp3 {
+		t.Fatalf("count must not be relative: %s", fp3)
+	}
+	// - a ttl at or after the system ttl never comes
+	if fp4, fp5 := LocalVariablesFingerprint(&testLvs{wTtl: 100}), LocalVariablesFingerprint(&testLvs{wTtl: 300}); fp4 != fp5 {
+		t.Fatalf("equal fingerprints of ttls after the system ttl expected:\n%s\n%s", fp4, fp5)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
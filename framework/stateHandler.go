@@ -47,6 +47,7 @@ const (
 	//------------------------------------------------------------
 	// state execution was stopped
 	// - ie STOP signal received while waiting4 an event, ie waiting to enter the critical section again
+	// - sequential executor: also if the machine has left the critical section to wait4 an event
 	// - nb: the critical section is not hold any more by the machine
 	STOPPED
 )
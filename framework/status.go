@@ -50,19 +50,42 @@ import (
 	. "github.com/peermodel/simulator/controller"
 	. "github.com/peermodel/simulator/debug"
 	. "github.com/peermodel/simulator/eventInterface"
+	. "github.com/peermodel/simulator/eventLog"
 	. "github.com/peermodel/simulator/helpers"
 	. "github.com/peermodel/simulator/metaContextInterface"
 	. "github.com/peermodel/simulator/scheduler"
 	"fmt"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
-	"syscall"
 )
 
 //////////////////////////////////////////////////////////////
 // global vars
 //////////////////////////////////////////////////////////////
 
+//------------------------------------------------------------
+// registered use cases: key = use case name
+// - eg for drivers that select the use case by its name
+var USE_CASES = map[string]InitAppUseCaseFuType{}
+
+//------------------------------------------------------------
+func RegisterUseCase(name string, initAppUseCaseFu InitAppUseCaseFuType) {
+	USE_CASES[name] = initAppUseCaseFu
+}
+
+//------------------------------------------------------------
+// registered state hooks: key = automaton name
+// - called after every state executed by a machine of the automaton, if the event log is on (see eventLog)
+// - eg to log the space operations of the model's automata
+var STATE_HOOKS = map[string]StateHookFuType{}
+
+//------------------------------------------------------------
+func RegisterStateHook(automatonName string, stateHookFu StateHookFuType) {
+	STATE_HOOKS[automatonName] = stateHookFu
+}
+
 //////////////////////////////////////////////////////////////
 // data type
 //////////////////////////////////////////////////////////////
@@ -72,6 +95,12 @@ import (
 // nb: app specific
 type InitAppUseCaseFuType func() *Status
 
+//------------------------------------------------------------
+// fu called after a state of the machine was executed
+// - prevState: the executed state; m.CurrentState is the next one ("exit" at the end)
+// - nb: the machine is still in the critical section
+type StateHookFuType func(s *Status, m *Machine, prevState string)
+
 //------------------------------------------------------------
 // reflects the exactly one shared status on which all machines operate
 // - nb: the used, unbounded channels perform a *synchonous* handshake
@@ -130,6 +159,33 @@ type Status struct {
 	// - critical section counter
 	MachineTerminationCounter int
 	//------------------------------------------------------------
+	// path of this run: machines let into the critical section so far
+	// - for model checking it starts with the path of the choice point
+	Path Path
+	//------------------------------------------------------------
+	// livelock detection: number of wiring firings since the last entry was committed into a goal container,
+	// and the states before the critical sections since then (see noProgress)
+	StepsWithoutProgress int
+	NoProgressStates     PathStates
+	//------------------------------------------------------------
+	// model checking of temporal properties: states of the path of this run
+	States PathStates
+	//------------------------------------------------------------
+	// random draws made on the path of this run (see RandomIntn)
+	Draws RandomDraws
+	//------------------------------------------------------------
+	// verification mode REPLAY: the path to be followed (nil otherwise)
+	Replay *ReplayTrace
+	//------------------------------------------------------------
+	// sequential executor: machine resumed by the controller that it has not yet executed (see executeResumedMachine)
+	resumedMachineKey string
+	//------------------------------------------------------------
+	// the snapshot of this run has been written (see takeSnapshot)
+	snapshotTakenFlag bool
+	//------------------------------------------------------------
+	// first error raised by a machine of this run; stops the run (see Machine.recoverError)
+	Err IContextError
+	//------------------------------------------------------------
 	// trick:
 	// - needed only by the code generator (written in Java) that transforms visio automata into go code
 	// -- so that fmt include is needed by every automaton -> in init state just sprintf machine name here
@@ -161,12 +217,22 @@ func NewStatus(systemTtl int, metaContext IMetaContext) *Status {
 	//............................................................
 	s.CurMachineKey = ""
 	//............................................................
+	s.Path = Path{}
+	s.Draws = RandomDraws{}
+	s.Replay = CUR_REPLAY_TRACE
+	//............................................................
 	// - create scheduler
 	s.Scheduler = NewScheduler()
 	// - create and append new slot for system end; its time is the system ttl
-	s.Scheduler = append(s.Scheduler, NewSttlSlot(systemTtl))
+	s.Scheduler = s.Scheduler.SortedInsert(NewSttlSlot(systemTtl))
 	//............................................................
 	s.DummyString = ""
+	//............................................................
+	// stochastic time: draws before the run, eg for the initial entries, are recorded before its first step
+	// - nb: the runtime seeds the random generator of the run before it creates the status
+	if nil != metaContext {
+		metaContext.SetRandomSource(s.RandomIntn)
+	}
 	//------------------------------------------------------------
 	// return
 	return s
@@ -222,6 +288,14 @@ func (s *Status) Clone4NewRun(systemTtl int) *Status {
 	//............................................................
 	// - CurMachineKey
 	newS.CurMachineKey = s.CurMachineKey
+	//............................................................
+	// - Path and progress
+	newS.Path = s.Path.Copy()
+	newS.States = append(PathStates{}, s.States...)
+	newS.StepsWithoutProgress = s.StepsWithoutProgress
+	newS.NoProgressStates = append(PathStates{}, s.NoProgressStates...)
+	newS.Draws = append(RandomDraws{}, s.Draws...)
+	newS.Replay = s.Replay
 	//------------------------------------------------------------
 	// - DummyString
 	newS.DummyString = s.DummyString
@@ -260,19 +334,8 @@ func (s *Status) EnterCriticalSection(m *Machine, mc *MachineControl) bool {
 				//------------------------------------------------------------
 				// ENTER
 				//------------------------------------------------------------
-				// assert that no machine is in the critical section
-				if s.CurMachineKey != "" {
-					/**/ m.SystemError(fmt.Sprintf("EnterCriticalSection: m = '%s' gets enter permit for non empty critical section, occupied by m = %s", m.Key(), s.CurMachineKey))
-				}
-				s.CurMachineKey = m.Key()
-				// fmt.Println(fmt.Sprintf("ENTER Critical Section (m = %s)", m.Key())) // DEBUG
-				//------------------------------------------------------------
-				// statistics
-				// - overall counter
-				s.CriticalSectionCounter++
-				// - per automaton counter
-				// -- nb: no lock needed as there is exactly only one machine in the CS and therefore executing
-				m.A.nCriticalSections++
+				// the machine is now in the critical section
+				s.enter(m)
 				//------------------------------------------------------------
 				// debug
 				// String2TraceFile(fmt.Sprintf(" EnterCriticalSection OK: m = %s, GID=%d\n", m.Key(), GetGoRoutineID())) // DEBUG
@@ -310,6 +373,33 @@ func (s *Status) EnterCriticalSection(m *Machine, mc *MachineControl) bool {
 	}
 }
 
+//------------------------------------------------------------
+// the machine enters the critical section
+// - set debug info which machine is now in the critical section, event log and statistics
+// - private fu
+func (s *Status) enter(m *Machine) {
+	//------------------------------------------------------------
+	// assert that no machine is in the critical section
+	if s.CurMachineKey != "" {
+		/**/ m.SystemError(fmt.Sprintf("EnterCriticalSection: m = '%s' gets enter permit for non empty critical section, occupied by m = %s", m.Key(), s.CurMachineKey))
+	}
+	s.CurMachineKey = m.Key()
+	// fmt.Println(fmt.Sprintf("ENTER Critical Section (m = %s)", m.Key())) // DEBUG
+	//------------------------------------------------------------
+	// event log
+	EVENT_LOG_MACHINE_KEY = s.CurMachineKey
+	if EventLogIsOn() {
+		m.logEvent(EventRecord{Type: LOG_ENTER})
+	}
+	//------------------------------------------------------------
+	// statistics
+	// - overall counter
+	s.CriticalSectionCounter++
+	// - per automaton counter
+	// -- nb: no lock needed as there is exactly only one machine in the CS and therefore executing
+	m.A.nCriticalSections++
+}
+
 //------------------------------------------------------------
 // general wait-for-an-event function for a machine:
 // - called via any Wait4 func via the machine's handler code
@@ -331,6 +421,7 @@ func (s *Status) EnterCriticalSection(m *Machine, mc *MachineControl) bool {
 // - and immediately tries to get the critical section again
 // -- which is possible if the condition is meanwhile filfilled
 // NB: the function blocks until the above algorithm is successful !!!
+// - sequential executor: it does not block, but returns to the controller (see sequentialExecutor)
 //............................................................
 // args:
 // - machine
@@ -355,9 +446,6 @@ func (s *Status) wait(m *Machine, eventType EventTypeEnum, targetSystemTime int,
 		m.SystemError(fmt.Sprintf("wait4 function called in sync machine, automaton = %s, eventType = %s", m.A.Name, eventType))
 	}
 	//------------------------------------------------------------
-	// increment event clock
-	EVENT_CLOCK++
-	//------------------------------------------------------------
 	s.StatusMutex.RLock() // LOCK FOR READ //
 	//------------------------------------------------------------
 	// get machine control from the machine (from status)
@@ -365,6 +453,21 @@ func (s *Status) wait(m *Machine, eventType EventTypeEnum, targetSystemTime int,
 	//------------------------------------------------------------
 	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
 	//------------------------------------------------------------
+	// the waiting machine is resumed in its wait state, ie its condition is fulfilled:
+	// - sequential executor (see step): it enters the critical section again here, ie where it left it below
+	// - restored machine (see snapshot): it has entered the critical section already (see Execute)
+	if mc.Waiting {
+		mc.Waiting = false
+		if SEQUENTIAL == EXECUTOR {
+			s.enter(m)
+		}
+		mc.Condition.Clear()
+		return true
+	}
+	//------------------------------------------------------------
+	// increment event clock
+	EVENT_CLOCK++
+	//------------------------------------------------------------
 	// assertion
 	if mc.Condition == nil {
 		s.SystemError(fmt.Sprintf("machine %s is in machine controls but has empty condition (i)", mc.M.Key()))
@@ -428,6 +531,14 @@ func (s *Status) wait(m *Machine, eventType EventTypeEnum, targetSystemTime int,
 	// - so another machine might work inbetween
 	s.LeaveCriticalSection(m)
 	//------------------------------------------------------------
+	// sequential executor: there is no go routine that could block here
+	// - the machine returns to the controller, which executes the wait state again when it resumes the machine (see above)
+	// - nb: the handler ends the state as if the machine was stopped; the condition is cleared when it enters again
+	if SEQUENTIAL == EXECUTOR {
+		mc.Waiting = true
+		return false
+	}
+	//------------------------------------------------------------
 	// ENTER
 	//------------------------------------------------------------
 	// debug:
@@ -500,6 +611,13 @@ func (s *Status) LeaveCriticalSection(m *Machine) {
 	// debug
 	// s.PrintMyGoRoutineId("LEAVE", m.Key()) // DEBUG
 	//------------------------------------------------------------
+	// event log
+	// - nb: the machine is still in the critical section
+	if EventLogIsOn() {
+		m.logEvent(EventRecord{Type: LOG_LEAVE})
+	}
+	EVENT_LOG_MACHINE_KEY = ""
+	//------------------------------------------------------------
 	// send leave to the controller channel
 	s.ControllerChannel <- NewChanSig(LEAVE, SENDER_IS_MACHINE, m.Key())
 }
@@ -522,6 +640,17 @@ func (s *Status) Run() {
 	//	}
 	//	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
 	//------------------------------------------------------------
+	// stochastic time: the model draws from the random generator of this run (see RandomIntn)
+	s.MetaContext.SetRandomSource(s.RandomIntn)
+	//------------------------------------------------------------
+	// metrics: collected from the records of the event log
+	if "" != METRICS_FILE {
+		s.MetaContext.StartMetrics(RUN_COUNT)
+	}
+	//------------------------------------------------------------
+	// event log: records of this run start here (see Controller for their end)
+	EventLogStartRun(RUN_COUNT)
+	//------------------------------------------------------------
 	// the trick: send KICK
 	s.ControllerChannel <- NewChanSig(KICK, SENDER_IS_SYSTEM, "Run" /* msg */)
 	//------------------------------------------------------------
@@ -636,6 +765,22 @@ func (s *Status) CheckAutomatonExistence(automatonName string) (*Automaton, bool
 	return a, flag
 }
 
+//------------------------------------------------------------
+// keys of all machine controls
+// - sequential executor: in the order of SortMachineKeys, so that ties are broken deterministically
+// - otherwise: in the (random) order of the map
+// - caution: caller must lock the status for read
+func (s *Status) machineKeys() []string {
+	keys := make([]string, 0, len(s.MachineControls))
+	for key := range s.MachineControls {
+		keys = append(keys, key)
+	}
+	if SEQUENTIAL == EXECUTOR {
+		SortMachineKeys(keys)
+	}
+	return keys
+}
+
 //------------------------------------------------------------
 // select next machine for execution depending on execution mode
 // - returns machine key of selected machine, or "" if no machine could be selected
@@ -656,13 +801,14 @@ func (s *Status) selectNextMachine() string {
 		// - select a machine whose issue time (= condition time) is smallest... very simple...
 		// - nb: wait4 condition is not checked (contradics the choice point creation for model checking mode)
 		// - TBD: is this mode useful? maybe better (= RANDOM and unfair) take any machine whose condition is fulfilled
-		// - nb: limited randomness is given in that map access via range is not deterministic
+		// - nb: limited randomness is given in that map access via range is not deterministic (except for the sequential executor)
 		//------------------------------------------------------------
 		// - init with not yet reached event time
 		curMinIssueTime := EVENT_CLOCK + 1
 		//------------------------------------------------------------
 		s.StatusMutex.RLock() // LOCK FOR READ //
-		for key, mc := range s.MachineControls {
+		for _, key := range s.machineKeys() {
+			mc := s.MachineControls[key]
 			//------------------------------------------------------------
 			// assertion
 			if mc.Condition == nil {
@@ -692,7 +838,8 @@ func (s *Status) selectNextMachine() string {
 		//------------------------------------------------------------
 		// caution: use lock, otherwise fatal error: concurrent map iteration and map write might sometimes occur here
 		s.StatusMutex.RLock() // LOCK FOR READ //
-		for key, mc := range s.MachineControls {
+		for _, key := range s.machineKeys() {
+			mc := s.MachineControls[key]
 			//------------------------------------------------------------
 			// assertion
 			if mc.Condition == nil {
@@ -727,14 +874,15 @@ func (s *Status) selectNextMachine() string {
 		// MIN_ISSUE_TIME_AND_CONDITION_FULFILLED:
 		//------------------------------------------------------------
 		// select the one whose issue time (= condition time) is smallest and condition fulfilled
-		// - nb: limited randomness is given in that map access via range is not deterministic
+		// - nb: limited randomness is given in that map access via range is not deterministic (except for the sequential executor)
 		//------------------------------------------------------------
 		// - init with not yet reached event time :-)
 		curMinIssueTime := EVENT_CLOCK + 1
 		//------------------------------------------------------------
 		// caution: use lock, otherwise fatal error: concurrent map iteration and map write might sometimes occur here
 		s.StatusMutex.RLock() // LOCK FOR READ //
-		for key, mc := range s.MachineControls {
+		for _, key := range s.machineKeys() {
+			mc := s.MachineControls[key]
 			//------------------------------------------------------------
 			// assertion
 			if mc.Condition == nil {
@@ -758,6 +906,15 @@ func (s *Status) selectNextMachine() string {
 		s.StatusMutex.RUnlock() // UNLOCK FOR READ //
 		// fmt.Println(fmt.Sprintf("%s: FAIRNESS: nextMachineKey = %s", thisFuNm, nextMachineKey)) // DEBUG
 
+	case RANDOM:
+		//------------------------------------------------------------
+		// RANDOM:
+		// - select one of the machines whose condition is fulfilled at random
+		//------------------------------------------------------------
+		if candidateKeys := s.randomCandidates(); 0 < len(candidateKeys) {
+			nextMachineKey = candidateKeys[s.RandomIntn(len(candidateKeys))]
+		}
+
 	default:
 		//------------------------------------------------------------
 		// DEFAULT:
@@ -769,6 +926,31 @@ func (s *Status) selectNextMachine() string {
 	return nextMachineKey
 }
 
+//------------------------------------------------------------
+// keys of the machines whose condition is fulfilled, ie the candidates of the selection at random
+// - nb: the keys are sorted, so that the selection only depends on the seed (see RandomIntn)
+// - private fu
+func (s *Status) randomCandidates() []string {
+	candidateKeys := []string{}
+	//------------------------------------------------------------
+	// caution: use lock, otherwise fatal error: concurrent map iteration and map write might sometimes occur here
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	for key, mc := range s.MachineControls {
+		//------------------------------------------------------------
+		// assertion
+		if mc.Condition == nil {
+			s.SystemError(fmt.Sprintf("machine %s is in machine controls but has empty condition (j)", key))
+		}
+		//------------------------------------------------------------
+		if s.ConditionIsFulfilled(mc) {
+			candidateKeys = append(candidateKeys, key)
+		}
+	}
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	SortMachineKeys(candidateKeys)
+	return candidateKeys
+}
+
 //------------------------------------------------------------
 // controller
 // - controls next run
@@ -784,12 +966,25 @@ func (s *Status) Controller() {
 	curCpDepth := 0
 	// - only for MC
 	prevSpaceUpdates := SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT
+	// - is the next machine a choice (ie recovered from or added to a CP)?
+	var choiceFlag bool
+	// - for livelock detection
+	lastGoalProgressCount := GOAL_PROGRESS_COUNT
+	lastWiringFiringCount := WIRING_FIRING_COUNT
+	// - only for MC (and its replay) of temporal properties: where does the path loop back to? (-1 = no loop)
+	propertiesFlag := (VERIFICATION_MODE == MODEL_CHECKING || VERIFICATION_MODE == REPLAY) && nil != s.MetaContext.PropertyValuation()
+	lassoStart := -1
+	// - deadlock found: its verdict is set after the temporal properties were checked (a property violation wins)
+	deadlockMsg := ""
 	//------------------------------------------------------------
 	// debug:
-	thisFuNm := "Controller"    // DEBUG
-	if REPLAY_TRACE.DoTrace() { // DEBUG
-		/**/ String2ReplayTraceFile(fmt.Sprintf("\nRUN = %d\n", RUN_COUNT)) // DEBUG
-	} // DEBUG
+	thisFuNm := "Controller" // DEBUG
+	//------------------------------------------------------------
+	// http api: serve the requests on this status
+	if nil != httpApi {
+		httpApi.attach(s)
+		defer httpApi.detach(s)
+	}
 
 	//////////////////////////////////////////////////////////////
 	// controller loop start:
@@ -804,6 +999,13 @@ controllerLoop:
 		//------------------------------------------------------------
 		// reset key for the next machine that will get the permission to ENTER:
 		nextMachineKey = ""
+		choiceFlag = false
+		//------------------------------------------------------------
+		// sequential executor: execute the machine resumed in the last loop, until it leaves the critical section
+		// - nb: it sends its LEAVE to the controller channel
+		if SEQUENTIAL == EXECUTOR {
+			s.executeResumedMachine()
+		}
 		//------------------------------------------------------------
 		// wait for control message from any machine:
 		ctrlMsg := <-s.ControllerChannel
@@ -839,6 +1041,18 @@ controllerLoop:
 			stopMsg = "USER"
 			break controllerLoop
 
+		case FAILED:
+			//============================================================
+			// FAILED:
+			//============================================================
+			//------------------------------------------------------------
+			// a machine raised an error (see Status.Err): it has released the critical section
+			// - stop all machines and end the controller loop
+			s.CurMachineKey = ""
+			stopFlag = true
+			stopMsg = "ERROR"
+			break controllerLoop
+
 		case KICK:
 			//============================================================
 			// KICK:
@@ -877,6 +1091,19 @@ controllerLoop:
 				// clear info who is in critical section
 				s.CurMachineKey = ""
 				//------------------------------------------------------------
+				// do all invariants still hold after this critical section?
+				if err := s.MetaContext.CheckInvariants(); nil != err {
+					SetVerdict(INVARIANT_VIOLATION, fmt.Sprintf("%s (t=%d)", err, CLOCK), s.Path, s.Draws)
+					String2TraceFile(fmt.Sprintf("\nINVARIANT VIOLATION: %s, t=%d, et=%d\n", err, CLOCK, EVENT_CLOCK))
+					s.MetaContext.SpacePrint(TRACE0, IND, true /* printAlsoEmptyContainersFlag */)
+					stopFlag = true
+					stopMsg = "INVARIANT VIOLATION"
+					break controllerLoop
+				}
+				//------------------------------------------------------------
+				// update the observables of the run (see runStatistics)
+				s.MetaContext.Observe()
+				//------------------------------------------------------------
 				// debug:
 				// - print all containers if there was a space change made by the last machine execution that sent the leave
 				if MODEL_CHECKING_DETAILS2_TRACE.DoTrace() && SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT > prevSpaceUpdates { // DEBUG
@@ -887,14 +1114,50 @@ controllerLoop:
 				} // DEBUG
 			}
 			//------------------------------------------------------------
+			// snapshot (if due)
+			// - nb: no machine is in the critical section
+			s.takeSnapshot()
+			//------------------------------------------------------------
+			// temporal properties: record the state; has the path run into a loop?
+			// - if so, the rest of the path repeats the loop forever -> end the path
+			// - nb: not for a choice recovered from a CP, whose state was recorded already
+			recoveredFlag := VERIFICATION_MODE == MODEL_CHECKING && MC_VARS.UseCurChoiceAsNextMachineFlag
+			if propertiesFlag && !recoveredFlag && (0 == len(s.States) || SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT > prevSpaceUpdates) {
+				if lassoStart = s.recordPathState(); 0 <= lassoStart {
+					//------------------------------------------------------------
+					// debug
+					if MODEL_CHECKING_TRACE.DoTrace() { // DEBUG
+						String2TraceFile(fmt.Sprintf("LASSO:            path loops back to its state %d (t=%d, et=%d)\n", lassoStart, CLOCK, EVENT_CLOCK)) // DEBUG
+					} // DEBUG
+					//------------------------------------------------------------
+					stopFlag = true
+					stopMsg = "LOOP"
+					break controllerLoop
+				}
+			}
+			//------------------------------------------------------------
 			// SELECT A NEXT MACHINE
 			//------------------------------------------------------------
 			if VERIFICATION_MODE != MODEL_CHECKING {
 				//------------------------------------------------------------
 				// VERIFICATION_MODE != MODEL_CHECKING
 				// - select machine from all existing machines
+				// - REPLAY: follow the replay trace instead
 				//------------------------------------------------------------
-				nextMachineKey = s.selectNextMachine()
+				if nil != s.Replay {
+					var err error
+					if nextMachineKey, err = s.replayNextMachine(); nil != err {
+						SetVerdict(REPLAY_DIVERGENCE, err.Error(), s.Path, s.Draws)
+						stopFlag = true
+						stopMsg = "REPLAY DIVERGENCE"
+						break controllerLoop
+					}
+					if "" != nextMachineKey && s.SelectsAtRandom() {
+						s.replaySelectionDraw()
+					}
+				} else {
+					nextMachineKey = s.selectNextMachine()
+				}
 				//------------------------------------------------------------
 			} else {
 				//------------------------------------------------------------
@@ -918,6 +1181,7 @@ controllerLoop:
 					//------------------------------------------------------------
 					// set next machine to current choice
 					nextMachineKey = MC_VARS.CurChoice
+					choiceFlag = true
 					//------------------------------------------------------------
 					// set cur depth of path of the current choice
 					curCpDepth = MC_VARS.CurChoicePoint.Depth
@@ -938,6 +1202,31 @@ controllerLoop:
 					// - select machine like above from all existing machines
 					nextMachineKey = s.selectNextMachine()
 					//------------------------------------------------------------
+					// if state has changed: has this state already been visited (by this or any other path)?
+					// - if so, all its successors have already been explored or are pending in a CP -> prune this path
+					// - not for temporal properties: they are checked along the whole path, and the pruned path could
+					//   reach the state with another history (eg a pending leads_to) -> no pruning
+					if SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT > prevSpaceUpdates && propertiesFlag {
+						MC_VARS.StatesExplored++
+					} else if SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT > prevSpaceUpdates {
+						stateHash := s.StateHash()
+						if firstRun, visitedFlag := MC_VARS.VisitedStates[stateHash]; visitedFlag {
+							MC_VARS.StatesPruned++
+							//------------------------------------------------------------
+							// debug
+							if MODEL_CHECKING_TRACE.DoTrace() { // DEBUG
+								String2TraceFile(fmt.Sprintf("PRUNE PATH:       state visited already in run %d (t=%d, et=%d)\n", firstRun, CLOCK, EVENT_CLOCK)) // DEBUG
+							} // DEBUG
+							//------------------------------------------------------------
+							// stop all machines and end the controller loop
+							stopFlag = true
+							stopMsg = "VISITED STATE"
+							break controllerLoop
+						}
+						MC_VARS.VisitedStates[stateHash] = RUN_COUNT
+						MC_VARS.StatesExplored++
+					}
+					//------------------------------------------------------------
 					// if state has changed since last CP
 					// - construct a choice point with all possible alternative candidates for this status
 					// -- and add them to the global choice points list
@@ -1037,6 +1326,7 @@ controllerLoop:
 							//------------------------------------------------------------
 							// add a new choice point
 							MC_VARS.ChoicePoints = append(MC_VARS.ChoicePoints, newCp)
+							choiceFlag = true
 							//------------------------------------------------------------
 							// debug
 							//............................................................
@@ -1102,13 +1392,13 @@ controllerLoop:
 		for {
 			//------------------------------------------------------------
 			// debug: for assertion below
-			schedulerLenBefore := len(s.Scheduler)
+			schedulerLenBefore := s.Scheduler.Len()
 			//------------------------------------------------------------
 			// get and remove next ripe slot, if any
 			nextRipeSlot, s.Scheduler, stopFlag = s.Scheduler.GetAndRemoveNextRipeSlot()
 			//------------------------------------------------------------
 			// debug
-			// - fmt.Println(fmt.Sprintf("after GetAndRemoveNextRipeSlot: len scheduler = %d", len(s.Scheduler))) // DEBUG
+			// - fmt.Println(fmt.Sprintf("after GetAndRemoveNextRipeSlot: len scheduler = %d", s.Scheduler.Len())) // DEBUG
 			//------------------------------------------------------------
 			// shall we stop now?
 			// - ie sys ttl has been reached
@@ -1130,8 +1420,8 @@ controllerLoop:
 				}
 				//------------------------------------------------------------
 				// assertion that scheduler has shrinked by 1
-				if (schedulerLenBefore - 1) != len(s.Scheduler) {
-					s.SystemError(fmt.Sprintf("ill. scheduler access: len before = %d, len after = %d", schedulerLenBefore, len(s.Scheduler)))
+				if (schedulerLenBefore - 1) != s.Scheduler.Len() {
+					s.SystemError(fmt.Sprintf("ill. scheduler access: len before = %d, len after = %d", schedulerLenBefore, s.Scheduler.Len()))
 				}
 				//------------------------------------------------------------
 				// execute the slot
@@ -1139,7 +1429,7 @@ controllerLoop:
 				s.MetaContext.ProcessRipeUserSlot(nextRipeSlot.UserSlot, &s.Scheduler)
 				//------------------------------------------------------------
 				// debug:
-				// - fmt.Printf("call ProcessRipeUserSlot, clock=%d, slot time=%d, len(scheduler)=%d\n", CLOCK, nextRipeSlot.Time, len(s.Scheduler)) // DEBUG
+				// - fmt.Printf("call ProcessRipeUserSlot, clock=%d, slot time=%d, len(scheduler)=%d\n", CLOCK, nextRipeSlot.Time, s.Scheduler.Len()) // DEBUG
 			} else {
 				//------------------------------------------------------------
 				// no more ripe slot -> break from *this* for-loop
@@ -1177,13 +1467,41 @@ controllerLoop:
 				} // DEBUG
 			}
 			//------------------------------------------------------------
-			// debug
-			if REPLAY_TRACE.DoTrace() { // DEBUG
-				String2ReplayTraceFile(fmt.Sprintf("%s\n", nextMachineKey)) // DEBUG
-			} // DEBUG
-			//------------------------------------------------------------
 			s.StatusMutex.RUnlock() // UNLOCK FOR READ //
 			//------------------------------------------------------------
+			// http api: serve the pending requests
+			// - nb: no machine is in the critical section
+			if nil != httpApi {
+				httpApi.serve(s)
+			}
+			//------------------------------------------------------------
+			// debugger: pause (if due)
+			// - nb: no machine is in the critical section
+			if nil != debugger && !debugger.beforeStep(s, nextMc) {
+				stopFlag = true
+				stopMsg = "DEBUGGER QUIT"
+				break controllerLoop
+			}
+			//------------------------------------------------------------
+			// record the step in the path of this run
+			s.Path = append(s.Path, PathStep{Clock: CLOCK, EventClock: EVENT_CLOCK, MachineKey: nextMachineKey, ChoiceFlag: choiceFlag})
+			//------------------------------------------------------------
+			// livelock: a cycle or too many steps without progress?
+			if loopStep := s.noProgress(&lastGoalProgressCount, &lastWiringFiringCount); len(s.Path) == loopStep {
+				SetVerdict(LIVELOCK, fmt.Sprintf("%d wiring firings without progress in goal containers %v (t=%d)", s.StepsWithoutProgress, GOAL_CONTAINERS, CLOCK), s.Path, s.Draws)
+				stopFlag = true
+				stopMsg = "LIVELOCK"
+				break controllerLoop
+			} else if 0 <= loopStep {
+				// - the current step closes the cycle, ie the counterexample is the lasso without it
+				path := s.Path[:len(s.Path)-1].Copy()
+				path[loopStep].LoopStartFlag = true
+				SetVerdict(LIVELOCK, fmt.Sprintf("cycle of %d critical sections without progress in goal containers %v (t=%d)", len(path)-loopStep, GOAL_CONTAINERS, CLOCK), path, s.Draws)
+				stopFlag = true
+				stopMsg = "LIVELOCK"
+				break controllerLoop
+			}
+			//------------------------------------------------------------
 			// RESUME
 			//------------------------------------------------------------
 			// resume the selected machine now !!!!!!!!!!!!!!!!!!!!!!!!!!!
@@ -1203,12 +1521,40 @@ controllerLoop:
 		//------------------------------------------------------------
 		// if no machine could be selected and time must be advanced -> kick the controller :-)
 		if "" == nextMachineKey {
+			//------------------------------------------------------------
+			// deadlock: nothing can happen any more?
+			// - nb: also checks whether the slots processed above have enabled a machine
+			// - temporal properties: the path stays in this state forever, ie it loops in it
+			// - the goal has been reached: normal termination
+			if (DEADLOCK_DETECTION || propertiesFlag) && s.isQuiescent() {
+				stopMsg = "TERMINATED"
+				if DEADLOCK_DETECTION && s.isDeadlocked() {
+					deadlockMsg = fmt.Sprintf("no machine is enabled and no time event is pending (t=%d, et=%d)", CLOCK, EVENT_CLOCK)
+					stopMsg = "DEADLOCK"
+				}
+				if propertiesFlag {
+					if lassoStart = s.recordPathState(); 0 > lassoStart {
+						lassoStart = len(s.States) - 1
+					}
+				}
+				stopFlag = true
+				break controllerLoop
+			}
+			//------------------------------------------------------------
+			// replay: all steps of the trace done?
+			if s.replayEnded() {
+				stopFlag = true
+				stopMsg = "END OF REPLAY TRACE"
+				break controllerLoop
+			}
 			//------------------------------------------------------------
 			// advance clock to next interesting time
-			if len(s.Scheduler) > 0 {
-				CLOCK = s.Scheduler[0].Time
+			if nextSlot := s.Scheduler.First(); nil != nextSlot {
+				prevClock := CLOCK
+				CLOCK = nextSlot.Time
+				logClockAdvance(prevClock)
 				if MODEL_CHECKING_DETAILS1_TRACE.DoTrace() { // DEBUG
-					String2TraceFile(fmt.Sprintf("\nNO MACHINE SELECTED\nADVANCE CLOCK TO %d of scheduler slot=%s", CLOCK, s.Scheduler[0].ToString(0))) // DEBUG
+					String2TraceFile(fmt.Sprintf("\nNO MACHINE SELECTED\nADVANCE CLOCK TO %d of scheduler slot=%s", CLOCK, nextSlot.ToString(0))) // DEBUG
 				} // DEBUG
 			}
 			//------------------------------------------------------------
@@ -1225,6 +1571,7 @@ controllerLoop:
 		// increment the system time aka CLOCK
 		// - "stepper motor"
 		CLOCK++
+		logClockAdvance(CLOCK - 1)
 		//------------------------------------------------------------
 		// debug
 		if MODEL_CHECKING_DETAILS2_TRACE.DoTrace() { // DEBUG
@@ -1246,6 +1593,39 @@ controllerLoop:
 	// end of controller loop
 	//////////////////////////////////////////////////////////////
 
+	//------------------------------------------------------------
+	// temporal properties: check them along the path of this run
+	// - nb: if the path did not loop, record its last state
+	if propertiesFlag && NO_VIOLATION == VERDICT {
+		if 0 > lassoStart {
+			lassoStart = s.recordPathState()
+		}
+		s.checkProperties(lassoStart)
+	}
+	if "" != deadlockMsg {
+		SetVerdict(DEADLOCK, deadlockMsg, s.Path, s.Draws)
+	}
+	//------------------------------------------------------------
+	// debug: replay trace of every run
+	if REPLAY_TRACE.DoTrace() { // DEBUG
+		WriteReplayTrace(RUN_COUNT, s.Path, s.Draws, s.SelectsAtRandom()) // DEBUG
+	} // DEBUG
+	//------------------------------------------------------------
+	// event log: end of the records of this run
+	// - nb: the machines stopped below are not logged any more
+	EventLogEndRun(stopMsg)
+	//------------------------------------------------------------
+	// metrics of this run
+	// - nb: not of a failed run
+	if nil != s.Err {
+		s.MetaContext.StopMetrics()
+	} else if "" != METRICS_FILE {
+		if fileNames, err := s.MetaContext.ExportMetrics(METRICS_FILE); nil != err {
+			s.SystemWarning(err.Error())
+		} else {
+			s.SystemInfo(fmt.Sprintf("METRICS: written to %s", strings.Join(fileNames, ", ")))
+		}
+	}
 	//------------------------------------------------------------
 	// stop flag?
 	// - nb: check here, because the execution of slots could also set the stop flag
@@ -1258,26 +1638,7 @@ controllerLoop:
 		String2TraceFile("\n")                                            // DEBUG
 		s.SystemInfo(fmt.Sprintf("STOP MACHINES because of %s", stopMsg)) // DEBUG
 		//------------------------------------------------------------
-		s.StatusMutex.RLock() // LOCK FOR READ //
-		//------------------------------------------------------------
-		// EXPLICITLY TERMINATE EVERY MACHINE IN MCS
-		// - nb: could also be a sync machine that is running in the thread of a (async) machine
-		nMachinesStopped := 0
-		for key, mc := range s.MachineControls {
-			//------------------------------------------------------------
-			// debug: print which machine is stopped
-			if CONTROLLER_TRACE.DoTrace() { // DEBUG
-				// mc.M.SystemInfo(fmt.Sprintf("%s: STOP %s, GID=%d", thisFuNm, key, mc.Gid)) // SYS INFO
-				mc.M.SystemInfo(fmt.Sprintf("STOP %s", key)) // SYS INFO
-			} //DEBUG
-			//------------------------------------------------------------
-			// 1.) send "stop" to machine's ctrl channel
-			mc.CtrlChan <- NewChanSig(STOP, SENDER_IS_SYSTEM, "Controller" /* msg */)
-			// - count number of stopped machines
-			nMachinesStopped++
-		}
-		//------------------------------------------------------------
-		s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+		s.StopMachines()
 		//------------------------------------------------------------
 		// debug
 		// - s.SystemInfo(fmt.Sprintf("%s: waiting for %d machines to send terminated signal", thisFuNm, nMachinesStopped)) // DEBUG
@@ -1323,6 +1684,39 @@ controllerLoop:
 	// - fmt.Println(fmt.Sprintf("Scheduler = %s\n", s.Scheduler.ToString(0)))                                      // DEBUG                                                                                    // DEBUG
 }
 
+//------------------------------------------------------------
+// stop all machines of the status
+// - nb: no machine must be in the critical section
+func (s *Status) StopMachines() {
+	s.StatusMutex.RLock() // LOCK FOR READ //
+	//------------------------------------------------------------
+	// EXPLICITLY TERMINATE EVERY MACHINE IN MCS
+	// - nb: could also be a sync machine that is running in the thread of a (async) machine
+	stoppedKeys := []string{}
+	for key, mc := range s.MachineControls {
+		//------------------------------------------------------------
+		// debug: print which machine is stopped
+		if CONTROLLER_TRACE.DoTrace() { // DEBUG
+			// mc.M.SystemInfo(fmt.Sprintf("%s: STOP %s, GID=%d", thisFuNm, key, mc.Gid)) // SYS INFO
+			mc.M.SystemInfo(fmt.Sprintf("STOP %s", key)) // SYS INFO
+		} //DEBUG
+		//------------------------------------------------------------
+		// 1.) send "stop" to machine's ctrl channel
+		// - sequential executor: there is no go routine that could receive it -> terminate the machine below
+		if SEQUENTIAL == EXECUTOR {
+			stoppedKeys = append(stoppedKeys, key)
+		} else {
+			mc.CtrlChan <- NewChanSig(STOP, SENDER_IS_SYSTEM, "Controller" /* msg */)
+		}
+	}
+	//------------------------------------------------------------
+	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
+	//------------------------------------------------------------
+	// sequential executor: terminate the stopped machines
+	// - nb: not under the lock, as clean up locks for write
+	s.terminateStoppedMachines(stoppedKeys)
+}
+
 //------------------------------------------------------------
 // resume a machine
 // - send it the enter signal
@@ -1342,7 +1736,7 @@ func (s *Status) resume(machineKey string) {
 	if nil == mc || nil == mc.M {
 		s.SystemInfo(fmt.Sprintf("cant' resume machine %s", machineKey)) // DEBUG
 		s.StatusMutex.RUnlock()                                          // UNLOCK FOR READ //
-		s.Panic()
+		s.Panic(NewInternalError(fmt.Sprintf("cant' resume machine %s", machineKey)))
 	}
 	//............................................................
 	// - get its mutex channel
@@ -1351,7 +1745,12 @@ func (s *Status) resume(machineKey string) {
 	s.StatusMutex.RUnlock() // UNLOCK FOR READ //
 	//------------------------------------------------------------
 	// send ENTER signal to machine's mutex channel
-	mutexChan <- NewChanSig(ENTER, SENDER_IS_SYSTEM, "Resume" /* msg */)
+	// - sequential executor: the controller executes the machine before it waits for the next signal (see executeResumedMachine)
+	if SEQUENTIAL == EXECUTOR {
+		s.resumedMachineKey = machineKey
+	} else {
+		mutexChan <- NewChanSig(ENTER, SENDER_IS_SYSTEM, "Resume" /* msg */)
+	}
 	//------------------------------------------------------------
 	// for debug only: increment the machine's nCriticalSections counter (in its mc)
 	// - CAUTION: use locking, otherwise "fatal error: concurrent map iteration and map write" might be reported here
@@ -1400,6 +1799,8 @@ func (s *Status) PrintStatistics() {
 		//------------------------------------------------------------
 		s.SystemInfo(fmt.Sprintf("- execution mode: %s", EXECUTION_MODE))
 		//------------------------------------------------------------
+		s.SystemInfo(fmt.Sprintf("- seed: %d (run seed: %d, %d random draws)", SEED, RUN_SEED, len(s.Draws)))
+		//------------------------------------------------------------
 		helpS := fmt.Sprintf("- verification mode: %s", VERIFICATION_MODE)
 		switch VERIFICATION_MODE {
 		case SIMULATION:
@@ -1409,13 +1810,25 @@ func (s *Status) PrintStatistics() {
 			for _, cp := range MC_VARS.ChoicePoints {
 				ncps += len(cp.Choices)
 			}
-			s.SystemInfo(fmt.Sprintf("%s (%d runs, %d CPs, depth=%d, still %d CPs and %d choices)", helpS, RUN_COUNT, MC_VARS.ChoicePointUuid-1, MC_VARS.CurPathDepth, len(MC_VARS.ChoicePoints), ncps))
+			s.SystemInfo(fmt.Sprintf("%s (%d runs, %d CPs, depth=%d, still %d CPs and %d choices, %d states explored, %d states pruned)", helpS, RUN_COUNT, MC_VARS.ChoicePointUuid-1, MC_VARS.CurPathDepth, len(MC_VARS.ChoicePoints), ncps, MC_VARS.StatesExplored, MC_VARS.StatesPruned))
 		default:
 			s.SystemInfo(fmt.Sprintf("%s", helpS))
 		}
 		//------------------------------------------------------------
 		s.SystemInfo(fmt.Sprintf("- %s", s.AutomataToString()))
 		//------------------------------------------------------------
+		if txStatistics := s.MetaContext.TxStatistics(); "" != txStatistics {
+			s.SystemInfo(fmt.Sprintf("- txs: %s", txStatistics))
+		}
+		//------------------------------------------------------------
+		if names := s.MetaContext.ObservableNames(); 0 < len(names) {
+			items := []string{}
+			for i, v := range s.MetaContext.ObservableValues() {
+				items = append(items, fmt.Sprintf("%s=%g", names[i], v))
+			}
+			s.SystemInfo(fmt.Sprintf("- observables: %s", strings.Join(items, ", ")))
+		}
+		//------------------------------------------------------------
 		s.SystemInfo(fmt.Sprintf("- %d go routines running", runtime.NumGoroutine()))
 		//------------------------------------------------------------
 		String2TraceFile("\n")
@@ -1444,7 +1857,7 @@ func (s *Status) PrintlnStarMessage(msgType MachineMessageTypeEnum, msgText stri
 }
 
 //------------------------------------------------------------
-func (s *Status) Panic() {
+func (s *Status) Panic(err IContextError) {
 	// print status ie all containers - for debug only
 	s.MetaContext.SpacePrint(TRACE0, 0, true /* printAlsoEmptyContainersFlag */)
 
@@ -1456,22 +1869,38 @@ func (s *Status) Panic() {
 	// print status - debug
 	// s.SpacePrint(0, false /* printAlsoEmptyContainersFlag */)
 
-	// exit
-	syscall.Exit(-1)
+	// raise the error (see debug: errors.go)
+	RaiseError(err)
 }
 
 //------------------------------------------------------------
 // should not occur, e.g. wrong model
 func (s *Status) UserError(err string) {
 	/**/ s.PrintlnStarMessage(USER_ERROR, err)
-	s.Panic()
+	s.Panic(NewModelError(err))
 }
 
 //------------------------------------------------------------
 // should not occur, system failure
 func (s *Status) SystemError(err string) {
 	/**/ s.PrintlnStarMessage(SYSTEM_ERROR, err)
-	s.Panic()
+	s.Panic(NewInternalError(err))
+}
+
+//------------------------------------------------------------
+// a machine has raised an error (see Machine.recoverError)
+// - keep the first error of the run, clean up the machine, release the critical section and tell the controller
+//   to stop the run: it stops all other machines and drops the metrics (see FAILED); the runtime then resets the
+//   globals of the run (see runtime: RunWithConfig)
+// - nb: the machine does not execute any more
+func (s *Status) failed(m *Machine, err IContextError) {
+	s.SystemInfo(fmt.Sprintf("%s FAILED: %s", m.Key(), err))
+	if nil == s.Err {
+		s.Err = err
+	}
+	s.cleanUpTerminatedMachine(m.Key(), m.StartType)
+	EVENT_LOG_MACHINE_KEY = ""
+	s.ControllerChannel <- NewChanSig(FAILED, SENDER_IS_MACHINE, m.Key())
 }
 
 //------------------------------------------------------------
@@ -1502,6 +1931,15 @@ func TimeChangeEvent() {
 	}
 }
 
+//------------------------------------------------------------
+// private fu:
+// log that the clock has advanced from prevClock to CLOCK
+func logClockAdvance(prevClock int) {
+	if EventLogIsOn() && prevClock != CLOCK {
+		LogEvent(EventRecord{Type: LOG_CLOCK, From: strconv.Itoa(prevClock), To: strconv.Itoa(CLOCK)})
+	}
+}
+
 //------------------------------------------------------------
 // check if condition of the machine control is fulfilled
 // - ie either user event condition or time condition
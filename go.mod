@@ -3,3 +3,5 @@ module github.com/peermodel/simulator
 go 1.14
 
 replace github.com/peermodel/simulator => ./
+
+require gopkg.in/yaml.v3 v3.0.1
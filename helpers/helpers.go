@@ -118,9 +118,9 @@ func GetGoRoutineID() uint64 {
 
 //------------------------------------------------------------
 // should not occur, e.g. wrong model
+// - raises a model error (see debug: ModelPanic); it stops the run and is returned to the caller, who reports it
 func UserError(err string) {
-	/**/ fmt.Println("USER_ERROR: ", err, "\n")
-	Panic(err)
+	ModelPanic(err)
 }
 
 //------------------------------------------------------------
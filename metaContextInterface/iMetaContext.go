@@ -30,7 +30,9 @@
 package contextInterface
 
 import (
+	. "github.com/peermodel/simulator/contextInterface"
 	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/eventInterface"
 	. "github.com/peermodel/simulator/latex"
 	. "github.com/peermodel/simulator/scheduler"
 	. "github.com/peermodel/simulator/slotInterface"
@@ -48,6 +50,46 @@ type IMetaContext interface {
 	MetaModel2Latex(testCaseName string, testCaseLatexConfig *LatexConfig)
 	ConditionIsFulfilled(condition *Event) bool
 	SpacePrint(tl TraceLevelEnum, nBlanks int, printAlsoEmptyContainersFlag bool)
+	// user defined invariants: returns the first violation (or nil)
+	CheckInvariants() error
+	// temporal properties: values of their atoms in the current state (nil if there are none),
+	// and check along a path of such valuations (loopStart < 0: finite path)
+	PropertyValuation() []bool
+	CheckProperties(word [][]bool, loopStart int) error
+	// deadlock detection: does one of the goal containers (see config) hold a committed entry?
+	GoalReached() bool
+	// for model checking: canonical description of the model data without volatile ids
+	StateFingerprint() string
+	// statistics: committed and rolled back transactions per concurrency control (txcc)
+	TxStatistics() string
+	// observables of a run (see framework: runStatistics): their names, their update after every critical section,
+	// and their values at the end of the run (NaN = undefined)
+	ObservableNames() []string
+	Observe()
+	ObservableValues() []float64
+	// snapshot of the status (see framework: snapshot): the model data that change at runtime, and their restore;
+	// - and the restore of the model specific parts of the status that were written by their Snapshot methods
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+	RestoreContext(data []byte) (IContext, error)
+	RestoreUserSlot(data []byte) (ISlot, error)
+	RestoreUserEvent(data []byte) (IEvent, error)
+	// debugger (see framework: debugger): space operations by hand; return the entry id
+	// - write the entry given by a model specific spec, and take an entry of a type ("" = any) from a container
+	DebugWriteEntry(spec string, scheduler *Scheduler) (string, error)
+	DebugTakeEntry(cid string, entryType string) (string, error)
+	// http api (see framework: httpApi): json views of all containers with their entries, and of the running transactions;
+	// - and the injection of an entry given by a model specific spec into the PIC of a peer; returns the entry id
+	ContainersJson() ([]byte, error)
+	RunningTransactionsJson() ([]byte, error)
+	InjectEntry(pid string, spec string, scheduler *Scheduler) (string, error)
+	// metrics (see config: METRICS_FILE): start to collect them for a run, and export them at its end; returns the file names
+	// - or stop to collect them without export, eg if the run failed
+	StartMetrics(run int)
+	ExportMetrics(base string) ([]string, error)
+	StopMetrics()
+	// stochastic time: random source of the model's distributions for the current run; returns a number in [0, n)
+	SetRandomSource(fu func(n int) int)
 	// require also the IPrint interface ...
 	IsEmpty() bool
 	Print(ind int)
@@ -0,0 +1,424 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+// 
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+// 
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Copyright: eva Kuehn
+////////////////////////////////////////
+
+package pmAutomata
+
+import (
+    "errors"
+    "fmt"
+    . "github.com/peermodel/simulator/contextInterface"
+    . "github.com/peermodel/simulator/debug"
+    . "github.com/peermodel/simulator/helpers"
+    . "github.com/peermodel/simulator/scheduler"
+    . "github.com/peermodel/simulator/pmModel"
+    . "github.com/peermodel/simulator/framework"
+)
+
+// --------------------------------------
+// OccTxCommit AUTOMATON:
+// --------------------------------------
+func NewAutomaton_OccTxCommit(automatonName string, createAutomatonFlag bool, a *Automaton) (*Automaton, *Machine) {
+    // --------------------------------------
+    // declare local variables (LVS) interface struct:
+    // --------------------------------------
+    type localVariables struct {
+        // --------------------------------
+        // alias variables: point into meta model -> do not deep copy but recompute!
+        wtx *Tx
+        c *Container
+        // --------------------------------
+        // ordinary variables:
+        cids Strings
+        cid string
+    }
+
+    // --------------------------------------
+    // create new automaton:
+    // --------------------------------------
+    if createAutomatonFlag {
+        a = NewAutomaton(automatonName)
+    }
+
+    // --------------------------------------
+    // create new machine:
+    // --------------------------------------
+    m := NewMachine(a)
+
+    // --------------------------------------
+    // alloc LVS:
+    // --------------------------------------
+    m.LocalVariables = new(localVariables)
+
+    if createAutomatonFlag {
+    // --------------------------------------
+    // define LVS copy function:
+    // --------------------------------------
+    a.LocalVariablesCopyFunction = func(theM *Machine, lvs interface{}) interface{} {
+        // --------------------------------
+        // cast ->:
+        tmpOrigLvs := lvs.(*localVariables)
+        // --------------------------------
+        // alloc LVS:
+        tmpNewLvs := new(localVariables)
+        // --------------------------------
+        // copy static fields:
+        *tmpNewLvs = *tmpOrigLvs
+        // --------------------------------
+        // copy dynamic fields:
+        if ! (IsPointer(tmpOrigLvs.cids) && nil == tmpOrigLvs.cids) {
+            tmpNewLvs.cids = tmpOrigLvs.cids.Copy()
+        }
+        // --------------------------------
+        // cast <-:
+        return (interface{})(tmpNewLvs)
+    }
+
+    // --------------------------------------
+    // define LVS alias function:
+    // --------------------------------------
+    a.CompleteLocalVariablesAliasFunction = func(s *Status, theM *Machine, lvs interface{}) interface{} {
+        // --------------------------------
+        // cast ->:
+        newLvs := lvs.(*localVariables)
+        // --------------------------------
+        newLvs.wtx = GetWtxAlias(theM, s)
+        newLvs.c = GetContainerAlias(theM, s)
+        // --------------------------------
+        // cast <-:
+        return (interface{})(newLvs)
+    }
+
+
+    // --------------------------------------
+    // init: INIT STATE
+    //   - GVars: [Wtxid]
+    // --------------------------------------
+    a.AddState("init", "OccTxCommit(ctx.Wtxid): OccTxCommit automaton: validate read set of wtx and make its changes visible", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
+        
+        // dummy code: helps that all imports are needed by every automaton
+        s.DummyString = fmt.Sprintf("dummy")
+        DummyHelpersFu()
+        DummySchedulerFu()
+        if ctx.DummyIContextFu().(IContext) == nil {}
+        // reset all error vars
+        ctx.RetErr = errors.New("")
+        ctx.RetErr = nil
+        
+        m.CurrentState = "1"
+
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 1: ACTION STATE
+    //   - GVars: [Wtxid]
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("1", "init variables", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        lvs.wtx = s.MetaContext.(*MetaContext).Transactions[ctx.Wtxid]
+        
+        m.CurrentState = "2"
+
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 2: ACTION STATE
+    //   - GVars: [RetErr]
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("2", "validate read set of wtx", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        ctx.RetErr = s.MetaContext.(*MetaContext).PeerSpace.OccValidate(lvs.wtx)
+        
+        m.CurrentState = "3"
+
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 3: CONDITION STATE
+    //   - GVars: [RetErr]
+    // --------------------------------------
+    a.AddState("3", "is read set valid?", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        
+        if (ctx.RetErr == nil) { m.CurrentState = "4" } else { m.CurrentState = "11" }
+
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 4: ACTION STATE
+    //   - LVars: [cids]
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("4", "collect containers changed by wtx", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- cids", lvs.cids)
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        lvs.cids = lvs.wtx.Occ.ChangedCids()
+        
+        m.CurrentState = "5"
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= cids", lvs.cids)
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 5: CONDITION STATE
+    //   - LVars: [cids]
+    // --------------------------------------
+    a.AddState("5", "is there any further changed container?", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- cids", lvs.cids)
+        
+        if (len(lvs.cids) > 0) { m.CurrentState = "6" } else { m.CurrentState = "10" }
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= cids", lvs.cids)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 6: ACTION STATE
+    //   - LVars: [cids, cid]
+    //   - Aliases:[c]
+    // --------------------------------------
+    a.AddState("6", "remove first cid from cids", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- cids", lvs.cids)
+        /**/ m.PrintlnS(TRACE0, TAB, "- cid", lvs.cid)
+        /**/ m.PrintlnX(TRACE0, TAB, "- c", lvs.c)
+        
+        lvs.cid = lvs.cids[0]
+        lvs.cids = lvs.cids[1:]
+        lvs.c = s.MetaContext.(*MetaContext).PeerSpace.Containers[lvs.cid]
+        
+        m.CurrentState = "7"
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= cids", lvs.cids)
+        /**/ m.PrintlnS(TRACE0, TAB, "= cid", lvs.cid)
+        /**/ m.PrintlnX(TRACE0, TAB, "= c", lvs.c)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 7: ACTION STATE
+    //   - LVars: [cid]
+    //   - Aliases:[wtx, c]
+    // --------------------------------------
+    a.AddState("7", "remove all entries in the container that are taken by wtx", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "- cid", lvs.cid)
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        /**/ m.PrintlnX(TRACE0, TAB, "- c", lvs.c)
+        
+        // assert that nil != lvs.c
+        tmpEs := Entries{}
+        for _, tmpE := range lvs.c.Entries {
+        	if lvs.wtx.Occ.IsTaken(lvs.cid, & tmpE) {
+        		// found -> do not copy to tmpEs
+        		// inform scheduler
+        		s.Scheduler = ClearEttsAndEttlSlot(s.Scheduler, tmpE.Id)
+        	} else {
+        		// not found -> keep entry ie copy it to tmpEs
+        		tmpEs = append(tmpEs, tmpE)
+        	}
+        }
+        lvs.c.SetEntries(tmpEs)
+        
+        m.CurrentState = "8"
+
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "= cid", lvs.cid)
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        /**/ m.PrintlnX(TRACE0, TAB, "= c", lvs.c)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 8: ACTION STATE
+    //   - GVars: [Wtxid]
+    //   - Aliases:[c]
+    // --------------------------------------
+    a.AddState("8", "on all entries in the container: remove WRITE-lock of tx", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnX(TRACE0, TAB, "- c", lvs.c)
+        
+        // commit the written entries (key container: they replace the ones with the same key)
+        lvs.c.CommitWrites(ctx.Wtxid)
+        for _, tmpE := range lvs.c.Entries {
+        	if tmpE.WLocks[ctx.Wtxid] > 0 {
+        		// remove write lock on tmpE: 
+        		delete(tmpE.WLocks, ctx.Wtxid)
+        		// inform scheduler about new entry - 
+        		// scheduler must insert GetAbsTts(ctx) and GetAbsTtl(ctx) slots for it: 
+        		s.Scheduler = SetEttsAndEttlSlot(s.Scheduler, tmpE.Id, tmpE.GetTts(), tmpE.GetTtl())
+        	}
+        }
+        
+        m.CurrentState = "9"
+
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnX(TRACE0, TAB, "= c", lvs.c)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 9: ACTION STATE
+    //   - LVars: [cid]
+    // --------------------------------------
+    a.AddState("9", "signal container change event", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "- cid", lvs.cid)
+        
+        s.MetaContext.(*MetaContext).PeerSpace.ContainerChangeEvent(lvs.cid)
+        
+        m.CurrentState = "5"
+
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "= cid", lvs.cid)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 10: ACTION STATE
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("10", "forget read set and written containers of wtx", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        lvs.wtx.Occ.Clear()
+        
+        m.CurrentState = "12"
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 11: ACTION STATE
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("11", "conflict: remove all entries written by wtx", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        s.MetaContext.(*MetaContext).PeerSpace.OccRemoveWrittenEntries(lvs.wtx)
+        
+        m.CurrentState = "10"
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 12: EXIT STATE
+    //   - GVars: [RetErr]
+    // --------------------------------------
+    a.AddState("12", "exit", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        
+        // Exit(ctx.RetErr)
+        
+        m.CurrentState = "exit" // docu
+        return EXIT
+        })
+    }
+
+    return a, m
+}
+
+////////////////////////////////////////
+// EOF of OccTxCommit AUTOMATON
+////////////////////////////////////////
@@ -187,7 +187,7 @@ func NewAutomaton_PccTxCommit(automatonName string, createAutomatonFlag bool, a
         			tmpEs = append(tmpEs, tmpE)
         		}
         	}
-        lvs.c.Entries = tmpEs
+        lvs.c.SetEntries(tmpEs)
         
         m.CurrentState = "3"
 
@@ -257,6 +257,8 @@ func NewAutomaton_PccTxCommit(automatonName string, createAutomatonFlag bool, a
         
         lvs.c = s.MetaContext.(*MetaContext).PeerSpace.Containers[lvs.cid]
         // assert nil != lvs.c
+        // commit the written entries (key container: they replace the ones with the same key)
+        lvs.c.CommitWrites(ctx.Wtxid)
         for _, tmpE := range lvs.c.Entries {
         	// remove write lock on tmpE: 
         	delete(tmpE.WLocks, ctx.Wtxid)
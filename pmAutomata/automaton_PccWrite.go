@@ -187,7 +187,7 @@ func NewAutomaton_PccWrite(automatonName string, createAutomatonFlag bool, a *Au
         lvs.e = ctx.Es[0]
         ctx.Es = append(ctx.Es[:0], ctx.Es[1:]...)
         lvs.e.AddLock(WRITE, ctx.Wtxid)
-        lvs.c.AddEntryPtr(lvs.e)
+        lvs.c.WriteEntryPtr(lvs.e)
         lvs.wtx.Pcc.LockedCids =   append(lvs.wtx.Pcc.LockedCids, ctx.Cid)
         
         m.CurrentState = "3"
@@ -201,7 +201,7 @@ func NewAutomaton_Read(automatonName string, createAutomatonFlag bool, a *Automa
         /**/ m.PrintlnX(TRACE0, TAB, "- c", lvs.c)
         
         for _, lvs.e = range lvs.es2 {
-          lvs.c.Entries = append(lvs.c.Entries, *lvs.e)
+          lvs.c.AddEntryPtr(lvs.e)
         }
         
         m.CurrentState = "6"
@@ -405,7 +405,7 @@ func NewAutomaton_Read(automatonName string, createAutomatonFlag bool, a *Automa
         /**/ m.PrintlnX(TRACE0, TAB, "- c", lvs.c)
         
         for _, lvs.e = range lvs.es2 {
-          lvs.c.Entries = append(lvs.c.Entries, *lvs.e)
+          lvs.c.AddEntryPtr(lvs.e)
         }
         
         m.CurrentState = "14"
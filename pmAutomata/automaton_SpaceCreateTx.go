@@ -198,7 +198,7 @@ func NewAutomaton_SpaceCreateTx(automatonName string, createAutomatonFlag bool,
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
         
-        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "4" } else { m.CurrentState = "2" }
+        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "4" } else { m.CurrentState = "6" }
 
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
@@ -259,6 +259,57 @@ func NewAutomaton_SpaceCreateTx(automatonName string, createAutomatonFlag bool,
         /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
         /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
         
+        return OK
+        })
+
+    // --------------------------------------
+    // 6: CONDITION STATE
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("6", "txcc is not pcc: check occ", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        if (lvs.wtx.Txcc == OCC) { m.CurrentState = "7" } else { m.CurrentState = "2" }
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 7: CALL STATE
+    //   - GVars: [RetErr, Wtxid]
+    // --------------------------------------
+    a.AddState("7", "call occ create tx – to init wtx", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
+        
+        // create and call new machine: 
+        foundAutomaton, foundFlag := s.CheckAutomatonExistence("OccCreateTx") 
+        theNewAutomaton, m1 := NewAutomaton_OccCreateTx("OccCreateTx", ! foundFlag, foundAutomaton) 
+        if !foundFlag { 
+            s.AddAutomaton(theNewAutomaton)
+        } 
+        ctx2 := m.Context.Copy().(IContext) 
+        ctx2 = m1.StartSync(s, ctx2) 
+        // copy back returned context variables: 
+        ctx.RetErr = ctx2.(*Context).RetErr
+        // debug: 
+        /**/ m.PrintlnResume()
+
+        m.CurrentState = "5"
+
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
+        
         return OK
         })
     }
@@ -195,7 +195,7 @@ func NewAutomaton_SpaceRead(automatonName string, createAutomatonFlag bool, a *A
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
         
-        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "4" } else { m.CurrentState = "6" }
+        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "4" } else { m.CurrentState = "8" }
 
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
@@ -347,6 +347,75 @@ func NewAutomaton_SpaceRead(automatonName string, createAutomatonFlag bool, a *A
         /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
         /**/ m.PrintlnX(TRACE0, TAB, "= l", lvs.l)
         
+        return OK
+        })
+
+    // --------------------------------------
+    // 8: CONDITION STATE
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("8", "guard link: txcc is not pcc: check occ", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        if (lvs.wtx.Txcc == OCC) { m.CurrentState = "9" } else { m.CurrentState = "6" }
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 9: CALL STATE
+    //   - GVars: [LinkNo, Wfid, Wid, Query, Vars, RetErr, Wtxid, Pid, RetEs, Cid]
+    // --------------------------------------
+    a.AddState("9", "call occ read", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnI(TRACE0, TAB, "- LinkNo", ctx.LinkNo)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wfid", ctx.Wfid)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wid", ctx.Wid)
+        /**/ m.PrintlnX(TRACE0, TAB, "- Query", ctx.Query)
+        /**/ m.PrintlnX(TRACE0, TAB, "- Vars", ctx.Vars)
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Pid", ctx.Pid)
+        /**/ m.PrintlnX(TRACE0, TAB, "- RetEs", ctx.RetEs)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Cid", ctx.Cid)
+        
+        // create and call new machine: 
+        foundAutomaton, foundFlag := s.CheckAutomatonExistence("OccRead") 
+        theNewAutomaton, m1 := NewAutomaton_OccRead("OccRead", ! foundFlag, foundAutomaton) 
+        if !foundFlag { 
+            s.AddAutomaton(theNewAutomaton)
+        } 
+        ctx2 := m.Context.Copy().(IContext) 
+        ctx2 = m1.StartSync(s, ctx2) 
+        // copy back returned context variables: 
+        ctx.Wfid = ctx2.(*Context).Wfid
+        ctx.RetErr = ctx2.(*Context).RetErr
+        ctx.RetEs = ctx2.(*Context).RetEs
+        // debug: 
+        /**/ m.PrintlnResume()
+
+        m.CurrentState = "3"
+
+        // debug: 
+        /**/ m.PrintlnI(TRACE0, TAB, "= LinkNo", ctx.LinkNo)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wfid", ctx.Wfid)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wid", ctx.Wid)
+        /**/ m.PrintlnX(TRACE0, TAB, "= Query", ctx.Query)
+        /**/ m.PrintlnX(TRACE0, TAB, "= Vars", ctx.Vars)
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Pid", ctx.Pid)
+        /**/ m.PrintlnX(TRACE0, TAB, "= RetEs", ctx.RetEs)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Cid", ctx.Cid)
+        
         return OK
         })
     }
@@ -182,7 +182,7 @@ func NewAutomaton_SpaceTxCommit(automatonName string, createAutomatonFlag bool,
     // 3: CONDITION STATE
     //   - GVars: [RetErr]
     // --------------------------------------
-    a.AddState("3", "check return value of pcc tx commit", func(s *Status, m *Machine) StateRetEnum {
+    a.AddState("3", "check return value of pcc or occ tx commit", func(s *Status, m *Machine) StateRetEnum {
         ctx := m.Context.(*Context)
         
         // debug: 
@@ -206,7 +206,7 @@ func NewAutomaton_SpaceTxCommit(automatonName string, createAutomatonFlag bool,
         // debug: 
         /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
         
-        s.MetaContext.(*MetaContext).Transactions[ctx.Wtxid].State = COMMITTED
+        s.MetaContext.(*MetaContext).Transactions[ctx.Wtxid].End(COMMITTED)
         
         m.CurrentState = "9"
 
@@ -226,7 +226,7 @@ func NewAutomaton_SpaceTxCommit(automatonName string, createAutomatonFlag bool,
         // debug: 
         /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
         
-        s.MetaContext.(*MetaContext).Transactions[ctx.Wtxid].State = ROLLEDBACK
+        s.MetaContext.(*MetaContext).Transactions[ctx.Wtxid].End(ROLLEDBACK)
         
         m.CurrentState = "9"
 
@@ -288,7 +288,7 @@ func NewAutomaton_SpaceTxCommit(automatonName string, createAutomatonFlag bool,
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
         
-        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "1" } else { m.CurrentState = "2" }
+        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "1" } else { m.CurrentState = "10" }
 
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
@@ -314,6 +314,57 @@ func NewAutomaton_SpaceTxCommit(automatonName string, createAutomatonFlag bool,
         // debug: 
         /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
         
+        return OK
+        })
+
+    // --------------------------------------
+    // 10: CONDITION STATE
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("10", "txcc is not pcc: check occ", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        if (lvs.wtx.Txcc == OCC) { m.CurrentState = "11" } else { m.CurrentState = "2" }
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 11: CALL STATE
+    //   - GVars: [RetErr, Wtxid]
+    // --------------------------------------
+    a.AddState("11", "call occ tx commit", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
+        
+        // create and call new machine: 
+        foundAutomaton, foundFlag := s.CheckAutomatonExistence("OccTxCommit") 
+        theNewAutomaton, m1 := NewAutomaton_OccTxCommit("OccTxCommit", ! foundFlag, foundAutomaton) 
+        if !foundFlag { 
+            s.AddAutomaton(theNewAutomaton)
+        } 
+        ctx2 := m.Context.Copy().(IContext) 
+        ctx2 = m1.StartSync(s, ctx2) 
+        // copy back returned context variables: 
+        ctx.RetErr = ctx2.(*Context).RetErr
+        // debug: 
+        /**/ m.PrintlnResume()
+
+        m.CurrentState = "3"
+
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
+        
         return OK
         })
     }
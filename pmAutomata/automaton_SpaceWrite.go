@@ -235,7 +235,7 @@ func NewAutomaton_SpaceWrite(automatonName string, createAutomatonFlag bool, a *
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
         
-        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "6" } else { m.CurrentState = "5" }
+        if (lvs.wtx.Txcc == PCC) { m.CurrentState = "6" } else { m.CurrentState = "8" }
 
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
@@ -246,7 +246,7 @@ func NewAutomaton_SpaceWrite(automatonName string, createAutomatonFlag bool, a *
     // --------------------------------------
     // 5: ERROR STATE
     // --------------------------------------
-    a.AddState("5", "OCC not yet implemented", func(s *Status, m *Machine) StateRetEnum {
+    a.AddState("5", "ill. txcc", func(s *Status, m *Machine) StateRetEnum {
         
         // debug: 
         
@@ -314,6 +314,61 @@ func NewAutomaton_SpaceWrite(automatonName string, createAutomatonFlag bool, a *
         // debug: 
         /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
         
+        return OK
+        })
+
+    // --------------------------------------
+    // 8: CONDITION STATE
+    //   - Aliases:[wtx]
+    // --------------------------------------
+    a.AddState("8", "txcc is not pcc: check occ", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "- wtx", lvs.wtx)
+        
+        if (lvs.wtx.Txcc == OCC) { m.CurrentState = "9" } else { m.CurrentState = "5" }
+
+        // debug: 
+        /**/ m.PrintlnX(TRACE0, TAB, "= wtx", lvs.wtx)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 9: CALL STATE
+    //   - GVars: [RetErr, Wtxid, Es, Cid]
+    // --------------------------------------
+    a.AddState("9", "call transactional write with optimistic concurrency control", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnX(TRACE0, TAB, "- Es", ctx.Es)
+        /**/ m.PrintlnS(TRACE0, TAB, "- Cid", ctx.Cid)
+        
+        // create and call new machine: 
+        foundAutomaton, foundFlag := s.CheckAutomatonExistence("OccWrite") 
+        theNewAutomaton, m1 := NewAutomaton_OccWrite("OccWrite", ! foundFlag, foundAutomaton) 
+        if !foundFlag { 
+            s.AddAutomaton(theNewAutomaton)
+        } 
+        ctx2 := m.Context.Copy().(IContext) 
+        ctx2 = m1.StartSync(s, ctx2) 
+        // copy back returned context variables: 
+        ctx.RetErr = ctx2.(*Context).RetErr
+        // debug: 
+        /**/ m.PrintlnResume()
+
+        m.CurrentState = "7"
+
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wtxid", ctx.Wtxid)
+        /**/ m.PrintlnX(TRACE0, TAB, "= Es", ctx.Es)
+        /**/ m.PrintlnS(TRACE0, TAB, "= Cid", ctx.Cid)
+        
         return OK
         })
     }
@@ -22,6 +22,7 @@
 package pmAutomata
 
 import (
+    "encoding/json"
     "errors"
     "fmt"
     . "github.com/peermodel/simulator/contextInterface"
@@ -135,6 +136,105 @@ func NewAutomaton_Wiring(automatonName string, createAutomatonFlag bool, a *Auto
         return (interface{})(newLvs)
     }
 
+    // --------------------------------------
+    // define LVS snapshot functions:
+    // --------------------------------------
+    // - json struct of the ordinary variables (the alias variables are recomputed)
+    type snapshotLocalVariables struct {
+        Exc *string
+        RepeatCount int
+        ReadEs EntryPtrs
+        Res bool
+        IopEs EntryPtrs
+        DwMachineNumber int
+        Dwid string
+        WTtl int
+        Es2 EntryPtrs
+        WTts int
+        Wait4Cid string
+        LTtl int
+        LTts int
+        StartTime int
+        E *Entry
+        WriteEs EntryPtrs
+        NLinks int
+    }
+    a.LocalVariablesSnapshotFunction = func(theM *Machine, lvs interface{}) ([]byte, error) {
+        // --------------------------------
+        // cast ->:
+        tmpLvs := lvs.(*localVariables)
+        // --------------------------------
+        tmpSnap := snapshotLocalVariables{
+            RepeatCount: tmpLvs.repeatCount,
+            ReadEs: tmpLvs.readEs,
+            Res: tmpLvs.res,
+            IopEs: tmpLvs.iopEs,
+            DwMachineNumber: tmpLvs.dwMachineNumber,
+            Dwid: tmpLvs.dwid,
+            WTtl: tmpLvs.wTtl,
+            Es2: tmpLvs.es2,
+            WTts: tmpLvs.wTts,
+            Wait4Cid: tmpLvs.wait4Cid,
+            LTtl: tmpLvs.lTtl,
+            LTts: tmpLvs.lTts,
+            StartTime: tmpLvs.startTime,
+            E: tmpLvs.e,
+            WriteEs: tmpLvs.writeEs,
+            NLinks: tmpLvs.nLinks,
+        }
+        if nil != tmpLvs.exc {
+            excMsg := tmpLvs.exc.Error()
+            tmpSnap.Exc = &excMsg
+        }
+        return json.Marshal(tmpSnap)
+    }
+    a.LocalVariablesRestoreFunction = func(theM *Machine, data []byte) (interface{}, error) {
+        tmpSnap := snapshotLocalVariables{}
+        if err := json.Unmarshal(data, &tmpSnap); nil != err {
+            return nil, err
+        }
+        // --------------------------------
+        // alloc LVS:
+        tmpNewLvs := &localVariables{
+            repeatCount: tmpSnap.RepeatCount,
+            readEs: tmpSnap.ReadEs,
+            res: tmpSnap.Res,
+            iopEs: tmpSnap.IopEs,
+            dwMachineNumber: tmpSnap.DwMachineNumber,
+            dwid: tmpSnap.Dwid,
+            wTtl: tmpSnap.WTtl,
+            es2: tmpSnap.Es2,
+            wTts: tmpSnap.WTts,
+            wait4Cid: tmpSnap.Wait4Cid,
+            lTtl: tmpSnap.LTtl,
+            lTts: tmpSnap.LTts,
+            startTime: tmpSnap.StartTime,
+            e: tmpSnap.E,
+            writeEs: tmpSnap.WriteEs,
+            nLinks: tmpSnap.NLinks,
+        }
+        if nil != tmpSnap.Exc {
+            tmpNewLvs.exc = errors.New(*tmpSnap.Exc)
+        }
+        // --------------------------------
+        // cast <-:
+        return (interface{})(tmpNewLvs), nil
+    }
+
+    // --------------------------------------
+    // define LVS fingerprint function:
+    // --------------------------------------
+    // - an infinite repeat count is never reached -> the repeat counter is then not part of the state
+    a.LocalVariablesFingerprintFunction = func(theM *Machine, lvs interface{}) string {
+        // --------------------------------
+        // cast ->:
+        tmpLvs := *lvs.(*localVariables)
+        // --------------------------------
+        if nil != tmpLvs.w && INFINITE == tmpLvs.w.GetRepeatCount(theM.Context.(*Context)) {
+            tmpLvs.repeatCount = 0
+        }
+        return LocalVariablesFingerprint(&tmpLvs)
+    }
 
     // --------------------------------------
     // init: INIT STATE
@@ -1027,7 +1127,7 @@ func NewAutomaton_Wiring(automatonName string, createAutomatonFlag bool, a *Auto
         // debug: 
         /**/ m.PrintlnResume()
 
-        m.CurrentState = "57"
+        m.CurrentState = "80"
 
         // debug: 
         /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
@@ -2170,6 +2270,54 @@ func NewAutomaton_Wiring(automatonName string, createAutomatonFlag bool, a *Auto
         // debug: 
         /**/ m.PrintlnX(TRACE0, TAB, "= writeEs", lvs.writeEs)
         
+        return OK
+        })
+
+    // --------------------------------------
+    // 80: CONDITION STATE
+    //   - GVars: [RetErr]
+    // --------------------------------------
+    a.AddState("80", "was tx commit ok? (occ: read set could be invalid)", func(s *Status, m *Machine) StateRetEnum {
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        
+        if (ctx.RetErr == nil) { m.CurrentState = "57" } else { m.CurrentState = "81" }
+
+        // debug: 
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        
+        return OK
+        })
+
+    // --------------------------------------
+    // 81: ACTION STATE
+    //   - GVars: [Wiid, LinkNo, RetErr]
+    //   - LVars: [exc]
+    // --------------------------------------
+    a.AddState("81", "tx conflict: inform scheduler about link termination; raise tx conflict exception", func(s *Status, m *Machine) StateRetEnum {
+        lvs := m.LocalVariables.(*localVariables)
+        ctx := m.Context.(*Context)
+        
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "- Wiid", ctx.Wiid)
+        /**/ m.PrintlnI(TRACE0, TAB, "- LinkNo", ctx.LinkNo)
+        /**/ m.PrintlnY(TRACE0, TAB, "- RetErr", ctx.RetErr)
+        /**/ m.PrintlnY(TRACE0, TAB, "- exc", lvs.exc)
+        
+        s.Scheduler = ClearLttsAndLttlSlot(s.Scheduler, ctx.Wiid, ctx.LinkNo)
+        Exception(m, s, TX_CONFLICT_EXCEPTION, ctx.RetErr.Error())
+        lvs.exc = errors.New("WIRING-TX-CONFLICT-EXCEPTION")
+        
+        m.CurrentState = "75"
+
+        // debug: 
+        /**/ m.PrintlnS(TRACE0, TAB, "= Wiid", ctx.Wiid)
+        /**/ m.PrintlnI(TRACE0, TAB, "= LinkNo", ctx.LinkNo)
+        /**/ m.PrintlnY(TRACE0, TAB, "= RetErr", ctx.RetErr)
+        /**/ m.PrintlnY(TRACE0, TAB, "= exc", lvs.exc)
+        
         return OK
         })
     }
@@ -174,7 +174,7 @@ func NewAutomaton_Write(automatonName string, createAutomatonFlag bool, a *Autom
         
         lvs.e = ctx.Es[0]
         ctx.Es = append(ctx.Es[:0], ctx.Es[1:]...)
-        lvs.c.AddEntryPtr(lvs.e)
+        lvs.c.WriteEntryPtr(lvs.e)
         
         m.CurrentState = "3"
 
@@ -23,7 +23,9 @@
 package pmAutomata
 
 import (
+	. "github.com/peermodel/simulator/config"
 	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/eventLog"
 	. "github.com/peermodel/simulator/framework"
 	. "github.com/peermodel/simulator/helpers"
 	. "github.com/peermodel/simulator/pmModel"
@@ -58,15 +60,56 @@ func NewPeerModelAutomataGenerator() *PeerModelAutomataGenerator {
 
 //------------------------------------------------------------
 // init the runtime model and start its machines:
+// - type check the meta model (nb: a model file has been checked together with its initial entries already)
+// - register the state hooks for the event log
 // - create all needed containers
 // - start all wiring machines
 func (a PeerModelAutomataGenerator) InitRuntimeModelAndStartMachines(s *Status) {
+	// - type check
+	if errs := s.MetaContext.(*MetaContext).PeerSpace.TypeCheck(nil); 0 < len(errs) {
+		ModelPanic(errs.Error())
+	}
+	// - register event log hooks
+	RegisterEventLogHooks()
 	// - create containers
 	a.CreateContainers4RuntimeModel(s)
 	// - start wiring machines
 	a.StartWiringMachines4RuntimeModel(s)
 }
 
+//------------------------------------------------------------
+// init a use case given by a model file (see pmModel/modelFile.go):
+// - create meta context and status, init the runtime model, start its machines and write the initial entries
+// - the returned status can re-init itself (needed for simulation runs)
+func (a PeerModelAutomataGenerator) InitModelFileUseCase(mf *ModelFile) (*Status, error) {
+	//------------------------------------------------------------
+	// create meta model
+	metaCtx, err := mf.NewMetaContext()
+	if nil != err {
+		return nil, err
+	}
+	//------------------------------------------------------------
+	// create status
+	s := NewStatus(SYSTEM_TTL, metaCtx)
+	s.InitAppUseCaseFu = func() *Status {
+		newS, err := a.InitModelFileUseCase(mf)
+		if nil != err {
+			ModelPanic(err.Error())
+		}
+		return newS
+	}
+	//------------------------------------------------------------
+	// init runtime model and start machines
+	a.InitRuntimeModelAndStartMachines(s)
+	//------------------------------------------------------------
+	// write initial entries
+	if err := mf.WriteEntries(metaCtx.PeerSpace, &s.Scheduler); nil != err {
+		return nil, err
+	}
+	//------------------------------------------------------------
+	return s, nil
+}
+
 //------------------------------------------------------------
 // init the runtime model (part a):
 // - create all needed containers
@@ -91,6 +134,8 @@ func (a PeerModelAutomataGenerator) CreateContainers4RuntimeModel(s *Status) {
 		// create pic and poc containers for peer
 		pic := *NewContainer(p.Pic)
 		poc := *NewContainer(p.Poc)
+		pic.Coordinator = p.PicCoordinator
+		poc.Coordinator = p.PocCoordinator
 		//------------------------------------------------------------
 		// debug
 		if RUN_TRACE.DoTrace() { // DEBUG
@@ -115,7 +160,10 @@ func (a PeerModelAutomataGenerator) StartWiringMachines4RuntimeModel(s *Status)
 	//------------------------------------------------------------
 	// for all peers
 	// - create and start their wiring machines
-	for _, p := range s.MetaContext.(*MetaContext).PeerSpace.Peers {
+	// - nb: in the order of the pids and wids, so that the machine numbers do not depend on the order of the maps
+	ps := s.MetaContext.(*MetaContext).PeerSpace
+	for _, pid := range ps.PeerPids {
+		p := ps.Peers[pid]
 		//------------------------------------------------------------
 		// debug
 		if RUN_TRACE.DoTrace() { // DEBUG
@@ -124,7 +172,8 @@ func (a PeerModelAutomataGenerator) StartWiringMachines4RuntimeModel(s *Status)
 		//------------------------------------------------------------
 		// start given number of wiring instance(s) (= wiring machine(s)) for each wiring
 		// - incl. its WIC (wiring internal container)
-		for _, w := range p.Wirings {
+		for _, wid := range p.WiringWids {
+			w := p.Wirings[wid]
 			//------------------------------------------------------------
 			// get max-threads property of the wiring
 			// - TBD: no eval required?!
@@ -686,6 +735,63 @@ func Exception(m *Machine, s *Status, exc ExceptionTypeEnum, msg string) {
 		excpadded = Padding(excpadded, 15, "-")
 		/**/ m.PrintlnStarMessage(EXCEPTION, fmt.Sprintf("%s %s", excpadded, msg))
 	}
+
+	// event log: also the wiring repeat exception
+	if EventLogIsOn() {
+		LogWiringException(m.Context.(*Context), exc, msg)
+	}
+}
+
+// =========================================================
+// names (ie comments) of the states of the automata at which the event log hooks log
+// - nb: the state ids are generated -> the states are looked up by their names (see StateName)
+const (
+	EXIT_STATE          = "exit"
+	TX_COMMITTED_STATE  = "set tx state to committed"
+	TX_ROLLEDBACK_STATE = "set tx state to rolledback"
+	UNDO_STATE          = "set state of wtx to ROLLEDBACK"
+)
+
+// =========================================================
+// register the state hooks that write the space operations and txs of the automata to the event log
+// - SpaceRead, SpaceWrite: at their exit, if the operation succeeded
+// - SpaceCreateTx: at its exit, if the wtx was created
+// - SpaceTxCommit: when the state of the wtx is set to committed or rolledback
+// - SpaceUndo: when the state of the wtx is set to rolledback
+func RegisterEventLogHooks() {
+	RegisterStateHook("SpaceRead", func(s *Status, m *Machine, prevState string) {
+		ctx := m.Context.(*Context)
+		if EXIT_STATE == m.StateName(prevState) && nil == ctx.RetErr {
+			LogSpaceOp(GetLinkAlias(m, s).Op, ctx, ctx.Cid, ctx.RetEs)
+		}
+	})
+	RegisterStateHook("SpaceWrite", func(s *Status, m *Machine, prevState string) {
+		ctx := m.Context.(*Context)
+		if EXIT_STATE == m.StateName(prevState) && nil == ctx.RetErr {
+			LogSpaceOp(WRITE, ctx, ctx.Cid, ctx.Es)
+		}
+	})
+	RegisterStateHook("SpaceCreateTx", func(s *Status, m *Machine, prevState string) {
+		ctx := m.Context.(*Context)
+		if EXIT_STATE == m.StateName(prevState) && nil == ctx.RetErr {
+			LogTx(LOG_TX_CREATE, ctx, ctx.Wtxid)
+		}
+	})
+	RegisterStateHook("SpaceTxCommit", func(s *Status, m *Machine, prevState string) {
+		ctx := m.Context.(*Context)
+		switch m.StateName(prevState) {
+		case TX_COMMITTED_STATE:
+			LogTx(LOG_TX_COMMIT, ctx, ctx.Wtxid)
+		case TX_ROLLEDBACK_STATE:
+			LogTx(LOG_TX_ROLLBACK, ctx, ctx.Wtxid)
+		}
+	})
+	RegisterStateHook("SpaceUndo", func(s *Status, m *Machine, prevState string) {
+		ctx := m.Context.(*Context)
+		if UNDO_STATE == m.StateName(prevState) {
+			LogTx(LOG_TX_ROLLBACK, ctx, ctx.Wtxid)
+		}
+	})
 }
 
 //////////////////////////////////////////////////////////////
@@ -146,8 +146,10 @@ func NewAutomaton_SpaceUndoRead(automatonName string, createAutomatonFlag bool,
 			m.PrintlnY(TRACE0, TAB, "Txcc", lvs.wtx.Txcc)
 			if PCC == lvs.wtx.Txcc {
 				m.CurrentState = "3"
+			} else if OCC == lvs.wtx.Txcc {
+				m.CurrentState = "9"
 			} else {
-				m.SystemError("OCC not yet implemented.") // @@@
+				m.SystemError("ill. txcc")
 			}
 
 			return OK
@@ -264,6 +266,19 @@ func NewAutomaton_SpaceUndoRead(automatonName string, createAutomatonFlag bool,
 
 			return OK
 		})
+		// ------------------------------------------------------------
+		// STATE 9:
+		// ------------------------------------------------------------
+		a.AddState("9", "OCC: remove the entries from the read set of wtx", func(s *Status, m *Machine) StateRetEnum {
+			lvs := m.LocalVariables.(*localVariables)
+			ctx := m.Context.(*Context)
+
+			lvs.wtx.Occ.RemoveReads(ctx.Cid, ctx.Es)
+
+			m.CurrentState = "exit"
+
+			return EXIT
+		})
 	}
 
 	return a, m
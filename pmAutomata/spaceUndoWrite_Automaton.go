@@ -144,10 +144,11 @@ func NewAutomaton_SpaceUndoWrite(automatonName string, createAutomatonFlag bool,
 
 			/**/
 			m.PrintlnY(TRACE0, TAB, "Txcc", lvs.wtx.Txcc)
-			if lvs.wtx.Txcc == PCC {
+			// pcc and occ: the written entries are WRITE-locked by wtx
+			if lvs.wtx.Txcc == PCC || lvs.wtx.Txcc == OCC {
 				m.CurrentState = "3"
 			} else {
-				m.SystemError("OCC not yet implemented.") // @@@
+				m.SystemError("ill. txcc")
 			}
 
 			return OK
@@ -152,13 +152,15 @@ func NewAutomaton_SpaceUndo(automatonName string, createAutomatonFlag bool, a *A
 		// ------------------------------------------------------------
 		// STATE 4:
 		// ------------------------------------------------------------
-		a.AddState("4_@@@", "OCC", func(s *Status, m *Machine) StateRetEnum {
-			// lvs := m.LocalVariables.(*localVariables)
+		a.AddState("4", "OCC: remove all entries written by wtx; forget its read set", func(s *Status, m *Machine) StateRetEnum {
+			lvs := m.LocalVariables.(*localVariables)
 
-			m.SystemError("OCC not yet implemented.") // @@@
-			m.CurrentState = "exit"
+			s.MetaContext.(*MetaContext).PeerSpace.OccRemoveWrittenEntries(lvs.wtx)
+			lvs.wtx.Occ.Clear()
 
-			return EXIT
+			m.CurrentState = "5"
+
+			return OK
 		})
 		// ------------------------------------------------------------
 		// STATE 5:
@@ -166,7 +168,7 @@ func NewAutomaton_SpaceUndo(automatonName string, createAutomatonFlag bool, a *A
 		a.AddState("5", "set state of wtx to ROLLEDBACK", func(s *Status, m *Machine) StateRetEnum {
 			lvs := m.LocalVariables.(*localVariables)
 
-			lvs.wtx.State = ROLLEDBACK
+			lvs.wtx.End(ROLLEDBACK)
 
 			m.CurrentState = "exit"
 
@@ -32,6 +32,7 @@ import (
 	. "github.com/peermodel/simulator/debug"
 	. "github.com/peermodel/simulator/scheduler"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -64,6 +65,8 @@ type Arg struct {
 
 	// function name
 	FuName SystemFunctionEnum
+	// function args (see systemFunctions.go)
+	FuArgs []Arg
 
 	// value (temporarily overwritten during eval for non val kinds):
 	// nb: entry type and url use string val!
@@ -105,6 +108,11 @@ func IFu(fuName SystemFunctionEnum) Arg {
 	return Arg{Kind: FU, Type: INT, FuName: fuName}
 }
 
+// ----------------------------------------
+func IFuArgs(fuName SystemFunctionEnum, fuArgs ...Arg) Arg {
+	return Arg{Kind: FU, Type: INT, FuName: fuName, FuArgs: fuArgs}
+}
+
 // ----------------------------------------
 func ILabel(name string) Arg {
 	return Arg{Kind: LABEL, Type: INT, Name: name}
@@ -125,6 +133,11 @@ func SFu(fuName SystemFunctionEnum) Arg {
 	return Arg{Kind: FU, Type: STRING, FuName: fuName}
 }
 
+// ----------------------------------------
+func SFuArgs(fuName SystemFunctionEnum, fuArgs ...Arg) Arg {
+	return Arg{Kind: FU, Type: STRING, FuName: fuName, FuArgs: fuArgs}
+}
+
 // ----------------------------------------
 func SEtype(val string) Arg {
 	return Arg{Kind: VAL, Type: STRING, StringSubType: ENTRY_TYPE, StringVal: val}
@@ -156,6 +169,11 @@ func BFu(fuName SystemFunctionEnum) Arg {
 	return Arg{Kind: FU, Type: BOOL, FuName: fuName}
 }
 
+// ----------------------------------------
+func BFuArgs(fuName SystemFunctionEnum, fuArgs ...Arg) Arg {
+	return Arg{Kind: FU, Type: BOOL, FuName: fuName, FuArgs: fuArgs}
+}
+
 // ----------------------------------------
 func BLabel(name string) Arg {
 	return Arg{Kind: LABEL, Type: BOOL, Name: name}
@@ -191,14 +209,14 @@ func ArrayRef(name string, indexArg Arg) string {
 	if (indexArg.Eval(Vars{}, nil /* no entry */)) {
 		// verify that result is INT
 		if indexArg.Type != INT {
-			Panic("ArrayLabel: index must have type INT")
+			ModelPanic("ArrayLabel: index must have type INT")
 		}
 		// generate label string of the form: label # index
 		s := fmt.Sprintf("%s%c%d", name, ArrayChar, indexArg.IntVal)
 		// fmt.Println(fmt.Sprintf("array (static): %s", s))
 		return s
 	} else {
-		Panic("ArrayLabel: index of ArrayRef must be statically evaluable to INT")
+		ModelPanic("ArrayLabel: index of ArrayRef must be statically evaluable to INT")
 	}
 	return "*** ill. ArrayRef usage ***" // TBD: why needed?
 }
@@ -268,6 +286,15 @@ func (a *Arg) Copy() Arg {
 	newA.Type = a.Type
 	// - Name:
 	newA.Name = a.Name
+	// - FuName:
+	newA.FuName = a.FuName
+	// - FuArgs:
+	if nil != a.FuArgs {
+		newA.FuArgs = make([]Arg, len(a.FuArgs))
+		for i := range a.FuArgs {
+			newA.FuArgs[i] = a.FuArgs[i].Copy()
+		}
+	}
 	// - IntVal:
 	newA.IntVal = a.IntVal
 	// - StringVal:
@@ -319,7 +346,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 	}
 
 	if nil == arg {
-		Panic("Eval: arg is nil")
+		EvalPanic("Eval: arg is nil")
 	}
 	switch arg.Kind {
 	// -------------------
@@ -340,7 +367,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 				/**/ String2TraceFile(fmt.Sprintf("VAL=%t\n", arg.BoolVal))
 			}
 		default:
-			Panic(fmt.Sprintf("Eval: VAL %s: ill. arg.Type = %s", arg.Kind, arg.Type))
+			EvalPanic(fmt.Sprintf("Eval: VAL %s: ill. arg.Type = %s", arg.Kind, arg.Type))
 		}
 
 	// -------------------
@@ -352,7 +379,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 		v := vars[arg.Name]
 		if "" == v.Kind {
 			// var does not exist
-			Panic(fmt.Sprintf("VAR: var %s does not exist", arg.Name))
+			EvalPanic(fmt.Sprintf("VAR: var %s does not exist", arg.Name))
 		} else {
 			// set arg's value and type temporarily to var's value
 			if ARGS_EVAL_TRACE.DoTrace() {
@@ -377,7 +404,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 				}
 				arg.BoolVal = v.BoolVal
 			default:
-				Panic(fmt.Sprintf("Eval: VAR %s: ill. v.Type = %s", arg.Name, v.Type))
+				EvalPanic(fmt.Sprintf("Eval: VAR %s: ill. v.Type = %s", arg.Name, v.Type))
 			}
 		}
 
@@ -393,8 +420,45 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			case CLOCK_FUNCTION:
 				arg.IntVal = Clock()
 				arg.Type = INT
+			case EXPONENTIAL_FUNCTION, UNIFORM_FUNCTION, NORMAL_FUNCTION, ERLANG_FUNCTION, EMPIRICAL_FUNCTION:
+				// eval the params and draw
+				params := make([]int, len(arg.FuArgs))
+				for i := range arg.FuArgs {
+					params[i] = arg.evalFuArg(i, INT, vars, entry).IntVal
+				}
+				arg.IntVal = RandomDistribution(arg.FuName, params)
+				arg.Type = INT
+			case LEN_FUNCTION:
+				arg.IntVal = len([]rune(arg.evalFuArg(0, STRING, vars, entry).StringVal))
+				arg.Type = INT
+			case MIN_FUNCTION:
+				a := arg.evalFuArg(0, INT, vars, entry).IntVal
+				b := arg.evalFuArg(1, INT, vars, entry).IntVal
+				if a < b {
+					arg.IntVal = a
+				} else {
+					arg.IntVal = b
+				}
+				arg.Type = INT
+			case MAX_FUNCTION:
+				a := arg.evalFuArg(0, INT, vars, entry).IntVal
+				b := arg.evalFuArg(1, INT, vars, entry).IntVal
+				if a > b {
+					arg.IntVal = a
+				} else {
+					arg.IntVal = b
+				}
+				arg.Type = INT
+			case ABS_FUNCTION:
+				arg.IntVal = arg.evalFuArg(0, INT, vars, entry).IntVal
+				if arg.IntVal < 0 {
+					arg.IntVal = -arg.IntVal
+				}
+				arg.Type = INT
+			case IF_FUNCTION:
+				arg.IntVal = arg.evalIf(vars, entry).IntVal
 			default:
-				Panic(fmt.Sprintf("Eval: ill. int fu = %s", arg.FuName))
+				EvalPanic(fmt.Sprintf("Eval: ill. int fu = %s", arg.FuName))
 			}
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile(fmt.Sprintf("FU=%d\n", arg.IntVal))
@@ -407,19 +471,43 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			case UUID_FUNCTION:
 				arg.StringVal = UuidUserFu()
 				arg.Type = STRING
+			case SUBSTR_FUNCTION:
+				str := arg.evalFuArg(0, STRING, vars, entry).StringVal
+				from := arg.evalFuArg(1, INT, vars, entry).IntVal
+				to := arg.evalFuArg(2, INT, vars, entry).IntVal
+				arg.StringVal = Substr(str, from, to)
+				arg.Type = STRING
+			case STR_FUNCTION:
+				arg.StringVal = strconv.Itoa(arg.evalFuArg(0, INT, vars, entry).IntVal)
+				arg.Type = STRING
+			case IF_FUNCTION:
+				arg.StringVal = arg.evalIf(vars, entry).StringVal
 			default:
-				Panic(fmt.Sprintf("Eval: ill. string fu = %s", arg.FuName))
+				EvalPanic(fmt.Sprintf("Eval: ill. string fu = %s", arg.FuName))
 			}
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile(fmt.Sprintf("FU=%s\n", arg.StringVal))
 			}
-			//		case BOOL:
-			//			// @@@ no bool fus yet
-			//			if ARGS_EVAL_TRACE.DoTrace() {
-			//				/**/ String2TraceFile(fmt.Sprintf("FU=%t\n", ...))
-			//			}
+		case BOOL:
+			switch arg.FuName {
+			case CONTAINS_FUNCTION:
+				str := arg.evalFuArg(0, STRING, vars, entry).StringVal
+				arg.BoolVal = strings.Contains(str, arg.evalFuArg(1, STRING, vars, entry).StringVal)
+				arg.Type = BOOL
+			case HASPREFIX_FUNCTION:
+				str := arg.evalFuArg(0, STRING, vars, entry).StringVal
+				arg.BoolVal = strings.HasPrefix(str, arg.evalFuArg(1, STRING, vars, entry).StringVal)
+				arg.Type = BOOL
+			case IF_FUNCTION:
+				arg.BoolVal = arg.evalIf(vars, entry).BoolVal
+			default:
+				EvalPanic(fmt.Sprintf("Eval: ill. bool fu = %s", arg.FuName))
+			}
+			if ARGS_EVAL_TRACE.DoTrace() {
+				/**/ String2TraceFile(fmt.Sprintf("FU=%t\n", arg.BoolVal))
+			}
 		default:
-			Panic(fmt.Sprintf("Eval: ill. fu = %s", arg.FuName))
+			EvalPanic(fmt.Sprintf("Eval: ill. fu = %s", arg.FuName))
 		}
 
 	// -------------------
@@ -427,7 +515,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 	case LABEL:
 		// entry must not be nil!
 		if nil == entry {
-			Panic(fmt.Sprintf("Eval: can't eval entry label (%s) in expression if no entry is given", arg.Name))
+			EvalPanic(fmt.Sprintf("Eval: can't eval entry label (%s) in expression if no entry is given", arg.Name))
 		}
 		if ARGS_EVAL_TRACE.DoTrace() {
 			/**/ String2TraceFile(fmt.Sprintf("LABEL=%s\n", arg.Name))
@@ -450,7 +538,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 					/**/ String2TraceFile("entry does not have a property with this label\n")
 				}
 
-				Panic(fmt.Sprintf("Eval: LABEL \"%s\": undefined entry property = %s;\n  entry = %s;\n", arg.Name, arg.Name, entry.ToString(0)))
+				EvalPanic(fmt.Sprintf("Eval: LABEL \"%s\": undefined entry property = %s;\n  entry = %s;\n", arg.Name, arg.Name, entry.ToString(0)))
 				return false
 			}
 			arg.Type = entryArg.Type
@@ -471,7 +559,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 					/**/ String2TraceFile(fmt.Sprintf("string bool=%s\n", entryArg.BoolVal))
 				}
 			default:
-				Panic(fmt.Sprintf("Eval: LABEL %s: ill. type = %s", arg.Type))
+				EvalPanic(fmt.Sprintf("Eval: LABEL %s: ill. type = %s", arg.Type))
 			}
 		}
 
@@ -485,7 +573,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile("left eval = not ok\n")
 			}
-			Panic(fmt.Sprintf("Eval: EXPR: left eval = not ok"))
+			EvalPanic(fmt.Sprintf("Eval: EXPR: left eval = not ok"))
 		}
 		if ARGS_EVAL_TRACE.DoTrace() {
 			/**/ String2TraceFile("left eval = ok\n")
@@ -498,14 +586,14 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 		if NOT != arg.ExprVal.Op && PLUS != arg.ExprVal.Op && MINUS != arg.ExprVal.Op {
 			// eval right
 			if !arg.ExprVal.Right.Eval(vars, entry) {
-				Panic(fmt.Sprintf("right eval = not ok"))
+				EvalPanic(fmt.Sprintf("right eval = not ok"))
 			}
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile("right eval = ok\n")
 			}
 			// check type compatibility:
 			if arg.ExprVal.Left.Type != arg.ExprVal.Right.Type {
-				Panic(fmt.Sprintf("EXPR: type incompatibility: left type = %s, right type = %s; full arg info = %s", arg.ExprVal.Left.Type.String(), arg.ExprVal.Right.Type.String(), arg.ToString(0)))
+				EvalPanic(fmt.Sprintf("EXPR: type incompatibility: left type = %s, right type = %s; full arg info = %s", arg.ExprVal.Left.Type.String(), arg.ExprVal.Right.Type.String(), arg.ToString(0)))
 			}
 		}
 		// apply operator (depending on the types of both sides) and temporarily set arg's value and type:
@@ -590,14 +678,14 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 				} else if arg.ExprVal.Right.Type == BOOL {
 					arg.StringVal = fmt.Sprintf("%d%t", arg.ExprVal.Left.IntVal, arg.ExprVal.Right.BoolVal)
 				} else {
-					Panic(fmt.Sprintf("Eval: CONCAT: ill. right arg type"))
+					EvalPanic(fmt.Sprintf("Eval: CONCAT: ill. right arg type"))
 				}
 				arg.Type = STRING
 				if ARGS_EVAL_TRACE.DoTrace() {
 					/**/ String2TraceFile(fmt.Sprintf("result=%s\n", arg.StringVal))
 				}
 			default:
-				Panic(fmt.Sprintf("Eval: EXPR: ill. int Op = %s", arg.ExprVal.Op))
+				EvalPanic(fmt.Sprintf("Eval: EXPR: ill. int Op = %s", arg.ExprVal.Op))
 			}
 		case STRING:
 			switch arg.ExprVal.Op {
@@ -624,14 +712,14 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 				} else if arg.ExprVal.Right.Type == BOOL {
 					arg.StringVal = fmt.Sprintf("%s%t", arg.ExprVal.Left.StringVal, arg.ExprVal.Right.BoolVal)
 				} else {
-					Panic(fmt.Sprintf("Eval: CONCAT: ill. right arg type"))
+					EvalPanic(fmt.Sprintf("Eval: CONCAT: ill. right arg type"))
 				}
 				arg.Type = STRING
 				if ARGS_EVAL_TRACE.DoTrace() {
 					/**/ String2TraceFile(fmt.Sprintf("result=%s\n", arg.StringVal))
 				}
 			default:
-				Panic(fmt.Sprintf("Eval: EXPR: ill. string Op = %s", arg.ExprVal.Op))
+				EvalPanic(fmt.Sprintf("Eval: EXPR: ill. string Op = %s", arg.ExprVal.Op))
 			}
 		case BOOL:
 			switch arg.ExprVal.Op {
@@ -674,17 +762,17 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 				} else if arg.ExprVal.Right.Type == BOOL {
 					arg.StringVal = fmt.Sprintf("%t%t", arg.ExprVal.Left.BoolVal, arg.ExprVal.Right.BoolVal)
 				} else {
-					Panic(fmt.Sprintf("Eval: CONCAT: ill. right arg type"))
+					EvalPanic(fmt.Sprintf("Eval: CONCAT: ill. right arg type"))
 				}
 				arg.Type = STRING
 				if ARGS_EVAL_TRACE.DoTrace() {
 					/**/ String2TraceFile(fmt.Sprintf("result=%s\n", arg.StringVal))
 				}
 			default:
-				Panic(fmt.Sprintf("Eval: EXPR: ill. bool Op = %s", arg.ExprVal.Op))
+				EvalPanic(fmt.Sprintf("Eval: EXPR: ill. bool Op = %s", arg.ExprVal.Op))
 			}
 		default:
-			Panic(fmt.Sprintf("Eval: EXPR: ill. Op type = %s", arg.ExprVal.Left.Type))
+			EvalPanic(fmt.Sprintf("Eval: EXPR: ill. Op type = %s", arg.ExprVal.Left.Type))
 		}
 
 	// -------------------
@@ -697,14 +785,14 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile("Eval: DYN_ARRAY_REF: eval of arg = not ok\n")
 			}
-			Panic(fmt.Sprintf("Eval: DYN_ARRAY_REF: eval of arg = not ok"))
+			EvalPanic(fmt.Sprintf("Eval: DYN_ARRAY_REF: eval of arg = not ok"))
 		}
 		if ARGS_EVAL_TRACE.DoTrace() {
 			/**/ String2TraceFile("DYN_ARRAY_REF arg eval = ok\n")
 		}
 		// check type to be INT:
 		if arg.ExprVal.Left.Type != INT {
-			Panic(fmt.Sprintf("DYN_ARRAY_REF: index must evaluate to INT, but found type = %s", arg.ExprVal.Left.Type.String()))
+			EvalPanic(fmt.Sprintf("DYN_ARRAY_REF: index must evaluate to INT, but found type = %s", arg.ExprVal.Left.Type.String()))
 		}
 
 		// construct the resolved label: <name> # <int> which is a STRING!
@@ -725,14 +813,14 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile("Eval: TYPED_ARRAY_LABEL: eval of arg = not ok\n")
 			}
-			Panic(fmt.Sprintf("Eval: TYPED_ARRAY_LABEL: eval of arg = not ok"))
+			EvalPanic(fmt.Sprintf("Eval: TYPED_ARRAY_LABEL: eval of arg = not ok"))
 		}
 		if ARGS_EVAL_TRACE.DoTrace() {
 			/**/ String2TraceFile("TYPED_ARRAY_LABEL arg eval = ok\n")
 		}
 		// check type to be STRING:
 		if arg.ExprVal.Left.Type != STRING {
-			Panic(fmt.Sprintf("TYPED_ARRAY_LABEL: label name must evaluate to STRING, but found type = %s", arg.ExprVal.Left.Type.String()))
+			EvalPanic(fmt.Sprintf("TYPED_ARRAY_LABEL: label name must evaluate to STRING, but found type = %s", arg.ExprVal.Left.Type.String()))
 		}
 		// construct the resolved typed label:
 		arg.Kind = LABEL
@@ -747,7 +835,7 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile("Eval: *TYPED_ARRAY_LABEL*: eval = not ok\n")
 			}
-			Panic(fmt.Sprintf("Eval: *TYPED_ARRAY_LABEL*: eval of arg = not ok"))
+			EvalPanic(fmt.Sprintf("Eval: *TYPED_ARRAY_LABEL*: eval of arg = not ok"))
 		}
 
 	// -------------------
@@ -760,14 +848,14 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			if ARGS_EVAL_TRACE.DoTrace() {
 				/**/ String2TraceFile("Eval: TYPED_ARRAY_VAL: eval of arg = not ok\n")
 			}
-			Panic(fmt.Sprintf("Eval: TYPED_ARRAY_LABEL: eval of arg = not ok"))
+			EvalPanic(fmt.Sprintf("Eval: TYPED_ARRAY_LABEL: eval of arg = not ok"))
 		}
 		if ARGS_EVAL_TRACE.DoTrace() {
 			/**/ String2TraceFile("TYPED_ARRAY_VAL arg eval = ok\n")
 		}
 		// check type to be STRING:
 		if arg.ExprVal.Left.Type != STRING {
-			Panic(fmt.Sprintf("TYPED_ARRAY_VAL: label name must evaluate to STRING, but found type = %s", arg.ExprVal.Left.Type.String()))
+			EvalPanic(fmt.Sprintf("TYPED_ARRAY_VAL: label name must evaluate to STRING, but found type = %s", arg.ExprVal.Left.Type.String()))
 		}
 		// construct the resolved typed val:
 		// - Kind
@@ -784,14 +872,14 @@ func (arg *Arg) Eval(vars Vars, entry *Entry) bool {
 			arg.BoolVal = arg.ExprVal.Left.BoolVal
 			// fmt.Println(fmt.Sprintf("    array val: Type=%s; Val=%t", arg.Type.String(), arg.BoolVal))
 		} else {
-			Panic(fmt.Sprintf("Eval: TYPED_ARRAY_LABEL: ill. arg type"))
+			EvalPanic(fmt.Sprintf("Eval: TYPED_ARRAY_LABEL: ill. arg type"))
 		}
 
 		arg.Name = arg.ExprVal.Left.StringVal
 
 	// -------------------
 	default:
-		Panic(fmt.Sprintf("Eval: ill. arg Kind = %s", arg.Kind))
+		EvalPanic(fmt.Sprintf("Eval: ill. arg Kind = %s", arg.Kind))
 	}
 	return true
 }
@@ -820,6 +908,12 @@ func (arg Arg) String() string {
 		s = fmt.Sprintf("%s%s", s, strings.Replace(arg.Name, "$", "\\$", 2))
 	case EXPR:
 		s = fmt.Sprintf("%s(%s)", s, arg.ExprVal)
+	case FU:
+		fuArgs := []string{}
+		for _, fuArg := range arg.FuArgs {
+			fuArgs = append(fuArgs, fuArg.String())
+		}
+		s = fmt.Sprintf("%s%s", s, arg.FuName.CallString(fuArgs))
 	}
 	return s
 }
@@ -892,7 +986,11 @@ func (a *Arg) ToStringWithDetails(tab int, text string, detailsFlag bool) string
 		if detailsFlag {
 			s = fmt.Sprintf("%s(FU=", s)
 		}
-		s = fmt.Sprintf("%s%s", s, a.FuName)
+		fuArgs := []string{}
+		for i := range a.FuArgs {
+			fuArgs = append(fuArgs, a.FuArgs[i].ToString(0))
+		}
+		s = fmt.Sprintf("%s%s", s, a.FuName.CallString(fuArgs))
 		if detailsFlag {
 			s = fmt.Sprintf("%s)", s)
 		}
@@ -0,0 +1,142 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// static type of an arg, ie without evaluating it
+// - follows the rules of Arg.Eval:
+// -- VAL, LABEL, VAR, TYPED_ARRAY_LABEL and TYPED_ARRAY_VAL have their declared type
+//    (nb: in a model file, a label must be declared with the type of the entry property; default is INT)
+// -- DYN_ARRAY_REF is a STRING with an INT index
+// -- EXPR: both sides have the same type; the op must be defined for it:
+//    INT: arithmetic and comparisons; STRING: EQUAL, NOT_EQUAL; BOOL: AND, OR, NOT, EQUAL, NOT_EQUAL;
+//    CONCAT for all types
+// -- FU: the types of its args and its result (see SystemFunctionEnum.Signature)
+// - used when a model file is loaded (see modelFile.go)
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"fmt"
+)
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// static type of the arg; returns an error with the position in the arg, if it is ill-typed
+func (arg *Arg) StaticType() (DataTypeEnum, error) {
+	switch arg.Kind {
+	case VAL, LABEL, VAR, TYPED_ARRAY_LABEL, TYPED_ARRAY_VAL:
+		return arg.Type, nil
+	case DYN_ARRAY_REF:
+		if err := expectType(&arg.ExprVal.Left, INT, "index of "+arg.StringVal); nil != err {
+			return STRING, err
+		}
+		return STRING, nil
+	case FU:
+		if err := arg.FuName.CheckNArgs(len(arg.FuArgs)); nil != err {
+			return arg.Type, err
+		}
+		argTypes, resultType := arg.FuName.Signature(arg.Type, len(arg.FuArgs))
+		for i := range arg.FuArgs {
+			if err := expectType(&arg.FuArgs[i], argTypes[i], fmt.Sprintf("arg %d of %s", i+1, arg.FuName)); nil != err {
+				return resultType, err
+			}
+		}
+		if resultType != arg.Type {
+			return resultType, fmt.Errorf("%s: result has type %s, but %s is declared", arg.ToString(0), resultType, arg.Type)
+		}
+		return resultType, nil
+	case EXPR:
+		return arg.ExprVal.staticType(arg)
+	default:
+		return arg.Type, fmt.Errorf("ill. arg kind \"%s\"", arg.Kind)
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// static type of the expression of the arg (see Arg.Eval)
+func (expr *Expr) staticType(arg *Arg) (DataTypeEnum, error) {
+	leftType, err := expr.Left.StaticType()
+	if nil != err {
+		return leftType, err
+	}
+	// unary ops:
+	if NOT == expr.Op || PLUS == expr.Op || MINUS == expr.Op {
+		if BOOL == leftType && NOT == expr.Op {
+			return BOOL, nil
+		}
+		return leftType, fmt.Errorf("%s: op %s is not defined for %s", arg.ToString(0), OP_NAMES[expr.Op], leftType)
+	}
+	rightType, err := expr.Right.StaticType()
+	if nil != err {
+		return rightType, err
+	}
+	if leftType != rightType {
+		return leftType, fmt.Errorf("%s: type incompatibility: left type = %s, right type = %s", arg.ToString(0), leftType, rightType)
+	}
+	switch expr.Op {
+	case CONCAT:
+		return STRING, nil
+	case EQUAL, NOT_EQUAL:
+		return BOOL, nil
+	case LESS, LESS_EQUAL, GREATER, GREATER_EQUAL:
+		if INT == leftType {
+			return BOOL, nil
+		}
+	case ADD, SUB, MUL, DIV, MOD:
+		if INT == leftType {
+			return INT, nil
+		}
+	case AND, OR:
+		if BOOL == leftType {
+			return BOOL, nil
+		}
+	}
+	return leftType, fmt.Errorf("%s: op %s is not defined for %s", arg.ToString(0), OP_NAMES[expr.Op], leftType)
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// private fu:
+// the arg must have the static type t
+func expectType(arg *Arg, t DataTypeEnum, what string) error {
+	argType, err := arg.StaticType()
+	if nil != err {
+		return err
+	}
+	if t != argType {
+		return fmt.Errorf("%s must be of type %s, but %s has type %s", what, t, arg.ToString(0), argType)
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -51,7 +51,7 @@ func SendService(ps *PeerSpace, wfid string, vars Vars, scheduler *Scheduler, in
 	dest := e.GetDest()
 	// @@@ /**/ m.PrintlnS(TRACE0, TAB, "dest", dest)
 	if "" == dest {
-		Panic(fmt.Sprintf("SendService: dest property not set on entry"))
+		ModelPanic(fmt.Sprintf("SendService: dest property not set on entry"))
 	}
 	// dest is a peer name -> add PIC:
 	// @@@ normalize this computation
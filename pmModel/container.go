@@ -27,6 +27,7 @@ import (
 	. "github.com/peermodel/simulator/debug"
 	. "github.com/peermodel/simulator/scheduler"
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -36,6 +37,10 @@ type Container struct {
 	// for eventing: implicitly set to 0 (i.e.< start value of CLOCK)
 	// caution: use event time here (EVENT_CLOCK)
 	LastUpdateEventTime int
+	// which entry is selected next, and what a write does (see coordinator.go); default BAG
+	Coordinator Coordinator
+	// secondary indexes (see containerIndex); built lazily -> not copied
+	index *containerIndex
 }
 
 ////////////////////////////////////////
@@ -70,6 +75,8 @@ func (c *Container) Copy() *Container {
 	newC.Entries = c.Entries.Copy()
 	// - LastUpdateEventTime:
 	newC.LastUpdateEventTime = c.LastUpdateEventTime
+	// - Coordinator:
+	newC.Coordinator = c.Coordinator
 	//------------------------------------------------------------
 	// return
 	return newC
@@ -83,23 +90,78 @@ func (c *Container) Copy() *Container {
 */
 func (c *Container) AddEntry(e Entry) {
 	c.Entries = append(c.Entries, e)
+	c.indexAdd()
 }
 
 // ----------------------------------------
 func (c *Container) AddEntryPtr(e *Entry) {
 	c.Entries = append(c.Entries, *e)
+	c.indexAdd()
+}
+
+// ----------------------------------------
+// adds a newly written entry: it gets a new version, so that occ recognizes
+// an entry that was taken and written back meanwhile
+// - if the entry is not WRITE-locked by a tx, it is committed at once (see CommitWrites):
+//   in a KEY container it replaces the entry with the same key
+func (c *Container) WriteEntryPtr(e *Entry) {
+	e.Version = NewEntryVersion()
+	if 0 == len(e.WLocks) {
+		c.replaceKey(e)
+		c.goalProgress()
+	}
+	c.AddEntryPtr(e)
+}
+
+// ----------------------------------------
+// the entries written by the tx (ie WRITE-locked by it) are committed
+// - must be called before the WRITE-locks of the tx are removed
+// - KEY container: they replace the entries with the same key (see replaceKeys)
+// - they get a new version in the order of their writes, so that FIFO and LIFO select in the order of the commits
+func (c *Container) CommitWrites(txid string) {
+	c.replaceKeys(txid)
+	written := []*Entry{}
+	for i := range c.Entries {
+		if 0 < c.Entries[i].WLocks[txid] {
+			written = append(written, &c.Entries[i])
+			c.goalProgress()
+		}
+	}
+	sort.Slice(written, func(i, j int) bool { return written[i].Version < written[j].Version })
+	for _, e := range written {
+		e.Version = NewEntryVersion()
+	}
+}
+
+// ----------------------------------------
+// an entry was committed into the container: progress for livelock detection, if it is a goal container
+func (c *Container) goalProgress() {
+	if IsGoalContainer(c.Id) {
+		GOAL_PROGRESS_COUNT++
+	}
+}
+
+// ----------------------------------------
+// replaces all entries (eg by the remaining ones after a commit)
+func (c *Container) SetEntries(es Entries) {
+	c.Entries = es
+	c.index = nil
 }
 
 // ----------------------------------------
 /*
-	Returns index to the next entry that fulfills selector; in any order;
+	Returns index to the next entry that fulfills selector; in the order of the coordinator of the container;
 	NB: count checking is done by caller;
 	Returns -1 if not found;
 
 	if eType == WILDCARD (= "*") -> wildcard!
+	uses the index of the container if possible (see containerIndex); the result is the same
 */
-// @@@ coordinator: not yet
 func (c *Container) SelectEntryIndex(vars Vars, eType string, selector *Arg) int {
+	if i, ok := c.selectEntryIndexByIndex(vars, eType, selector); ok {
+		return i
+	}
+	next := -1
 	// iterate over all entries e in the container
 	for i, e := range c.Entries {
 		// check entry type of e
@@ -107,23 +169,49 @@ func (c *Container) SelectEntryIndex(vars Vars, eType string, selector *Arg) int
 			// either empty selector, or apply selector to entry e
 			if selector.Apply(vars, &e) {
 				// entry fulfills selector
-				return i
+				if c.Coordinator.firstIsNext() {
+					return i
+				}
+				if -1 == next || c.Coordinator.precedes(&c.Entries[i], &c.Entries[next]) {
+					next = i
+				}
 			}
 		}
 	}
-	// no suitable entry found
-	return -1
+	return next
+}
+
+// ----------------------------------------
+// index of the next entry of the type that is not locked by any tx, in the order of the coordinator; -1 if none
+// - for a take without tx (see DebugTakeEntry): entries that are read, taken or written (not yet committed)
+//   by a tx are skipped, as a wiring's take does (see PccRead)
+func (c *Container) nextUnlockedIndex(eType string) int {
+	next := -1
+	for i := range c.Entries {
+		e := &c.Entries[i]
+		if (WILDCARD != eType && e.GetType() != eType) || e.Locked() {
+			continue
+		}
+		if c.Coordinator.firstIsNext() {
+			return i
+		}
+		if -1 == next || c.Coordinator.precedes(e, &c.Entries[next]) {
+			next = i
+		}
+	}
+	return next
 }
 
 // ----------------------------------------
 /*
-	Returns pointer to the next entry that fulfills selector; in any order. Shared!!
+	Returns pointer to the next entry that fulfills selector; in the order of the coordinator. Shared!!
+	The caller may change the entry -> the index of the container is dropped (see InvalidateIndex).
 
 	if eType == WILDCARD (= "*") -> wildcard!
 */
-// @@@ coordinator: not yet
 func (c *Container) GetPtrToNextEntry(vars Vars, eType string, selector *Arg) *Entry {
 	entryIndex := c.SelectEntryIndex(vars, eType, selector)
+	c.InvalidateIndex()
 	// /**/ String2TraceFile(fmt.Sprintf("etype ", eType))
 	// /**/ selector.Print()
 	// /**/ String2TraceFile(fmt.Sprintf("  -> entryIndex = %d", entryIndex))
@@ -135,10 +223,11 @@ func (c *Container) GetPtrToNextEntry(vars Vars, eType string, selector *Arg) *E
 
 // ----------------------------------------
 // like GetPtrToNextEntry, but returns a copy of the next entry that fulfills the query
+// - nb: keeps the index of the container
 func (c *Container) SelectEntry(vars Vars, eType string, selector *Arg) *Entry {
-	e := c.GetPtrToNextEntry(vars, eType, selector)
-	if nil != e {
-		retEntry := e.Copy()
+	entryIndex := c.SelectEntryIndex(vars, eType, selector)
+	if -1 != entryIndex {
+		retEntry := c.Entries[entryIndex].Copy()
 		return retEntry
 	} else {
 		return nil
@@ -150,8 +239,11 @@ func (c *Container) SelectEntry(vars Vars, eType string, selector *Arg) *Entry {
 // returns pointer to removed entry (if exists) - otherwise nil
 // func (c *Container) RemoveEntry(eid string) error {
 func (c *Container) RemoveEntry(eid string) *Entry {
+	if e, ok := c.removeEntryByIndex(eid); ok {
+		return e
+	}
 	es, e := RemoveEntryFromEntries(c.Entries, eid)
-	c.Entries = es
+	c.SetEntries(es)
 	return e
 }
 
@@ -239,6 +331,21 @@ func ContainerPtrChangeEvent(c *Container) {
 	SPACE_UPDATE_COUNT_SINCE_LAST_CHOICE_POINT++
 }
 
+// ----------------------------------------
+// canonical description of the container contents for model checking
+// - nb: the last update event time is relevant for the wait4 conditions of the machines
+// - the order of the entries is irrelevant for BAG, otherwise it determines the next selected entry
+//   (or the entry that wins at a KEY commit) -> see OrderedFingerprint
+func (c *Container) Fingerprint() string {
+	if nil == c {
+		return "nil"
+	}
+	if BAG == c.Coordinator.Type {
+		return fmt.Sprintf("%s@%s%s", c.Id, EventTimeFingerprint(c.LastUpdateEventTime), c.Entries.Fingerprint())
+	}
+	return fmt.Sprintf("%s@%s:%s%s", c.Id, EventTimeFingerprint(c.LastUpdateEventTime), c.Coordinator, c.Entries.OrderedFingerprint())
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
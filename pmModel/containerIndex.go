@@ -0,0 +1,395 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// secondary indexes of a container
+// - by entry type: always
+// - by the value of an entry property: for the labels configured in INDEX_LABELS
+// the index only preselects candidates; the selector is still applied to each of them in the order
+// of the entries -> the result is identical to the linear scan (incl. the panics of an ill. selector):
+// - a selector "label == value" (value is a VAL or VAR) only considers the entries with that value,
+//   and those where the label is missing or of another type (the linear scan panics on them)
+// - all other selectors consider all entries of the type
+// - WILDCARD type: linear scan
+// - the coordinator of the container (see coordinator.go) decides which of the candidates that fulfill
+//   the selector is the next one
+// every entry gets a sequence number in the order of the entries; the position of an entry is its
+// sequence number minus the number of removed entries before it (counted by a fenwick tree)
+// - the index is built lazily, maintained by AddEntry(Ptr) and RemoveEntry, and dropped by SetEntries
+//   and by every change of an entry in place, eg of its eprops (see InvalidateIndex and GetPtrToNextEntry)
+// - nb: a direct assignment of the entries is recognized by their number and their backing array, and the
+//   index is rebuilt
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/helpers"
+	"fmt"
+	"sort"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type containerIndex struct {
+	// configured labels at build time
+	labels []string
+	// number of entries covered by the index
+	size int
+	// first element of the backing array of the entries covered by the index (see entriesBase)
+	base *Entry
+	// sequence numbers of the entries
+	seqs map[string]int
+	// eid of each sequence number
+	eids []string
+	// next free sequence number; must be less than the capacity of the removed counter
+	next int
+	// number of removed sequence numbers that are still contained in the lists below
+	dead int
+	// fenwick tree: removed sequence numbers
+	removed []int
+	// sequence numbers per entry type, in the order of the entries
+	byType map[string][]int
+	// property index per entry type and label
+	byProp map[string]map[string]*propIndex
+}
+
+// ----------------------------------------
+// sequence numbers of the entries of one type, in the order of the entries
+type propIndex struct {
+	// key = data type and value of the property (see propKey)
+	byVal map[string][]int
+	// all entries with the property, per data type
+	byDataType map[DataTypeEnum][]int
+	// entries without the property (or with an ill. type)
+	missing []int
+}
+
+////////////////////////////////////////
+// constructor
+////////////////////////////////////////
+
+// ----------------------------------------
+// returns nil if the entries cannot be indexed (ie an eid occurs twice)
+func newContainerIndex(es Entries) *containerIndex {
+	idx := new(containerIndex)
+	idx.labels = INDEX_LABELS
+	idx.seqs = map[string]int{}
+	idx.eids = make([]string, 0, 2*len(es)+64)
+	idx.removed = make([]int, cap(idx.eids)+1)
+	idx.byType = map[string][]int{}
+	idx.byProp = map[string]map[string]*propIndex{}
+	for i := range es {
+		if !idx.add(&es[i]) {
+			return nil
+		}
+	}
+	idx.base = entriesBase(es)
+	return idx
+}
+
+////////////////////////////////////////
+// container methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// returns the index of the container, (re)builds it if needed; nil if the entries cannot be indexed
+func (c *Container) getIndex() *containerIndex {
+	idx := c.index
+	if nil == idx || idx.size != len(c.Entries) || idx.base != entriesBase(c.Entries) || idx.dead > idx.size+64 || !sameLabels(idx.labels, INDEX_LABELS) {
+		idx = newContainerIndex(c.Entries)
+		c.index = idx
+	}
+	return idx
+}
+
+// ----------------------------------------
+// the last entry was appended to the entries
+func (c *Container) indexAdd() {
+	idx := c.index
+	if nil == idx {
+		return
+	}
+	if idx.size != len(c.Entries)-1 || idx.next == cap(idx.eids) || !idx.add(&c.Entries[len(c.Entries)-1]) {
+		// rebuild it on the next use
+		c.index = nil
+		return
+	}
+	// - nb: the append might have moved the entries
+	idx.base = entriesBase(c.Entries)
+}
+
+// ----------------------------------------
+// an entry of the container was changed in place, eg its eprops: the index is rebuilt on the next use
+// - nb: AddEntry(Ptr), RemoveEntry and SetEntries maintain the index themselves
+func (c *Container) InvalidateIndex() {
+	c.index = nil
+}
+
+// ----------------------------------------
+// position of the next entry that fulfills the selector, using the index; -1 if not found
+// - ok is false, if the index cannot be used
+func (c *Container) selectEntryIndexByIndex(vars Vars, eType string, selector *Arg) (int, bool) {
+	if WILDCARD == eType {
+		return -1, false
+	}
+	idx := c.getIndex()
+	if nil == idx {
+		return -1, false
+	}
+	next := -1
+	for _, seq := range idx.candidates(vars, eType, selector) {
+		i := idx.position(seq)
+		e := c.Entries[i]
+		if selector.Apply(vars, &e) {
+			if c.Coordinator.firstIsNext() {
+				return i, true
+			}
+			if -1 == next || c.Coordinator.precedes(&c.Entries[i], &c.Entries[next]) {
+				next = i
+			}
+		}
+	}
+	return next, true
+}
+
+// ----------------------------------------
+// removes the entry with the given eid using the index
+// - ok is false, if the index cannot be used
+func (c *Container) removeEntryByIndex(eid string) (*Entry, bool) {
+	idx := c.getIndex()
+	if nil == idx {
+		return nil, false
+	}
+	seq, found := idx.seqs[eid]
+	if !found {
+		return nil, true
+	}
+	i := idx.position(seq)
+	if c.Entries[i].Id != eid {
+		SystemError(fmt.Sprintf("container %s: index out of sync for eid=%s", c.Id, eid))
+		c.index = nil
+		return nil, false
+	}
+	e := c.Entries[i]
+	c.Entries = append(c.Entries[:i], c.Entries[i+1:]...)
+	idx.remove(seq)
+	return &e, true
+}
+
+////////////////////////////////////////
+// index methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// first element of the backing array of the entries; nil if there is none
+func entriesBase(es Entries) *Entry {
+	if 0 == cap(es) {
+		return nil
+	}
+	return &es[:1][0]
+}
+
+// ----------------------------------------
+// append entry; returns false if its eid is already contained
+func (idx *containerIndex) add(e *Entry) bool {
+	if _, found := idx.seqs[e.Id]; found {
+		return false
+	}
+	seq := idx.next
+	idx.next++
+	idx.seqs[e.Id] = seq
+	idx.eids = append(idx.eids, e.Id)
+	idx.size++
+	eType := e.GetType()
+	idx.byType[eType] = append(idx.byType[eType], seq)
+	if 0 == len(idx.labels) {
+		return true
+	}
+	props := idx.byProp[eType]
+	if nil == props {
+		props = map[string]*propIndex{}
+		idx.byProp[eType] = props
+	}
+	for _, label := range idx.labels {
+		pi := props[label]
+		if nil == pi {
+			pi = &propIndex{byVal: map[string][]int{}, byDataType: map[DataTypeEnum][]int{}}
+			props[label] = pi
+		}
+		key, ok := propKey(e.EProps[label])
+		if !ok {
+			pi.missing = append(pi.missing, seq)
+			continue
+		}
+		pi.byVal[key] = append(pi.byVal[key], seq)
+		t := e.EProps[label].Type
+		pi.byDataType[t] = append(pi.byDataType[t], seq)
+	}
+	return true
+}
+
+// ----------------------------------------
+// the entry is not removed from the lists, but skipped there (see alive)
+func (idx *containerIndex) remove(seq int) {
+	delete(idx.seqs, idx.eids[seq])
+	idx.size--
+	idx.dead++
+	for i := seq + 1; i < len(idx.removed); i += i & -i {
+		idx.removed[i]++
+	}
+}
+
+// ----------------------------------------
+func (idx *containerIndex) alive(seq int) bool {
+	seq2, found := idx.seqs[idx.eids[seq]]
+	return found && seq2 == seq
+}
+
+// ----------------------------------------
+// current position of the entry in the entries
+func (idx *containerIndex) position(seq int) int {
+	n := 0
+	for i := seq; i > 0; i -= i & -i {
+		n += idx.removed[i]
+	}
+	return seq - n
+}
+
+// ----------------------------------------
+// sequence numbers of the entries the selector must be applied to, in the order of the entries
+func (idx *containerIndex) candidates(vars Vars, eType string, selector *Arg) []int {
+	label, key, t, ok := idx.equalitySelector(vars, selector)
+	if !ok {
+		return idx.aliveOnly(idx.byType[eType])
+	}
+	pi := idx.byProp[eType][label]
+	if nil == pi {
+		return []int{}
+	}
+	seqs := append(idx.aliveOnly(pi.byVal[key]), idx.aliveOnly(pi.missing)...)
+	for t2, l := range pi.byDataType {
+		if t2 != t {
+			seqs = append(seqs, idx.aliveOnly(l)...)
+		}
+	}
+	sort.Ints(seqs)
+	return seqs
+}
+
+// ----------------------------------------
+func (idx *containerIndex) aliveOnly(l []int) []int {
+	res := []int{}
+	for _, seq := range l {
+		if idx.alive(seq) {
+			res = append(res, seq)
+		}
+	}
+	return res
+}
+
+// ----------------------------------------
+// is the selector "label == value" with an indexed label? -> label, key and data type of the value
+// - value must be a VAL or an existing VAR (no FU: it could have side effects, eg uuid)
+func (idx *containerIndex) equalitySelector(vars Vars, selector *Arg) (string, string, DataTypeEnum, bool) {
+	if nil == selector || EXPR != selector.Kind || nil == selector.ExprVal || EQUAL != selector.ExprVal.Op {
+		return "", "", 0, false
+	}
+	labelArg, valArg := selector.ExprVal.Left, selector.ExprVal.Right
+	if LABEL != labelArg.Kind {
+		labelArg, valArg = valArg, labelArg
+	}
+	if LABEL != labelArg.Kind || !containsLabel(idx.labels, labelArg.Name) {
+		return "", "", 0, false
+	}
+	switch valArg.Kind {
+	case VAL:
+	case VAR:
+		// like Eval: the value of the var with the declared type of the arg
+		v := vars[valArg.Name]
+		if "" == v.Kind {
+			return "", "", 0, false
+		}
+		v.Type = valArg.Type
+		valArg = v
+	default:
+		return "", "", 0, false
+	}
+	key, ok := propKey(valArg)
+	if !ok {
+		return "", "", 0, false
+	}
+	return labelArg.Name, key, valArg.Type, true
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// key of a basic value; false if arg is empty or not basic
+func propKey(arg Arg) (string, bool) {
+	if "" == arg.Kind {
+		return "", false
+	}
+	switch arg.Type {
+	case INT:
+		return fmt.Sprintf("i:%d", arg.IntVal), true
+	case STRING:
+		return fmt.Sprintf("s:%s", arg.StringVal), true
+	case BOOL:
+		return fmt.Sprintf("b:%t", arg.BoolVal), true
+	}
+	return "", false
+}
+
+// ----------------------------------------
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ----------------------------------------
+func sameLabels(l1 []string, l2 []string) bool {
+	if len(l1) != len(l2) {
+		return false
+	}
+	for i := range l1 {
+		if l1[i] != l2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -0,0 +1,129 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the container index: after every mutation, it selects the same entries as the linear scan
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/config"
+	"math/rand"
+	"testing"
+)
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// the next entry that fulfills the selector, without the index (see SelectEntryIndex)
+func linearSelectEntryIndex(c *Container, eType string, selector *Arg) int {
+	next := -1
+	for i := range c.Entries {
+		if c.Entries[i].GetType() == eType && selector.Apply(nil, &c.Entries[i]) {
+			if c.Coordinator.firstIsNext() {
+				return i
+			}
+			if -1 == next || c.Coordinator.precedes(&c.Entries[i], &c.Entries[next]) {
+				next = i
+			}
+		}
+	}
+	return next
+}
+
+// ----------------------------------------
+// all selections with and without the index must be equal
+func checkIndex(t *testing.T, c *Container, step int, op string) {
+	t.Helper()
+	for _, eType := range []string{"A", "B"} {
+		for k := 0; k < 4; k++ {
+			for _, selector := range []*Arg{nil, XValP(ILabel("k"), EQUAL, IVal(k)), XValP(ILabel("k"), LESS, IVal(k))} {
+				if expected, got := linearSelectEntryIndex(c, eType, selector), c.SelectEntryIndex(nil, eType, selector); expected != got {
+					t.Fatalf("step %d (%s): %s k=%d: %d expected, got %d", step, op, eType, k, expected, got)
+				}
+			}
+		}
+	}
+}
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+// random mutations of all kinds
+func TestContainerIndexConsistency(t *testing.T) {
+	prevLabels := INDEX_LABELS
+	defer func() { INDEX_LABELS = prevLabels }()
+	INDEX_LABELS = []string{"k"}
+	for _, co := range []Coordinator{{Type: BAG}, {Type: FIFO}, {Type: PRIORITY, Label: "k"}} {
+		rnd := rand.New(rand.NewSource(1))
+		c := NewContainer("P1_PIC")
+		c.Coordinator = co
+		for step := 0; step < 2000; step++ {
+			op := ""
+			switch r := rnd.Intn(10); {
+			case r < 4 || 0 == len(c.Entries):
+				op = "write"
+				writeTestEntry(c, []string{"A", "B"}[rnd.Intn(2)], step, "", map[string]int{"k": rnd.Intn(4)})
+			case r < 6:
+				op = "remove"
+				c.RemoveEntry(c.Entries[rnd.Intn(len(c.Entries))].Id)
+			case r < 7:
+				op = "change in place"
+				if e := c.GetPtrToNextEntry(nil, "A", XValP(ILabel("k"), EQUAL, IVal(rnd.Intn(4)))); nil != e {
+					e.EProps.SetIntVal("k", rnd.Intn(4))
+				}
+			case r < 8:
+				op = "assign a reversed copy"
+				es := Entries{}
+				for i := len(c.Entries) - 1; 0 <= i; i-- {
+					es = append(es, c.Entries[i])
+				}
+				c.Entries = es
+			case r < 9:
+				op = "swap in place"
+				i, j := rnd.Intn(len(c.Entries)), rnd.Intn(len(c.Entries))
+				c.Entries[i], c.Entries[j] = c.Entries[j], c.Entries[i]
+				c.InvalidateIndex()
+			default:
+				op = "set entries"
+				es := Entries{}
+				for _, e := range c.Entries {
+					if 0 != rnd.Intn(3) {
+						es = append(es, e)
+					}
+				}
+				c.SetEntries(es)
+			}
+			checkIndex(t, c, step, op)
+		}
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
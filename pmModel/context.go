@@ -136,6 +136,16 @@ func (ctx Context) MachineKeySuffix() string {
 	return fmt.Sprintf("%s__%s", ctx.Pid, ctx.Wid)
 }
 
+// ----------------------------------------
+// IErrorPosition interface implementation (see debug: ErrorContext)
+// - link no is -1 outside of a wiring
+func (ctx Context) ErrorPosition() (pid string, wid string, linkNo int) {
+	if "" == ctx.Wid {
+		return ctx.Pid, "", -1
+	}
+	return ctx.Pid, ctx.Wid, ctx.LinkNo
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
@@ -0,0 +1,224 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// coordinator of a container: which of the entries that fulfill a query is read/taken next,
+// and what a write does
+// - BAG: default; the first one in the order of the entries (nb: this order changes, as the read
+//   machines remove the selected entries and restore them at the end)
+// - FIFO: the one committed first (every write without tx and every commit gives an entry a new version,
+//   see Container.WriteEntryPtr and Container.CommitWrites)
+// - LIFO: the one committed last
+// - PRIORITY: the one with the greatest INT value of the label; equal values and entries without
+//   the label (they come last) in FIFO order; a label of another type is an eval error
+// - KEY: a map per entry type with the value of the label as key: a write replaces the entry of the
+//   same type with the same key; entries without the label are not replaced; selection as BAG
+// -- the replacement is done when the written entry becomes visible, ie by a write without tx, or by
+//    the commit of the tx that wrote it (see Container.CommitWrites); nb: a replaced entry may still be locked by
+//    another tx -> an occ tx then gets a conflict
+// - selector and count of the query are applied as before
+// - declared for the PIC and POC of a peer (see Peer.PicCoordinator and Peer.PocCoordinator), eg in the
+//   model file: "pic": {"coordinator": "priority", "label": "prio"}
+// - nb: the PIC and POC are the only modeled containers
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	"fmt"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type CoordinatorTypeEnum int
+
+const (
+	BAG CoordinatorTypeEnum = iota
+	FIFO
+	LIFO
+	PRIORITY
+	KEY
+)
+
+func (t CoordinatorTypeEnum) String() string {
+	switch t {
+	case BAG:
+		return "bag"
+	case FIFO:
+		return "fifo"
+	case LIFO:
+		return "lifo"
+	case PRIORITY:
+		return "priority"
+	case KEY:
+		return "key"
+	default:
+		return "ill. coordinator type"
+	}
+}
+
+// ----------------------------------------
+// Label: of the priority or key; empty for the other types
+type Coordinator struct {
+	Type  CoordinatorTypeEnum
+	Label string
+}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// eg "fifo" or "priority(prio)"
+func (co Coordinator) String() string {
+	if "" == co.Label {
+		return co.Type.String()
+	}
+	return fmt.Sprintf("%s(%s)", co.Type, co.Label)
+}
+
+// ----------------------------------------
+// is the label needed?
+func (co Coordinator) NeedsLabel() bool {
+	return PRIORITY == co.Type || KEY == co.Type
+}
+
+// ----------------------------------------
+// is the first entry (in the order of the entries) that fulfills the query the next one?
+// - otherwise all of them must be compared (see precedes)
+func (co Coordinator) firstIsNext() bool {
+	return BAG == co.Type || KEY == co.Type
+}
+
+// ----------------------------------------
+// is entry e1 selected before entry e2?
+func (co Coordinator) precedes(e1 *Entry, e2 *Entry) bool {
+	switch co.Type {
+	case FIFO:
+		return e1.Version < e2.Version
+	case LIFO:
+		return e1.Version > e2.Version
+	case PRIORITY:
+		prio1, ok1 := co.priority(e1)
+		prio2, ok2 := co.priority(e2)
+		if ok1 != ok2 {
+			return ok1
+		}
+		if ok1 && prio1 != prio2 {
+			return prio1 > prio2
+		}
+		return e1.Version < e2.Version
+	default:
+		return false
+	}
+}
+
+// ----------------------------------------
+// priority of the entry; false if the entry has no priority label
+func (co Coordinator) priority(e *Entry) (int, bool) {
+	arg, found := e.EProps[co.Label]
+	if !found {
+		return 0, false
+	}
+	if INT != arg.Type {
+		EvalPanic(fmt.Sprintf("priority label %s of entry %s must be of type %s, but is %s", co.Label, e.Id, INT, arg.Type))
+	}
+	return arg.IntVal, true
+}
+
+// ----------------------------------------
+// key of the entry in a KEY container; false if the entry has no key (or the container is no map)
+func (co Coordinator) key(e *Entry) (string, bool) {
+	if KEY != co.Type {
+		return "", false
+	}
+	k, ok := propKey(e.EProps[co.Label])
+	if !ok {
+		return "", false
+	}
+	return e.GetType() + SEP + k, true
+}
+
+// ----------------------------------------
+// KEY container: the entries that are WRITE-locked by the tx replace the other entries with the same key
+// - entries written (and not yet committed) by another tx are kept; they replace at their commit
+// - if the tx wrote a key twice, the last write wins
+func (c *Container) replaceKeys(txid string) {
+	if KEY != c.Coordinator.Type {
+		return
+	}
+	// last written entry per key:
+	written := map[string]*Entry{}
+	for i := range c.Entries {
+		e := &c.Entries[i]
+		if 0 == e.WLocks[txid] {
+			continue
+		}
+		if k, ok := c.Coordinator.key(e); ok {
+			if last := written[k]; nil == last || last.Version < e.Version {
+				written[k] = e
+			}
+		}
+	}
+	if 0 == len(written) {
+		return
+	}
+	tmpEs := Entries{}
+	for _, e := range c.Entries {
+		if k, ok := c.Coordinator.key(&e); ok {
+			last := written[k]
+			if nil != last && last.Id != e.Id && (0 == len(e.WLocks) || 0 < e.WLocks[txid]) {
+				continue
+			}
+		}
+		tmpEs = append(tmpEs, e)
+	}
+	c.SetEntries(tmpEs)
+}
+
+// ----------------------------------------
+// KEY container: removes the entries with the same key as e, that are not WRITE-locked
+// - for a write without tx
+func (c *Container) replaceKey(e *Entry) {
+	k, ok := c.Coordinator.key(e)
+	if !ok {
+		return
+	}
+	eids := []string{}
+	for i := range c.Entries {
+		if k2, ok2 := c.Coordinator.key(&c.Entries[i]); ok2 && k2 == k && 0 == len(c.Entries[i].WLocks) {
+			eids = append(eids, c.Entries[i].Id)
+		}
+	}
+	for _, eid := range eids {
+		c.RemoveEntry(eid)
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
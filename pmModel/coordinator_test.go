@@ -0,0 +1,189 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the coordinators of the containers
+// - the entries are numbered by their label n in the order of their writes
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// new entry of the type with the number n and the given int labels
+// - a tx (if given) WRITE-locks it, ie it is not yet committed
+func writeTestEntry(c *Container, eType string, n int, txid string, labels map[string]int) {
+	e := NewEntry(eType)
+	e.EProps.SetIntVal("n", n)
+	for label, val := range labels {
+		e.EProps.SetIntVal(label, val)
+	}
+	if "" != txid {
+		e.AddLock(WRITE, txid)
+	}
+	c.WriteEntryPtr(e)
+}
+
+// ----------------------------------------
+// the numbers of the entries of the type, in the order in which they are taken
+func takeOrder(c *Container, eType string) string {
+	ns := []string{}
+	for i := c.SelectEntryIndex(nil, eType, nil); -1 != i; i = c.SelectEntryIndex(nil, eType, nil) {
+		ns = append(ns, fmt.Sprintf("%d", c.Entries[i].EProps.GetIntVal("n")))
+		c.RemoveEntry(c.Entries[i].Id)
+	}
+	return strings.Join(ns, " ")
+}
+
+// ----------------------------------------
+// the numbers of the entries in the container, in the order of the entries
+func entryNumbers(c *Container) string {
+	ns := []string{}
+	for _, e := range c.Entries {
+		ns = append(ns, fmt.Sprintf("%s%d", e.GetType(), e.EProps.GetIntVal("n")))
+	}
+	return strings.Join(ns, " ")
+}
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+// entry 2 has no priority
+func TestCoordinatorSelectionOrder(t *testing.T) {
+	tests := []struct {
+		co       Coordinator
+		expected string
+	}{
+		{Coordinator{Type: BAG}, "0 1 2 3 4"},
+		{Coordinator{Type: FIFO}, "0 1 2 3 4"},
+		{Coordinator{Type: LIFO}, "4 3 2 1 0"},
+		{Coordinator{Type: PRIORITY, Label: "prio"}, "1 3 4 0 2"},
+	}
+	prios := []map[string]int{{"prio": 1}, {"prio": 3}, nil, {"prio": 3}, {"prio": 2}}
+	for _, test := range tests {
+		c := NewContainer("P1_PIC")
+		c.Coordinator = test.co
+		for n, prio := range prios {
+			writeTestEntry(c, "A", n, "", prio)
+		}
+		if got := takeOrder(c, "A"); test.expected != got {
+			t.Errorf("%s: %s expected, got %s", test.co, test.expected, got)
+		}
+	}
+}
+
+// ----------------------------------------
+// FIFO and LIFO select in the order of the commits, not of the writes
+func TestCoordinatorCommitOrder(t *testing.T) {
+	for _, test := range []struct {
+		co       Coordinator
+		expected string
+	}{
+		{Coordinator{Type: FIFO}, "1 0 2"},
+		{Coordinator{Type: LIFO}, "2 0 1"},
+	} {
+		c := NewContainer("P1_PIC")
+		c.Coordinator = test.co
+		writeTestEntry(c, "A", 0, "tx1", nil)
+		writeTestEntry(c, "A", 1, "", nil)
+		c.CommitWrites("tx1")
+		c.RemoveAllEntryLocks("tx1")
+		writeTestEntry(c, "A", 2, "", nil)
+		if got := takeOrder(c, "A"); test.expected != got {
+			t.Errorf("%s: %s expected, got %s", test.co, test.expected, got)
+		}
+	}
+}
+
+// ----------------------------------------
+// a write replaces the entry of the same type with the same key when it becomes visible
+func TestKeyCoordinator(t *testing.T) {
+	c := NewContainer("P1_PIC")
+	c.Coordinator = Coordinator{Type: KEY, Label: "k"}
+	writeTestEntry(c, "A", 0, "", map[string]int{"k": 1})
+	writeTestEntry(c, "A", 1, "", map[string]int{"k": 2})
+	writeTestEntry(c, "B", 2, "", map[string]int{"k": 1})
+	writeTestEntry(c, "A", 3, "", nil)
+	writeTestEntry(c, "A", 4, "", nil)
+	writeTestEntry(c, "A", 5, "", map[string]int{"k": 1})
+	if expected, got := "A1 B2 A3 A4 A5", entryNumbers(c); expected != got {
+		t.Fatalf("without tx: %s expected, got %s", expected, got)
+	}
+	// - in a tx: at its commit; the last write of the key wins
+	writeTestEntry(c, "A", 6, "tx1", map[string]int{"k": 2})
+	writeTestEntry(c, "A", 7, "tx1", map[string]int{"k": 2})
+	if expected, got := "A1 B2 A3 A4 A5 A6 A7", entryNumbers(c); expected != got {
+		t.Fatalf("before the commit: %s expected, got %s", expected, got)
+	}
+	c.CommitWrites("tx1")
+	c.RemoveAllEntryLocks("tx1")
+	if expected, got := "B2 A3 A4 A5 A7", entryNumbers(c); expected != got {
+		t.Fatalf("after the commit: %s expected, got %s", expected, got)
+	}
+}
+
+// ----------------------------------------
+// the order of the writes is part of the fingerprint of an ordered container, but not of a bag
+func TestCoordinatorFingerprint(t *testing.T) {
+	for _, test := range []struct {
+		co        Coordinator
+		equalFlag bool
+	}{
+		{Coordinator{Type: BAG}, true},
+		{Coordinator{Type: FIFO}, false},
+		{Coordinator{Type: LIFO}, false},
+	} {
+		c1, c2 := NewContainer("P1_PIC"), NewContainer("P1_PIC")
+		c1.Coordinator, c2.Coordinator = test.co, test.co
+		writeTestEntry(c1, "A", 0, "", nil)
+		writeTestEntry(c1, "A", 1, "", nil)
+		writeTestEntry(c2, "A", 1, "", nil)
+		writeTestEntry(c2, "A", 0, "", nil)
+		if fp1, fp2 := c1.Fingerprint(), c2.Fingerprint(); test.equalFlag != (fp1 == fp2) {
+			t.Errorf("%s: equal fingerprints %v expected:\n%s\n%s", test.co, test.equalFlag, fp1, fp2)
+		}
+		// - nb: the versions themselves are volatile
+		c3 := NewContainer("P1_PIC")
+		c3.Coordinator = test.co
+		writeTestEntry(c3, "A", 0, "", nil)
+		writeTestEntry(c3, "A", 1, "", nil)
+		if fp1, fp3 := c1.Fingerprint(), c3.Fingerprint(); fp1 != fp3 {
+			t.Errorf("%s: equal fingerprints expected:\n%s\n%s", test.co, fp1, fp3)
+		}
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -0,0 +1,115 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// space operations made by hand in the debugger (see framework: debugger)
+// - they behave like committed space operations of a wiring: they raise the container change event,
+//   and are logged in the event log (without wiring)
+// - an entry to be written is given like in a model file (see EntrySpec), in yaml or json, eg:
+//   {peer: P1, container: PIC, type: A, eprops: {ttl: {int: 5}}}
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/eventLog"
+	. "github.com/peermodel/simulator/scheduler"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+)
+
+////////////////////////////////////////
+// IMetaContext interface implementation:
+////////////////////////////////////////
+
+// ----------------------------------------
+// write the entry of the spec into its container; returns the entry id
+func (metaCtx MetaContext) DebugWriteEntry(spec string, scheduler *Scheduler) (string, error) {
+	eSpec, err := parseEntrySpec(spec)
+	if nil != err {
+		return "", err
+	}
+	cid, e, err := eSpec.toEntry(metaCtx.PeerSpace)
+	if nil != err {
+		return "", fmt.Errorf("entry spec: %s", err)
+	}
+	metaCtx.PeerSpace.Write(cid, e, Vars{}, scheduler)
+	return e.Id, nil
+}
+
+// ----------------------------------------
+// take the next entry of the type ("" = any) from the container; returns the entry id
+// - like a committed take of a wiring: in the order of the coordinator, and entries locked by a tx are skipped
+//   (see Container.nextUnlockedIndex)
+func (metaCtx MetaContext) DebugTakeEntry(cid string, entryType string) (string, error) {
+	c := metaCtx.PeerSpace.Containers[cid]
+	if nil == c {
+		return "", fmt.Errorf("ill. container %s", cid)
+	}
+	if "" == entryType {
+		entryType = WILDCARD
+	}
+	i := c.nextUnlockedIndex(entryType)
+	if -1 == i {
+		if WILDCARD == entryType {
+			return "", fmt.Errorf("container %s has no entry that is not locked by a tx", cid)
+		}
+		return "", fmt.Errorf("no entry of type %s in container %s that is not locked by a tx", entryType, cid)
+	}
+	eid := c.Entries[i].Id
+	c.RemoveEntry(eid)
+	ContainerPtrChangeEvent(c)
+	if EventLogIsOn() {
+		LogEvent(EventRecord{Type: LOG_TAKE, Cid: cid, Eids: []string{eid}})
+	}
+	return eid, nil
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// private fu:
+// entry spec given in yaml or json
+func parseEntrySpec(spec string) (*EntrySpec, error) {
+	// convert yaml to json (see LoadModelFile)
+	var raw interface{}
+	if err := yaml.Unmarshal([]byte(spec), &raw); nil != err {
+		return nil, fmt.Errorf("entry spec: %s", err)
+	}
+	data, err := json.Marshal(raw)
+	if nil != err {
+		return nil, fmt.Errorf("entry spec: %s", err)
+	}
+	eSpec := &EntrySpec{}
+	if err := json.Unmarshal(data, eSpec); nil != err {
+		return nil, fmt.Errorf("entry spec: %s", err)
+	}
+	return eSpec, nil
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
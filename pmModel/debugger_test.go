@@ -0,0 +1,92 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the space operations of the debugger
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+// a take skips the entries that are locked by a tx, in the order of the coordinator
+func TestDebugTakeEntrySkipsLockedEntries(t *testing.T) {
+	metaCtx := NewMetaContext()
+	c := NewContainer("P1_PIC")
+	c.Coordinator = Coordinator{Type: LIFO}
+	metaCtx.PeerSpace.Containers[c.Id] = c
+	writeTestEntry(c, "A", 0, "", nil)
+	writeTestEntry(c, "B", 1, "", nil)
+	writeTestEntry(c, "A", 2, "", nil)
+	// - read by a tx, and written, but not yet committed, by a tx
+	c.Entries[2].AddLock(READ, "tx1")
+	writeTestEntry(c, "A", 3, "tx2", nil)
+	tests := []struct {
+		entryType string
+		expected  string
+		err       string
+	}{
+		{"A", "A0", ""},
+		{"A", "", "no entry of type A in container P1_PIC that is not locked by a tx"},
+		{"", "B1", ""},
+		{"", "", "container P1_PIC has no entry that is not locked by a tx"},
+	}
+	for i, test := range tests {
+		numbers := map[string]string{}
+		for _, e := range c.Entries {
+			numbers[e.Id] = fmt.Sprintf("%s%d", e.GetType(), e.EProps.GetIntVal("n"))
+		}
+		eid, err := metaCtx.DebugTakeEntry(c.Id, test.entryType)
+		if "" != test.err {
+			if nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("take %d: error %q expected, got %v", i, test.err, err)
+			}
+			continue
+		}
+		if nil != err {
+			t.Fatalf("take %d: %s", i, err)
+		}
+		if test.expected != numbers[eid] {
+			t.Fatalf("take %d: %s expected, got %s", i, test.expected, numbers[eid])
+		}
+	}
+	if expected, got := "A2 A3", entryNumbers(c); expected != got {
+		t.Fatalf("the locked entries %s expected, got %s", expected, got)
+	}
+	if _, err := metaCtx.DebugTakeEntry("P9_PIC", "A"); nil == err {
+		t.Fatalf("error of the container expected")
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
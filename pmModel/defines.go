@@ -28,6 +28,9 @@ import (
 
 var UUID int
 
+// last entry version (see NewEntryVersion):
+var ENTRY_VERSION int
+
 // specialized peers and containers:
 const IOP_PEER string = "IOP"
 const IOP_PIC string = "IOP_PIC"
@@ -182,6 +185,7 @@ const (
 	WIRING_TTL_EXCEPTION
 	SYSTEM_STOP
 	WIRING_STOP
+	TX_CONFLICT_EXCEPTION
 )
 
 // try to keep names ca. same size (<= 13) -> is padded with that number
@@ -197,6 +201,8 @@ func (t ExceptionTypeEnum) String() string {
 		return "WIRING-TTL"
 	case WIRING_STOP:
 		return "WIRING-STOP"
+	case TX_CONFLICT_EXCEPTION:
+		return "TX-CONFLICT"
 	default:
 		return "ill. exception type"
 	}
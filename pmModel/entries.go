@@ -24,6 +24,9 @@ package pmModel
 
 import (
 	. "github.com/peermodel/simulator/debug"
+	"fmt"
+	"sort"
+	"strings"
 )
 
 // @@@ should better be a map! with key = eid
@@ -51,6 +54,33 @@ func (es Entries) Copy() Entries {
 	return newEs
 }
 
+// ----------------------------------------
+// canonical description for model checking: order independent
+func (es Entries) Fingerprint() string {
+	fps := make([]string, len(es))
+	for i := range es {
+		fps[i] = es[i].Fingerprint()
+	}
+	sort.Strings(fps)
+	return fmt.Sprintf("{%s}", strings.Join(fps, ", "))
+}
+
+// ----------------------------------------
+// canonical description for model checking: in the order of the versions
+// - for a coordinator that selects by the order of the writes (see Coordinator); the versions themselves are volatile
+func (es Entries) OrderedFingerprint() string {
+	sorted := make([]*Entry, len(es))
+	for i := range es {
+		sorted[i] = &es[i]
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	fps := make([]string, len(sorted))
+	for i, e := range sorted {
+		fps[i] = e.Fingerprint()
+	}
+	return fmt.Sprintf("[%s]", strings.Join(fps, ", "))
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
@@ -44,6 +44,8 @@ type Entry struct {
 	Data EntryPtrs
 	// locks:
 	Locks
+	// version: new with every write into a container (see occ)
+	Version int
 }
 
 ////////////////////////////////////////
@@ -200,11 +202,43 @@ func (e Entry) Copy() *Entry {
 	}
 	// - Locks:
 	newE.Locks = e.Locks.Copy()
+	// - Version:
+	newE.Version = e.Version
 	//------------------------------------------------------------
 	// return
 	return newE
 }
 
+// ----------------------------------------
+// canonical description for model checking (see Status.StateFingerprint):
+// - without the volatile id (uuid) and without the tx ids of the locks (only lock counts)
+// - data entries are sorted
+// - relative times (see TimeFingerprint): tts and ttl are written relative to the CLOCK
+func (e *Entry) Fingerprint() string {
+	if nil == e {
+		return "nil"
+	}
+	eprops := e.EProps
+	times := ""
+	if RELATIVE_TIME_FINGERPRINT_FLAG {
+		eprops = EProps{}
+		for label, arg := range e.EProps {
+			if TTS != label && TTL != label {
+				eprops[label] = arg
+			}
+		}
+		times = fmt.Sprintf(", tts@%s, ttl@%s", TimeFingerprint(e.GetTts()), TimeFingerprint(e.GetTtl()))
+	}
+	s := fmt.Sprintf("<%s%s", eprops.ToStringWithDetails(0, "", false /* detailsFlag */, true /* printTypeFlag */, false /* omitDefaultsFlag */), times)
+	if 0 < len(e.Data) {
+		s = fmt.Sprintf("%s, Data=%s", s, e.Data.Fingerprint())
+	}
+	if nR, nW, nD := e.Locks.Counts(); 0 < nR+nW+nD {
+		s = fmt.Sprintf("%s, Locks=%d/%d/%d", s, nR, nW, nD)
+	}
+	return fmt.Sprintf("%s>", s)
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
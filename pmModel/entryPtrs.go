@@ -25,6 +25,8 @@ package pmModel
 import (
 	. "github.com/peermodel/simulator/debug"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // @@@ should better be a map! with key = eid
@@ -121,7 +123,7 @@ func (es EntryPtrs) EvalAndApply(vars Vars, eprops EProps) {
 						/**/ PRINT_ARG_TYPE_FLAG = true
 						/**/ eprops.Println(TAB)
 					}
-					Panic(fmt.Sprintf("can't eval eprop with label=%s", label))
+					EvalPanic(fmt.Sprintf("can't eval eprop with label=%s", label))
 				}
 
 				switch label {
@@ -155,7 +157,7 @@ func (es EntryPtrs) EvalAndApply(vars Vars, eprops EProps) {
 						}
 						tmpE.SetBoolVal(label, eprop.BoolVal)
 					default:
-						Panic(fmt.Sprintf("ill. eprop type = %s", eprop.Type))
+						EvalPanic(fmt.Sprintf("ill. eprop type = %s", eprop.Type))
 					}
 				}
 			}
@@ -163,6 +165,22 @@ func (es EntryPtrs) EvalAndApply(vars Vars, eprops EProps) {
 	}
 }
 
+// ----------------------------------------
+// canonical description for model checking: order independent
+// - nb: nil and empty are the same
+func (es EntryPtrs) Fingerprint() string {
+	fps := make([]string, 0, len(es))
+	for _, e := range es {
+		if nil == e {
+			fps = append(fps, "nil")
+		} else {
+			fps = append(fps, e.Fingerprint())
+		}
+	}
+	sort.Strings(fps)
+	return fmt.Sprintf("{%s}", strings.Join(fps, ", "))
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
@@ -0,0 +1,139 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// records of the peer model for the structured event log (see eventLog)
+// - space operations of links (logged by the automata, see pmAutomata: RegisterEventLogHooks):
+// -- READ, TEST -> read; TAKE, DELETE -> take; writes into C2 -> write
+// - writes of services and of entry exceptions -> write; output of services into SOUT -> emit
+// - tx create, commit and rollback
+// - exceptions: expired entries (the wrapped exception entry is logged as write)
+// - processed pm slots
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/eventLog"
+	"fmt"
+)
+
+////////////////////////////////////////
+// consts
+////////////////////////////////////////
+
+// ----------------------------------------
+// msg of the exception record of an expired entry
+const ENTRY_EXPIRED_MSG string = "entry ttl expired"
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// IEventLogIds interface implementation
+func (ctx Context) EventLogIds() (string, string, string) {
+	return ctx.Pid, ctx.Wid, ctx.Wiid
+}
+
+// ----------------------------------------
+// ids of the entries
+func (es EntryPtrs) Eids() []string {
+	eids := []string{}
+	for _, e := range es {
+		if nil != e {
+			eids = append(eids, e.Id)
+		}
+	}
+	return eids
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// log a space operation of a link: op of the link, or WRITE
+// - ops without space access (CALL, CREATE, NOOP) are not logged
+func LogSpaceOp(op SpaceOpTypeEnum, ctx *Context, cid string, es EntryPtrs) {
+	var t EventRecordTypeEnum
+	switch op {
+	case READ, TEST:
+		t = LOG_READ
+	case TAKE, DELETE:
+		t = LOG_TAKE
+	case WRITE:
+		t = LOG_WRITE
+	default:
+		return
+	}
+	LogEvent(EventRecord{Type: t, Pid: ctx.Pid, Wid: ctx.Wid, Wiid: ctx.Wiid, Cid: cid, Eids: es.Eids(), Txid: ctx.Wtxid})
+}
+
+// ----------------------------------------
+// log create, commit or rollback of a tx
+func LogTx(t EventRecordTypeEnum, ctx *Context, txid string) {
+	LogEvent(EventRecord{Type: t, Pid: ctx.Pid, Wid: ctx.Wid, Wiid: ctx.Wiid, Txid: txid})
+}
+
+// ----------------------------------------
+// log an exception of a wiring
+func LogWiringException(ctx *Context, exc ExceptionTypeEnum, msg string) {
+	LogEvent(EventRecord{Type: LOG_EXCEPTION, Pid: ctx.Pid, Wid: ctx.Wid, Wiid: ctx.Wiid, Msg: fmt.Sprintf("%s: %s", exc, msg)})
+}
+
+// ----------------------------------------
+// private fu:
+// log a committed write or emit of one entry
+func logWrite(t EventRecordTypeEnum, cid string, e *Entry) {
+	if EventLogIsOn() {
+		LogEvent(EventRecord{Type: t, Cid: cid, Eids: []string{e.Id}})
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// log the exception for an expired entry
+func logEntryException(cid string, eid string) {
+	if EventLogIsOn() {
+		LogEvent(EventRecord{Type: LOG_EXCEPTION, Cid: cid, Eids: []string{eid}, Msg: ENTRY_EXPIRED_MSG})
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// log a processed pm slot
+func logPMSlot(slot *PMSlot) {
+	if !EventLogIsOn() {
+		return
+	}
+	rec := EventRecord{Type: LOG_SLOT, Pid: slot.Pid, Wid: slot.Wid, Wiid: slot.Wiid, Msg: slot.Type.String()}
+	if "" != slot.Eid {
+		rec.Eids = []string{slot.Eid}
+	}
+	LogEvent(rec)
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
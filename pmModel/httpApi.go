@@ -0,0 +1,95 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// peer model views and operations of the http api (see framework: httpApi)
+// - containers and transactions are given in json like in the snapshot (see snapshot)
+// - an injected entry is given like in a model file (see EntrySpec), without peer and container, eg:
+//   {type: A, eprops: {ttl: {int: 5}}}
+// -- it is written by PeerSpace.Write, ie like by a wiring: the container change event is raised, its tts and ttl
+//    are scheduled, and it is logged in the event log
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/scheduler"
+	"encoding/json"
+	"fmt"
+)
+
+////////////////////////////////////////
+// IMetaContext interface implementation:
+////////////////////////////////////////
+
+// ----------------------------------------
+// all containers in the order of their creation
+func (metaCtx MetaContext) ContainersJson() ([]byte, error) {
+	ps := metaCtx.PeerSpace
+	containers := []*Container{}
+	for _, cid := range ps.ContainerCids {
+		if c := ps.Containers[cid]; nil != c {
+			containers = append(containers, c)
+		}
+	}
+	return json.Marshal(containers)
+}
+
+// ----------------------------------------
+// the running transactions, by tx id
+func (metaCtx MetaContext) RunningTransactionsJson() ([]byte, error) {
+	txs := map[string]*Tx{}
+	for tid, tx := range metaCtx.Transactions {
+		if RUNNING == tx.State {
+			txs[tid] = tx
+		}
+	}
+	return json.Marshal(txs)
+}
+
+// ----------------------------------------
+// write the entry of the spec into the PIC of the peer; returns the entry id
+func (metaCtx MetaContext) InjectEntry(pid string, spec string, scheduler *Scheduler) (string, error) {
+	if nil == metaCtx.PeerSpace.Peers[pid] {
+		return "", fmt.Errorf("ill. peer %s", pid)
+	}
+	eSpec, err := parseEntrySpec(spec)
+	if nil != err {
+		return "", err
+	}
+	if ("" != eSpec.Peer && pid != eSpec.Peer) || ("" != eSpec.Container && PIC != eSpec.Container) {
+		return "", fmt.Errorf("entry spec: only the PIC of peer %s can be written", pid)
+	}
+	eSpec.Peer = pid
+	eSpec.Container = PIC
+	cid, e, err := eSpec.toEntry(metaCtx.PeerSpace)
+	if nil != err {
+		return "", fmt.Errorf("entry spec: %s", err)
+	}
+	metaCtx.PeerSpace.Write(cid, e, Vars{}, scheduler)
+	return e.Id, nil
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
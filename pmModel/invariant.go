@@ -0,0 +1,231 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// invariants over the peer space
+// - checked by the controller after every critical section (see MetaContext.CheckInvariants)
+// - the query selects entries by type and selector; its min and max give the allowed range
+//   (default: 0..ALL; count k means exactly k)
+// -- ENTRY_COUNT: number of entries in a container (WILDCARD = all PICs and POCs, summed up)
+// -- PEER_COUNT: number of peers that hold an entry in their PIC or POC
+// -- FU: go callback
+// - nb: entries written by a not yet committed tx are not counted
+// - examples:
+// -- "P1_PIC never holds more than 3 tokens":
+//    NewEntryCountInvariant("maxTokens", "P1_PIC", Query{Typ: SEtype("token"), Min: IVal(0), Max: IVal(3)})
+// -- "at most one peer holds a lock":
+//    NewPeerCountInvariant("mutex", Query{Typ: SEtype("lock"), Min: IVal(0), Max: IVal(1)})
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	"fmt"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type InvariantTypeEnum int
+
+// ----------------------------------------
+const (
+	ENTRY_COUNT_INVARIANT InvariantTypeEnum = iota
+	PEER_COUNT_INVARIANT
+	FU_INVARIANT
+)
+
+// ----------------------------------------
+// go callback: returns whether the invariant holds and a description of the violation
+type InvariantFuType func(ps *PeerSpace) (bool, string)
+
+// ----------------------------------------
+type Invariant struct {
+	Name string
+	Type InvariantTypeEnum
+	// container for ENTRY_COUNT; WILDCARD = all PICs and POCs
+	Cid string
+	// entry type, selector and allowed range (min, max); count is converted into min and max
+	Q Query
+	// callback for FU
+	Fu InvariantFuType
+}
+
+// ----------------------------------------
+type Invariants []*Invariant
+
+////////////////////////////////////////
+// constructors
+////////////////////////////////////////
+
+// ----------------------------------------
+func NewEntryCountInvariant(name string, cid string, q Query) *Invariant {
+	inv := new(Invariant)
+	inv.Name = name
+	inv.Type = ENTRY_COUNT_INVARIANT
+	inv.Cid = cid
+	inv.Q = convertInvariantQuery(q)
+	return inv
+}
+
+// ----------------------------------------
+func NewPeerCountInvariant(name string, q Query) *Invariant {
+	inv := new(Invariant)
+	inv.Name = name
+	inv.Type = PEER_COUNT_INVARIANT
+	inv.Q = convertInvariantQuery(q)
+	return inv
+}
+
+// ----------------------------------------
+func NewFuInvariant(name string, fu InvariantFuType) *Invariant {
+	inv := new(Invariant)
+	inv.Name = name
+	inv.Type = FU_INVARIANT
+	inv.Fu = fu
+	return inv
+}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// does the invariant hold in the peer space?
+// - returns also a description of the violation
+func (inv *Invariant) Check(ps *PeerSpace) (bool, string) {
+	switch inv.Type {
+	case ENTRY_COUNT_INVARIANT:
+		return inv.inRange(countEntriesInCid(ps, inv.Cid, &inv.Q), fmt.Sprintf("entries in %s", inv.Cid))
+	case PEER_COUNT_INVARIANT:
+		n := 0
+		for _, pid := range ps.PeerPids {
+			p := ps.Peers[pid]
+			if nil == p {
+				continue
+			}
+			if 0 < countEntries(ps.Containers[p.Pic], &inv.Q)+countEntries(ps.Containers[p.Poc], &inv.Q) {
+				n++
+			}
+		}
+		return inv.inRange(n, "peers")
+	case FU_INVARIANT:
+		if nil == inv.Fu {
+			ModelPanic(fmt.Sprintf("invariant %s: callback is missing", inv.Name))
+		}
+		return inv.Fu(ps)
+	default:
+		Panic(fmt.Sprintf("invariant %s: ill. invariant type", inv.Name))
+		return false, ""
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// is n within min and max of the query?
+func (inv *Invariant) inRange(n int, what string) (bool, string) {
+	min := inv.Q.GetMin(Vars{})
+	max := inv.Q.GetMax(Vars{})
+	if n < min || (ALL != max && n > max) {
+		maxS := "ALL"
+		if ALL != max {
+			maxS = fmt.Sprintf("%d", max)
+		}
+		return false, fmt.Sprintf("%d %s of type %s, allowed: %d..%s", n, what, inv.Q.GetTyp(Vars{}), min, maxS)
+	}
+	return true, ""
+}
+
+// ----------------------------------------
+func (t InvariantTypeEnum) String() string {
+	switch t {
+	case ENTRY_COUNT_INVARIANT:
+		return "entry_count"
+	case PEER_COUNT_INVARIANT:
+		return "peer_count"
+	case FU_INVARIANT:
+		return "fu"
+	default:
+		return "ill. invariant type"
+	}
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// private fu:
+// number of committed entries in the container that fulfill type and selector of the query
+// - nb: also used by observables
+func countEntries(c *Container, q *Query) int {
+	if nil == c {
+		return 0
+	}
+	eType := q.GetTyp(Vars{})
+	n := 0
+	for i := range c.Entries {
+		e := &c.Entries[i]
+		if _, nWriteLocks, _ := e.Locks.Counts(); 0 < nWriteLocks {
+			continue
+		}
+		if (WILDCARD == eType || e.GetType() == eType) && q.Sel.Apply(Vars{}, e) {
+			n++
+		}
+	}
+	return n
+}
+
+// ----------------------------------------
+// private fu:
+// countEntries summed up over the container; WILDCARD = all PICs and POCs
+func countEntriesInCid(ps *PeerSpace, cid string, q *Query) int {
+	n := 0
+	for _, cid2 := range ps.ContainerCids {
+		if (WILDCARD == cid && CidIsPicOrPoc(cid2)) || cid2 == cid {
+			n += countEntries(ps.Containers[cid2], q)
+		}
+	}
+	return n
+}
+
+// ----------------------------------------
+// private fu:
+// count is converted into min and max; missing min = 0, missing max = ALL
+func convertInvariantQuery(q Query) Query {
+	q1 := convertQueryCountToMinMax(q)
+	if q1.Min.IsEmpty() {
+		q1.Min = IVal(0)
+	}
+	if q1.Max.IsEmpty() {
+		q1.Max = IVal(ALL)
+	}
+	return q1
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
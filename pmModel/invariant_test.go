@@ -0,0 +1,207 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the invariants over the peer space and of their model file specs
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// peer space with the peers P1 and P2 and their empty PICs and POCs
+func newInvariantTestSpace() *PeerSpace {
+	ps := NewPeerSpace()
+	for _, pid := range []string{"P1", "P2"} {
+		p := NewPeer(pid)
+		ps.AddPeer(p)
+		ps.AddContainer(NewContainer(p.Pic))
+		ps.AddContainer(NewContainer(p.Poc))
+	}
+	return ps
+}
+
+// ----------------------------------------
+// "" ... the invariant holds
+func invariantViolation(ps *PeerSpace, inv *Invariant) string {
+	ok, msg := inv.Check(ps)
+	if ok != ("" == msg) {
+		return "ill. result: " + msg
+	}
+	return msg
+}
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+func TestEntryCountInvariant(t *testing.T) {
+	ps := newInvariantTestSpace()
+	pic := ps.Containers["P1_PIC"]
+	writeTestEntry(pic, "token", 1, "", nil)
+	writeTestEntry(pic, "token", 2, "", nil)
+	writeTestEntry(pic, "token", 3, "", nil)
+	writeTestEntry(pic, "other", 4, "", nil)
+	writeTestEntry(ps.Containers["P2_POC"], "token", 5, "", nil)
+	tests := []struct {
+		name     string
+		inv      *Invariant
+		expected string
+	}{
+		{"max reached", NewEntryCountInvariant("i", "P1_PIC", Query{Typ: SEtype("token"), Min: IVal(0), Max: IVal(3)}), ""},
+		{"max exceeded", NewEntryCountInvariant("i", "P1_PIC", Query{Typ: SEtype("token"), Max: IVal(2)}),
+			"3 entries in P1_PIC of type token, allowed: 0..2"},
+		{"min not reached", NewEntryCountInvariant("i", "P1_POC", Query{Typ: SEtype("token"), Min: IVal(1)}),
+			"0 entries in P1_POC of type token, allowed: 1..ALL"},
+		{"count", NewEntryCountInvariant("i", "P1_PIC", Query{Typ: SEtype("token"), Count: IVal(2)}),
+			"3 entries in P1_PIC of type token, allowed: 2..2"},
+		{"selector", NewEntryCountInvariant("i", "P1_PIC", Query{Typ: SEtype("token"), Max: IVal(1), Sel: XValP(ILabel("n"), GREATER, IVal(2))}), ""},
+		{"all PICs and POCs", NewEntryCountInvariant("i", WILDCARD, Query{Typ: SEtype("token"), Max: IVal(3)}),
+			"4 entries in * of type token, allowed: 0..3"},
+		{"any type", NewEntryCountInvariant("i", WILDCARD, Query{Typ: SEtype(WILDCARD), Count: IVal(5)}), ""},
+	}
+	for _, test := range tests {
+		if got := invariantViolation(ps, test.inv); test.expected != got {
+			t.Errorf("%s: %q expected, got %q", test.name, test.expected, got)
+		}
+	}
+	// - an entry written by a not yet committed tx is not counted
+	writeTestEntry(pic, "token", 6, "tx1", nil)
+	if got := invariantViolation(ps, NewEntryCountInvariant("i", "P1_PIC", Query{Typ: SEtype("token"), Max: IVal(3)})); "" != got {
+		t.Errorf("uncommitted entry: no violation expected, got %q", got)
+	}
+}
+
+// ----------------------------------------
+// "at most one peer holds a lock"
+func TestPeerCountInvariant(t *testing.T) {
+	ps := newInvariantTestSpace()
+	mutex := NewPeerCountInvariant("mutex", Query{Typ: SEtype("lock"), Max: IVal(1)})
+	writeTestEntry(ps.Containers["P1_PIC"], "lock", 1, "", nil)
+	writeTestEntry(ps.Containers["P1_POC"], "lock", 2, "", nil)
+	if got := invariantViolation(ps, mutex); "" != got {
+		t.Fatalf("one peer with 2 locks: no violation expected, got %q", got)
+	}
+	writeTestEntry(ps.Containers["P2_POC"], "lock", 3, "", nil)
+	if expected, got := "2 peers of type lock, allowed: 0..1", invariantViolation(ps, mutex); expected != got {
+		t.Fatalf("%q expected, got %q", expected, got)
+	}
+}
+
+// ----------------------------------------
+// the first violated invariant is reported, with its name
+func TestCheckInvariants(t *testing.T) {
+	ps := newInvariantTestSpace()
+	if err := ps.CheckInvariants(); nil != err {
+		t.Fatalf("no invariants: nil expected, got %s", err)
+	}
+	calls := 0
+	ps.AddInvariant(NewFuInvariant("fu", func(ps *PeerSpace) (bool, string) {
+		calls++
+		n := len(ps.Containers["P2_PIC"].Entries)
+		return 0 == n, "P2_PIC is not empty"
+	}))
+	ps.AddInvariant(NewEntryCountInvariant("maxTokens", "P1_PIC", Query{Typ: SEtype("token"), Max: IVal(1)}))
+	if err := ps.CheckInvariants(); nil != err || 1 != calls {
+		t.Fatalf("no violation and 1 call expected, got %v, %d calls", err, calls)
+	}
+	writeTestEntry(ps.Containers["P1_PIC"], "token", 1, "", nil)
+	writeTestEntry(ps.Containers["P1_PIC"], "token", 2, "", nil)
+	expected := "invariant maxTokens violated: 2 entries in P1_PIC of type token, allowed: 0..1"
+	if err := ps.CheckInvariants(); nil == err || expected != err.Error() {
+		t.Fatalf("%q expected, got %v", expected, err)
+	}
+	writeTestEntry(ps.Containers["P2_PIC"], "x", 3, "", nil)
+	expected = "invariant fu violated: P2_PIC is not empty"
+	if err := ps.CheckInvariants(); nil == err || expected != err.Error() {
+		t.Fatalf("%q expected, got %v", expected, err)
+	}
+	// - the copies share the invariants
+	if ps2 := ps.Copy(); 2 != len(ps2.Invariants) || ps.Invariants[0] != ps2.Invariants[0] {
+		t.Fatalf("shared invariants expected")
+	}
+}
+
+// ----------------------------------------
+// the invariants of the model file are added to the peer space and saved again, except the go callbacks
+func TestModelFileInvariants(t *testing.T) {
+	invariants := `invariants:
+  - {name: maxTokens, type: entry_count, c: P1_PIC, query: {typ: {subtype: ENTRY_TYPE, string: token}, max: 3}}
+  - {name: mutex, type: peer_count, query: {typ: {subtype: ENTRY_TYPE, string: lock}, count: 1}}
+`
+	metaCtx, err := loadTestModel(t, "m.yaml", TEST_MODEL_YAML+invariants).NewMetaContext()
+	if nil != err {
+		t.Fatal(err)
+	}
+	ps := metaCtx.PeerSpace
+	if 2 != len(ps.Invariants) {
+		t.Fatalf("2 invariants expected, got %d", len(ps.Invariants))
+	}
+	if inv := ps.Invariants[0]; "maxTokens" != inv.Name || ENTRY_COUNT_INVARIANT != inv.Type || "P1_PIC" != inv.Cid ||
+		0 != inv.Q.GetMin(Vars{}) || 3 != inv.Q.GetMax(Vars{}) {
+		t.Errorf("maxTokens: entry_count of P1_PIC with range 0..3 expected, got %s %s %d..%d", inv.Type, inv.Cid, inv.Q.GetMin(Vars{}), inv.Q.GetMax(Vars{}))
+	}
+	if inv := ps.Invariants[1]; "mutex" != inv.Name || PEER_COUNT_INVARIANT != inv.Type || 1 != inv.Q.GetMin(Vars{}) || 1 != inv.Q.GetMax(Vars{}) {
+		t.Errorf("mutex: peer_count with range 1..1 expected, got %s %d..%d", inv.Type, inv.Q.GetMin(Vars{}), inv.Q.GetMax(Vars{}))
+	}
+	ps.AddInvariant(NewFuInvariant("fu", func(ps *PeerSpace) (bool, string) { return true, "" }))
+	mf := ps.ToModelFile("test")
+	if 2 != len(mf.Invariants) || "maxTokens" != mf.Invariants[0].Name || "peer_count" != mf.Invariants[1].Type {
+		t.Fatalf("the 2 invariants of the model file expected, got %v", mf.Invariants)
+	}
+}
+
+// ----------------------------------------
+func TestModelFileInvariantErrors(t *testing.T) {
+	query := "query: {typ: {subtype: ENTRY_TYPE, string: token}, max: 3}"
+	tests := []struct {
+		invariants string
+		expected   string
+	}{
+		{"  - {type: entry_count, c: P1_PIC, " + query + "}", "invariant 0: name is missing"},
+		{"  - {name: i, type: entry_count, c: P1_PIC, " + query + "}\n  - {name: i, type: peer_count, " + query + "}",
+			"invariant i: defined twice"},
+		{"  - {name: i, type: entry_count, c: P1_PIC}", "invariant i: query is missing"},
+		{"  - {name: i, type: entry_count, " + query + "}", "invariant i: container is missing"},
+		{"  - {name: i, type: sum, " + query + "}", "invariant i: ill. type \"sum\" (use entry_count or peer_count)"},
+	}
+	for _, test := range tests {
+		_, err := loadTestModel(t, "m.yaml", TEST_MODEL_YAML+"invariants:\n"+test.invariants+"\n").NewMetaContext()
+		if nil == err || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%q expected, got %v", test.expected, err)
+		}
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
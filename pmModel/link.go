@@ -378,7 +378,7 @@ func (l *Link) ResolveLinkArgs(vars Vars, es EntryPtrs) Vars {
 	}
 	for label, v := range l.LVars {
 		if !v.Eval(vars, e) {
-			Panic(fmt.Sprintf("ResolveLinkArgs: ill. var specification for link; var name = %s", label))
+			ModelPanic(fmt.Sprintf("ResolveLinkArgs: ill. var specification for link; var name = %s", label))
 		}
 		a := Arg{Kind: VAL, Type: v.Type, IntVal: v.IntVal, StringVal: v.StringVal, BoolVal: v.BoolVal}
 		vars[label] = a
@@ -420,6 +420,13 @@ func convertQueryCountToMinMax(q Query) Query {
 	return q1
 }
 
+// --------------------------------------------
+// model checking: an alias into the meta model in the LVS of a machine is recomputed from
+// - its context at choice point recovery -> not part of the state
+func (l *Link) Fingerprint() string {
+	return "-"
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
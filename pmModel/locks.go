@@ -230,6 +230,23 @@ func (locks *Locks) IsEmpty() bool {
 	}
 }
 
+// ----------------------------------------
+// number of read, write and delete locks
+// - nb: the tx ids are volatile -> used for the model checking fingerprint of an entry
+func (locks Locks) Counts() (int, int, int) {
+	nR, nW, nD := 0, 0, 0
+	for _, i := range locks.RLocks {
+		nR += i
+	}
+	for _, i := range locks.WLocks {
+		nW += i
+	}
+	for _, i := range locks.DLocks {
+		nD += i
+	}
+	return nR, nW, nD
+}
+
 ////////////////////////////////////////
 // debug
 ////////////////////////////////////////
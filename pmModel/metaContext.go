@@ -27,6 +27,9 @@ import (
 	. "github.com/peermodel/simulator/latex"
 	. "github.com/peermodel/simulator/scheduler"
 	. "github.com/peermodel/simulator/slotInterface"
+	"fmt"
+	"sort"
+	"strings"
 )
 
 type MetaContext struct {
@@ -65,6 +68,7 @@ func NewMetaContext() *MetaContext {
 
 //------------------------------------------------------------
 // deep copy
+// - nb: the invariants of the peer space are shared
 func (metaCtx MetaContext) Copy() interface{} {
 	//------------------------------------------------------------
 	// alloc new context:
@@ -110,6 +114,114 @@ func (metaCtx MetaContext) ProcessRipeUserSlot(userSlot ISlot, scheduler *Schedu
 	metaCtx.PeerSpace.ProcessRipePMSlot(userSlot, scheduler)
 }
 
+// ----------------------------------------
+// check the invariants of the peer space; returns the first violation (or nil)
+func (metaCtx MetaContext) CheckInvariants() error {
+	return metaCtx.PeerSpace.CheckInvariants()
+}
+
+// ----------------------------------------
+// values of the atoms of the temporal properties (nil if there are none)
+func (metaCtx MetaContext) PropertyValuation() []bool {
+	return metaCtx.PeerSpace.PropertyValuation()
+}
+
+// ----------------------------------------
+// check the temporal properties along a path; returns the first violation (or nil)
+func (metaCtx MetaContext) CheckProperties(word [][]bool, loopStart int) error {
+	return metaCtx.PeerSpace.CheckProperties(word, loopStart)
+}
+
+// ----------------------------------------
+// deadlock detection: does one of the goal containers hold a committed entry?
+func (metaCtx MetaContext) GoalReached() bool {
+	return metaCtx.PeerSpace.GoalReached()
+}
+
+// ----------------------------------------
+// canonical description of the model data for model checking
+// - peer space contents and the running transactions (without their volatile ids)
+// - nb: committed and rolled back transactions are kept in the map, but do not influence the further execution
+// - nb: running transactions that have neither locked, written nor read anything are skipped: the wiring creates
+//   a new tx after each commit, which is left behind when the wiring instance ends; they would make a visited state look new
+func (metaCtx MetaContext) StateFingerprint() string {
+	txFps := []string{}
+	for _, tx := range metaCtx.Transactions {
+		if RUNNING == tx.State && !tx.isUnused() {
+			if OCC == tx.Txcc {
+				txFps = append(txFps, fmt.Sprintf("%s%s", tx.Fingerprint(), metaCtx.PeerSpace.OccFingerprint(tx)))
+			} else {
+				txFps = append(txFps, tx.Fingerprint())
+			}
+		}
+	}
+	sort.Strings(txFps)
+	return fmt.Sprintf("%stx=%s\n", metaCtx.PeerSpace.StateFingerprint(), strings.Join(txFps, ", "))
+}
+
+////////////////////////////////////////
+// random source
+////////////////////////////////////////
+
+// ----------------------------------------
+// random source of the distributions for the current run (see systemFunctions)
+func (metaCtx MetaContext) SetRandomSource(fu func(n int) int) {
+	SetRandomSource(fu)
+}
+
+////////////////////////////////////////
+// statistics
+////////////////////////////////////////
+
+// ----------------------------------------
+// names of the observables (see observable)
+func (metaCtx MetaContext) ObservableNames() []string {
+	names := []string{}
+	for _, obs := range metaCtx.PeerSpace.Observables {
+		names = append(names, obs.Name)
+	}
+	return names
+}
+
+// ----------------------------------------
+// update the observables after a critical section
+func (metaCtx MetaContext) Observe() {
+	metaCtx.PeerSpace.Observe()
+}
+
+// ----------------------------------------
+// values of the observables at the end of the run, in the order of their names
+func (metaCtx MetaContext) ObservableValues() []float64 {
+	vals := []float64{}
+	for _, obs := range metaCtx.PeerSpace.Observables {
+		vals = append(vals, obs.Value(metaCtx.PeerSpace))
+	}
+	return vals
+}
+
+// ----------------------------------------
+// number of committed, rolled back and still running txs per txcc (empty if there are no txs)
+// - nb: txs are never removed from the meta context
+func (metaCtx MetaContext) TxStatistics() string {
+	counts := map[string]map[string]int{}
+	for _, tx := range metaCtx.Transactions {
+		if nil == counts[tx.Txcc] {
+			counts[tx.Txcc] = map[string]int{}
+		}
+		counts[tx.Txcc][tx.State]++
+	}
+	txccs := []string{}
+	for txcc := range counts {
+		txccs = append(txccs, txcc)
+	}
+	sort.Strings(txccs)
+	items := []string{}
+	for _, txcc := range txccs {
+		items = append(items, fmt.Sprintf("%s: %d committed, %d rolled back, %d running", txcc, counts[txcc][COMMITTED], counts[txcc][ROLLEDBACK], counts[txcc][RUNNING]))
+	}
+	return strings.Join(items, "; ")
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
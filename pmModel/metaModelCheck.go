@@ -0,0 +1,404 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2015
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// plausibility check of the meta model (see pmsim validate)
+// - errors:
+// -- there must be at least one guard link
+// -- ttl on non mandatory link and on NOOP link must be 0
+// -- a dest (lprops, eprops, initial entries) must be a peer
+// -- a sub peer (SubPid) must be a peer
+// -- a service (Sid) must have a service wrapper in the wiring
+// -- a priority or key coordinator of a PIC or POC must have a label
+// - warnings:
+// -- last link does not commit
+// -- entry type is read by a guard, but never written (by an action, an initial entry or the system)
+// -- wiring is unreachable: the entry types of its guards are never written by the initial entries or by reachable wirings
+// - only values (VAL) are checked, eg not a dest given by a var; entry types are not distinguished by container
+// - if a wiring writes entry types that are not known statically (eg by a service), reachability is not checked
+// - the results are returned, in the order of peers, wirings and links (see CheckMetaModel);
+//   MetaModelCheck raises the first error as model error instead
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	"fmt"
+	"strings"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type CheckSeverityEnum int
+
+const (
+	CHECK_WARNING CheckSeverityEnum = iota
+	CHECK_ERROR
+)
+
+func (t CheckSeverityEnum) String() string {
+	switch t {
+	case CHECK_WARNING:
+		return "warning"
+	case CHECK_ERROR:
+		return "error"
+	default:
+		return "ill. check severity"
+	}
+}
+
+// ----------------------------------------
+// result of the meta model check with its position
+// - LinkNo is -1 for the wiring itself; Wid is "" for the peer (or for an entry)
+type CheckResult struct {
+	Severity CheckSeverityEnum
+	Pid      string
+	Wid      string
+	LinkNo   int
+	Msg      string
+}
+
+// ----------------------------------------
+type CheckResults []*CheckResult
+
+// ----------------------------------------
+// system generated entry types
+var SYSTEM_ENTRY_TYPES = []string{DEST_WRAP, SOURCE_WRAP, EXCEPTION_WRAP, EXCEPTION_ON_ABORT}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// =========================================================
+// check plausibility of the meta model (see DOCU above)
+// - the initial entries are those in the containers
+// - tbd: subconditions @@@ zb nur lesen alleine reicht nicht, wenn zb kein
+// --      take aus dem selben container erfolgt etc.
+// --      oder: 1. guard must ein take sein (tbd)
+// @@@ TBD:
+// - wiring tts must not be infinite
+func (ps *PeerSpace) CheckMetaModel() CheckResults {
+	results := CheckResults{}
+	add := func(severity CheckSeverityEnum, pid string, wid string, linkNo int, msg string) {
+		results = append(results, &CheckResult{Severity: severity, Pid: pid, Wid: wid, LinkNo: linkNo, Msg: msg})
+	}
+	//------------------------------------------------------------
+	// initial entries: dest
+	for _, cid := range ps.ContainerCids {
+		if c := ps.Containers[cid]; nil != c {
+			for _, e := range c.Entries {
+				if dest, ok := staticString(e.EProps[DEST]); ok && nil == ps.Peers[dest] {
+					add(CHECK_ERROR, "", "", -1, fmt.Sprintf("entry %s in %s: dest %s is not a peer", e.Id, cid, dest))
+				}
+			}
+		}
+	}
+	//------------------------------------------------------------
+	// check all wirings
+	for _, pid := range ps.PeerPids {
+		p := ps.Peers[pid]
+		if nil == p {
+			continue
+		}
+		// check the coordinators:
+		if p.PicCoordinator.NeedsLabel() && "" == p.PicCoordinator.Label {
+			add(CHECK_ERROR, pid, "", -1, fmt.Sprintf("%s: coordinator %s needs a label", PIC, p.PicCoordinator.Type))
+		}
+		if p.PocCoordinator.NeedsLabel() && "" == p.PocCoordinator.Label {
+			add(CHECK_ERROR, pid, "", -1, fmt.Sprintf("%s: coordinator %s needs a label", POC, p.PocCoordinator.Type))
+		}
+		for _, w := range p.wiringsInOrder() {
+			wid := strings.TrimPrefix(w.Id, p.Id+SEP)
+			// is there at least one guard link?
+			// @@@ tbd: subconditions
+			firstGuardIndex := -1
+			for i, l := range w.Links {
+				if -1 == firstGuardIndex && GUARD == l.Type {
+					firstGuardIndex = i
+				}
+				// check if ttl on optional link is 0:
+				if !l.GetMandatory(nil /* ctx */) && linkTtlIsNotZero(l) {
+					add(CHECK_ERROR, pid, wid, i, "ttl on non mandatory link must be 0")
+				}
+				// check if ttl on noop link is 0:
+				if NOOP == l.Op && linkTtlIsNotZero(l) {
+					add(CHECK_ERROR, pid, wid, i, "ttl on NOOP link must be 0")
+				}
+				// check the sub peer:
+				if "" != l.SubPid && IOP_PEER != l.SubPid && nil == ps.Peers[fmt.Sprintf("%s%s%s", p.Id, SEP, l.SubPid)] {
+					add(CHECK_ERROR, pid, wid, i, fmt.Sprintf("sub peer %s does not exist", l.SubPid))
+				}
+				if IOP_PEER == l.SubPid && nil == ps.Peers[IOP_PEER] {
+					add(CHECK_ERROR, pid, wid, i, fmt.Sprintf("sub peer %s does not exist (system peer is missing)", l.SubPid))
+				}
+				// check the service:
+				if SERVICE_IN == l.Type || SERVICE == l.Type || SERVICE_OUT == l.Type {
+					if nil == w.ServiceWrappers[l.Sid] {
+						add(CHECK_ERROR, pid, wid, i, fmt.Sprintf("service %s has no service wrapper", l.Sid))
+					}
+				}
+				// check the dests:
+				if dest, ok := staticString(l.LProps[DEST]); ok && nil == ps.Peers[dest] {
+					add(CHECK_ERROR, pid, wid, i, fmt.Sprintf("lprops: dest %s is not a peer", dest))
+				}
+				if dest, ok := staticString(l.EProps[DEST]); ok && nil == ps.Peers[dest] {
+					add(CHECK_ERROR, pid, wid, i, fmt.Sprintf("eprops: dest %s is not a peer", dest))
+				}
+			}
+			// check if there is at least one guard link:
+			if -1 == firstGuardIndex {
+				add(CHECK_ERROR, pid, wid, -1, "no guard link")
+			}
+			// check if last link has commit set:
+			if 0 < len(w.Links) {
+				if arg := w.Links[len(w.Links)-1].LProps[COMMIT]; "" == arg.Kind || (VAL == arg.Kind && !arg.BoolVal) {
+					add(CHECK_WARNING, pid, wid, len(w.Links)-1, "last link does not commit")
+				}
+			}
+		}
+	}
+	//------------------------------------------------------------
+	// entry types
+	results = append(results, ps.checkEntryTypes()...)
+	return results
+}
+
+// ----------------------------------------
+// private fu:
+// warnings for entry types that are read, but never written, and for unreachable wirings
+// - nb: system peers are not reported
+func (ps *PeerSpace) checkEntryTypes() CheckResults {
+	results := CheckResults{}
+	//------------------------------------------------------------
+	// written by the system and the initial entries
+	initialTypes := map[string]bool{}
+	for _, etype := range SYSTEM_ENTRY_TYPES {
+		initialTypes[etype] = true
+	}
+	for _, cid := range ps.ContainerCids {
+		if c := ps.Containers[cid]; nil != c {
+			for _, e := range c.Entries {
+				initialTypes[e.GetType()] = true
+			}
+		}
+	}
+	//------------------------------------------------------------
+	// all wirings in order, and the entry types they write
+	peers := []*Peer{}
+	wirings := []*Wiring{}
+	writtenTypes := map[string]bool{}
+	for etype := range initialTypes {
+		writtenTypes[etype] = true
+	}
+	for _, pid := range ps.PeerPids {
+		if p := ps.Peers[pid]; nil != p {
+			for _, w := range p.wiringsInOrder() {
+				peers = append(peers, p)
+				wirings = append(wirings, w)
+				etypes, _ := w.writtenEntryTypes(p.IsSysPeerFlag)
+				for _, etype := range etypes {
+					writtenTypes[etype] = true
+				}
+			}
+		}
+	}
+	//------------------------------------------------------------
+	// read, but never written
+	reportedTypes := map[string]bool{}
+	for i, w := range wirings {
+		p := peers[i]
+		if p.IsSysPeerFlag {
+			continue
+		}
+		for j, l := range w.Links {
+			if etype, ok := l.readEntryType(); ok && !writtenTypes[etype] && !reportedTypes[etype] {
+				reportedTypes[etype] = true
+				results = append(results, &CheckResult{Severity: CHECK_WARNING, Pid: p.Id, Wid: strings.TrimPrefix(w.Id, p.Id+SEP), LinkNo: j,
+					Msg: fmt.Sprintf("entry type %s is read, but never written", etype)})
+			}
+		}
+	}
+	//------------------------------------------------------------
+	// reachable wirings: fix point, starting with the initial entries
+	reachable := make([]bool, len(wirings))
+	producedTypes := initialTypes
+	for changedFlag := true; changedFlag; {
+		changedFlag = false
+		for i, w := range wirings {
+			if reachable[i] || "" != w.missingEntryType(producedTypes) {
+				continue
+			}
+			reachable[i] = true
+			changedFlag = true
+			etypes, knownFlag := w.writtenEntryTypes(peers[i].IsSysPeerFlag)
+			if !knownFlag {
+				// any entry type might be written
+				return results
+			}
+			for _, etype := range etypes {
+				producedTypes[etype] = true
+			}
+		}
+	}
+	for i, w := range wirings {
+		if !reachable[i] && !peers[i].IsSysPeerFlag {
+			results = append(results, &CheckResult{Severity: CHECK_WARNING, Pid: peers[i].Id, Wid: strings.TrimPrefix(w.Id, peers[i].Id+SEP), LinkNo: -1,
+				Msg: fmt.Sprintf("wiring is unreachable: entry type %s of its guard is never produced", w.missingEntryType(producedTypes))})
+		}
+	}
+	return results
+}
+
+// ----------------------------------------
+// private fu:
+// wirings of the peer in the order of their creation
+func (p *Peer) wiringsInOrder() []*Wiring {
+	wirings := []*Wiring{}
+	for _, wid := range p.WiringWids {
+		if w := p.Wirings[wid]; nil != w {
+			wirings = append(wirings, w)
+		}
+	}
+	return wirings
+}
+
+// ----------------------------------------
+// private fu:
+// entry types written by the actions of the wiring
+// - knownFlag is false, if other entry types might be written, ie by a not static type, or
+//   by a wildcard together with a service of a (non system) peer
+func (w *Wiring) writtenEntryTypes(sysPeerFlag bool) (etypes []string, knownFlag bool) {
+	knownFlag = true
+	serviceFlag := false
+	for _, l := range w.Links {
+		if SERVICE == l.Type && !sysPeerFlag {
+			serviceFlag = true
+		}
+	}
+	for _, l := range w.Links {
+		if ACTION != l.Type || (WRITE != l.Op && CREATE != l.Op) {
+			continue
+		}
+		etype, ok := staticString(l.Q.Typ)
+		if !ok || (WILDCARD == etype && serviceFlag) {
+			knownFlag = false
+		} else if WILDCARD != etype {
+			etypes = append(etypes, etype)
+		}
+	}
+	return etypes, knownFlag
+}
+
+// ----------------------------------------
+// private fu:
+// entry type that a guard of the wiring needs, but that is not produced ("" if there is none)
+// - a guard needs its entry type if it is mandatory and its min is a value greater 0
+func (w *Wiring) missingEntryType(producedTypes map[string]bool) string {
+	for _, l := range w.Links {
+		etype, ok := l.readEntryType()
+		if !ok || producedTypes[etype] || !l.GetMandatory(nil /* ctx */) {
+			continue
+		}
+		if VAL == l.Q.Min.Kind && 0 < l.Q.Min.IntVal {
+			return etype
+		}
+	}
+	return ""
+}
+
+// ----------------------------------------
+// private fu:
+// static entry type read (or taken or deleted) by a guard (but not a wildcard)
+func (l *Link) readEntryType() (string, bool) {
+	if GUARD != l.Type || (READ != l.Op && TAKE != l.Op && DELETE != l.Op) {
+		return "", false
+	}
+	etype, ok := staticString(l.Q.Typ)
+	if !ok || WILDCARD == etype {
+		return "", false
+	}
+	return etype, true
+}
+
+// =========================================================
+// check plausibility of the meta model and raise the first error as model error (see CheckMetaModel)
+// - testCaseName serves only for docu
+// - warnings are ignored
+func (ps *PeerSpace) MetaModelCheck(testCaseName string) {
+	for _, r := range ps.CheckMetaModel() {
+		if CHECK_ERROR == r.Severity {
+			ModelPanic(fmt.Sprintf("%s: Meta Model Check: %s", testCaseName, r))
+		}
+	}
+}
+
+// ----------------------------------------
+// true if there are errors
+func (results CheckResults) HasErrors() bool {
+	for _, r := range results {
+		if CHECK_ERROR == r.Severity {
+			return true
+		}
+	}
+	return false
+}
+
+// ----------------------------------------
+// eg "warning: peer P1: wiring W1: link 2: last link does not commit"
+func (r *CheckResult) String() string {
+	return strings.Join(append(append([]string{r.Severity.String()}, position(r.Pid, r.Wid, r.LinkNo)...), r.Msg), ": ")
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// private fu:
+// string value of the arg, if it is a value
+func staticString(arg Arg) (string, bool) {
+	if VAL == arg.Kind && STRING == arg.Type {
+		return arg.StringVal, true
+	}
+	return "", false
+}
+
+// ----------------------------------------
+// private fu:
+// can the ttl of the link be other than 0?
+// - a value that is computed at run time, eg drawn from a distribution (FU) or given by a variable, is not
+//   known to be 0; nb: without ctx, GetTtl returns its IntVal, which is 0 then
+func linkTtlIsNotZero(l *Link) bool {
+	if arg := l.LProps[TTL]; "" != arg.Kind && VAL != arg.Kind {
+		return true
+	}
+	return 0 != l.GetTtl(nil /* ctx */)
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
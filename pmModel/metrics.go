@@ -0,0 +1,379 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// metrics of a run per wiring and per container (see config: METRICS_FILE)
+// - collected from the records of the event log (see eventLog: SetEventSink), ie they count what is logged there:
+// -- wiring: instances fired, ie the ones that ended with the commit or the rollback of their tx (nb: not the created
+//    txs, as a wiring creates the tx of its next instance in advance), commits, rollbacks, repeat exceptions, ttl
+//    exceptions (wiring and link ttl), and the mean latency of the committed instances from the creation of their tx,
+//    ie the start of the guards, to the commit (in CLOCK units)
+// -- container: entries written, taken and expired, and the occupancy, ie the number of its entries; the occupancy
+//    of all containers is observed at every sample and after every space op and tx end
+// - time series: a sample of all metrics is taken at the start of the run, whenever the clock advances
+//   (for the time before, ie at its end), and at the end of the run
+// - export at the end of the run (see Controller):
+// -- "<base>_<run>.csv": the time series, one line per sample and metric: run,clock,kind,id,metric,value
+// -- "<base>_<run>.prom": the final values in the prometheus text exposition format, labeled by run and wid/cid
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/eventLog"
+	. "github.com/peermodel/simulator/scheduler"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type WiringMetrics struct {
+	Fired            int
+	Commits          int
+	Rollbacks        int
+	RepeatExceptions int
+	TtlExceptions    int
+	// sum and number of the latencies of the commits
+	latencySum   int
+	latencyCount int
+}
+
+// ----------------------------------------
+type ContainerMetrics struct {
+	Written      int
+	Taken        int
+	Expired      int
+	Occupancy    int
+	MaxOccupancy int
+}
+
+// ----------------------------------------
+// private:
+type metricsCollector struct {
+	ps  *PeerSpace
+	run int
+	// key = wid
+	wirings map[string]*WiringMetrics
+	// key = cid
+	containers map[string]*ContainerMetrics
+	// creation time of the running wiring txs; key = txid
+	txStarts map[string]int
+	// csv lines of the time series
+	lines [][]string
+	// clock of the last sample (-1 = none)
+	lastSampleClock int
+}
+
+////////////////////////////////////////
+// vars
+////////////////////////////////////////
+
+// ----------------------------------------
+// private:
+// metrics of the current run; nil if off
+var metrics *metricsCollector
+
+////////////////////////////////////////
+// IMetaContext interface implementation:
+////////////////////////////////////////
+
+// ----------------------------------------
+// start to collect the metrics of the run
+// - nb: call before the event log starts the run
+func (metaCtx MetaContext) StartMetrics(run int) {
+	metrics = &metricsCollector{
+		ps:              metaCtx.PeerSpace,
+		run:             run,
+		wirings:         map[string]*WiringMetrics{},
+		containers:      map[string]*ContainerMetrics{},
+		txStarts:        map[string]int{},
+		lastSampleClock: -1,
+	}
+	SetEventSink(metrics.record)
+}
+
+// ----------------------------------------
+// stop to collect the metrics, and write them to "<base>_<run>.csv" and "<base>_<run>.prom"; returns the file names
+func (metaCtx MetaContext) ExportMetrics(base string) ([]string, error) {
+	mc := metrics
+	metaCtx.StopMetrics()
+	if nil == mc {
+		return nil, nil
+	}
+	mc.sample(CLOCK)
+	csvFile := fmt.Sprintf("%s_%d.csv", base, mc.run)
+	promFile := fmt.Sprintf("%s_%d.prom", base, mc.run)
+	//------------------------------------------------------------
+	// csv
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"run", "clock", "kind", "id", "metric", "value"})
+	w.WriteAll(mc.lines)
+	if err := w.Error(); nil != err {
+		return nil, fmt.Errorf("metrics: %s", err)
+	}
+	if err := ioutil.WriteFile(csvFile, buf.Bytes(), 0644); nil != err {
+		return nil, fmt.Errorf("metrics: %s", err)
+	}
+	//------------------------------------------------------------
+	// prometheus
+	if err := ioutil.WriteFile(promFile, []byte(mc.prometheus()), 0644); nil != err {
+		return nil, fmt.Errorf("metrics: %s", err)
+	}
+	return []string{csvFile, promFile}, nil
+}
+
+// ----------------------------------------
+// stop to collect the metrics without export, eg if the run failed
+func (metaCtx MetaContext) StopMetrics() {
+	SetEventSink(nil)
+	metrics = nil
+}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// private fu:
+// event sink (see eventLog: SetEventSink)
+func (mc *metricsCollector) record(rec EventRecord) {
+	switch rec.Type {
+	case LOG_RUN_START:
+		mc.sample(rec.Clock)
+	case LOG_CLOCK:
+		if from, err := strconv.Atoi(rec.From); nil == err {
+			mc.sample(from)
+		}
+	case LOG_WRITE:
+		mc.container(rec.Cid).Written += len(rec.Eids)
+		mc.observe()
+	case LOG_TAKE:
+		mc.container(rec.Cid).Taken += len(rec.Eids)
+		mc.observe()
+	case LOG_TX_CREATE:
+		mc.txStarts[rec.Txid] = rec.Clock
+	case LOG_TX_COMMIT:
+		wm := mc.wiring(rec.Wid)
+		wm.Fired++
+		wm.Commits++
+		if start, ok := mc.txStarts[rec.Txid]; ok {
+			wm.latencySum += rec.Clock - start
+			wm.latencyCount++
+			delete(mc.txStarts, rec.Txid)
+		}
+		mc.observe()
+	case LOG_TX_ROLLBACK:
+		wm := mc.wiring(rec.Wid)
+		wm.Fired++
+		wm.Rollbacks++
+		delete(mc.txStarts, rec.Txid)
+		mc.observe()
+	case LOG_EXCEPTION:
+		switch {
+		case ENTRY_EXPIRED_MSG == rec.Msg:
+			mc.container(rec.Cid).Expired += len(rec.Eids)
+			mc.observe()
+		case strings.HasPrefix(rec.Msg, fmt.Sprintf("%s:", WIRING_REPEAT_EXCEPTION)):
+			mc.wiring(rec.Wid).RepeatExceptions++
+		case strings.HasPrefix(rec.Msg, fmt.Sprintf("%s:", WIRING_TTL_EXCEPTION)),
+			strings.HasPrefix(rec.Msg, fmt.Sprintf("%s:", LINK_TTL_EXCEPTION)):
+			mc.wiring(rec.Wid).TtlExceptions++
+		}
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// take a sample of all metrics at the clock
+// - nb: the occupancy of all containers is the current one
+func (mc *metricsCollector) sample(clock int) {
+	if clock == mc.lastSampleClock {
+		return
+	}
+	mc.lastSampleClock = clock
+	mc.observe()
+	run := strconv.Itoa(mc.run)
+	t := strconv.Itoa(clock)
+	add := func(kind string, id string, metric string, value string) {
+		mc.lines = append(mc.lines, []string{run, t, kind, id, metric, value})
+	}
+	for _, cid := range mc.cids() {
+		cm := mc.container(cid)
+		add("container", cid, "occupancy", strconv.Itoa(cm.Occupancy))
+		add("container", cid, "written", strconv.Itoa(cm.Written))
+		add("container", cid, "taken", strconv.Itoa(cm.Taken))
+		add("container", cid, "expired", strconv.Itoa(cm.Expired))
+	}
+	for _, wid := range mc.wids() {
+		wm := mc.wiring(wid)
+		add("wiring", wid, "fired", strconv.Itoa(wm.Fired))
+		add("wiring", wid, "commits", strconv.Itoa(wm.Commits))
+		add("wiring", wid, "rollbacks", strconv.Itoa(wm.Rollbacks))
+		add("wiring", wid, "repeat_exceptions", strconv.Itoa(wm.RepeatExceptions))
+		add("wiring", wid, "ttl_exceptions", strconv.Itoa(wm.TtlExceptions))
+		add("wiring", wid, "mean_latency", strconv.FormatFloat(wm.MeanLatency(), 'f', -1, 64))
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// final values in the prometheus text exposition format
+func (mc *metricsCollector) prometheus() string {
+	var b strings.Builder
+	family := func(name string, typ string, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+	wids := mc.wids()
+	wiringFamily := func(name string, typ string, help string, value func(wm *WiringMetrics) string) {
+		family(name, typ, help)
+		for _, wid := range wids {
+			fmt.Fprintf(&b, "%s{run=\"%d\",wid=\"%s\"} %s\n", name, mc.run, wid, value(mc.wiring(wid)))
+		}
+	}
+	cids := mc.cids()
+	containerFamily := func(name string, typ string, help string, value func(cm *ContainerMetrics) int) {
+		family(name, typ, help)
+		for _, cid := range cids {
+			fmt.Fprintf(&b, "%s{run=\"%d\",cid=\"%s\"} %d\n", name, mc.run, cid, value(mc.container(cid)))
+		}
+	}
+	wiringFamily("pm_wiring_fired_total", "counter", "Wiring instances fired.", func(wm *WiringMetrics) string { return strconv.Itoa(wm.Fired) })
+	wiringFamily("pm_wiring_commits_total", "counter", "Committed wiring instances.", func(wm *WiringMetrics) string { return strconv.Itoa(wm.Commits) })
+	wiringFamily("pm_wiring_rollbacks_total", "counter", "Rolled back wiring instances.", func(wm *WiringMetrics) string { return strconv.Itoa(wm.Rollbacks) })
+	wiringFamily("pm_wiring_repeat_exceptions_total", "counter", "Wiring repeat exceptions.", func(wm *WiringMetrics) string { return strconv.Itoa(wm.RepeatExceptions) })
+	wiringFamily("pm_wiring_ttl_exceptions_total", "counter", "Wiring and link ttl exceptions.", func(wm *WiringMetrics) string { return strconv.Itoa(wm.TtlExceptions) })
+	wiringFamily("pm_wiring_mean_latency", "gauge", "Mean time from the start of the guards to the commit.", func(wm *WiringMetrics) string {
+		return strconv.FormatFloat(wm.MeanLatency(), 'f', -1, 64)
+	})
+	containerFamily("pm_container_written_total", "counter", "Entries written.", func(cm *ContainerMetrics) int { return cm.Written })
+	containerFamily("pm_container_taken_total", "counter", "Entries taken.", func(cm *ContainerMetrics) int { return cm.Taken })
+	containerFamily("pm_container_expired_total", "counter", "Entries expired.", func(cm *ContainerMetrics) int { return cm.Expired })
+	containerFamily("pm_container_occupancy", "gauge", "Entries in the container.", func(cm *ContainerMetrics) int { return cm.Occupancy })
+	containerFamily("pm_container_max_occupancy", "gauge", "Max. entries observed in the container.", func(cm *ContainerMetrics) int { return cm.MaxOccupancy })
+	return b.String()
+}
+
+// ----------------------------------------
+// private fu:
+// metrics of the wiring (created if needed)
+func (mc *metricsCollector) wiring(wid string) *WiringMetrics {
+	wm := mc.wirings[wid]
+	if nil == wm {
+		wm = &WiringMetrics{}
+		mc.wirings[wid] = wm
+	}
+	return wm
+}
+
+// ----------------------------------------
+// private fu:
+// metrics of the container (created if needed)
+func (mc *metricsCollector) container(cid string) *ContainerMetrics {
+	cm := mc.containers[cid]
+	if nil == cm {
+		cm = &ContainerMetrics{}
+		mc.containers[cid] = cm
+	}
+	return cm
+}
+
+// ----------------------------------------
+// private fu:
+// update the occupancy (and max. occupancy) of all containers of the peer space
+func (mc *metricsCollector) observe() {
+	for _, cid := range mc.ps.ContainerCids {
+		if c := mc.ps.Containers[cid]; nil != c {
+			cm := mc.container(cid)
+			cm.Occupancy = len(c.Entries)
+			if cm.Occupancy > cm.MaxOccupancy {
+				cm.MaxOccupancy = cm.Occupancy
+			}
+		}
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// ids of all containers of the peer space in the order of their creation, and of the ones only known by records
+func (mc *metricsCollector) cids() []string {
+	cids := []string{}
+	known := map[string]bool{}
+	for _, cid := range mc.ps.ContainerCids {
+		cids = append(cids, cid)
+		known[cid] = true
+	}
+	others := []string{}
+	for cid := range mc.containers {
+		if !known[cid] && "" != cid {
+			others = append(others, cid)
+		}
+	}
+	sort.Strings(others)
+	return append(cids, others...)
+}
+
+// ----------------------------------------
+// private fu:
+// ids of all wirings of the peers, and of the ones only known by records, sorted
+func (mc *metricsCollector) wids() []string {
+	known := map[string]bool{}
+	for _, p := range mc.ps.Peers {
+		for wid := range p.Wirings {
+			known[wid] = true
+		}
+	}
+	for wid := range mc.wirings {
+		if "" != wid {
+			known[wid] = true
+		}
+	}
+	wids := []string{}
+	for wid := range known {
+		wids = append(wids, wid)
+	}
+	sort.Strings(wids)
+	return wids
+}
+
+// ----------------------------------------
+// mean latency of the commits; 0 if none
+func (wm *WiringMetrics) MeanLatency() float64 {
+	if 0 == wm.latencyCount {
+		return 0
+	}
+	return float64(wm.latencySum) / float64(wm.latencyCount)
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -0,0 +1,1201 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2015
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// declarative model file (json or yaml)
+// - alternative to the go code generated by the translator: peers, wirings, links and initial entries
+//   are read from a file and built with the same constructors (NewPeer, NewWiring, AddGuard, ...)
+// - file format is selected by the extension: .json, or .yaml/.yml
+// - usage:
+// -- mf, err := LoadModelFile(path)
+// -- metaCtx, err := mf.NewMetaContext()
+// -- ... create status, containers and machines (see pmAutomata) ...
+// -- err = mf.WriteEntries(metaCtx.PeerSpace, &s.Scheduler)
+// - and vice versa: ps.ToModelFile(name).Save(path)
+// - services are referenced by name (see SERVICE_REGISTRY)
+// - invariants: entry_count and peer_count (see Invariant); go callbacks must be added in code
+// - temporal properties: formula trees whose atoms are such invariants (see Property)
+// - observables of the simulation runs: final_count and first_nonempty (see Observable)
+// - args are either json scalars (int, string, bool values) or objects, eg:
+// -- {"kind": "VAL", "type": "STRING", "subtype": "ENTRY_TYPE", "string": "Order"}
+// -- {"kind": "LABEL", "type": "INT", "name": "price"}
+// -- {"kind": "EXPR", "op": "LESS", "left": {...}, "right": {...}}
+// -- {"kind": "FU", "type": "INT", "fu": "exponential()", "args": [10]} (see systemFunctions.go)
+// - the types of the args are checked when they are loaded (see Arg.StaticType), and the whole peer space
+//   with the initial entries when the meta context is created (see typeCheck.go)
+// - coordinators of PIC and POC of a peer, eg "pic": {"coordinator": "fifo"} (see coordinator.go)
+// - TBD: entry data (nested entries) are not serialized
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/scheduler"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+////////////////////////////////////////
+// data types
+// - nb: all field names are the json (and yaml) keys
+////////////////////////////////////////
+
+// ----------------------------------------
+type ModelFile struct {
+	Name        string           `json:"name,omitempty"`
+	SystemPeers []SystemPeerSpec `json:"system_peers,omitempty"`
+	Peers       []PeerSpec       `json:"peers"`
+	Entries     []EntrySpec      `json:"entries,omitempty"`
+	Invariants  []InvariantSpec  `json:"invariants,omitempty"`
+	Properties  []PropertySpec   `json:"properties,omitempty"`
+	Observables []ObservableSpec `json:"observables,omitempty"`
+}
+
+// ----------------------------------------
+// built-in peer: IOP_PEER or STOP_PEER
+type SystemPeerSpec struct {
+	Peer   string   `json:"peer"`
+	WProps ArgsSpec `json:"wprops,omitempty"`
+}
+
+// ----------------------------------------
+type PeerSpec struct {
+	Id      string           `json:"id"`
+	Pic     *CoordinatorSpec `json:"pic,omitempty"`
+	Poc     *CoordinatorSpec `json:"poc,omitempty"`
+	Wirings []WiringSpec     `json:"wirings,omitempty"`
+}
+
+// ----------------------------------------
+// coordinator of the PIC or POC: bag (default), fifo, lifo, priority or key
+// label: int label of the priority, or label of the key
+type CoordinatorSpec struct {
+	Coordinator string `json:"coordinator"`
+	Label       string `json:"label,omitempty"`
+}
+
+// ----------------------------------------
+// nb: wiring id is the modeled one, ie without peer prefix
+type WiringSpec struct {
+	Id       string        `json:"id"`
+	WProps   ArgsSpec      `json:"wprops,omitempty"`
+	Services []ServiceSpec `json:"services,omitempty"`
+	Links    []LinkSpec    `json:"links,omitempty"`
+}
+
+// ----------------------------------------
+// service id within the wiring and name of the registered service function
+type ServiceSpec struct {
+	Sid  string `json:"sid"`
+	Name string `json:"name"`
+}
+
+// ----------------------------------------
+// type: guard, action, sin, sout, service; case-insensitive
+// c: modeled container of guard (C1) or action (C2), eg PIC or POC
+// op: space op, case-insensitive; not needed for sout and service
+type LinkSpec struct {
+	Type   string     `json:"type"`
+	SubPid string     `json:"subpid,omitempty"`
+	C      string     `json:"c,omitempty"`
+	Op     string     `json:"op,omitempty"`
+	Sid    string     `json:"sid,omitempty"`
+	Query  *QuerySpec `json:"query,omitempty"`
+	LProps ArgsSpec   `json:"lprops,omitempty"`
+	EProps ArgsSpec   `json:"eprops,omitempty"`
+	Vars   ArgsSpec   `json:"vars,omitempty"`
+}
+
+// ----------------------------------------
+// nb: min and max are derived from count (see convertQueryCountToMinMax), unless they are given explicitly
+type QuerySpec struct {
+	Typ   *ArgSpec `json:"typ,omitempty"`
+	Sel   *ArgSpec `json:"sel,omitempty"`
+	Count *ArgSpec `json:"count,omitempty"`
+	Min   *ArgSpec `json:"min,omitempty"`
+	Max   *ArgSpec `json:"max,omitempty"`
+}
+
+// ----------------------------------------
+// initial entry in the PIC or POC of a peer
+type EntrySpec struct {
+	Peer      string   `json:"peer"`
+	Container string   `json:"container"`
+	Type      string   `json:"type"`
+	EProps    ArgsSpec `json:"eprops,omitempty"`
+}
+
+// ----------------------------------------
+// type: entry_count or peer_count
+// c: container id for entry_count, eg P1_PIC; "*" = all PICs and POCs
+type InvariantSpec struct {
+	Name  string     `json:"name"`
+	Type  string     `json:"type"`
+	C     string     `json:"c,omitempty"`
+	Query *QuerySpec `json:"query"`
+}
+
+// ----------------------------------------
+// type: final_count or first_nonempty
+// c: container id, eg P1_POC; "*" = all PICs and POCs
+type ObservableSpec struct {
+	Name  string     `json:"name"`
+	Type  string     `json:"type"`
+	C     string     `json:"c"`
+	Query *QuerySpec `json:"query"`
+}
+
+// ----------------------------------------
+type PropertySpec struct {
+	Name    string       `json:"name"`
+	Formula *FormulaSpec `json:"formula"`
+}
+
+// ----------------------------------------
+// op: atom, not, and, or, always, eventually, until, leads_to
+// - unary operators use left
+type FormulaSpec struct {
+	Op    string         `json:"op"`
+	Atom  *InvariantSpec `json:"atom,omitempty"`
+	Left  *FormulaSpec   `json:"left,omitempty"`
+	Right *FormulaSpec   `json:"right,omitempty"`
+}
+
+// ----------------------------------------
+type ArgsSpec map[string]*ArgSpec
+
+// ----------------------------------------
+// serialized Arg; caution: keep up to date with Arg struct
+// - kind defaults to VAL
+// - op is the name of the OpTypeEnum, eg LESS (see OP_NAMES)
+// - fu is the name of the system function, eg clock(); args are its args (only for the distributions)
+// - DYN_ARRAY_REF: name = label name, left = index
+// - TYPED_ARRAY_LABEL, TYPED_ARRAY_VAL: left = arg
+type ArgSpec struct {
+	Kind    string     `json:"kind,omitempty"`
+	Type    string     `json:"type,omitempty"`
+	SubType string     `json:"subtype,omitempty"`
+	Name    string     `json:"name,omitempty"`
+	Fu      string     `json:"fu,omitempty"`
+	Args    []*ArgSpec `json:"args,omitempty"`
+	Int     *int       `json:"int,omitempty"`
+	String  *string    `json:"string,omitempty"`
+	Bool    *bool      `json:"bool,omitempty"`
+	Op      string     `json:"op,omitempty"`
+	Left    *ArgSpec   `json:"left,omitempty"`
+	Right   *ArgSpec   `json:"right,omitempty"`
+}
+
+// ----------------------------------------
+// names of the op types (their String() is not unique, eg ADD and PLUS)
+var OP_NAMES = map[OpTypeEnum]string{
+	EQUAL:         "EQUAL",
+	LESS:          "LESS",
+	LESS_EQUAL:    "LESS_EQUAL",
+	GREATER:       "GREATER",
+	GREATER_EQUAL: "GREATER_EQUAL",
+	NOT_EQUAL:     "NOT_EQUAL",
+	ADD:           "ADD",
+	SUB:           "SUB",
+	MUL:           "MUL",
+	DIV:           "DIV",
+	MOD:           "MOD",
+	PLUS:          "PLUS",
+	MINUS:         "MINUS",
+	AND:           "AND",
+	OR:            "OR",
+	NOT:           "NOT",
+	CONCAT:        "CONCAT",
+	UNUSED:        "UNUSED",
+}
+
+////////////////////////////////////////
+// load & save
+////////////////////////////////////////
+
+// ----------------------------------------
+func LoadModelFile(path string) (*ModelFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if nil != err {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+	case ".yaml", ".yml":
+		// convert yaml to json, so that only one set of field tags is needed
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); nil != err {
+			return nil, fmt.Errorf("model file %s: %s", path, err)
+		}
+		if data, err = json.Marshal(raw); nil != err {
+			return nil, fmt.Errorf("model file %s: %s", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("model file %s: unknown file type (use .json, .yaml or .yml)", path)
+	}
+	mf := new(ModelFile)
+	if err := json.Unmarshal(data, mf); nil != err {
+		return nil, fmt.Errorf("model file %s: %s", path, err)
+	}
+	return mf, nil
+}
+
+// ----------------------------------------
+func (mf *ModelFile) Save(path string) error {
+	data, err := json.MarshalIndent(mf, "", "  ")
+	if nil != err {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data = append(data, '\n')
+	case ".yaml", ".yml":
+		// json is yaml: parse it into a node (keeps the key order) and print it in block style
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); nil != err {
+			return err
+		}
+		clearYamlStyle(&node)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); nil != err {
+			return err
+		}
+		enc.Close()
+		data = buf.Bytes()
+	default:
+		return fmt.Errorf("model file %s: unknown file type (use .json, .yaml or .yml)", path)
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+////////////////////////////////////////
+// model file -> peer space
+////////////////////////////////////////
+
+// ----------------------------------------
+// create a new meta context whose peer space contains all peers and invariants of the model file
+// - nb: containers and entries are not yet created (see WriteEntries)
+func (mf *ModelFile) NewMetaContext() (*MetaContext, error) {
+	metaCtx := NewMetaContext()
+	if err := mf.AddPeers(metaCtx.PeerSpace); nil != err {
+		return nil, err
+	}
+	if err := mf.AddInvariants(metaCtx.PeerSpace); nil != err {
+		return nil, err
+	}
+	if err := mf.AddProperties(metaCtx.PeerSpace); nil != err {
+		return nil, err
+	}
+	if err := mf.AddObservables(metaCtx.PeerSpace); nil != err {
+		return nil, err
+	}
+	if err := mf.TypeCheck(metaCtx.PeerSpace); nil != err {
+		return nil, err
+	}
+	return metaCtx, nil
+}
+
+// ----------------------------------------
+// add system peers and peers (with wirings and links) to the peer space
+func (mf *ModelFile) AddPeers(ps *PeerSpace) error {
+	// ----------
+	// system peers:
+	for _, sp := range mf.SystemPeers {
+		wprops, err := sp.WProps.toArgs()
+		if nil != err {
+			return fmt.Errorf("system peer %s: %s", sp.Peer, err)
+		}
+		switch sp.Peer {
+		case IOP_PEER:
+			ps.AddMetaModel_IOP_PEER(WProps(wprops))
+		case STOP_PEER:
+			ps.AddMetaModel_STOP_PEER(WProps(wprops))
+		default:
+			return fmt.Errorf("ill. system peer \"%s\" (use %s or %s)", sp.Peer, IOP_PEER, STOP_PEER)
+		}
+	}
+	// ----------
+	// peers:
+	for _, pSpec := range mf.Peers {
+		if "" == pSpec.Id {
+			return fmt.Errorf("peer without id")
+		}
+		if nil != ps.Peers[pSpec.Id] {
+			return fmt.Errorf("peer %s: defined twice", pSpec.Id)
+		}
+		p := NewPeer(pSpec.Id)
+		var err error
+		if p.PicCoordinator, err = pSpec.Pic.toCoordinator(); nil != err {
+			return fmt.Errorf("peer %s: %s: %s", pSpec.Id, PIC, err)
+		}
+		if p.PocCoordinator, err = pSpec.Poc.toCoordinator(); nil != err {
+			return fmt.Errorf("peer %s: %s: %s", pSpec.Id, POC, err)
+		}
+		for _, wSpec := range pSpec.Wirings {
+			w, err := wSpec.toWiring()
+			if nil != err {
+				return fmt.Errorf("peer %s: %s", pSpec.Id, err)
+			}
+			// add wiring to peer & resolve names:
+			p.AddWiring(w)
+		}
+		ps.AddPeer(p)
+	}
+	return nil
+}
+
+// ----------------------------------------
+// add the invariants to the peer space
+func (mf *ModelFile) AddInvariants(ps *PeerSpace) error {
+	for i, invSpec := range mf.Invariants {
+		if "" == invSpec.Name {
+			return fmt.Errorf("invariant %d: name is missing", i)
+		}
+		for _, other := range ps.Invariants {
+			if other.Name == invSpec.Name {
+				return fmt.Errorf("invariant %s: defined twice", invSpec.Name)
+			}
+		}
+		inv, err := invSpec.toInvariant()
+		if nil != err {
+			return fmt.Errorf("invariant %s: %s", invSpec.Name, err)
+		}
+		ps.AddInvariant(inv)
+	}
+	return nil
+}
+
+// ----------------------------------------
+// add the temporal properties to the peer space
+func (mf *ModelFile) AddProperties(ps *PeerSpace) error {
+	for i, propSpec := range mf.Properties {
+		if "" == propSpec.Name {
+			return fmt.Errorf("property %d: name is missing", i)
+		}
+		for _, other := range ps.Properties {
+			if other.Name == propSpec.Name {
+				return fmt.Errorf("property %s: defined twice", propSpec.Name)
+			}
+		}
+		f, err := propSpec.Formula.toFormula()
+		if nil != err {
+			return fmt.Errorf("property %s: %s", propSpec.Name, err)
+		}
+		ps.AddProperty(NewProperty(propSpec.Name, f))
+	}
+	return nil
+}
+
+// ----------------------------------------
+// add the observables to the peer space
+func (mf *ModelFile) AddObservables(ps *PeerSpace) error {
+	for i, obsSpec := range mf.Observables {
+		if "" == obsSpec.Name {
+			return fmt.Errorf("observable %d: name is missing", i)
+		}
+		for _, other := range ps.Observables {
+			if other.Name == obsSpec.Name {
+				return fmt.Errorf("observable %s: defined twice", obsSpec.Name)
+			}
+		}
+		obs, err := obsSpec.toObservable()
+		if nil != err {
+			return fmt.Errorf("observable %s: %s", obsSpec.Name, err)
+		}
+		ps.AddObservable(obs)
+	}
+	return nil
+}
+
+// ----------------------------------------
+// write the initial entries into their PICs and POCs
+// - caution: the containers must have been created already
+func (mf *ModelFile) WriteEntries(ps *PeerSpace, scheduler *Scheduler) error {
+	for i, eSpec := range mf.Entries {
+		cid, e, err := eSpec.toEntry(ps)
+		if nil != err {
+			return fmt.Errorf("entry %d: %s", i, err)
+		}
+		ps.Write(cid, e, Vars{}, scheduler)
+	}
+	return nil
+}
+
+// ----------------------------------------
+// static type check of the peer space together with the initial entries (see typeCheck.go)
+// - nb: the entries are only converted, not created (ie they get no uuid)
+func (mf *ModelFile) TypeCheck(ps *PeerSpace) error {
+	entries := []*Entry{}
+	for i, eSpec := range mf.Entries {
+		eprops, err := eSpec.EProps.toArgs()
+		if nil != err {
+			return fmt.Errorf("entry %d: %s", i, err)
+		}
+		e := &Entry{Id: fmt.Sprintf("%d", i), EProps: EProps(eprops)}
+		e.SetStringVal(TYPE, eSpec.Type)
+		entries = append(entries, e)
+	}
+	if errs := ps.TypeCheck(entries); 0 < len(errs) {
+		return errs
+	}
+	return nil
+}
+
+// ----------------------------------------
+// new entry of the spec, and the id of its container
+func (eSpec *EntrySpec) toEntry(ps *PeerSpace) (string, *Entry, error) {
+	cid := fmt.Sprintf("%s%s%s", eSpec.Peer, SEP, eSpec.Container)
+	if nil == ps.Containers[cid] {
+		return "", nil, fmt.Errorf("ill. container %s", cid)
+	}
+	if "" == eSpec.Type {
+		return "", nil, fmt.Errorf("entry type is missing")
+	}
+	eprops, err := eSpec.EProps.toArgs()
+	if nil != err {
+		return "", nil, err
+	}
+	e := NewEntry(eSpec.Type)
+	for label, arg := range eprops {
+		if TYPE != label {
+			e.EProps[label] = arg
+		}
+	}
+	return cid, e, nil
+}
+
+// ----------------------------------------
+// nb: the name of an atom of a formula is optional
+func (invSpec *InvariantSpec) toInvariant() (*Invariant, error) {
+	if nil == invSpec.Query {
+		return nil, fmt.Errorf("query is missing")
+	}
+	q, err := invSpec.Query.toQuery()
+	if nil != err {
+		return nil, err
+	}
+	name := invSpec.Name
+	switch invSpec.Type {
+	case ENTRY_COUNT_INVARIANT.String():
+		if "" == invSpec.C {
+			return nil, fmt.Errorf("container is missing")
+		}
+		if "" == name {
+			name = fmt.Sprintf("%s_in_%s", q.Typ.StringVal, invSpec.C)
+		}
+		return NewEntryCountInvariant(name, invSpec.C, q), nil
+	case PEER_COUNT_INVARIANT.String():
+		if "" == name {
+			name = fmt.Sprintf("%s_in_peers", q.Typ.StringVal)
+		}
+		return NewPeerCountInvariant(name, q), nil
+	default:
+		return nil, fmt.Errorf("ill. type \"%s\" (use %s or %s)", invSpec.Type, ENTRY_COUNT_INVARIANT, PEER_COUNT_INVARIANT)
+	}
+}
+
+// ----------------------------------------
+func (obsSpec *ObservableSpec) toObservable() (*Observable, error) {
+	if nil == obsSpec.Query {
+		return nil, fmt.Errorf("query is missing")
+	}
+	if "" == obsSpec.C {
+		return nil, fmt.Errorf("container is missing")
+	}
+	q, err := obsSpec.Query.toQuery()
+	if nil != err {
+		return nil, err
+	}
+	switch obsSpec.Type {
+	case FINAL_COUNT_OBSERVABLE.String():
+		return NewFinalCountObservable(obsSpec.Name, obsSpec.C, q), nil
+	case FIRST_NONEMPTY_OBSERVABLE.String():
+		return NewFirstNonEmptyObservable(obsSpec.Name, obsSpec.C, q), nil
+	default:
+		return nil, fmt.Errorf("ill. type \"%s\" (use %s or %s)", obsSpec.Type, FINAL_COUNT_OBSERVABLE, FIRST_NONEMPTY_OBSERVABLE)
+	}
+}
+
+// ----------------------------------------
+func (fSpec *FormulaSpec) toFormula() (*Formula, error) {
+	if nil == fSpec {
+		return nil, fmt.Errorf("formula is missing")
+	}
+	var left, right *Formula
+	var err error
+	switch fSpec.Op {
+	case LTL_ATOM.String():
+		if nil == fSpec.Atom {
+			return nil, fmt.Errorf("atom is missing")
+		}
+		inv, err := fSpec.Atom.toInvariant()
+		if nil != err {
+			return nil, fmt.Errorf("atom: %s", err)
+		}
+		return LtlAtom(inv), nil
+	case LTL_NOT.String(), LTL_ALWAYS.String(), LTL_EVENTUALLY.String():
+		if left, err = fSpec.Left.toFormula(); nil != err {
+			return nil, fmt.Errorf("%s: %s", fSpec.Op, err)
+		}
+	case LTL_AND.String(), LTL_OR.String(), LTL_UNTIL.String(), LTL_LEADS_TO.String():
+		if left, err = fSpec.Left.toFormula(); nil != err {
+			return nil, fmt.Errorf("%s: %s", fSpec.Op, err)
+		}
+		if right, err = fSpec.Right.toFormula(); nil != err {
+			return nil, fmt.Errorf("%s: %s", fSpec.Op, err)
+		}
+	default:
+		return nil, fmt.Errorf("ill. operator \"%s\"", fSpec.Op)
+	}
+	switch fSpec.Op {
+	case LTL_NOT.String():
+		return LtlNot(left), nil
+	case LTL_ALWAYS.String():
+		return LtlAlways(left), nil
+	case LTL_EVENTUALLY.String():
+		return LtlEventually(left), nil
+	case LTL_AND.String():
+		return LtlAnd(left, right), nil
+	case LTL_OR.String():
+		return LtlOr(left, right), nil
+	case LTL_UNTIL.String():
+		return LtlUntil(left, right), nil
+	default:
+		return LtlLeadsTo(left, right), nil
+	}
+}
+
+// ----------------------------------------
+func (wSpec *WiringSpec) toWiring() (*Wiring, error) {
+	if "" == wSpec.Id {
+		return nil, fmt.Errorf("wiring without id")
+	}
+	w := NewWiring(wSpec.Id)
+	wprops, err := wSpec.WProps.toArgs()
+	if nil != err {
+		return nil, fmt.Errorf("wiring %s: %s", wSpec.Id, err)
+	}
+	if txcc, found := wprops[TXCC]; found && VAL == txcc.Kind && PCC != txcc.StringVal && OCC != txcc.StringVal {
+		return nil, fmt.Errorf("wiring %s: ill. %s \"%s\" (use %s or %s)", wSpec.Id, TXCC, txcc.StringVal, PCC, OCC)
+	}
+	w.WProps = WProps(wprops)
+	// ----------
+	// services:
+	for _, swSpec := range wSpec.Services {
+		fu := LookupService(swSpec.Name)
+		if nil == fu {
+			return nil, fmt.Errorf("wiring %s: service %s is not registered", wSpec.Id, swSpec.Name)
+		}
+		w.AddServiceWrapper(swSpec.Sid, NewServiceWrapper(fu, swSpec.Name))
+	}
+	// ----------
+	// links (keep the order!):
+	for i, lSpec := range wSpec.Links {
+		l, err := lSpec.toLink()
+		if nil != err {
+			return nil, fmt.Errorf("wiring %s: link %d: %s", wSpec.Id, i, err)
+		}
+		w.AddLink(l)
+	}
+	return w, nil
+}
+
+// ----------------------------------------
+// nil spec -> BAG
+func (cSpec *CoordinatorSpec) toCoordinator() (Coordinator, error) {
+	if nil == cSpec {
+		return Coordinator{}, nil
+	}
+	for _, t := range []CoordinatorTypeEnum{BAG, FIFO, LIFO, PRIORITY, KEY} {
+		if t.String() == cSpec.Coordinator {
+			co := Coordinator{Type: t, Label: cSpec.Label}
+			if co.NeedsLabel() && "" == co.Label {
+				return co, fmt.Errorf("coordinator %s needs a label", t)
+			}
+			if !co.NeedsLabel() && "" != co.Label {
+				return co, fmt.Errorf("coordinator %s has no label", t)
+			}
+			return co, nil
+		}
+	}
+	return Coordinator{}, fmt.Errorf("ill. coordinator \"%s\"", cSpec.Coordinator)
+}
+
+// ----------------------------------------
+func (lSpec *LinkSpec) toLink() (*Link, error) {
+	// ----------
+	// link type & op:
+	var typ LinkTypeEnum
+	found := false
+	typeNames := []string{}
+	for _, t := range []LinkTypeEnum{GUARD, ACTION, SERVICE_IN, SERVICE_OUT, SERVICE} {
+		typeNames = append(typeNames, t.String())
+		if !found && strings.EqualFold(t.String(), lSpec.Type) {
+			typ = t
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("ill. link type \"%s\" (valid: %s)", lSpec.Type, strings.Join(typeNames, ", "))
+	}
+	var op SpaceOpTypeEnum
+	if GUARD == typ || ACTION == typ || SERVICE_IN == typ {
+		found = false
+		opNames := []string{}
+		for _, o := range []SpaceOpTypeEnum{CALL, CREATE, DELETE, NOOP, READ, TAKE, TEST, WRITE} {
+			opNames = append(opNames, o.String())
+			if !found && strings.EqualFold(o.String(), lSpec.Op) {
+				op = o
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("ill. space op \"%s\" of %s link (valid: %s)", lSpec.Op, typ, strings.Join(opNames, ", "))
+		}
+	}
+	// ----------
+	// args:
+	q := Query{}
+	if nil != lSpec.Query {
+		var err error
+		if q, err = lSpec.Query.toQuery(); nil != err {
+			return nil, err
+		}
+	}
+	lprops, err := lSpec.LProps.toArgs()
+	if nil != err {
+		return nil, err
+	}
+	eprops, err := lSpec.EProps.toArgs()
+	if nil != err {
+		return nil, err
+	}
+	vars, err := lSpec.Vars.toArgs()
+	if nil != err {
+		return nil, err
+	}
+	// ----------
+	// create link with the proper constructor
+	var l *Link
+	switch typ {
+	case GUARD:
+		l = NewGuard(lSpec.SubPid, lSpec.C, op, q, LProps(lprops), EProps(eprops), Vars(vars))
+	case SERVICE_IN:
+		l = NewSin(op, q, lSpec.Sid, LProps(lprops), EProps(eprops), Vars(vars))
+	case SERVICE:
+		l = NewScall(lSpec.Sid, LProps(lprops), EProps(eprops), Vars(vars))
+	case SERVICE_OUT:
+		l = NewSout(q, lSpec.Sid, LProps(lprops), EProps(eprops), Vars(vars))
+	case ACTION:
+		l = NewAction(lSpec.SubPid, lSpec.C, op, q, LProps(lprops), EProps(eprops), Vars(vars))
+	}
+	// explicit min and max overwrite the ones derived from count
+	if nil != lSpec.Query {
+		if nil != lSpec.Query.Min {
+			l.Q.Min = q.Min
+		}
+		if nil != lSpec.Query.Max {
+			l.Q.Max = q.Max
+		}
+	}
+	return l, nil
+}
+
+// ----------------------------------------
+func (qSpec *QuerySpec) toQuery() (Query, error) {
+	q := Query{}
+	var err error
+	if q.Typ, err = qSpec.Typ.toArg(); nil != err {
+		return q, fmt.Errorf("query typ: %s", err)
+	}
+	if nil != qSpec.Sel {
+		sel, err := qSpec.Sel.toArg()
+		if nil != err {
+			return q, fmt.Errorf("query sel: %s", err)
+		}
+		q.Sel = &sel
+	}
+	if q.Count, err = qSpec.Count.toArg(); nil != err {
+		return q, fmt.Errorf("query count: %s", err)
+	}
+	if q.Min, err = qSpec.Min.toArg(); nil != err {
+		return q, fmt.Errorf("query min: %s", err)
+	}
+	if q.Max, err = qSpec.Max.toArg(); nil != err {
+		return q, fmt.Errorf("query max: %s", err)
+	}
+	return q, nil
+}
+
+// ----------------------------------------
+func (argsSpec ArgsSpec) toArgs() (Args, error) {
+	args := Args{}
+	for label, aSpec := range argsSpec {
+		arg, err := aSpec.toArg()
+		if nil != err {
+			return nil, fmt.Errorf("%s: %s", label, err)
+		}
+		args[label] = arg
+	}
+	return args, nil
+}
+
+// ----------------------------------------
+// nil spec yields the empty arg
+func (aSpec *ArgSpec) toArg() (Arg, error) {
+	arg := Arg{}
+	if nil == aSpec {
+		return arg, nil
+	}
+	// ----------
+	// kind:
+	arg.Kind = aSpec.Kind
+	if "" == arg.Kind {
+		arg.Kind = VAL
+	}
+	// ----------
+	// type: explicit, or implied by the given value
+	switch aSpec.Type {
+	case "":
+		if nil != aSpec.String {
+			arg.Type = STRING
+		} else if nil != aSpec.Bool {
+			arg.Type = BOOL
+		} else {
+			arg.Type = INT
+		}
+	case INT.String():
+		arg.Type = INT
+	case STRING.String():
+		arg.Type = STRING
+	case BOOL.String():
+		arg.Type = BOOL
+	default:
+		return arg, fmt.Errorf("ill. type \"%s\"", aSpec.Type)
+	}
+	// ----------
+	// string sub type:
+	switch aSpec.SubType {
+	case "", NORMAL.String():
+		arg.StringSubType = NORMAL
+	case ENTRY_TYPE.String():
+		arg.StringSubType = ENTRY_TYPE
+	case URL.String():
+		arg.StringSubType = URL
+	default:
+		return arg, fmt.Errorf("ill. subtype \"%s\"", aSpec.SubType)
+	}
+	// ----------
+	// values:
+	arg.Name = aSpec.Name
+	if nil != aSpec.Int {
+		arg.IntVal = *aSpec.Int
+	}
+	if nil != aSpec.String {
+		arg.StringVal = *aSpec.String
+	}
+	if nil != aSpec.Bool {
+		arg.BoolVal = *aSpec.Bool
+	}
+	// ----------
+	// kind specific fields:
+	switch arg.Kind {
+	case VAL, LABEL, VAR:
+	case FU:
+		found := false
+		for _, f := range SYSTEM_FUNCTIONS {
+			if f.String() == aSpec.Fu {
+				arg.FuName = f
+				found = true
+			}
+		}
+		if !found {
+			return arg, fmt.Errorf("ill. system function \"%s\"", aSpec.Fu)
+		}
+		if err := arg.FuName.CheckNArgs(len(aSpec.Args)); nil != err {
+			return arg, err
+		}
+		// the type defaults to the result type of the function (nb: for if() to INT)
+		if "" == aSpec.Type && IF_FUNCTION != arg.FuName {
+			_, arg.Type = arg.FuName.Signature(arg.Type, len(aSpec.Args))
+		}
+		for _, fuArgSpec := range aSpec.Args {
+			fuArg, err := fuArgSpec.toArg()
+			if nil != err {
+				return arg, err
+			}
+			arg.FuArgs = append(arg.FuArgs, fuArg)
+		}
+	case EXPR, DYN_ARRAY_REF, TYPED_ARRAY_LABEL, TYPED_ARRAY_VAL:
+		expr := Expr{Op: UNUSED}
+		if EXPR == arg.Kind {
+			found := false
+			for op, name := range OP_NAMES {
+				if name == aSpec.Op {
+					expr.Op = op
+					found = true
+				}
+			}
+			if !found {
+				return arg, fmt.Errorf("ill. op \"%s\"", aSpec.Op)
+			}
+		}
+		var err error
+		if expr.Left, err = aSpec.Left.toArg(); nil != err {
+			return arg, err
+		}
+		if expr.Right, err = aSpec.Right.toArg(); nil != err {
+			return arg, err
+		}
+		arg.ExprVal = &expr
+		// nb: the array ref keeps the label name in the string val
+		if DYN_ARRAY_REF == arg.Kind {
+			arg.StringVal = aSpec.Name
+			arg.Name = ""
+		}
+	default:
+		return arg, fmt.Errorf("ill. kind \"%s\"", aSpec.Kind)
+	}
+	return arg, nil
+}
+
+////////////////////////////////////////
+// peer space -> model file
+////////////////////////////////////////
+
+// ----------------------------------------
+// serialize the meta model, the entries of all PICs and POCs, the invariants and the properties
+// - dynamic wirings, go callback invariants and properties with such atoms are skipped
+func (ps *PeerSpace) ToModelFile(name string) *ModelFile {
+	mf := new(ModelFile)
+	mf.Name = name
+	mf.Peers = []PeerSpec{}
+	for _, pid := range ps.PeerPids {
+		p := ps.Peers[pid]
+		if nil == p {
+			continue
+		}
+		// ----------
+		// system peers: only their wiring properties are needed
+		if p.IsSysPeerFlag {
+			sp := SystemPeerSpec{Peer: p.Id}
+			for _, w := range p.Wirings {
+				sp.WProps = argsToSpec(Args(w.WProps))
+			}
+			mf.SystemPeers = append(mf.SystemPeers, sp)
+			continue
+		}
+		// ----------
+		// user peers:
+		pSpec := PeerSpec{Id: p.Id, Pic: coordinatorToSpec(p.PicCoordinator), Poc: coordinatorToSpec(p.PocCoordinator)}
+		for _, wid := range p.WiringWids {
+			w := p.Wirings[wid]
+			if nil == w || w.DynamicWiringFlag {
+				continue
+			}
+			pSpec.Wirings = append(pSpec.Wirings, w.toSpec(p))
+		}
+		mf.Peers = append(mf.Peers, pSpec)
+	}
+	// ----------
+	// entries of PICs and POCs:
+	for _, pid := range ps.PeerPids {
+		p := ps.Peers[pid]
+		if nil == p {
+			continue
+		}
+		for _, cName := range []string{PIC, POC} {
+			c := ps.Containers[fmt.Sprintf("%s%s%s", p.Id, SEP, cName)]
+			if nil == c {
+				continue
+			}
+			for _, e := range c.Entries {
+				eprops := Args{}
+				for label, arg := range e.EProps {
+					if TYPE != label {
+						eprops[label] = arg
+					}
+				}
+				mf.Entries = append(mf.Entries, EntrySpec{Peer: p.Id, Container: cName, Type: e.GetType(), EProps: argsToSpec(eprops)})
+			}
+		}
+	}
+	// ----------
+	// invariants:
+	for _, inv := range ps.Invariants {
+		if invSpec := inv.toSpec(); nil != invSpec {
+			mf.Invariants = append(mf.Invariants, *invSpec)
+		}
+	}
+	// ----------
+	// properties:
+	for _, prop := range ps.Properties {
+		if fSpec := prop.F.toSpec(); nil != fSpec {
+			mf.Properties = append(mf.Properties, PropertySpec{Name: prop.Name, Formula: fSpec})
+		}
+	}
+	// ----------
+	// observables:
+	for _, obs := range ps.Observables {
+		if obsSpec := obs.toSpec(); nil != obsSpec {
+			mf.Observables = append(mf.Observables, *obsSpec)
+		}
+	}
+	return mf
+}
+
+// ----------------------------------------
+// nil for go callbacks
+func (inv *Invariant) toSpec() *InvariantSpec {
+	if FU_INVARIANT == inv.Type {
+		return nil
+	}
+	qSpec := QuerySpec{Typ: argToSpec(inv.Q.Typ), Min: argToSpec(inv.Q.Min), Max: argToSpec(inv.Q.Max)}
+	if nil != inv.Q.Sel {
+		qSpec.Sel = argToSpec(*inv.Q.Sel)
+	}
+	return &InvariantSpec{Name: inv.Name, Type: inv.Type.String(), C: inv.Cid, Query: &qSpec}
+}
+
+// ----------------------------------------
+// nil for go callbacks
+func (obs *Observable) toSpec() *ObservableSpec {
+	if FU_OBSERVABLE == obs.Type {
+		return nil
+	}
+	qSpec := QuerySpec{Typ: argToSpec(obs.Q.Typ)}
+	if nil != obs.Q.Sel {
+		qSpec.Sel = argToSpec(*obs.Q.Sel)
+	}
+	return &ObservableSpec{Name: obs.Name, Type: obs.Type.String(), C: obs.Cid, Query: &qSpec}
+}
+
+// ----------------------------------------
+// nil if an atom is a go callback
+func (f *Formula) toSpec() *FormulaSpec {
+	fSpec := &FormulaSpec{Op: f.Op.String()}
+	if LTL_ATOM == f.Op {
+		if fSpec.Atom = f.Atom.toSpec(); nil == fSpec.Atom {
+			return nil
+		}
+		return fSpec
+	}
+	if fSpec.Left = f.Left.toSpec(); nil == fSpec.Left {
+		return nil
+	}
+	if nil != f.Right {
+		if fSpec.Right = f.Right.toSpec(); nil == fSpec.Right {
+			return nil
+		}
+	}
+	return fSpec
+}
+
+// ----------------------------------------
+// BAG -> nil
+func coordinatorToSpec(co Coordinator) *CoordinatorSpec {
+	if BAG == co.Type {
+		return nil
+	}
+	return &CoordinatorSpec{Coordinator: co.Type.String(), Label: co.Label}
+}
+
+// ----------------------------------------
+func (w *Wiring) toSpec(p *Peer) WiringSpec {
+	wSpec := WiringSpec{Id: strings.TrimPrefix(w.Id, p.Id+SEP)}
+	wSpec.WProps = argsToSpec(Args(w.WProps))
+	// ----------
+	// services, sorted by sid:
+	sids := []string{}
+	for sid := range w.ServiceWrappers {
+		sids = append(sids, sid)
+	}
+	sort.Strings(sids)
+	for _, sid := range sids {
+		wSpec.Services = append(wSpec.Services, ServiceSpec{Sid: sid, Name: w.ServiceWrappers[sid].Name})
+	}
+	// ----------
+	// links:
+	for _, l := range w.Links {
+		lSpec := LinkSpec{Type: l.Type.String(), SubPid: l.SubPid, C: l.modelC, Sid: l.Sid}
+		if GUARD == l.Type || ACTION == l.Type || SERVICE_IN == l.Type {
+			lSpec.Op = l.Op.String()
+		}
+		if !l.Q.IsEmpty() {
+			qSpec := QuerySpec{Typ: argToSpec(l.Q.Typ), Count: argToSpec(l.Q.Count)}
+			if nil != l.Q.Sel {
+				qSpec.Sel = argToSpec(*l.Q.Sel)
+			}
+			// min and max are only needed, if they cannot be derived from count
+			if l.Q.Count.IsEmpty() {
+				qSpec.Min = argToSpec(l.Q.Min)
+				qSpec.Max = argToSpec(l.Q.Max)
+			}
+			lSpec.Query = &qSpec
+		}
+		lSpec.LProps = argsToSpec(Args(l.LProps))
+		lSpec.EProps = argsToSpec(Args(l.EProps))
+		lSpec.Vars = argsToSpec(Args(l.LVars))
+		wSpec.Links = append(wSpec.Links, lSpec)
+	}
+	return wSpec
+}
+
+// ----------------------------------------
+func argsToSpec(args Args) ArgsSpec {
+	if 0 == len(args) {
+		return nil
+	}
+	argsSpec := ArgsSpec{}
+	for label, arg := range args {
+		argsSpec[label] = argToSpec(arg)
+	}
+	return argsSpec
+}
+
+// ----------------------------------------
+// empty arg yields nil
+func argToSpec(arg Arg) *ArgSpec {
+	if arg.IsEmpty() {
+		return nil
+	}
+	aSpec := new(ArgSpec)
+	aSpec.Kind = arg.Kind
+	aSpec.Type = arg.Type.String()
+	if NORMAL != arg.StringSubType {
+		aSpec.SubType = arg.StringSubType.String()
+	}
+	aSpec.Name = arg.Name
+	switch arg.Kind {
+	case VAL:
+		switch arg.Type {
+		case INT:
+			i := arg.IntVal
+			aSpec.Int = &i
+		case STRING:
+			s := arg.StringVal
+			aSpec.String = &s
+		case BOOL:
+			b := arg.BoolVal
+			aSpec.Bool = &b
+		}
+	case FU:
+		aSpec.Fu = arg.FuName.String()
+		for _, fuArg := range arg.FuArgs {
+			aSpec.Args = append(aSpec.Args, argToSpec(fuArg))
+		}
+	case EXPR, DYN_ARRAY_REF, TYPED_ARRAY_LABEL, TYPED_ARRAY_VAL:
+		if EXPR == arg.Kind {
+			aSpec.Op = OP_NAMES[arg.ExprVal.Op]
+		}
+		if DYN_ARRAY_REF == arg.Kind {
+			aSpec.Name = arg.StringVal
+		}
+		aSpec.Left = argToSpec(arg.ExprVal.Left)
+		aSpec.Right = argToSpec(arg.ExprVal.Right)
+	}
+	return aSpec
+}
+
+////////////////////////////////////////
+// json: basic values may be given as scalars
+////////////////////////////////////////
+
+// ----------------------------------------
+func (aSpec *ArgSpec) UnmarshalJSON(data []byte) error {
+	// object:
+	type argSpecAlias ArgSpec
+	if 0 < len(data) && '{' == data[0] {
+		return json.Unmarshal(data, (*argSpecAlias)(aSpec))
+	}
+	// scalar:
+	var v interface{}
+	if err := json.Unmarshal(data, &v); nil != err {
+		return err
+	}
+	*aSpec = ArgSpec{Kind: VAL}
+	switch x := v.(type) {
+	case float64:
+		i := int(x)
+		if float64(i) != x {
+			return fmt.Errorf("ill. arg value %v: only int is supported", x)
+		}
+		aSpec.Type = INT.String()
+		aSpec.Int = &i
+	case string:
+		aSpec.Type = STRING.String()
+		aSpec.String = &x
+	case bool:
+		aSpec.Type = BOOL.String()
+		aSpec.Bool = &x
+	default:
+		return fmt.Errorf("ill. arg value %s", string(data))
+	}
+	return nil
+}
+
+// ----------------------------------------
+func (aSpec ArgSpec) MarshalJSON() ([]byte, error) {
+	// scalar:
+	if VAL == aSpec.Kind && "" == aSpec.SubType {
+		switch {
+		case nil != aSpec.Int && INT.String() == aSpec.Type:
+			return json.Marshal(*aSpec.Int)
+		case nil != aSpec.String && STRING.String() == aSpec.Type:
+			return json.Marshal(*aSpec.String)
+		case nil != aSpec.Bool && BOOL.String() == aSpec.Type:
+			return json.Marshal(*aSpec.Bool)
+		}
+	}
+	// object:
+	type argSpecAlias ArgSpec
+	return json.Marshal(argSpecAlias(aSpec))
+}
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+func clearYamlStyle(node *yaml.Node) {
+	// nb: strings that would be read as another type (eg "1") are still quoted by the encoder
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYamlStyle(child)
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -0,0 +1,205 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the model file: load, save and the conversion into the peer space
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// the links of the only wiring of the peer
+func testLinks(t *testing.T, ps *PeerSpace, pid string) []*Link {
+	t.Helper()
+	p := ps.Peers[pid]
+	if nil == p || 1 != len(p.WiringWids) {
+		t.Fatalf("peer %s with one wiring expected", pid)
+	}
+	return p.Wirings[p.WiringWids[0]].Links
+}
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+func TestLoadModelFileYaml(t *testing.T) {
+	mf := loadTestModel(t, "m.yaml", TEST_MODEL_YAML)
+	if "test" != mf.Name || 1 != len(mf.Peers) || 1 != len(mf.Entries) || 1 != len(mf.SystemPeers) {
+		t.Fatalf("model not loaded: %+v", mf)
+	}
+	metaCtx, err := mf.NewMetaContext()
+	if nil != err {
+		t.Fatal(err)
+	}
+	ps := metaCtx.PeerSpace
+	if nil == ps.Peers["P1"] || nil == ps.Peers[STOP_PEER] {
+		t.Fatalf("peers P1 and %s expected, got %v", STOP_PEER, ps.PeerPids)
+	}
+	if FIFO != ps.Peers["P1"].PocCoordinator.Type || BAG != ps.Peers["P1"].PicCoordinator.Type {
+		t.Fatalf("fifo POC and bag PIC expected")
+	}
+	links := testLinks(t, ps, "P1")
+	if 2 != len(links) || GUARD != links[0].Type || TAKE != links[0].Op || ACTION != links[1].Type || WRITE != links[1].Op {
+		t.Fatalf("guard take and action write expected, got %d links", len(links))
+	}
+}
+
+// ----------------------------------------
+// the json of a loaded yaml model is the same model
+func TestSaveLoadRoundTrip(t *testing.T) {
+	mf := loadTestModel(t, "m.yaml", TEST_MODEL_YAML)
+	want, _ := json.Marshal(mf)
+	for _, name := range []string{"m.json", "m.yml"} {
+		path := writeTestFile(t, name, "")
+		if err := mf.Save(path); nil != err {
+			t.Fatalf("%s: %s", name, err)
+		}
+		mf2, err := LoadModelFile(path)
+		if nil != err {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if got, _ := json.Marshal(mf2); string(want) != string(got) {
+			t.Errorf("%s: round trip changed the model:\n%s\n%s", name, want, got)
+		}
+	}
+	// - and the peer space written back gives the same links
+	metaCtx, err := mf.NewMetaContext()
+	if nil != err {
+		t.Fatal(err)
+	}
+	mf3 := metaCtx.PeerSpace.ToModelFile("test")
+	metaCtx3, err := mf3.NewMetaContext()
+	if nil != err {
+		t.Fatal(err)
+	}
+	links, links3 := testLinks(t, metaCtx.PeerSpace, "P1"), testLinks(t, metaCtx3.PeerSpace, "P1")
+	for i := range links {
+		if links[i].Fingerprint() != links3[i].Fingerprint() {
+			t.Errorf("link %d: %s expected, got %s", i, links[i].Fingerprint(), links3[i].Fingerprint())
+		}
+	}
+}
+
+// ----------------------------------------
+func TestLinkTypeAndOpCaseInsensitive(t *testing.T) {
+	for _, spec := range []LinkSpec{
+		{Type: "GUARD", C: "PIC", Op: "TAKE"},
+		{Type: "Guard", C: "PIC", Op: "Take"},
+		{Type: "guard", C: "PIC", Op: "take"},
+	} {
+		l, err := spec.toLink()
+		if nil != err {
+			t.Fatalf("%s %s: %s", spec.Type, spec.Op, err)
+		}
+		if GUARD != l.Type || TAKE != l.Op {
+			t.Fatalf("%s %s: guard take expected, got %s %s", spec.Type, spec.Op, l.Type, l.Op)
+		}
+	}
+	// - the op of a sout is not needed
+	if l, err := (&LinkSpec{Type: "SOUT", Sid: "S1"}).toLink(); nil != err || SERVICE_OUT != l.Type {
+		t.Fatalf("sout expected: %v", err)
+	}
+}
+
+// ----------------------------------------
+// the error lists the valid values
+func TestLinkTypeAndOpErrors(t *testing.T) {
+	_, err := (&LinkSpec{Type: "gard", C: "PIC", Op: "take"}).toLink()
+	if nil == err || !strings.Contains(err.Error(), "gard") || !strings.Contains(err.Error(), "guard, action, sin, sout, service") {
+		t.Fatalf("link type error with the valid types expected, got %v", err)
+	}
+	_, err = (&LinkSpec{Type: "action", C: "POC", Op: "put"}).toLink()
+	if nil == err || !strings.Contains(err.Error(), "put") || !strings.Contains(err.Error(), "write") || !strings.Contains(err.Error(), "take") {
+		t.Fatalf("space op error with the valid ops expected, got %v", err)
+	}
+}
+
+// ----------------------------------------
+func TestLoadModelFileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		data  string
+		parts []string
+	}{
+		{"file type", "m.txt", TEST_MODEL_YAML, []string{"unknown file type"}},
+		{"yaml syntax", "m.yaml", "peers: [", []string{"m.yaml"}},
+		{"json syntax", "m.json", `{"peers": 1}`, []string{"m.json"}},
+	}
+	for _, test := range tests {
+		_, err := LoadModelFile(writeTestFile(t, test.file, test.data))
+		if nil == err {
+			t.Errorf("%s: error expected", test.name)
+			continue
+		}
+		for _, part := range test.parts {
+			if !strings.Contains(err.Error(), part) {
+				t.Errorf("%s: error %q does not mention %q", test.name, err, part)
+			}
+		}
+	}
+}
+
+// ----------------------------------------
+func TestNewMetaContextErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		parts []string
+	}{
+		{"peer twice", "peers: [{id: P1}, {id: P1}]", []string{"P1", "twice"}},
+		{"system peer", "system_peers: [{peer: Start}]\npeers: []", []string{"Start"}},
+		{"coordinator label", "peers: [{id: P1, pic: {coordinator: priority}}]", []string{"P1", "label"}},
+		{"coordinator", "peers: [{id: P1, poc: {coordinator: heap}}]", []string{"heap"}},
+		{"link type", "peers: [{id: P1, wirings: [{id: W1, links: [{type: guards, c: PIC, op: take}]}]}]", []string{"W1", "link 0", "guards"}},
+		{"service", "peers: [{id: P1, wirings: [{id: W1, services: [{sid: S1, name: noSuchService}]}]}]", []string{"noSuchService"}},
+	}
+	for _, test := range tests {
+		mf := loadTestModel(t, "m.yaml", test.data)
+		_, err := mf.NewMetaContext()
+		if nil == err {
+			t.Errorf("%s: error expected", test.name)
+			continue
+		}
+		for _, part := range test.parts {
+			if !strings.Contains(err.Error(), part) {
+				t.Errorf("%s: error %q does not mention %q", test.name, err, part)
+			}
+		}
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -0,0 +1,170 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// observables of a run: one number per run, aggregated over the simulation runs (see framework: runStatistics)
+// - the query selects entries by type and selector (count, min and max are ignored)
+// -- FINAL_COUNT: number of entries in a container at the end of the run (WILDCARD = all PICs and POCs, summed up)
+// -- FIRST_NONEMPTY: clock when the container first held such an entry; undefined if it never did
+// -- FU: go callback, evaluated at the end of the run
+// - updated by the controller after every critical section (see MetaContext.Observe)
+// -- nb: the times are kept in the peer space, ie they are per run; they are not part of a snapshot, so a
+//    restored run observes from the snapshot on
+// - nb: entries written by a not yet committed tx are not counted (like for invariants)
+// - examples:
+// -- "tokens left in P1_POC": NewFinalCountObservable("tokensLeft", "P1_POC", Query{Typ: SEtype("token")})
+// -- "time of the first result": NewFirstNonEmptyObservable("firstResult", "P2_POC", Query{Typ: SEtype("result")})
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/scheduler"
+	"fmt"
+	"math"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type ObservableTypeEnum int
+
+// ----------------------------------------
+const (
+	FINAL_COUNT_OBSERVABLE ObservableTypeEnum = iota
+	FIRST_NONEMPTY_OBSERVABLE
+	FU_OBSERVABLE
+)
+
+// ----------------------------------------
+// go callback: returns the value of the observable at the end of the run; NaN = undefined
+type ObservableFuType func(ps *PeerSpace) float64
+
+// ----------------------------------------
+type Observable struct {
+	Name string
+	Type ObservableTypeEnum
+	// container for FINAL_COUNT and FIRST_NONEMPTY; WILDCARD = all PICs and POCs
+	Cid string
+	// entry type and selector
+	Q Query
+	// callback for FU
+	Fu ObservableFuType
+}
+
+// ----------------------------------------
+type Observables []*Observable
+
+////////////////////////////////////////
+// constructors
+////////////////////////////////////////
+
+// ----------------------------------------
+func NewFinalCountObservable(name string, cid string, q Query) *Observable {
+	obs := new(Observable)
+	obs.Name = name
+	obs.Type = FINAL_COUNT_OBSERVABLE
+	obs.Cid = cid
+	obs.Q = q
+	return obs
+}
+
+// ----------------------------------------
+func NewFirstNonEmptyObservable(name string, cid string, q Query) *Observable {
+	obs := new(Observable)
+	obs.Name = name
+	obs.Type = FIRST_NONEMPTY_OBSERVABLE
+	obs.Cid = cid
+	obs.Q = q
+	return obs
+}
+
+// ----------------------------------------
+func NewFuObservable(name string, fu ObservableFuType) *Observable {
+	obs := new(Observable)
+	obs.Name = name
+	obs.Type = FU_OBSERVABLE
+	obs.Fu = fu
+	return obs
+}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// update the observable after a critical section
+func (obs *Observable) Observe(ps *PeerSpace) {
+	if FIRST_NONEMPTY_OBSERVABLE != obs.Type {
+		return
+	}
+	if _, ok := ps.ObservedTimes[obs.Name]; ok {
+		return
+	}
+	if 0 < countEntriesInCid(ps, obs.Cid, &obs.Q) {
+		ps.ObservedTimes[obs.Name] = CLOCK
+	}
+}
+
+// ----------------------------------------
+// value of the observable at the end of the run; NaN = undefined
+func (obs *Observable) Value(ps *PeerSpace) float64 {
+	switch obs.Type {
+	case FINAL_COUNT_OBSERVABLE:
+		return float64(countEntriesInCid(ps, obs.Cid, &obs.Q))
+	case FIRST_NONEMPTY_OBSERVABLE:
+		if t, ok := ps.ObservedTimes[obs.Name]; ok {
+			return float64(t)
+		}
+		return math.NaN()
+	case FU_OBSERVABLE:
+		if nil == obs.Fu {
+			ModelPanic(fmt.Sprintf("observable %s: callback is missing", obs.Name))
+		}
+		return obs.Fu(ps)
+	default:
+		Panic(fmt.Sprintf("observable %s: ill. observable type", obs.Name))
+		return math.NaN()
+	}
+}
+
+// ----------------------------------------
+func (t ObservableTypeEnum) String() string {
+	switch t {
+	case FINAL_COUNT_OBSERVABLE:
+		return "final_count"
+	case FIRST_NONEMPTY_OBSERVABLE:
+		return "first_nonempty"
+	case FU_OBSERVABLE:
+		return "fu"
+	default:
+		return "ill. observable type"
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -0,0 +1,238 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// optimistic concurrency control (wiring property txcc = occ)
+// - read, take: the entries are not locked, but remembered with their version in the read set of the tx
+// -- taken entries stay in their container until commit; the tx itself does not select them again
+// - write: as with pcc, the entries are written with a WRITE-lock of the tx, ie only the tx sees them
+// - commit: validation of the read set; every entry must
+// -- still be in its container with the same version (every write gives an entry a new version,
+//    see Container.WriteEntryPtr -> also an entry that was taken and written back is recognized)
+// -- not be DELETE- or WRITE-locked by another (pcc) tx; a taken entry must not be READ-locked either
+// - validation ok: taken entries are removed, the WRITE-locks of written entries are removed
+// - conflict: written entries are removed, the tx is rolled back and the wiring raises a tx conflict
+//   exception -> the wiring instance is repeated (see wiring property repeat_count)
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/helpers"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------
+// entry in the read set of a tx
+type OccRead struct {
+	Cid     string
+	Eid     string
+	Version int
+	// TAKE or DELETE: entry is removed at commit
+	DeleteFlag bool
+}
+
+type OccReads []OccRead
+
+type Occ struct {
+	ReadSet OccReads
+	// containers into which the tx has written
+	WrittenCids Strings
+}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+//------------------------------------------------------------
+// deep copy
+func (occ Occ) Copy() Occ {
+	//------------------------------------------------------------
+	// alloc
+	newOcc := Occ{}
+	//------------------------------------------------------------
+	// copy all fields:
+	// - ReadSet:
+	newOcc.ReadSet = append(OccReads{}, occ.ReadSet...)
+	// - WrittenCids:
+	newOcc.WrittenCids = occ.WrittenCids.Copy()
+	//------------------------------------------------------------
+	// return
+	return newOcc
+}
+
+// ----------------------------------------
+// add the entries read from cid to the read set
+func (occ *Occ) AddReads(cid string, es EntryPtrs, deleteFlag bool) {
+	for _, e := range es {
+		occ.ReadSet = append(occ.ReadSet, OccRead{Cid: cid, Eid: e.Id, Version: e.Version, DeleteFlag: deleteFlag})
+	}
+}
+
+// ----------------------------------------
+// undo read: remove the entries read from cid from the read set
+func (occ *Occ) RemoveReads(cid string, es EntryPtrs) {
+	for _, e := range es {
+		for i, r := range occ.ReadSet {
+			if cid == r.Cid && e.Id == r.Eid {
+				occ.ReadSet = append(occ.ReadSet[:i], occ.ReadSet[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// ----------------------------------------
+// has the tx taken (or deleted) this version of the entry in cid?
+func (occ *Occ) IsTaken(cid string, e *Entry) bool {
+	for _, r := range occ.ReadSet {
+		if r.DeleteFlag && cid == r.Cid && e.Id == r.Eid && e.Version == r.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// ----------------------------------------
+func (occ *Occ) AddWrittenCid(cid string) {
+	for _, writtenCid := range occ.WrittenCids {
+		if cid == writtenCid {
+			return
+		}
+	}
+	occ.WrittenCids = append(occ.WrittenCids, cid)
+}
+
+// ----------------------------------------
+// all containers that are changed by the commit: with taken entries or written entries
+func (occ *Occ) ChangedCids() Strings {
+	cids := Strings{}
+	for _, r := range occ.ReadSet {
+		if r.DeleteFlag {
+			cids = append(cids.RemoveString(r.Cid), r.Cid)
+		}
+	}
+	for _, cid := range occ.WrittenCids {
+		cids = append(cids.RemoveString(cid), cid)
+	}
+	return cids
+}
+
+// ----------------------------------------
+// forget read set and written cids
+func (occ *Occ) Clear() {
+	occ.ReadSet = OccReads{}
+	occ.WrittenCids = Strings{}
+}
+
+// ----------------------------------------
+// validate the read set of the tx against the current peer space; returns the first conflict
+func (ps *PeerSpace) OccValidate(tx *Tx) error {
+	for _, r := range tx.Occ.ReadSet {
+		c := ps.Containers[r.Cid]
+		if nil == c {
+			return fmt.Errorf("OCC: ill. cid=%s", r.Cid)
+		}
+		e := c.occEntry(r.Eid, r.Version)
+		if nil == e {
+			return fmt.Errorf("OCC: entry %s in %s has been taken or changed by another tx", r.Eid, r.Cid)
+		}
+		if e.WriteLockedByOtherTxOrDeleteLocked(tx.Id) || (r.DeleteFlag && e.ReadLockedByOtherTx(tx.Id)) {
+			return fmt.Errorf("OCC: entry %s in %s is locked by another tx", r.Eid, r.Cid)
+		}
+	}
+	return nil
+}
+
+// ----------------------------------------
+// remove all entries written by the tx (they are WRITE-locked by it)
+func (ps *PeerSpace) OccRemoveWrittenEntries(tx *Tx) {
+	for _, cid := range tx.Occ.WrittenCids {
+		c := ps.Containers[cid]
+		if nil == c {
+			SystemError(fmt.Sprintf("OCC: ill. cid=%s", cid))
+			continue
+		}
+		tmpEs := Entries{}
+		for _, e := range c.Entries {
+			if 0 == e.WLocks[tx.Id] {
+				tmpEs = append(tmpEs, e)
+			}
+		}
+		c.SetEntries(tmpEs)
+	}
+}
+
+// ----------------------------------------
+// canonical description of the read set for model checking
+// - without eids and versions: only whether each read entry is still valid
+func (ps *PeerSpace) OccFingerprint(tx *Tx) string {
+	fps := []string{}
+	for _, r := range tx.Occ.ReadSet {
+		valid := false
+		if c := ps.Containers[r.Cid]; nil != c {
+			valid = nil != c.occEntry(r.Eid, r.Version)
+		}
+		fps = append(fps, fmt.Sprintf("%s/%t/%t", r.Cid, r.DeleteFlag, valid))
+	}
+	sort.Strings(fps)
+	return fmt.Sprintf("[%s]", strings.Join(fps, " "))
+}
+
+// ----------------------------------------
+// private fu:
+// the entry with the given id and version; nil if not found
+func (c *Container) occEntry(eid string, version int) *Entry {
+	for i := range c.Entries {
+		if eid == c.Entries[i].Id && version == c.Entries[i].Version {
+			return &c.Entries[i]
+		}
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// debug
+////////////////////////////////////////
+
+// ----------------------------------------
+func (occ Occ) ToString(tab int) string {
+	s := NBlanksToString("", tab)
+	reads := []string{}
+	for _, r := range occ.ReadSet {
+		op := "r"
+		if r.DeleteFlag {
+			op = "t"
+		}
+		reads = append(reads, fmt.Sprintf("%s:%s@%s#%d", op, r.Eid, r.Cid, r.Version))
+	}
+	s = fmt.Sprintf("%s<ReadSet=[%s], WrittenCids=%s>", s, strings.Join(reads, " "), occ.WrittenCids.ToString(0))
+	return s
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -0,0 +1,159 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the validation, the undo and the fingerprint of occ txs
+// - the read sets are built directly, as the occ automata do it
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// peer space with P1_PIC (entries A1 and A2) and the empty P1_POC
+func newOccTestSpace() (*PeerSpace, *Container, *Container) {
+	ps := NewPeerSpace()
+	pic := NewContainer("P1_PIC")
+	poc := NewContainer("P1_POC")
+	ps.AddContainer(pic)
+	ps.AddContainer(poc)
+	writeTestEntry(pic, "A", 1, "", nil)
+	writeTestEntry(pic, "A", 2, "", nil)
+	return ps, pic, poc
+}
+
+// ----------------------------------------
+func newOccTestTx(txid string) *Tx {
+	return &Tx{Id: txid, State: RUNNING, Txcc: OCC}
+}
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+// the tx has read (or taken) the 1st entry of PIC; then the space is changed by others
+func TestOccValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		deleteFlag bool
+		change     func(ps *PeerSpace, pic *Container, e *Entry)
+		// "" ... valid
+		expected string
+	}{
+		{"unchanged", true, func(ps *PeerSpace, pic *Container, e *Entry) {}, ""},
+		{"other entry taken", true, func(ps *PeerSpace, pic *Container, e *Entry) { pic.RemoveEntry(pic.Entries[1].Id) }, ""},
+		{"version changed", false, func(ps *PeerSpace, pic *Container, e *Entry) { pic.WriteEntryPtr(pic.RemoveEntry(e.Id)) },
+			"has been taken or changed by another tx"},
+		{"taken by another tx", true, func(ps *PeerSpace, pic *Container, e *Entry) { pic.RemoveEntry(e.Id) },
+			"has been taken or changed by another tx"},
+		{"write-locked by another tx", false, func(ps *PeerSpace, pic *Container, e *Entry) { e.AddLock(WRITE, "tx2") },
+			"is locked by another tx"},
+		{"write-locked by the tx", true, func(ps *PeerSpace, pic *Container, e *Entry) { e.AddLock(WRITE, "tx1") }, ""},
+		{"delete-locked by another tx", false, func(ps *PeerSpace, pic *Container, e *Entry) { e.AddLock(DELETE, "tx2") },
+			"is locked by another tx"},
+		{"read-locked by another tx: read", false, func(ps *PeerSpace, pic *Container, e *Entry) { e.AddLock(READ, "tx2") }, ""},
+		{"read-locked by another tx: taken", true, func(ps *PeerSpace, pic *Container, e *Entry) { e.AddLock(READ, "tx2") },
+			"is locked by another tx"},
+		{"container removed", false, func(ps *PeerSpace, pic *Container, e *Entry) { delete(ps.Containers, pic.Id) }, "ill. cid=P1_PIC"},
+	}
+	for _, test := range tests {
+		ps, pic, _ := newOccTestSpace()
+		tx := newOccTestTx("tx1")
+		e := &pic.Entries[0]
+		tx.Occ.AddReads(pic.Id, EntryPtrs{e}, test.deleteFlag)
+		test.change(ps, pic, e)
+		err := ps.OccValidate(tx)
+		switch {
+		case "" == test.expected && nil != err:
+			t.Errorf("%s: valid expected, got %s", test.name, err)
+		case "" != test.expected && (nil == err || !strings.Contains(err.Error(), test.expected)):
+			t.Errorf("%s: %q expected, got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+// ----------------------------------------
+// only the entries that the tx has written into its written containers are removed
+func TestOccRemoveWrittenEntries(t *testing.T) {
+	ps, pic, poc := newOccTestSpace()
+	tx := newOccTestTx("tx1")
+	writeTestEntry(poc, "B", 1, "tx1", nil)
+	writeTestEntry(poc, "B", 2, "tx2", nil)
+	writeTestEntry(poc, "B", 3, "", nil)
+	writeTestEntry(poc, "B", 4, "tx1", nil)
+	tx.Occ.AddWrittenCid(poc.Id)
+	// - not written by the tx: not searched
+	writeTestEntry(pic, "B", 5, "tx1", nil)
+	ps.OccRemoveWrittenEntries(tx)
+	if got := entryNumbers(poc); "B2 B3" != got {
+		t.Errorf("P1_POC: B2 B3 expected, got %s", got)
+	}
+	if got := entryNumbers(pic); "A1 A2 B5" != got {
+		t.Errorf("P1_PIC: A1 A2 B5 expected, got %s", got)
+	}
+	if i := poc.SelectEntryIndex(nil, "B", nil); -1 == i || 2 != poc.Entries[i].EProps.GetIntVal("n") {
+		t.Errorf("P1_POC: B2 must be selectable after the removal")
+	}
+}
+
+// ----------------------------------------
+// the fingerprint describes the read set without eids and versions, but with its validity
+func TestOccFingerprint(t *testing.T) {
+	ps, pic, _ := newOccTestSpace()
+	tx1 := newOccTestTx("tx1")
+	tx1.Occ.AddReads(pic.Id, EntryPtrs{&pic.Entries[0]}, true)
+	tx1.Occ.AddReads(pic.Id, EntryPtrs{&pic.Entries[1]}, false)
+	// - the same reads of other entries, in the other order
+	tx2 := newOccTestTx("tx2")
+	tx2.Occ.AddReads(pic.Id, EntryPtrs{&pic.Entries[0]}, false)
+	tx2.Occ.AddReads(pic.Id, EntryPtrs{&pic.Entries[1]}, true)
+	expected := "[P1_PIC/false/true P1_PIC/true/true]"
+	if got := ps.OccFingerprint(tx1); expected != got {
+		t.Errorf("tx1: %s expected, got %s", expected, got)
+	}
+	if got := ps.OccFingerprint(tx2); expected != got {
+		t.Errorf("tx2: %s expected, got %s", expected, got)
+	}
+	// - the entry taken by tx1 is changed by another tx: the read of it is not valid any more
+	pic.WriteEntryPtr(pic.RemoveEntry(pic.Entries[0].Id))
+	if got, expected := ps.OccFingerprint(tx1), "[P1_PIC/false/true P1_PIC/true/false]"; expected != got {
+		t.Errorf("tx1 after the change: %s expected, got %s", expected, got)
+	}
+	// - no reads
+	if got := ps.OccFingerprint(newOccTestTx("tx3")); "[]" != got {
+		t.Errorf("no reads: [] expected, got %s", got)
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -34,8 +34,11 @@ type Peer struct {
 	Poc           string
 	Wirings       map[string]*Wiring
 	IsSysPeerFlag bool
-	// for debug only
+	// for debug only; and the order in which the wiring machines are started
 	WiringWids Strings
+	// coordinators of PIC and POC (see coordinator.go); default BAG
+	PicCoordinator Coordinator
+	PocCoordinator Coordinator
 }
 
 ////////////////////////////////////////
@@ -85,6 +88,9 @@ func (p *Peer) Copy() *Peer {
 	newP.WiringWids = p.WiringWids.Copy()
 	// - IsSysPeerFlag
 	newP.IsSysPeerFlag = p.IsSysPeerFlag
+	// - PicCoordinator, PocCoordinator:
+	newP.PicCoordinator = p.PicCoordinator
+	newP.PocCoordinator = p.PocCoordinator
 	//------------------------------------------------------------
 	// return
 	return newP
@@ -181,6 +187,13 @@ func (p *Peer) RemoveWiring(w *Wiring) {
 	delete(p.Wirings, w.Id)
 }
 
+// --------------------------------------------
+// model checking: an alias into the meta model in the LVS of a machine is recomputed from
+// - its context at choice point recovery -> not part of the state
+func (p *Peer) Fingerprint() string {
+	return "-"
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
@@ -28,12 +28,14 @@ import (
 	. "github.com/peermodel/simulator/config"
 	. "github.com/peermodel/simulator/debug"
 	. "github.com/peermodel/simulator/eventInterface"
+	. "github.com/peermodel/simulator/eventLog"
 	. "github.com/peermodel/simulator/helpers"
 	. "github.com/peermodel/simulator/latex"
 	. "github.com/peermodel/simulator/scheduler"
 	. "github.com/peermodel/simulator/slotInterface"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -47,8 +49,21 @@ type PeerSpace struct {
 	Containers map[string]*Container
 	//------------------------------------------------------------
 	// for debug only: is needed to be able to print containers always in the same order...
+	// - PeerPids: also the order in which the wiring machines are started
 	PeerPids      Strings
 	ContainerCids Strings
+	//------------------------------------------------------------
+	// invariants that must hold after every critical section
+	// - nb: they do not change at runtime and are therefore shared by all copies
+	Invariants Invariants
+	//------------------------------------------------------------
+	// temporal properties checked by the model checker along each path (shared, like invariants)
+	Properties Properties
+	//------------------------------------------------------------
+	// observables of the simulation runs (shared, like invariants)
+	Observables Observables
+	// - times observed in this run (see FIRST_NONEMPTY_OBSERVABLE); key = name of the observable
+	ObservedTimes map[string]int
 }
 
 ////////////////////////////////////////
@@ -59,6 +74,7 @@ func NewPeerSpace() *PeerSpace {
 	ps := new(PeerSpace)
 	ps.Peers = make(map[string]*Peer)
 	ps.Containers = make(map[string]*Container)
+	ps.ObservedTimes = make(map[string]int)
 	return ps
 }
 
@@ -90,10 +106,105 @@ func (ps *PeerSpace) Copy() *PeerSpace {
 	// - ContainerCids:
 	newPS.ContainerCids = ps.ContainerCids.Copy()
 	//------------------------------------------------------------
+	// - Invariants (share):
+	newPS.Invariants = ps.Invariants
+	// - Properties (share):
+	newPS.Properties = ps.Properties
+	// - Observables (share):
+	newPS.Observables = ps.Observables
+	// - ObservedTimes:
+	for name, t := range ps.ObservedTimes {
+		newPS.ObservedTimes[name] = t
+	}
+	//------------------------------------------------------------
 	// return
 	return newPS
 }
 
+// ----------------------------------------
+// caution: the name must be unique
+func (ps *PeerSpace) AddInvariant(inv *Invariant) {
+	for _, other := range ps.Invariants {
+		if other.Name == inv.Name {
+			ModelPanic(fmt.Sprintf("invariant %s: defined twice", inv.Name))
+		}
+	}
+	ps.Invariants = append(ps.Invariants, inv)
+}
+
+// ----------------------------------------
+// check all invariants; returns the first violation (or nil)
+func (ps *PeerSpace) CheckInvariants() error {
+	for _, inv := range ps.Invariants {
+		if ok, msg := inv.Check(ps); !ok {
+			return fmt.Errorf("invariant %s violated: %s", inv.Name, msg)
+		}
+	}
+	return nil
+}
+
+// ----------------------------------------
+// caution: the name must be unique
+func (ps *PeerSpace) AddProperty(prop *Property) {
+	for _, other := range ps.Properties {
+		if other.Name == prop.Name {
+			ModelPanic(fmt.Sprintf("property %s: defined twice", prop.Name))
+		}
+	}
+	ps.Properties = append(ps.Properties, prop)
+}
+
+// ----------------------------------------
+// values of the atoms of all properties (concatenated in the order of the properties)
+// - nil if there are no properties
+func (ps *PeerSpace) PropertyValuation() []bool {
+	if 0 == len(ps.Properties) {
+		return nil
+	}
+	vals := []bool{}
+	for _, prop := range ps.Properties {
+		vals = append(vals, prop.Valuation(ps)...)
+	}
+	return vals
+}
+
+// ----------------------------------------
+// caution: the name must be unique
+func (ps *PeerSpace) AddObservable(obs *Observable) {
+	for _, other := range ps.Observables {
+		if other.Name == obs.Name {
+			ModelPanic(fmt.Sprintf("observable %s: defined twice", obs.Name))
+		}
+	}
+	ps.Observables = append(ps.Observables, obs)
+}
+
+// ----------------------------------------
+// update all observables after a critical section
+func (ps *PeerSpace) Observe() {
+	for _, obs := range ps.Observables {
+		obs.Observe(ps)
+	}
+}
+
+// ----------------------------------------
+// check all properties along a word of valuations (see PropertyValuation); returns the first violation (or nil)
+// - loopStart: index where the word loops back to after its last valuation; -1 = finite word
+func (ps *PeerSpace) CheckProperties(word [][]bool, loopStart int) error {
+	offset := 0
+	for _, prop := range ps.Properties {
+		propWord := make([][]bool, len(word))
+		for i, vals := range word {
+			propWord[i] = vals[offset : offset+len(prop.atoms)]
+		}
+		offset += len(prop.atoms)
+		if !prop.Holds(propWord, loopStart) {
+			return fmt.Errorf("property %s violated: %s", prop.Name, prop.F.ToString())
+		}
+	}
+	return nil
+}
+
 // ----------------------------------------
 func (ps *PeerSpace) AddPeer(p *Peer) {
 	ps.Peers[p.Id] = p
@@ -108,6 +219,47 @@ func (ps *PeerSpace) AddContainer(c *Container) {
 	ps.ContainerCids = ps.ContainerCids.SortedInsertString(c.Id)
 }
 
+// ----------------------------------------
+// canonical description of the space contents for model checking:
+// - all containers (sorted by cid) with their entries, but without volatile entry ids
+// - per peer the number of its wirings (dynamic wirings have volatile ids)
+func (ps *PeerSpace) StateFingerprint() string {
+	var sb strings.Builder
+	cids := make([]string, 0, len(ps.Containers))
+	for cid := range ps.Containers {
+		cids = append(cids, cid)
+	}
+	sort.Strings(cids)
+	for _, cid := range cids {
+		sb.WriteString(ps.Containers[cid].Fingerprint())
+		sb.WriteString("\n")
+	}
+	pids := make([]string, 0, len(ps.Peers))
+	for pid := range ps.Peers {
+		pids = append(pids, pid)
+	}
+	sort.Strings(pids)
+	for _, pid := range pids {
+		sb.WriteString(fmt.Sprintf("%s:%d\n", pid, len(ps.Peers[pid].Wirings)))
+	}
+	return sb.String()
+}
+
+// ----------------------------------------
+// has the goal been reached, ie does one of the goal containers (see config) hold a committed entry?
+func (ps *PeerSpace) GoalReached() bool {
+	for _, cid := range GOAL_CONTAINERS {
+		if c := ps.Containers[cid]; nil != c {
+			for _, e := range c.Entries {
+				if 0 == len(e.WLocks) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // =========================================================
 // user space API
 // =========================================================
@@ -177,7 +329,7 @@ func (ps *PeerSpace) doWrite(cid string, e *Entry, vars Vars, raiseContainerChan
 	}
 	// ----------
 	// write:
-	c.Entries = append(c.Entries, *e)
+	c.WriteEntryPtr(e)
 	// ----------
 	// raise container change event:
 	if raiseContainerChangeEventFlag {
@@ -198,6 +350,7 @@ func (ps *PeerSpace) doWrite(cid string, e *Entry, vars Vars, raiseContainerChan
 // trigger a new tts/ttl slot in the scheduler for emitted entry
 func (ps *PeerSpace) Write(cid string, e *Entry, vars Vars, scheduler *Scheduler) {
 	ps.doWrite(cid, e, vars, true /* raiseContainerChangeEventFlag */, true /* informSchedulerFlag */, scheduler)
+	logWrite(LOG_WRITE, cid, e)
 }
 
 // ----------------------------------------
@@ -207,6 +360,7 @@ func (ps *PeerSpace) Write(cid string, e *Entry, vars Vars, scheduler *Scheduler
 //     does not trigger a new tts/ttl slot in the scheduler for emitted entry
 func (ps *PeerSpace) Emit(cid string, e *Entry, vars Vars, scheduler *Scheduler) {
 	ps.doWrite(cid, e, vars, false /* raiseContainerChangeEventFlag */, false /* informSchedulerFlag */, scheduler)
+	logWrite(LOG_EMIT, cid, e)
 }
 
 ////////////////////////////////////////
@@ -385,6 +539,7 @@ func (ps *PeerSpace) EntryHunter(eid string, scheduler *Scheduler) {
 func RaiseExceptionForOutdatedEntry(ps *PeerSpace, eid string, foundC *Container, pocCid string, scheduler *Scheduler) {
 	// remove entry from container
 	e := foundC.RemoveEntry(eid)
+	logEntryException(foundC.Id, eid)
 
 	// /**/ SystemInfo(fmt.Sprintf("@@@DEBUG: raise exception for outdated entry = %s", e.ToString(0)))
 	if SCHEDULER_TRACE.DoTrace() {
@@ -403,59 +558,12 @@ func RaiseExceptionForOutdatedEntry(ps *PeerSpace, eid string, foundC *Container
 	return
 }
 
-// =========================================================
-// check plausibility of the meta model:
-// - there must be at least one guard link
-// -- tbd: subconditions @@@ zb nur lesen alleine reicht nicht, wenn zb kein
-// --      take aus dem selben container erfolgt etc.
-// --      oder: 1. guard must ein take sein (tbd)
-// - last link must have a commit
-// - ttl on optional link must be 0
-// - ttl on noop link must be 0
-// testCaseName serves only for docu
-// raises user error
-// @@@ TBD:
-// - wiring tts must not be infinite
-func (ps *PeerSpace) MetaModelCheck(testCaseName string) {
-	// prefix for user error:
-	preString := fmt.Sprintf("%s: Meta Model Check: ", testCaseName)
-	// check all peers:
-	for _, p := range ps.Peers {
-		// check all wirings:
-		for wid, w := range p.Wirings {
-			// is there at least one guard link?
-			// @@@ tbd: subconditions
-			firstGuardIndex := -1
-			for i, l := range w.Links {
-				if -1 == firstGuardIndex && GUARD == l.Type {
-					firstGuardIndex = i
-				}
-
-				// check if ttl on optional link is 0:
-				if !l.GetMandatory(nil /* ctx */) && 0 != l.GetTtl(nil /* ctx */) {
-					Panic(fmt.Sprintf("%s%s, LinkNo=%d: ttl on non mandatory link must be 0", preString, wid, i))
-				}
-				// check if ttl on noop link is 0:
-				if NOOP == l.Op && 0 != l.GetTtl(nil /* ctx */) {
-					Panic(fmt.Sprintf("%s%s, LinkNo=%d: ttl on NOOP link must be 0", preString, wid, i))
-				}
-			}
-			// check if there is at least one guard link:
-			if -1 == firstGuardIndex {
-				Panic(fmt.Sprintf("%s%s: no guard link", preString, wid))
-			}
-			// check if last link has commit set:
-			if false == w.Links[len(w.Links)-1].GetCommit(nil /* ctx */) {
-			}
-		}
-	}
-}
-
 //------------------------------------------------------------
 // process a ripe pm slot
 // - currently only for entry ttl expired an actions is performed
 func (ps *PeerSpace) ProcessRipePMSlot(userSlot ISlot, scheduler *Scheduler) {
 	pmSlot := userSlot.(*PMSlot)
+	logPMSlot(pmSlot)
 	switch pmSlot.Type {
 	case ETTL:
 		//------------------------------------------------------------
@@ -504,6 +612,12 @@ func (ps *PeerSpace) MetaModel2Latex(testCaseName string, testCaseLatexConfig *L
 			file.WriteString("%%======================================================================= \n")
 			file.WriteString(fmt.Sprintf("\\subsection{%s} \n\n", ConvertString2LatexString(p.Id)))
 
+			// coordinators of pic and poc, if not the default:
+			if BAG != p.PicCoordinator.Type || BAG != p.PocCoordinator.Type {
+				file.WriteString(fmt.Sprintf("%s: %s, %s: %s \n\n", PIC, ConvertString2LatexString(p.PicCoordinator.String()),
+					POC, ConvertString2LatexString(p.PocCoordinator.String())))
+			}
+
 			// print all wirings: sorted:
 			for wIndex := 0; wIndex < len(p.WiringWids); wIndex++ {
 				wid := p.WiringWids[wIndex]
@@ -649,7 +763,7 @@ func (ps *PeerSpace) MetaModel2Latex(testCaseName string, testCaseLatexConfig *L
 						if !nextService {
 							nextService = true
 							if nil == w.ServiceWrappers[l.Sid] {
-								Panic(fmt.Sprintf("MetaModel2Latex: ill. meta model: service %s does not exist", l.Sid))
+								ModelPanic(fmt.Sprintf("MetaModel2Latex: ill. meta model: service %s does not exist", l.Sid))
 							}
 							file.WriteString(fmt.Sprintf("  \\BeginService{%s:%s} \n", ConvertString2LatexString(l.Sid), ConvertString2LatexString(w.ServiceWrappers[l.Sid].Name)))
 						}
@@ -672,6 +786,9 @@ func (ps *PeerSpace) MetaModel2Latex(testCaseName string, testCaseLatexConfig *L
 						// print start service if not yet:
 						if !nextService {
 							nextService = true
+							if nil == w.ServiceWrappers[l.Sid] {
+								ModelPanic(fmt.Sprintf("MetaModel2Latex: ill. meta model: service %s does not exist", l.Sid))
+							}
 							file.WriteString(fmt.Sprintf("  \\BeginService{%s:%s} \n", ConvertString2LatexString(l.Sid), ConvertString2LatexString(w.ServiceWrappers[l.Sid].Name)))
 						}
 						// file.WriteString("    \\callServiceArrow{}{}{}{}{}{} \n")
@@ -887,6 +1004,7 @@ func (ps *PeerSpace) CheckEttl(e *Entry, c *Container, scheduler *Scheduler) {
 		// --
 		// remove entry from container
 		c.RemoveEntry(e.Id)
+		logEntryException(c.Id, e.Id)
 		// --
 		// wrap entry into exception
 		excE := e.ExceptionWrap("@@@DUMP-STELLE-1")
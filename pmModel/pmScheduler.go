@@ -48,57 +48,33 @@ func SetEttsAndEttlSlot(scheduler Scheduler, eid string, etts int, ettl int) Sch
 		/**/ String2TraceFile(fmt.Sprintf("  tts=%d, ttl=%d\n", tts, ttl))
 	}
 	// -------------------
-	// search for entry in the list:
-	// update if tt* >= current time AND tt* <= SYSTEM_TTL, else remove the slot
-	// do it in extra loops, because the list might be changed
+	// update the slots if tt* >= current time AND tt* <= SYSTEM_TTL, else remove them;
+	// insert them if not found
 	// tts:
-	ttsFoundFlag := false
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && ETTS == slot.UserSlot.(*PMSlot).Type && eid == slot.UserSlot.(*PMSlot).Eid {
-			// found:
-			ttsFoundFlag = true
-			// update or remove the slot:
-			if CLOCK <= tts && SYSTEM_TTL >= tts {
-				slot.Time = tts
-			} else {
-				// remove
-				schedulerTmp := append(scheduler[:i], scheduler[i+1:]...)
-				scheduler = schedulerTmp
+	ttsKey := PMSlotKey(ETTS, eid, 0)
+	if CLOCK <= tts && SYSTEM_TTL >= tts {
+		ttsFoundFlag := false
+		if scheduler, ttsFoundFlag = scheduler.Reschedule(ttsKey, tts); !ttsFoundFlag {
+			scheduler = scheduler.SortedInsert(NewUserSlot(tts, NewEttsSlot(eid)))
+			if SCHEDULER_DETAILS_TRACE.DoTrace() {
+				/**/ String2TraceFile(fmt.Sprintf("  new tts slot inserted\n"))
 			}
-			break
 		}
+	} else {
+		scheduler = scheduler.Cancel(ttsKey)
 	}
 	// ttl:
-	ttlFoundFlag := false
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && ETTL == slot.UserSlot.(*PMSlot).Type && eid == slot.UserSlot.(*PMSlot).Eid {
-			// found:
-			ttlFoundFlag = true
-			// update the slot:
-			if CLOCK <= ttl && SYSTEM_TTL >= ttl {
-				slot.Time = ttl
-			} else {
-				// remove
-				scheduler = append(scheduler[:i], scheduler[i+1:]...)
+	ttlKey := PMSlotKey(ETTL, eid, 0)
+	if CLOCK <= ttl && SYSTEM_TTL >= ttl {
+		ttlFoundFlag := false
+		if scheduler, ttlFoundFlag = scheduler.Reschedule(ttlKey, ttl); !ttlFoundFlag {
+			scheduler = scheduler.SortedInsert(NewUserSlot(tts, NewEttlSlot(eid)))
+			if SCHEDULER_DETAILS_TRACE.DoTrace() {
+				/**/ String2TraceFile(fmt.Sprintf("  new ttl slot inserted\n"))
 			}
-			break
-		}
-	}
-	// -------------------
-	// insert slots if not found & treated above and if tt* >= current time AND tt* <= SYSTEM_TTL:
-	// tts:
-	if !ttsFoundFlag && CLOCK <= tts && SYSTEM_TTL >= tts {
-		scheduler = scheduler.SortedInsert(NewUserSlot(tts, NewEttsSlot(eid)))
-		if SCHEDULER_DETAILS_TRACE.DoTrace() {
-			/**/ String2TraceFile(fmt.Sprintf("  new tts slot inserted\n"))
-		}
-	}
-	// ttl:
-	if !ttlFoundFlag && CLOCK <= ttl && SYSTEM_TTL >= ttl {
-		scheduler = scheduler.SortedInsert(NewUserSlot(tts, NewEttlSlot(eid)))
-		if SCHEDULER_DETAILS_TRACE.DoTrace() {
-			/**/ String2TraceFile(fmt.Sprintf("  new ttl slot inserted\n"))
 		}
+	} else {
+		scheduler = scheduler.Cancel(ttlKey)
 	}
 	// -------------------
 	// return changed scheduler
@@ -220,20 +196,8 @@ func ClearEttsAndEttlSlot(scheduler Scheduler, eid string) Scheduler {
 		String2TraceFile(fmt.Sprintf("ClearEttsAndEttlSlot for entry=%s\n", eid))
 	}
 
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && ETTS == slot.UserSlot.(*PMSlot).Type && eid == slot.UserSlot.(*PMSlot).Eid {
-			// found: remove:
-			scheduler = append(scheduler[:i], scheduler[i+1:]...)
-			break
-		}
-	}
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && ETTL == slot.UserSlot.(*PMSlot).Type && eid == slot.UserSlot.(*PMSlot).Eid {
-			// found: remove:
-			scheduler = append(scheduler[:i], scheduler[i+1:]...)
-			break
-		}
-	}
+	scheduler = scheduler.Cancel(PMSlotKey(ETTS, eid, 0))
+	scheduler = scheduler.Cancel(PMSlotKey(ETTL, eid, 0))
 	if SCHEDULER_DETAILS_TRACE.DoTrace() {
 		/**/ scheduler.Println(TAB * 2)
 	}
@@ -252,20 +216,8 @@ func ClearWttsAndWttlSlot(scheduler Scheduler, wid string) Scheduler {
 		/**/ String2TraceFile(fmt.Sprintf("ClearWttsAndWttlSlot for wid=%s\n", wid))
 	}
 
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && WTTS == slot.UserSlot.(*PMSlot).Type && wid == slot.UserSlot.(*PMSlot).Wid {
-			// found: remove:
-			scheduler = append(scheduler[:i], scheduler[i+1:]...)
-			break
-		}
-	}
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && WTTL == slot.UserSlot.(*PMSlot).Type && wid == slot.UserSlot.(*PMSlot).Wid {
-			// found:  remove:
-			scheduler = append(scheduler[:i], scheduler[i+1:]...)
-			break
-		}
-	}
+	scheduler = scheduler.Cancel(PMSlotKey(WTTS, wid, 0))
+	scheduler = scheduler.Cancel(PMSlotKey(WTTL, wid, 0))
 	if SCHEDULER_DETAILS_TRACE.DoTrace() {
 		/**/ scheduler.Println(TAB * 2)
 	}
@@ -284,20 +236,8 @@ func ClearLttsAndLttlSlot(scheduler Scheduler, wiid string, linkNo int) Schedule
 		/**/ String2TraceFile(fmt.Sprintf("ClearLttsAndLttlSlot for wiid=%s, linkNo=%d\n", wiid, linkNo))
 	}
 
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && LTTS == slot.UserSlot.(*PMSlot).Type && wiid == slot.UserSlot.(*PMSlot).Wiid && linkNo == slot.UserSlot.(*PMSlot).LinkNo {
-			// found:  remove:
-			scheduler = append(scheduler[:i], scheduler[i+1:]...)
-			break
-		}
-	}
-	for i, slot := range scheduler {
-		if USER_SLOT == slot.Type && WTTL == slot.UserSlot.(*PMSlot).Type && wiid == slot.UserSlot.(*PMSlot).Wiid && linkNo == slot.UserSlot.(*PMSlot).LinkNo {
-			// found:  remove:
-			scheduler = append(scheduler[:i], scheduler[i+1:]...)
-			break
-		}
-	}
+	scheduler = scheduler.Cancel(PMSlotKey(LTTS, wiid, linkNo))
+	// nb: the lttl slot stays; it only expires (see ProcessRipePMSlot)
 	if SCHEDULER_DETAILS_TRACE.DoTrace() {
 		/**/ scheduler.Println(TAB * 2)
 	}
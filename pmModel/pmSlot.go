@@ -127,6 +127,39 @@ func (slot *PMSlot) Copy() interface{} {
 	return newSlot
 }
 
+// ----------------------------------------
+// key for the scheduler
+func (slot *PMSlot) Key() string {
+	switch slot.Type {
+	case ETTS, ETTL:
+		return PMSlotKey(slot.Type, slot.Eid, 0)
+	case LTTS, LTTL:
+		return PMSlotKey(slot.Type, slot.Wiid, slot.LinkNo)
+	case WTTS, WTTL:
+		return PMSlotKey(slot.Type, slot.Wid, 0)
+	default:
+		return PMSlotKey(slot.Type, slot.Pid, 0)
+	}
+}
+
+// ----------------------------------------
+// canonical description for model checking
+// - eid and wiid are volatile (uuids) and therefore omitted; the entry's
+//   tts/ttl are part of the entry's fingerprint anyhow
+func (slot *PMSlot) Fingerprint() string {
+	return fmt.Sprintf("%s<wid=%s, linkNo=%d, pid=%s>", slot.Type, slot.Wid, slot.LinkNo, slot.Pid)
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// key of a pm slot: id is the eid, wiid or wid depending on the slot type; linkNo only for LTTS, LTTL
+func PMSlotKey(slotType PMSlotTypeEnum, id string, linkNo int) string {
+	return fmt.Sprintf("%s:%s:%d", slotType, id, linkNo)
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
@@ -0,0 +1,350 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// temporal properties (LTL without next) over container predicates
+// - atoms are invariants used as state predicates (see Invariant), eg "P1_PIC holds a request"
+// - operators: not, and, or, always, eventually, until, leads-to
+// -- LtlLeadsTo(f, g) = LtlAlways(f -> LtlEventually(g))
+// - evaluated by the model checker at the end of every path (see framework: PathState):
+// -- lasso (the path runs into a loop, or deadlocks = loops in its last state): exact evaluation
+// -- otherwise (system ttl): the path might go on -> pending eventualities are not
+//    reported, only violations on the finite path (eg of always)
+// -- nb: paths are not pruned at visited states while properties are checked
+// - example: "every request in P1_PIC is eventually answered by a reply in P1_POC":
+//   LtlLeadsTo(
+//     LtlAtom(NewEntryCountInvariant("request", "P1_PIC", Query{Typ: SEtype("request"), Min: IVal(1)})),
+//     LtlAtom(NewEntryCountInvariant("reply", "P1_POC", Query{Typ: SEtype("reply"), Min: IVal(1)})))
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	"fmt"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type LtlOpTypeEnum int
+
+// ----------------------------------------
+// nb: TRUE, FALSE and RELEASE are only needed internally (negation normal form)
+const (
+	LTL_ATOM LtlOpTypeEnum = iota
+	LTL_NOT
+	LTL_AND
+	LTL_OR
+	LTL_ALWAYS
+	LTL_EVENTUALLY
+	LTL_UNTIL
+	LTL_LEADS_TO
+	LTL_TRUE
+	LTL_FALSE
+	LTL_RELEASE
+)
+
+// ----------------------------------------
+// formula tree; unary operators use Left
+type Formula struct {
+	Op    LtlOpTypeEnum
+	Atom  *Invariant
+	Left  *Formula
+	Right *Formula
+}
+
+// ----------------------------------------
+type Property struct {
+	Name string
+	F    *Formula
+	// atoms of the formula in the order of the valuations (see PropertyValuation)
+	atoms Invariants
+	// negation normal form of the formula with atom indices
+	nnf *nnfFormula
+}
+
+// ----------------------------------------
+type Properties []*Property
+
+// ----------------------------------------
+// private:
+// negation normal form: negations only at atoms; atoms are indices into the valuation
+type nnfFormula struct {
+	op      LtlOpTypeEnum
+	atomIdx int
+	negated bool
+	left    *nnfFormula
+	right   *nnfFormula
+}
+
+////////////////////////////////////////
+// constructors
+////////////////////////////////////////
+
+// ----------------------------------------
+func LtlAtom(inv *Invariant) *Formula {
+	return &Formula{Op: LTL_ATOM, Atom: inv}
+}
+
+// ----------------------------------------
+func LtlNot(f *Formula) *Formula {
+	return &Formula{Op: LTL_NOT, Left: f}
+}
+
+// ----------------------------------------
+func LtlAnd(f *Formula, g *Formula) *Formula {
+	return &Formula{Op: LTL_AND, Left: f, Right: g}
+}
+
+// ----------------------------------------
+func LtlOr(f *Formula, g *Formula) *Formula {
+	return &Formula{Op: LTL_OR, Left: f, Right: g}
+}
+
+// ----------------------------------------
+func LtlAlways(f *Formula) *Formula {
+	return &Formula{Op: LTL_ALWAYS, Left: f}
+}
+
+// ----------------------------------------
+func LtlEventually(f *Formula) *Formula {
+	return &Formula{Op: LTL_EVENTUALLY, Left: f}
+}
+
+// ----------------------------------------
+// f holds until g holds, and g holds eventually
+func LtlUntil(f *Formula, g *Formula) *Formula {
+	return &Formula{Op: LTL_UNTIL, Left: f, Right: g}
+}
+
+// ----------------------------------------
+// whenever f holds, g holds eventually
+func LtlLeadsTo(f *Formula, g *Formula) *Formula {
+	return &Formula{Op: LTL_LEADS_TO, Left: f, Right: g}
+}
+
+// ----------------------------------------
+// caution: panics if the formula is not well formed
+func NewProperty(name string, f *Formula) *Property {
+	p := new(Property)
+	p.Name = name
+	p.F = f
+	p.atoms = Invariants{}
+	p.nnf = p.toNnf(f, false)
+	return p
+}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// values of the atoms in the peer space
+func (p *Property) Valuation(ps *PeerSpace) []bool {
+	vals := make([]bool, len(p.atoms))
+	for i, atom := range p.atoms {
+		vals[i], _ = atom.Check(ps)
+	}
+	return vals
+}
+
+// ----------------------------------------
+// does the property hold for the word of valuations?
+// - loopStart: index where the word loops back to after its last valuation; -1 = finite word
+func (p *Property) Holds(word [][]bool, loopStart int) bool {
+	if 0 == len(word) {
+		return true
+	}
+	return p.nnf.eval(word, loopStart)[0]
+}
+
+// ----------------------------------------
+func (f *Formula) ToString() string {
+	if nil == f {
+		return "nil"
+	}
+	switch f.Op {
+	case LTL_ATOM:
+		if nil == f.Atom {
+			return "nil"
+		}
+		return f.Atom.Name
+	case LTL_NOT, LTL_ALWAYS, LTL_EVENTUALLY:
+		return fmt.Sprintf("%s(%s)", f.Op, f.Left.ToString())
+	default:
+		return fmt.Sprintf("(%s %s %s)", f.Left.ToString(), f.Op, f.Right.ToString())
+	}
+}
+
+// ----------------------------------------
+func (t LtlOpTypeEnum) String() string {
+	switch t {
+	case LTL_ATOM:
+		return "atom"
+	case LTL_NOT:
+		return "not"
+	case LTL_AND:
+		return "and"
+	case LTL_OR:
+		return "or"
+	case LTL_ALWAYS:
+		return "always"
+	case LTL_EVENTUALLY:
+		return "eventually"
+	case LTL_UNTIL:
+		return "until"
+	case LTL_LEADS_TO:
+		return "leads_to"
+	case LTL_TRUE:
+		return "true"
+	case LTL_FALSE:
+		return "false"
+	case LTL_RELEASE:
+		return "release"
+	default:
+		return "ill. ltl op type"
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// convert into negation normal form and collect the atoms
+// - always f = false release f, eventually f = true until f
+// - not (f until g) = (not f) release (not g), and vice versa
+func (p *Property) toNnf(f *Formula, negated bool) *nnfFormula {
+	if nil == f {
+		ModelPanic(fmt.Sprintf("property %s: formula is incomplete", p.Name))
+	}
+	binary := func(op LtlOpTypeEnum, dualOp LtlOpTypeEnum, left *Formula, right *Formula) *nnfFormula {
+		if negated {
+			op = dualOp
+		}
+		return &nnfFormula{op: op, left: p.toNnf(left, negated), right: p.toNnf(right, negated)}
+	}
+	constant := func(value bool) *nnfFormula {
+		if value != negated {
+			return &nnfFormula{op: LTL_TRUE}
+		}
+		return &nnfFormula{op: LTL_FALSE}
+	}
+	switch f.Op {
+	case LTL_ATOM:
+		if nil == f.Atom {
+			ModelPanic(fmt.Sprintf("property %s: atom without predicate", p.Name))
+		}
+		p.atoms = append(p.atoms, f.Atom)
+		return &nnfFormula{op: LTL_ATOM, atomIdx: len(p.atoms) - 1, negated: negated}
+	case LTL_NOT:
+		return p.toNnf(f.Left, !negated)
+	case LTL_AND:
+		return binary(LTL_AND, LTL_OR, f.Left, f.Right)
+	case LTL_OR:
+		return binary(LTL_OR, LTL_AND, f.Left, f.Right)
+	case LTL_UNTIL:
+		return binary(LTL_UNTIL, LTL_RELEASE, f.Left, f.Right)
+	case LTL_ALWAYS:
+		if negated {
+			return &nnfFormula{op: LTL_UNTIL, left: constant(true), right: p.toNnf(f.Left, true)}
+		}
+		return &nnfFormula{op: LTL_RELEASE, left: constant(false), right: p.toNnf(f.Left, false)}
+	case LTL_EVENTUALLY:
+		if negated {
+			return &nnfFormula{op: LTL_RELEASE, left: constant(true), right: p.toNnf(f.Left, true)}
+		}
+		return &nnfFormula{op: LTL_UNTIL, left: constant(true), right: p.toNnf(f.Left, false)}
+	case LTL_LEADS_TO:
+		return p.toNnf(LtlAlways(LtlOr(LtlNot(f.Left), LtlEventually(f.Right))), negated)
+	default:
+		Panic(fmt.Sprintf("property %s: ill. operator %s", p.Name, f.Op))
+		return nil
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// truth values at all positions of the word
+// - the successor of the last position is loopStart; for a finite word (loopStart < 0) the word
+//   might go on -> until and release are assumed to hold after the end
+// - until is the least, release the greatest fixpoint on the loop
+func (f *nnfFormula) eval(word [][]bool, loopStart int) []bool {
+	n := len(word)
+	res := make([]bool, n)
+	switch f.op {
+	case LTL_TRUE, LTL_FALSE:
+		for i := range res {
+			res[i] = LTL_TRUE == f.op
+		}
+	case LTL_ATOM:
+		for i := range res {
+			res[i] = word[i][f.atomIdx] != f.negated
+		}
+	case LTL_AND, LTL_OR:
+		left := f.left.eval(word, loopStart)
+		right := f.right.eval(word, loopStart)
+		for i := range res {
+			if LTL_AND == f.op {
+				res[i] = left[i] && right[i]
+			} else {
+				res[i] = left[i] || right[i]
+			}
+		}
+	case LTL_UNTIL, LTL_RELEASE:
+		left := f.left.eval(word, loopStart)
+		right := f.right.eval(word, loopStart)
+		// - start value of the fixpoint iteration
+		for i := range res {
+			res[i] = LTL_RELEASE == f.op
+		}
+		for changed := true; changed; {
+			changed = false
+			for i := n - 1; i >= 0; i-- {
+				next := true
+				if i < n-1 {
+					next = res[i+1]
+				} else if 0 <= loopStart {
+					next = res[loopStart]
+				}
+				var val bool
+				if LTL_UNTIL == f.op {
+					val = right[i] || (left[i] && next)
+				} else {
+					val = right[i] && (left[i] || next)
+				}
+				if val != res[i] {
+					res[i] = val
+					changed = true
+				}
+			}
+		}
+	default:
+		Panic(fmt.Sprintf("ill. nnf operator %s", f.op))
+	}
+	return res
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -92,7 +92,7 @@ func (q *Query) GetCount(vars Vars) int {
 	if q.Count.Eval(vars, nil /* no entry */) && INT == q.Count.Type {
 		return (q.Count.IntVal)
 	} else {
-		Panic(fmt.Sprintf("Query: ill. query count specification: q = %s", q.ToString(0)))
+		EvalPanic(fmt.Sprintf("Query: ill. query count specification: q = %s", q.ToString(0)))
 		return 0
 	}
 }
@@ -102,7 +102,7 @@ func (q *Query) GetMin(vars Vars) int {
 	if q.Min.Eval(vars, nil /* no entry */) && INT == q.Min.Type {
 		return (q.Min.IntVal)
 	} else {
-		Panic(fmt.Sprintf("Query: ill. query min specification: q = %s", q.ToString(0)))
+		EvalPanic(fmt.Sprintf("Query: ill. query min specification: q = %s", q.ToString(0)))
 		return 0
 	}
 }
@@ -112,7 +112,7 @@ func (q *Query) GetMax(vars Vars) int {
 	if q.Max.Eval(vars, nil /* no entry */) && INT == q.Max.Type {
 		return (q.Max.IntVal)
 	} else {
-		Panic(fmt.Sprintf("Query: ill. query max specification: q = %s", q.ToString(0)))
+		EvalPanic(fmt.Sprintf("Query: ill. query max specification: q = %s", q.ToString(0)))
 		return 0
 	}
 }
@@ -122,7 +122,7 @@ func (q *Query) GetTyp(vars Vars) string {
 	if q.Typ.Eval(vars, nil /* no entry */) && STRING == q.Typ.Type {
 		return (q.Typ.StringVal)
 	} else {
-		Panic(fmt.Sprintf("Query: ill. query typ specification: q = %s", q.ToString(0)))
+		EvalPanic(fmt.Sprintf("Query: ill. query typ specification: q = %s", q.ToString(0)))
 		return ""
 	}
 }
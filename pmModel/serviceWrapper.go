@@ -32,7 +32,8 @@ type ServiceFunc func(ps *PeerSpace, wfid string, vars Vars, scheduler *Schedule
 
 type ServiceWrapper struct {
 	// service function:
-	Fu ServiceFunc
+	// - not in a snapshot, which looks it up by name (see snapshot.go)
+	Fu ServiceFunc `json:"-"`
 	// only for docu (optional):
 	Name string
 	// internally used only: resolved automatically:
@@ -40,6 +41,30 @@ type ServiceWrapper struct {
 	OutCid string
 }
 
+////////////////////////////////////////
+// service registry
+// - maps service names to service functions, so that services can be referenced by name
+//   (e.g. in model files, see modelFile.go)
+// - the built-in services are registered by default; user services must be registered before use
+////////////////////////////////////////
+
+var SERVICE_REGISTRY = map[string]ServiceFunc{
+	"SendService":       SendService,
+	"SourceWrapService": SourceWrapService,
+	"StopService":       StopService,
+}
+
+// ----------------------------------------
+func RegisterService(name string, fu ServiceFunc) {
+	SERVICE_REGISTRY[name] = fu
+}
+
+// ----------------------------------------
+// returns nil if no service with the name is registered
+func LookupService(name string) ServiceFunc {
+	return SERVICE_REGISTRY[name]
+}
+
 ////////////////////////////////////////
 // constructors
 ////////////////////////////////////////
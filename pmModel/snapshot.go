@@ -0,0 +1,238 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// snapshot of the peer model data (see framework: snapshot)
+// - the data that change at runtime, in json:
+// -- all containers with their entries, and the transactions
+// -- the dynamic wirings, and the wiring order of every peer
+// -- the counters of the ids (see Uuid, NewEntryVersion) and of the system functions (see Fid, UuidUserFu)
+// - the rest of the model (peers, static wirings, invariants, properties) does not change at runtime
+//   -> it is kept from the use case the snapshot is restored to; which must have the peers of the snapshot
+// - the services of the dynamic wirings are restored by their names (see SERVICE_REGISTRY)
+// - the return error of a context is kept as its message
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/contextInterface"
+	. "github.com/peermodel/simulator/eventInterface"
+	. "github.com/peermodel/simulator/helpers"
+	. "github.com/peermodel/simulator/slotInterface"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+type metaContextSnapshot struct {
+	// key = cid
+	Containers    map[string]*Container
+	ContainerCids Strings
+	// key = pid
+	Peers        map[string]*peerSnapshot
+	Transactions map[string]*Tx
+	// counters
+	Uuid         int
+	EntryVersion int
+	FidCnt       int
+	UuidCnt      int
+}
+
+// ----------------------------------------
+type peerSnapshot struct {
+	WiringWids     Strings
+	DynamicWirings []*Wiring
+}
+
+// ----------------------------------------
+// nb: the field RetErr hides the one of the context
+type contextSnapshot struct {
+	Context
+	// nil if no error
+	RetErr *string
+}
+
+////////////////////////////////////////
+// IMetaContext interface implementation:
+////////////////////////////////////////
+
+// ----------------------------------------
+func (metaCtx MetaContext) Snapshot() ([]byte, error) {
+	ps := metaCtx.PeerSpace
+	snap := metaContextSnapshot{
+		Containers:    ps.Containers,
+		ContainerCids: ps.ContainerCids,
+		Peers:         map[string]*peerSnapshot{},
+		Transactions:  metaCtx.Transactions,
+		Uuid:          UUID,
+		EntryVersion:  ENTRY_VERSION,
+		FidCnt:        FID_CNT,
+		UuidCnt:       UUID_CNT,
+	}
+	for pid, p := range ps.Peers {
+		pSnap := &peerSnapshot{WiringWids: p.WiringWids, DynamicWirings: []*Wiring{}}
+		for _, wid := range p.WiringWids {
+			// nb: a removed dynamic wiring is still in the wiring order (see RemoveWiring)
+			if w := p.Wirings[wid]; nil != w && w.DynamicWiringFlag {
+				pSnap.DynamicWirings = append(pSnap.DynamicWirings, w)
+			}
+		}
+		snap.Peers[pid] = pSnap
+	}
+	return json.Marshal(snap)
+}
+
+// ----------------------------------------
+// restore the data of the snapshot into this meta context
+// - caution: the ids of the system functions are reset, too
+func (metaCtx MetaContext) Restore(data []byte) error {
+	snap := metaContextSnapshot{}
+	if err := json.Unmarshal(data, &snap); nil != err {
+		return fmt.Errorf("snapshot: meta context: %s", err)
+	}
+	ps := metaCtx.PeerSpace
+	//------------------------------------------------------------
+	// check the peers
+	pids := []string{}
+	for pid := range snap.Peers {
+		if nil == ps.Peers[pid] {
+			return fmt.Errorf("snapshot: peer %s is not in the model", pid)
+		}
+		pids = append(pids, pid)
+	}
+	if len(pids) != len(ps.Peers) {
+		return fmt.Errorf("snapshot: the model has other peers than the snapshot (%v)", ps.PeerPids)
+	}
+	sort.Strings(pids)
+	//------------------------------------------------------------
+	// dynamic wirings
+	for _, pid := range pids {
+		p := ps.Peers[pid]
+		for wid, w := range p.Wirings {
+			if w.DynamicWiringFlag {
+				delete(p.Wirings, wid)
+			}
+		}
+		for _, w := range snap.Peers[pid].DynamicWirings {
+			for sid, sw := range w.ServiceWrappers {
+				if sw.Fu = LookupService(sw.Name); nil == sw.Fu {
+					return fmt.Errorf("snapshot: service %s (%s) of dynamic wiring %s is not registered", sw.Name, sid, w.Id)
+				}
+			}
+			p.Wirings[w.Id] = w
+		}
+		p.WiringWids = snap.Peers[pid].WiringWids
+	}
+	//------------------------------------------------------------
+	// containers
+	if nil == snap.Containers {
+		snap.Containers = map[string]*Container{}
+	}
+	ps.Containers = snap.Containers
+	ps.ContainerCids = snap.ContainerCids
+	//------------------------------------------------------------
+	// transactions
+	for txid := range metaCtx.Transactions {
+		delete(metaCtx.Transactions, txid)
+	}
+	for txid, tx := range snap.Transactions {
+		metaCtx.Transactions[txid] = tx
+	}
+	//------------------------------------------------------------
+	// counters
+	UUID = snap.Uuid
+	ENTRY_VERSION = snap.EntryVersion
+	FID_CNT = snap.FidCnt
+	UUID_CNT = snap.UuidCnt
+	return nil
+}
+
+// ----------------------------------------
+func (metaCtx MetaContext) RestoreContext(data []byte) (IContext, error) {
+	snap := contextSnapshot{}
+	if err := json.Unmarshal(data, &snap); nil != err {
+		return nil, fmt.Errorf("snapshot: context: %s", err)
+	}
+	ctx := snap.Context
+	if nil != snap.RetErr {
+		ctx.RetErr = errors.New(*snap.RetErr)
+	}
+	return &ctx, nil
+}
+
+// ----------------------------------------
+func (metaCtx MetaContext) RestoreUserSlot(data []byte) (ISlot, error) {
+	slot := new(PMSlot)
+	if err := json.Unmarshal(data, slot); nil != err {
+		return nil, fmt.Errorf("snapshot: slot: %s", err)
+	}
+	return slot, nil
+}
+
+// ----------------------------------------
+func (metaCtx MetaContext) RestoreUserEvent(data []byte) (IEvent, error) {
+	evt := new(PMUserEvent)
+	if err := json.Unmarshal(data, evt); nil != err {
+		return nil, fmt.Errorf("snapshot: user event: %s", err)
+	}
+	return evt, nil
+}
+
+////////////////////////////////////////
+// IContext interface implementation:
+////////////////////////////////////////
+
+// ----------------------------------------
+func (ctx Context) Snapshot() ([]byte, error) {
+	snap := contextSnapshot{Context: ctx}
+	if nil != ctx.RetErr {
+		msg := ctx.RetErr.Error()
+		snap.RetErr = &msg
+	}
+	return json.Marshal(snap)
+}
+
+////////////////////////////////////////
+// ISlot and IEvent interface implementation:
+////////////////////////////////////////
+
+// ----------------------------------------
+func (slot *PMSlot) Snapshot() ([]byte, error) {
+	return json.Marshal(slot)
+}
+
+// ----------------------------------------
+func (e *PMUserEvent) Snapshot() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
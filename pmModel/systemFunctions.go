@@ -20,17 +20,57 @@
 // Date: 2017
 ////////////////////////////////////////
 
+////////////////////////////////////////
+// DOCU:
+// stochastic time: int system functions that draw a time from a probability distribution
+// - usable wherever an int time arg is allowed, eg tts and ttl in WProps, LProps and EProps;
+//   a service duration is modeled by the tts of the out link of the service
+// - their params are args, too (see Arg.FuArgs), that are evaluated before the draw:
+// -- exponential(mean)
+// -- uniform(min, max): min..max, both included
+// -- normal(mean, sd)
+// -- erlang(k, mean): sum of k exponential phases with mean/k each
+// -- empirical(v1, w1, v2, w2, ...): value vi with weight wi
+// - the drawn value is rounded to an int; negative values are cut to 0
+// - all draws are made by the random generator of the run (see framework: RandomIntn),
+//   ie they depend on the seed, are recorded in the replay trace, and restored with a snapshot
+// -- nb: also the draws for the initial entries, which are made before the first step (see framework: NewStatus)
+// -- nb: model checking does not branch over the drawn values; every path uses its own draws
+// - go services can draw directly, eg: RandomExponential(10)
+// - examples:
+// -- tts of a wiring: WProps{TTS: IExponential(IVal(10))}
+// -- in a model file: {"kind": "FU", "type": "INT", "fu": "uniform()", "args": [5, 15]}
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// functions of the expression language; usable in selectors, EProps, LProps, WProps and link vars
+// - string: len(s) INT, substr(s, from, to) STRING, contains(s, t) BOOL, hasprefix(s, p) BOOL
+// -- nb: positions count characters; substr takes [from, to), cut to the string
+// - int: min(a, b), max(a, b), abs(a), and str(i) STRING (int to string)
+// - if(c, a, b): a if the BOOL c holds, else b; only the selected branch is evaluated;
+//   a, b and the result have the type of the fu arg (see IIf, SIf, BIf)
+// - the types of the args are checked statically (see Arg.StaticType), eg when a model file is loaded
+// - examples:
+// -- selector: BContains(SLabel("name"), SVal("x"))
+// -- in a model file: {"kind": "FU", "fu": "min()", "args": [{"kind": "LABEL", "name": "n"}, 10]}
+////////////////////////////////////////
+
 package pmModel
 
 import (
+	. "github.com/peermodel/simulator/debug"
 	. "github.com/peermodel/simulator/helpers"
 	. "github.com/peermodel/simulator/scheduler"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
 )
 
 ////////////////////////////////////////
 // system function
-// @@@ no args supported
+// - the functions with args use Arg.FuArgs
 ////////////////////////////////////////
 
 type SystemFunctionEnum int
@@ -39,8 +79,28 @@ const (
 	CLOCK_FUNCTION SystemFunctionEnum = iota
 	FID_FUNCTION
 	UUID_FUNCTION
+	EXPONENTIAL_FUNCTION
+	UNIFORM_FUNCTION
+	NORMAL_FUNCTION
+	ERLANG_FUNCTION
+	EMPIRICAL_FUNCTION
+	LEN_FUNCTION
+	SUBSTR_FUNCTION
+	CONTAINS_FUNCTION
+	HASPREFIX_FUNCTION
+	STR_FUNCTION
+	MIN_FUNCTION
+	MAX_FUNCTION
+	ABS_FUNCTION
+	IF_FUNCTION
 )
 
+// all system functions (eg for the lookup by name)
+var SYSTEM_FUNCTIONS = []SystemFunctionEnum{CLOCK_FUNCTION, FID_FUNCTION, UUID_FUNCTION,
+	EXPONENTIAL_FUNCTION, UNIFORM_FUNCTION, NORMAL_FUNCTION, ERLANG_FUNCTION, EMPIRICAL_FUNCTION,
+	LEN_FUNCTION, SUBSTR_FUNCTION, CONTAINS_FUNCTION, HASPREFIX_FUNCTION, STR_FUNCTION,
+	MIN_FUNCTION, MAX_FUNCTION, ABS_FUNCTION, IF_FUNCTION}
+
 // output function in a readable form
 func (f SystemFunctionEnum) String() string {
 	switch f {
@@ -50,11 +110,207 @@ func (f SystemFunctionEnum) String() string {
 		return "fid()"
 	case UUID_FUNCTION:
 		return "uuid()"
+	case EXPONENTIAL_FUNCTION:
+		return "exponential()"
+	case UNIFORM_FUNCTION:
+		return "uniform()"
+	case NORMAL_FUNCTION:
+		return "normal()"
+	case ERLANG_FUNCTION:
+		return "erlang()"
+	case EMPIRICAL_FUNCTION:
+		return "empirical()"
+	case LEN_FUNCTION:
+		return "len()"
+	case SUBSTR_FUNCTION:
+		return "substr()"
+	case CONTAINS_FUNCTION:
+		return "contains()"
+	case HASPREFIX_FUNCTION:
+		return "hasprefix()"
+	case STR_FUNCTION:
+		return "str()"
+	case MIN_FUNCTION:
+		return "min()"
+	case MAX_FUNCTION:
+		return "max()"
+	case ABS_FUNCTION:
+		return "abs()"
+	case IF_FUNCTION:
+		return "if()"
 	default:
 		return "ill. system function"
 	}
 }
 
+// ----------------------------------------
+// call of the function with its printed args, eg min(n,10)
+// - nb: without blanks, as in expressions (see Expr.ToString)
+func (f SystemFunctionEnum) CallString(args []string) string {
+	if 0 == len(args) {
+		return f.String()
+	}
+	return fmt.Sprintf("%s(%s)", strings.TrimSuffix(f.String(), "()"), strings.Join(args, ","))
+}
+
+// ----------------------------------------
+// types of the args and of the result of the function
+// - t is the type of the fu arg, which is the result type of if()
+func (f SystemFunctionEnum) Signature(t DataTypeEnum, nArgs int) ([]DataTypeEnum, DataTypeEnum) {
+	switch f {
+	case CLOCK_FUNCTION:
+		return []DataTypeEnum{}, INT
+	case FID_FUNCTION, UUID_FUNCTION:
+		return []DataTypeEnum{}, STRING
+	case EXPONENTIAL_FUNCTION, UNIFORM_FUNCTION, NORMAL_FUNCTION, ERLANG_FUNCTION, EMPIRICAL_FUNCTION:
+		argTypes := []DataTypeEnum{}
+		for i := 0; i < nArgs; i++ {
+			argTypes = append(argTypes, INT)
+		}
+		return argTypes, INT
+	case LEN_FUNCTION:
+		return []DataTypeEnum{STRING}, INT
+	case SUBSTR_FUNCTION:
+		return []DataTypeEnum{STRING, INT, INT}, STRING
+	case CONTAINS_FUNCTION, HASPREFIX_FUNCTION:
+		return []DataTypeEnum{STRING, STRING}, BOOL
+	case STR_FUNCTION:
+		return []DataTypeEnum{INT}, STRING
+	case MIN_FUNCTION, MAX_FUNCTION:
+		return []DataTypeEnum{INT, INT}, INT
+	case ABS_FUNCTION:
+		return []DataTypeEnum{INT}, INT
+	case IF_FUNCTION:
+		return []DataTypeEnum{BOOL, t, t}, t
+	default:
+		Panic(fmt.Sprintf("ill. system function %d", f))
+		return nil, t
+	}
+}
+
+// ----------------------------------------
+// is it a distribution?
+func (f SystemFunctionEnum) IsDistribution() bool {
+	switch f {
+	case EXPONENTIAL_FUNCTION, UNIFORM_FUNCTION, NORMAL_FUNCTION, ERLANG_FUNCTION, EMPIRICAL_FUNCTION:
+		return true
+	default:
+		return false
+	}
+}
+
+// ----------------------------------------
+// check the number of args of the function; returns nil if ok
+func (f SystemFunctionEnum) CheckNArgs(n int) error {
+	expected := 0
+	switch f {
+	case EXPONENTIAL_FUNCTION, LEN_FUNCTION, STR_FUNCTION, ABS_FUNCTION:
+		expected = 1
+	case UNIFORM_FUNCTION, NORMAL_FUNCTION, ERLANG_FUNCTION, CONTAINS_FUNCTION, HASPREFIX_FUNCTION, MIN_FUNCTION, MAX_FUNCTION:
+		expected = 2
+	case SUBSTR_FUNCTION, IF_FUNCTION:
+		expected = 3
+	case EMPIRICAL_FUNCTION:
+		if 0 == n || 0 != n%2 {
+			return fmt.Errorf("%s needs pairs of value and weight, got %d args", f, n)
+		}
+		return nil
+	}
+	if expected != n {
+		return fmt.Errorf("%s needs %d args, got %d", f, expected, n)
+	}
+	return nil
+}
+
+////////////////////////////////////////
+// constructors of the distributions
+////////////////////////////////////////
+
+// ----------------------------------------
+func IExponential(mean Arg) Arg {
+	return IFuArgs(EXPONENTIAL_FUNCTION, mean)
+}
+
+// ----------------------------------------
+func IUniform(min Arg, max Arg) Arg {
+	return IFuArgs(UNIFORM_FUNCTION, min, max)
+}
+
+// ----------------------------------------
+func INormal(mean Arg, sd Arg) Arg {
+	return IFuArgs(NORMAL_FUNCTION, mean, sd)
+}
+
+// ----------------------------------------
+func IErlang(k Arg, mean Arg) Arg {
+	return IFuArgs(ERLANG_FUNCTION, k, mean)
+}
+
+// ----------------------------------------
+// valuesAndWeights: v1, w1, v2, w2, ...
+func IEmpirical(valuesAndWeights ...Arg) Arg {
+	return IFuArgs(EMPIRICAL_FUNCTION, valuesAndWeights...)
+}
+
+////////////////////////////////////////
+// constructors of the expression functions
+////////////////////////////////////////
+
+// ----------------------------------------
+func ILen(s Arg) Arg {
+	return IFuArgs(LEN_FUNCTION, s)
+}
+
+// ----------------------------------------
+func SSubstr(s Arg, from Arg, to Arg) Arg {
+	return SFuArgs(SUBSTR_FUNCTION, s, from, to)
+}
+
+// ----------------------------------------
+func BContains(s Arg, t Arg) Arg {
+	return BFuArgs(CONTAINS_FUNCTION, s, t)
+}
+
+// ----------------------------------------
+func BHasPrefix(s Arg, prefix Arg) Arg {
+	return BFuArgs(HASPREFIX_FUNCTION, s, prefix)
+}
+
+// ----------------------------------------
+func SStr(i Arg) Arg {
+	return SFuArgs(STR_FUNCTION, i)
+}
+
+// ----------------------------------------
+func IMin(a Arg, b Arg) Arg {
+	return IFuArgs(MIN_FUNCTION, a, b)
+}
+
+// ----------------------------------------
+func IMax(a Arg, b Arg) Arg {
+	return IFuArgs(MAX_FUNCTION, a, b)
+}
+
+// ----------------------------------------
+func IAbs(a Arg) Arg {
+	return IFuArgs(ABS_FUNCTION, a)
+}
+
+// ----------------------------------------
+func IIf(c Arg, a Arg, b Arg) Arg {
+	return IFuArgs(IF_FUNCTION, c, a, b)
+}
+
+// ----------------------------------------
+func SIf(c Arg, a Arg, b Arg) Arg {
+	return SFuArgs(IF_FUNCTION, c, a, b)
+}
+
+// ----------------------------------------
+func BIf(c Arg, a Arg, b Arg) Arg {
+	return BFuArgs(IF_FUNCTION, c, a, b)
+}
+
 ////////////////////////////////////////
 // methods
 ////////////////////////////////////////
@@ -95,6 +351,189 @@ func Clock() int {
 	return CLOCK
 }
 
+// --------------------------------------------
+// substring of the characters [from, to), cut to the string
+func Substr(s string, from int, to int) string {
+	runes := []rune(s)
+	if from < 0 {
+		from = 0
+	}
+	if to > len(runes) {
+		to = len(runes)
+	}
+	if from >= to {
+		return ""
+	}
+	return string(runes[from:to])
+}
+
+// --------------------------------------------
+// private fu:
+// eval the i-th fu arg, which must have type t; returns it
+func (arg *Arg) evalFuArg(i int, t DataTypeEnum, vars Vars, entry *Entry) *Arg {
+	if i >= len(arg.FuArgs) {
+		EvalPanic(fmt.Sprintf("Eval: %s: arg %d is missing", arg.FuName, i+1))
+	}
+	fuArg := &arg.FuArgs[i]
+	if !fuArg.Eval(vars, entry) || t != fuArg.Type {
+		EvalPanic(fmt.Sprintf("Eval: %s: arg %d must be of type %s", arg.FuName, i+1, t))
+	}
+	return fuArg
+}
+
+// --------------------------------------------
+// private fu:
+// eval if(): only the selected branch; returns it
+func (arg *Arg) evalIf(vars Vars, entry *Entry) *Arg {
+	if arg.evalFuArg(0, BOOL, vars, entry).BoolVal {
+		return arg.evalFuArg(1, arg.Type, vars, entry)
+	}
+	return arg.evalFuArg(2, arg.Type, vars, entry)
+}
+
+////////////////////////////////////////
+// random source
+////////////////////////////////////////
+
+// number of the equally probable values of one draw for a continuous distribution
+const RANDOM_RESOLUTION int = 1 << 30
+
+// --------------------------------------------
+// random number in [0, n); nb: n > 0
+type RandomSourceFuType func(n int) int
+
+// random source of the current run; set by the framework for every status and run (see MetaContext.SetRandomSource)
+var randomSource RandomSourceFuType
+
+// --------------------------------------------
+func SetRandomSource(fu RandomSourceFuType) {
+	randomSource = fu
+}
+
+// --------------------------------------------
+// private fu:
+// random int in [0, n)
+func randomIntn(n int) int {
+	if nil == randomSource {
+		Panic("random distribution: no random source; draws are only possible in a run")
+	}
+	return randomSource(n)
+}
+
+// --------------------------------------------
+// private fu:
+// random float in (0, 1)
+func randomUnit() float64 {
+	return (float64(randomIntn(RANDOM_RESOLUTION)) + 0.5) / float64(RANDOM_RESOLUTION)
+}
+
+// --------------------------------------------
+// private fu:
+// round to a time, ie an int >= 0
+func toTime(x float64) int {
+	if x <= 0 {
+		return 0
+	}
+	return int(math.Floor(x + 0.5))
+}
+
+////////////////////////////////////////
+// distributions
+// - caution: they panic on ill. params
+////////////////////////////////////////
+
+// --------------------------------------------
+func RandomExponential(mean int) int {
+	if mean < 0 {
+		EvalPanic(fmt.Sprintf("exponential(): mean = %d must not be negative", mean))
+	}
+	return toTime(-float64(mean) * math.Log(randomUnit()))
+}
+
+// --------------------------------------------
+func RandomUniform(min int, max int) int {
+	if min > max {
+		EvalPanic(fmt.Sprintf("uniform(): min = %d is greater than max = %d", min, max))
+	}
+	return toTime(float64(min + randomIntn(max-min+1)))
+}
+
+// --------------------------------------------
+// box-muller transform
+func RandomNormal(mean int, sd int) int {
+	if sd < 0 {
+		EvalPanic(fmt.Sprintf("normal(): sd = %d must not be negative", sd))
+	}
+	u1 := randomUnit()
+	u2 := randomUnit()
+	z := math.Sqrt(-2*math.Log(u1)) * math.Cos(2*math.Pi*u2)
+	return toTime(float64(mean) + float64(sd)*z)
+}
+
+// --------------------------------------------
+func RandomErlang(k int, mean int) int {
+	if k < 1 {
+		EvalPanic(fmt.Sprintf("erlang(): k = %d must be at least 1", k))
+	}
+	if mean < 0 {
+		EvalPanic(fmt.Sprintf("erlang(): mean = %d must not be negative", mean))
+	}
+	sum := 0.0
+	for i := 0; i < k; i++ {
+		sum -= math.Log(randomUnit())
+	}
+	return toTime(float64(mean) / float64(k) * sum)
+}
+
+// --------------------------------------------
+// valuesAndWeights: v1, w1, v2, w2, ...
+func RandomEmpirical(valuesAndWeights []int) int {
+	if err := EMPIRICAL_FUNCTION.CheckNArgs(len(valuesAndWeights)); nil != err {
+		EvalPanic(err.Error())
+	}
+	total := 0
+	for i := 1; i < len(valuesAndWeights); i += 2 {
+		if valuesAndWeights[i] < 0 {
+			EvalPanic(fmt.Sprintf("empirical(): weight = %d must not be negative", valuesAndWeights[i]))
+		}
+		total += valuesAndWeights[i]
+	}
+	if 0 == total {
+		EvalPanic("empirical(): the sum of the weights must be positive")
+	}
+	r := randomIntn(total)
+	for i := 1; i < len(valuesAndWeights); i += 2 {
+		if r < valuesAndWeights[i] {
+			return toTime(float64(valuesAndWeights[i-1]))
+		}
+		r -= valuesAndWeights[i]
+	}
+	return toTime(float64(valuesAndWeights[len(valuesAndWeights)-2])) // not reached
+}
+
+// --------------------------------------------
+// draw from the distribution with the evaluated params
+func RandomDistribution(f SystemFunctionEnum, params []int) int {
+	if err := f.CheckNArgs(len(params)); nil != err {
+		Panic(err.Error())
+	}
+	switch f {
+	case EXPONENTIAL_FUNCTION:
+		return RandomExponential(params[0])
+	case UNIFORM_FUNCTION:
+		return RandomUniform(params[0], params[1])
+	case NORMAL_FUNCTION:
+		return RandomNormal(params[0], params[1])
+	case ERLANG_FUNCTION:
+		return RandomErlang(params[0], params[1])
+	case EMPIRICAL_FUNCTION:
+		return RandomEmpirical(params)
+	default:
+		Panic(fmt.Sprintf("%s is not a distribution", f))
+		return 0
+	}
+}
+
 ////////////////////////////////////////
 // EOF
 ////////////////////////////////////////
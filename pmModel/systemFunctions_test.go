@@ -0,0 +1,297 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the system functions: the random distributions and the functions of the expression language
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	. "github.com/peermodel/simulator/debug"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// seeded random source; the previous one is restored by the returned fu
+func seededRandomSource(seed int64) func() {
+	prev := randomSource
+	SetRandomSource(rand.New(rand.NewSource(seed)).Intn)
+	return func() { SetRandomSource(prev) }
+}
+
+// ----------------------------------------
+// n draws from all distributions
+func drawAll(n int) []int {
+	draws := []int{}
+	for i := 0; i < n; i++ {
+		draws = append(draws, RandomExponential(3), RandomUniform(1, 6), RandomNormal(4, 2), RandomErlang(2, 6), RandomEmpirical([]int{0, 1, 5, 3}))
+	}
+	return draws
+}
+
+// ----------------------------------------
+// eval the arg; returns the error it raises, if any
+// - nb: the trace of the error is not written
+func evalTestArg(arg *Arg, vars Vars, entry *Entry) (err error) {
+	traceFile := TRACE_FILE
+	TRACE_FILE = nil
+	defer func() {
+		TRACE_FILE = traceFile
+		if r := recover(); nil != r {
+			err = RecoveredError(r)
+		}
+	}()
+	arg.Eval(vars, entry)
+	return nil
+}
+
+// ----------------------------------------
+// value of the evaluated arg, eg 3, "ab" or true
+func evaluatedValue(arg *Arg) interface{} {
+	switch arg.Type {
+	case INT:
+		return arg.IntVal
+	case STRING:
+		return arg.StringVal
+	case BOOL:
+		return arg.BoolVal
+	default:
+		return nil
+	}
+}
+
+// ----------------------------------------
+func drawMean(fu func() int, n int) float64 {
+	sum := 0
+	for i := 0; i < n; i++ {
+		sum += fu()
+	}
+	return float64(sum) / float64(n)
+}
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+// the same seed gives the same draws
+func TestRandomDistributionsReproducible(t *testing.T) {
+	restore := seededRandomSource(7)
+	draws1 := drawAll(100)
+	restore()
+	restore = seededRandomSource(7)
+	draws2 := drawAll(100)
+	restore()
+	for i := range draws1 {
+		if draws1[i] != draws2[i] {
+			t.Fatalf("draw %d: %d expected, got %d", i, draws1[i], draws2[i])
+		}
+		if 0 > draws1[i] {
+			t.Fatalf("draw %d: negative time %d", i, draws1[i])
+		}
+	}
+}
+
+// ----------------------------------------
+// the means are near the params
+func TestRandomDistributionMeans(t *testing.T) {
+	defer seededRandomSource(1)()
+	const n = 20000
+	tests := []struct {
+		name     string
+		fu       func() int
+		expected float64
+	}{
+		{"exponential", func() int { return RandomExponential(10) }, 10},
+		{"uniform", func() int { return RandomUniform(2, 8) }, 5},
+		{"normal", func() int { return RandomNormal(20, 3) }, 20},
+		{"erlang", func() int { return RandomErlang(3, 12) }, 12},
+		{"empirical", func() int { return RandomEmpirical([]int{0, 1, 8, 3}) }, 6},
+	}
+	for _, test := range tests {
+		if got := drawMean(test.fu, n); got < 0.95*test.expected || got > 1.05*test.expected {
+			t.Errorf("%s: mean %.2f expected, got %.2f", test.name, test.expected, got)
+		}
+	}
+}
+
+// ----------------------------------------
+// values of weight 0 are never drawn
+func TestRandomEmpiricalWeights(t *testing.T) {
+	defer seededRandomSource(3)()
+	for i := 0; i < 1000; i++ {
+		if v := RandomEmpirical([]int{1, 0, 2, 5, 3, 0}); 2 != v {
+			t.Fatalf("only 2 expected, got %d", v)
+		}
+	}
+}
+
+// ----------------------------------------
+// the functions of the expression language with values, labels of the entry and vars
+func TestExpressionFunctions(t *testing.T) {
+	e := NewEntry("A")
+	e.EProps.SetIntVal("n", 12)
+	e.EProps.SetStringVal("name", "Grüße")
+	vars := Vars{"v": IVal(-4)}
+	tests := []struct {
+		arg      Arg
+		expected interface{}
+	}{
+		{ILen(SVal("")), 0},
+		{ILen(SLabel("name")), 5},
+		{SSubstr(SLabel("name"), IVal(1), IVal(4)), "rüß"},
+		{SSubstr(SVal("abc"), IVal(-2), IVal(2)), "ab"},
+		{SSubstr(SVal("abc"), IVal(1), IVal(10)), "bc"},
+		{SSubstr(SVal("abc"), IVal(2), IVal(1)), ""},
+		{SSubstr(SVal("abc"), IVal(5), IVal(7)), ""},
+		{BContains(SLabel("name"), SVal("üß")), true},
+		{BContains(SVal("abc"), SVal("x")), false},
+		{BContains(SVal("abc"), SVal("")), true},
+		{BHasPrefix(SLabel("name"), SVal("Gr")), true},
+		{BHasPrefix(SVal("abc"), SVal("b")), false},
+		{SStr(IVal(-7)), "-7"},
+		{SStr(ILabel("n")), "12"},
+		{IMin(ILabel("n"), IVal(10)), 10},
+		{IMin(IVar("v"), IVal(10)), -4},
+		{IMax(ILabel("n"), IVal(10)), 12},
+		{IMax(IVal(3), IVal(3)), 3},
+		{IAbs(IVar("v")), 4},
+		{IAbs(IVal(5)), 5},
+		{IAbs(IMin(IVar("v"), ILabel("n"))), 4},
+		{IIf(BVal(true), IVal(1), IVal(2)), 1},
+		{IIf(BContains(SLabel("name"), SVal("x")), IVal(1), IVal(2)), 2},
+		{SIf(BVal(false), SVal("a"), SStr(ILabel("n"))), "12"},
+		{BIf(BHasPrefix(SVal("abc"), SVal("a")), BVal(false), BVal(true)), false},
+		{ILen(SStr(IMax(IVar("v"), IVal(100)))), 3},
+	}
+	for _, test := range tests {
+		arg := test.arg
+		call := arg.String()
+		if err := evalTestArg(&arg, vars, e); nil != err {
+			t.Errorf("%s: %s", call, err)
+			continue
+		}
+		if got := evaluatedValue(&arg); test.expected != got {
+			t.Errorf("%s: %v expected, got %v", call, test.expected, got)
+		}
+	}
+}
+
+// ----------------------------------------
+// if() evaluates only the selected branch, ie the other one may fail
+func TestIfEvaluatesSelectedBranchOnly(t *testing.T) {
+	failing := IAbs(SVal("x"))
+	arg := IIf(BVal(true), IVal(1), failing)
+	if err := evalTestArg(&arg, nil, nil); nil != err || 1 != arg.IntVal {
+		t.Fatalf("1 expected, got %d (%v)", arg.IntVal, err)
+	}
+	arg = IIf(BVal(false), IVal(1), failing)
+	if err := evalTestArg(&arg, nil, nil); nil == err {
+		t.Fatalf("error of the selected branch expected")
+	}
+}
+
+// ----------------------------------------
+// wrong args raise an eval error, no other panic
+// - nb: a model file is type checked statically, but go use cases are not
+func TestExpressionFunctionErrors(t *testing.T) {
+	e := NewEntry("A")
+	e.EProps.SetIntVal("n", 12)
+	tests := []struct {
+		arg Arg
+		msg string
+	}{
+		{ILen(IVal(5)), "len(): arg 1 must be of type STRING"},
+		{ILen(SLabel("n")), "len(): arg 1 must be of type STRING"},
+		{SSubstr(SVal("abc"), SVal("0"), IVal(1)), "substr(): arg 2 must be of type INT"},
+		{SFuArgs(SUBSTR_FUNCTION, SVal("abc"), IVal(0)), "substr(): arg 3 is missing"},
+		{BContains(SVal("abc"), IVal(1)), "contains(): arg 2 must be of type STRING"},
+		{BHasPrefix(BVal(true), SVal("a")), "hasprefix(): arg 1 must be of type STRING"},
+		{SStr(SVal("x")), "str(): arg 1 must be of type INT"},
+		{IMin(SVal("a"), IVal(1)), "min(): arg 1 must be of type INT"},
+		{IMax(IVal(1), BVal(true)), "max(): arg 2 must be of type INT"},
+		{IFuArgs(MAX_FUNCTION, IVal(1)), "max(): arg 2 is missing"},
+		{IAbs(BVal(false)), "abs(): arg 1 must be of type INT"},
+		{IAbs(IVar("undefined")), "var undefined does not exist"},
+		{IAbs(ILabel("undefined")), "undefined entry property"},
+		{IIf(IVal(1), IVal(2), IVal(3)), "if(): arg 1 must be of type BOOL"},
+		{IIf(BVal(true), SVal("a"), IVal(3)), "if(): arg 2 must be of type INT"},
+		{SIf(BVal(false), SVal("a"), IVal(3)), "if(): arg 3 must be of type STRING"},
+		{BFuArgs(IF_FUNCTION, BVal(true)), "if(): arg 2 is missing"},
+	}
+	for _, test := range tests {
+		arg := test.arg
+		call := arg.String()
+		err := evalTestArg(&arg, Vars{}, e)
+		if _, ok := err.(*EvalError); !ok {
+			t.Errorf("%s: eval error expected, got %T: %v", call, err, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%s: %q expected in: %s", call, test.msg, err)
+		}
+	}
+}
+
+// ----------------------------------------
+// the functions are written as calls into the latex of a link
+func TestExpressionFunctionsLatex(t *testing.T) {
+	mf := loadTestModel(t, "m.yaml", strings.Replace(TEST_MODEL_YAML, "query: {typ: {subtype: ENTRY_TYPE, string: A}, count: 1}",
+		`query: {typ: {subtype: ENTRY_TYPE, string: A}, count: 1}
+            eprops:
+              m: {kind: FU, fu: min(), args: [{kind: LABEL, type: INT, name: x}, 10]}
+              s: {kind: FU, type: STRING, fu: if(), args: [{kind: FU, fu: hasprefix(), args: ["a_b", "a"]}, "yes", {kind: FU, fu: str(), args: [5]}]}`, 1))
+	metaCtx, err := mf.NewMetaContext()
+	if nil != err {
+		t.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "pmModel_test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	typeCntQueryEPropsVarsLProps2Latex(f, testLinks(t, metaCtx.PeerSpace, "P1")[1])
+	f.Close()
+	data, err := ioutil.ReadFile(f.Name())
+	if nil != err {
+		t.Fatal(err)
+	}
+	// - type, count, sel, eprops, vars, lprops
+	expected := `{A}{1}{}{m\tteqs min(x,10), s\tteqs if(hasprefix(\ttdqt a\ttusc b\ttdqt ,\ttdqt a\ttdqt ),\ttdqt yes\ttdqt ,str(5))}{}{}` + "\n"
+	if expected != string(data) {
+		t.Fatalf("latex:\n%s\nexpected, got:\n%s", expected, data)
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
@@ -23,8 +23,11 @@
 package pmModel
 
 import (
+	. "github.com/peermodel/simulator/config"
 	. "github.com/peermodel/simulator/debug"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // tx state
@@ -39,12 +42,21 @@ type Tx struct {
 	// OCC or PCC
 	Txcc string
 	Pcc
+	Occ
 }
 
 ////////////////////////////////////////
 // methods
 ////////////////////////////////////////
 
+// ----------------------------------------
+// the tx of a wiring instance ends with the state COMMITTED or ROLLEDBACK: the instance has fired
+// - counted for livelock detection (see config: WIRING_FIRING_COUNT)
+func (tx *Tx) End(state string) {
+	tx.State = state
+	WIRING_FIRING_COUNT++
+}
+
 //------------------------------------------------------------
 // deep copy
 func (tx *Tx) Copy() *Tx {
@@ -61,11 +73,34 @@ func (tx *Tx) Copy() *Tx {
 	newTx.Txcc = tx.Txcc
 	// - Pcc:
 	newTx.Pcc.LockedCids = tx.Pcc.LockedCids.Copy()
+	// - Occ:
+	newTx.Occ = tx.Occ.Copy()
 	//------------------------------------------------------------
 	// return
 	return newTx
 }
 
+// ----------------------------------------
+// canonical description for model checking: without the volatile tx id
+// - nb: the read set of occ depends on the peer space (see PeerSpace.OccFingerprint)
+func (tx *Tx) Fingerprint() string {
+	if nil == tx {
+		return "nil"
+	}
+	lockedCids := tx.Pcc.LockedCids.Copy()
+	sort.Strings(lockedCids)
+	writtenCids := tx.Occ.WrittenCids.Copy()
+	sort.Strings(writtenCids)
+	return fmt.Sprintf("<%s, %s, %s, %s>", tx.State, tx.Txcc, strings.Join(lockedCids, " "), strings.Join(writtenCids, " "))
+}
+
+// ----------------------------------------
+// private fu:
+// has the tx neither locked, written nor read anything yet?
+func (tx *Tx) isUnused() bool {
+	return 0 == len(tx.Pcc.LockedCids) && 0 == len(tx.Occ.WrittenCids) && 0 == len(tx.Occ.ReadSet)
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
@@ -90,6 +125,9 @@ func (tx *Tx) ToString(tab int) string {
 	s = fmt.Sprintf("%sState=%s, ", s, tx.State)
 	s = fmt.Sprintf("%sTxcc=%s, Pcc=", s, tx.Txcc)
 	s = fmt.Sprintf("%s%a", s, tx.Pcc.LockedCids.ToString(0))
+	if OCC == tx.Txcc {
+		s = fmt.Sprintf("%s, Occ=%s", s, tx.Occ.ToString(0))
+	}
 	return s
 }
 
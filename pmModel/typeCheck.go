@@ -0,0 +1,382 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// static type check of the meta model, before any machine is started (see pmAutomata: InitRuntimeModelAndStartMachines)
+// - checks all args of all wirings: WProps, and per link its query (Typ, Sel, Count, Min, Max), Vars, LProps and EProps
+// -- every arg must be well-typed (see Arg.StaticType)
+// -- system properties must have their type, eg tts INT, dest STRING, commit BOOL; the query: Typ STRING,
+//    Sel BOOL, Count/Min/Max INT
+// -- a label must have the type of the entry property of the entry type of the link (see Query.Typ)
+// -- a var must be defined by the Vars of a link before (or of the link itself for its LProps and EProps),
+//    with the same type; nb: after a service call every var might be defined
+// - the types of the entry properties are inferred per entry type from the initial entries and from the
+//   EProps of the links; an entry type must not have a property with two types
+// -- nb: properties that are only set by services are not known -> their labels are not checked
+// - all errors are reported at once, with their positions
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+////////////////////////////////////////
+// data types
+////////////////////////////////////////
+
+// ----------------------------------------
+// position and message of a type error
+// - LinkNo is -1 for the wiring itself; Pid is "" for an initial entry
+type TypeError struct {
+	Pid    string
+	Wid    string
+	LinkNo int
+	// the arg, eg "sel" or "eprops: price"
+	What string
+	Msg  string
+}
+
+// ----------------------------------------
+type TypeErrors []*TypeError
+
+// ----------------------------------------
+// private type:
+// state of the type check
+type typeChecker struct {
+	errs TypeErrors
+	// inferred types of the entry properties: entry type -> label -> type
+	labelTypes map[string]map[string]DataTypeEnum
+	// current position
+	pid    string
+	wid    string
+	linkNo int
+	// vars of the current wiring whose definition is ill-typed: their uses are not checked (no follow-up errors)
+	illVars map[string]bool
+}
+
+// ----------------------------------------
+// types of the system properties
+var WPROPS_TYPES = map[string]DataTypeEnum{TTS: INT, TTL: INT, TXCC: STRING, ON_ABORT: BOOL, REPEAT_COUNT: INT, MAX_THREADS: INT}
+var LPROPS_TYPES = map[string]DataTypeEnum{TTS: INT, TTL: INT, DEST: STRING, SOURCE: STRING, FLOW: BOOL, MANDATORY: BOOL, COMMIT: BOOL}
+var EPROPS_TYPES = map[string]DataTypeEnum{TTS: INT, TTL: INT, DEST: STRING, FID: STRING, TYPE: STRING}
+
+////////////////////////////////////////
+// methods
+////////////////////////////////////////
+
+// ----------------------------------------
+// type check of the meta model; entries are the initial entries that are not yet in the containers
+// - returns all errors (nil if there are none)
+func (ps *PeerSpace) TypeCheck(entries []*Entry) TypeErrors {
+	tc := &typeChecker{labelTypes: map[string]map[string]DataTypeEnum{}, linkNo: -1}
+	//------------------------------------------------------------
+	// infer the types of the entry properties:
+	// - initial entries
+	for _, cid := range ps.ContainerCids {
+		if c := ps.Containers[cid]; nil != c {
+			for i := range c.Entries {
+				tc.inferEntry(&c.Entries[i])
+			}
+		}
+	}
+	for _, e := range entries {
+		tc.inferEntry(e)
+	}
+	// - EProps of the links
+	ps.forAllWirings(tc, func(w *Wiring) {
+		for i, l := range w.Links {
+			tc.linkNo = i
+			etype := linkEntryType(l)
+			for _, label := range sortedLabels(Args(l.EProps)) {
+				arg := l.EProps[label]
+				if t, err := arg.StaticType(); nil == err {
+					tc.addLabelType(etype, label, t, "eprops: "+label)
+				}
+			}
+		}
+	})
+	//------------------------------------------------------------
+	// check all args
+	ps.forAllWirings(tc, func(w *Wiring) {
+		// - WProps: evaluated when the wiring starts, ie without vars and entry
+		tc.linkNo = -1
+		for _, label := range sortedLabels(Args(w.WProps)) {
+			arg := w.WProps[label]
+			tc.checkArg(&arg, "wprops: "+label, typeOfSystemProp(WPROPS_TYPES, label), "", nil, true /* varsOpenFlag */)
+		}
+		// - links: their vars are defined in the order of the links
+		vars := map[string]DataTypeEnum{}
+		tc.illVars = map[string]bool{}
+		varsOpenFlag := false
+		for i, l := range w.Links {
+			tc.linkNo = i
+			etype := linkEntryType(l)
+			tc.checkArg(&l.Q.Typ, "typ", typePtr(STRING), etype, vars, varsOpenFlag)
+			if nil != l.Q.Sel {
+				tc.checkArg(l.Q.Sel, "sel", typePtr(BOOL), etype, vars, varsOpenFlag)
+			}
+			tc.checkArg(&l.Q.Count, "count", typePtr(INT), etype, vars, varsOpenFlag)
+			tc.checkArg(&l.Q.Min, "min", typePtr(INT), etype, vars, varsOpenFlag)
+			tc.checkArg(&l.Q.Max, "max", typePtr(INT), etype, vars, varsOpenFlag)
+			// vars of the link: evaluated with the vars before
+			linkVars := map[string]DataTypeEnum{}
+			for _, label := range sortedLabels(Args(l.LVars)) {
+				arg := l.LVars[label]
+				if t, ok := tc.checkArg(&arg, "vars: "+label, nil, etype, vars, varsOpenFlag); ok {
+					linkVars[label] = t
+					delete(tc.illVars, label)
+				} else {
+					tc.illVars[label] = true
+				}
+			}
+			for label, t := range linkVars {
+				vars[label] = t
+			}
+			for _, label := range sortedLabels(Args(l.LProps)) {
+				arg := l.LProps[label]
+				tc.checkArg(&arg, "lprops: "+label, typeOfSystemProp(LPROPS_TYPES, label), etype, vars, varsOpenFlag)
+			}
+			for _, label := range sortedLabels(Args(l.EProps)) {
+				arg := l.EProps[label]
+				tc.checkArg(&arg, "eprops: "+label, typeOfSystemProp(EPROPS_TYPES, label), etype, vars, varsOpenFlag)
+			}
+			// a service may set any var
+			if SERVICE == l.Type {
+				varsOpenFlag = true
+			}
+		}
+	})
+	return tc.errs
+}
+
+// ----------------------------------------
+// private fu:
+// call fu for all wirings of all peers, in the order of their creation; sets the position
+func (ps *PeerSpace) forAllWirings(tc *typeChecker, fu func(w *Wiring)) {
+	for _, pid := range ps.PeerPids {
+		p := ps.Peers[pid]
+		if nil == p {
+			continue
+		}
+		for _, wid := range p.WiringWids {
+			w := p.Wirings[wid]
+			if nil == w {
+				continue
+			}
+			tc.pid = p.Id
+			// the modeled wiring id, ie without peer prefix (see model file)
+			tc.wid = strings.TrimPrefix(w.Id, p.Id+SEP)
+			tc.linkNo = -1
+			fu(w)
+		}
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// infer the types of the properties of an initial entry
+func (tc *typeChecker) inferEntry(e *Entry) {
+	tc.pid = ""
+	tc.wid = ""
+	tc.linkNo = -1
+	for _, label := range sortedLabels(Args(e.EProps)) {
+		arg := e.EProps[label]
+		if t, err := arg.StaticType(); nil == err {
+			tc.addLabelType(e.GetType(), label, t, fmt.Sprintf("entry %s: %s", e.Id, label))
+		} else {
+			tc.addError(fmt.Sprintf("entry %s: %s", e.Id, label), err.Error())
+		}
+	}
+}
+
+// ----------------------------------------
+// private fu:
+// add the inferred type of the property of the entry type; an error if it has another type already
+func (tc *typeChecker) addLabelType(etype string, label string, t DataTypeEnum, what string) {
+	if "" == etype || WILDCARD == etype {
+		return
+	}
+	if nil == tc.labelTypes[etype] {
+		tc.labelTypes[etype] = map[string]DataTypeEnum{}
+	}
+	if known, ok := tc.labelTypes[etype][label]; ok {
+		if known != t {
+			tc.addError(what, fmt.Sprintf("property %s of entry type %s has type %s, but also %s", label, etype, known, t))
+		}
+		return
+	}
+	tc.labelTypes[etype][label] = t
+}
+
+// ----------------------------------------
+// private fu:
+// check the arg: its type (if expected is not nil), and the types of its labels and vars
+// - etype: entry type whose properties the labels refer to ("" = unknown)
+// - vars: the defined vars with their types; varsOpenFlag: undefined vars are not reported
+// - returns the type of the arg and whether it is well-typed
+func (tc *typeChecker) checkArg(arg *Arg, what string, expected *DataTypeEnum, etype string, vars map[string]DataTypeEnum, varsOpenFlag bool) (DataTypeEnum, bool) {
+	if arg.IsEmpty() {
+		return arg.Type, true
+	}
+	okFlag := true
+	t, err := arg.StaticType()
+	if nil != err {
+		tc.addError(what, err.Error())
+		okFlag = false
+	} else if nil != expected && *expected != t {
+		tc.addError(what, fmt.Sprintf("%s has type %s, but %s is expected", arg.ToString(0), t, *expected))
+		okFlag = false
+	}
+	arg.walk(func(a *Arg) {
+		switch a.Kind {
+		case LABEL:
+			if known, ok := tc.labelTypes[etype][a.Name]; ok && known != a.Type {
+				tc.addError(what, fmt.Sprintf("label %s has type %s, but property %s of entry type %s has type %s", a.Name, a.Type, a.Name, etype, known))
+				okFlag = false
+			}
+		case VAR:
+			if tc.illVars[a.Name] {
+				break
+			}
+			if known, ok := vars[a.Name]; ok {
+				if known != a.Type {
+					tc.addError(what, fmt.Sprintf("var %s is used as %s, but is defined as %s", a.Name, a.Type, known))
+					okFlag = false
+				}
+			} else if !varsOpenFlag {
+				tc.addError(what, fmt.Sprintf("var %s is not defined by a link before", a.Name))
+				okFlag = false
+			}
+		}
+	})
+	return t, okFlag
+}
+
+// ----------------------------------------
+// private fu:
+// nb: the same error of a link is reported once, eg if min and max are derived from count
+func (tc *typeChecker) addError(what string, msg string) {
+	for _, err := range tc.errs {
+		if err.Pid == tc.pid && err.Wid == tc.wid && err.LinkNo == tc.linkNo && err.Msg == msg {
+			return
+		}
+	}
+	tc.errs = append(tc.errs, &TypeError{Pid: tc.pid, Wid: tc.wid, LinkNo: tc.linkNo, What: what, Msg: msg})
+}
+
+// ----------------------------------------
+// private fu:
+// call fu for the arg and all its sub args
+func (arg *Arg) walk(fu func(a *Arg)) {
+	fu(arg)
+	if nil != arg.ExprVal {
+		arg.ExprVal.Left.walk(fu)
+		if !arg.ExprVal.Right.IsEmpty() {
+			arg.ExprVal.Right.walk(fu)
+		}
+	}
+	for i := range arg.FuArgs {
+		arg.FuArgs[i].walk(fu)
+	}
+}
+
+// ----------------------------------------
+func (err *TypeError) Error() string {
+	return strings.Join(append(position(err.Pid, err.Wid, err.LinkNo), err.What, err.Msg), ": ")
+}
+
+// ----------------------------------------
+// all errors, one per line
+func (errs TypeErrors) Error() string {
+	lines := []string{fmt.Sprintf("%d type errors:", len(errs))}
+	for _, err := range errs {
+		lines = append(lines, "- "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+////////////////////////////////////////
+// functions
+////////////////////////////////////////
+
+// ----------------------------------------
+// private fu:
+// position in the meta model, eg [peer P1, wiring W1, link 2]; empty parts are omitted
+func position(pid string, wid string, linkNo int) []string {
+	pos := []string{}
+	if "" != pid {
+		pos = append(pos, "peer "+pid)
+	}
+	if "" != wid {
+		pos = append(pos, "wiring "+wid)
+	}
+	if 0 <= linkNo {
+		pos = append(pos, fmt.Sprintf("link %d", linkNo))
+	}
+	return pos
+}
+
+// ----------------------------------------
+// private fu:
+// entry type of the link, if it is static ("" otherwise)
+func linkEntryType(l *Link) string {
+	if VAL == l.Q.Typ.Kind && STRING == l.Q.Typ.Type {
+		return l.Q.Typ.StringVal
+	}
+	return ""
+}
+
+// ----------------------------------------
+// private fu:
+// expected type of the (system) property; nil for user properties
+func typeOfSystemProp(types map[string]DataTypeEnum, label string) *DataTypeEnum {
+	if t, ok := types[label]; ok {
+		return &t
+	}
+	return nil
+}
+
+// ----------------------------------------
+// private fu:
+func typePtr(t DataTypeEnum) *DataTypeEnum {
+	return &t
+}
+
+// ----------------------------------------
+// private fu:
+func sortedLabels(args Args) []string {
+	labels := []string{}
+	for label := range args {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
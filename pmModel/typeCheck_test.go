@@ -0,0 +1,200 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// tests of the static type check
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////
+// test data
+////////////////////////////////////////
+
+// ----------------------------------------
+// one error of each kind, in two wirings
+const TEST_ILL_TYPED_MODEL_YAML = `name: illTyped
+peers:
+  - id: P1
+    wirings:
+      - id: W1
+        wprops: {tts: {string: soon}}
+        links:
+          - type: guard
+            c: PIC
+            op: take
+            query:
+              typ: {subtype: ENTRY_TYPE, string: A}
+              count: 1
+              sel: {kind: EXPR, op: LESS, left: {kind: LABEL, type: STRING, name: x}, right: 10}
+          - type: action
+            c: POC
+            op: write
+            query: {typ: {subtype: ENTRY_TYPE, string: A}, count: 1}
+            lprops: {dest: {kind: VAR, type: STRING, name: d}}
+            eprops: {y: 1}
+      - id: W2
+        links:
+          - {type: guard, c: PIC, op: take, query: {typ: {subtype: ENTRY_TYPE, string: A}, count: {string: one}}}
+entries:
+  - {peer: P1, container: PIC, type: A, eprops: {x: 3, y: s}}
+`
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// the type errors of the model
+func typeErrors(t *testing.T, data string) TypeErrors {
+	t.Helper()
+	_, err := loadTestModel(t, "m.yaml", data).NewMetaContext()
+	if nil == err {
+		return nil
+	}
+	errs, ok := err.(TypeErrors)
+	if !ok {
+		t.Fatalf("type errors expected, got %v", err)
+	}
+	return errs
+}
+
+////////////////////////////////////////
+// tests
+////////////////////////////////////////
+
+// ----------------------------------------
+// all errors are reported at once, with their positions
+func TestTypeCheckCollectsAllErrors(t *testing.T) {
+	errs := typeErrors(t, TEST_ILL_TYPED_MODEL_YAML)
+	expected := []string{
+		"peer P1: wiring W1: wprops: tts: \"soon\" has type STRING, but INT is expected",
+		"peer P1: wiring W1: link 0: sel: label x has type STRING, but property x of entry type A has type INT",
+		"peer P1: wiring W1: link 1: lprops: dest: var d is not defined by a link before",
+		"peer P1: wiring W1: link 1: eprops: y: property y of entry type A has type STRING, but also INT",
+		"peer P1: wiring W2: link 0: count: \"one\" has type STRING, but INT is expected",
+	}
+	for _, msg := range expected {
+		if !strings.Contains(errs.Error(), msg) {
+			t.Errorf("%q expected in:\n%s", msg, errs)
+		}
+	}
+	for _, err := range errs {
+		if "P1" != err.Pid || ("W1" != err.Wid && "W2" != err.Wid) {
+			t.Errorf("position expected: %s", err)
+		}
+		// - the wiring itself has no link
+		if strings.HasPrefix(err.What, "wprops") != (-1 == err.LinkNo) {
+			t.Errorf("link position expected: %s", err)
+		}
+	}
+}
+
+// ----------------------------------------
+// a var defined by a link before is known, with its type
+func TestTypeCheckVars(t *testing.T) {
+	model := func(varType string) string {
+		return `peers:
+  - id: P1
+    wirings:
+      - id: W1
+        links:
+          - type: guard
+            c: PIC
+            op: take
+            query: {typ: {subtype: ENTRY_TYPE, string: A}, count: 1}
+            vars: {d: {kind: LABEL, type: ` + varType + `, name: to}}
+          - type: action
+            c: POC
+            op: write
+            query: {typ: {subtype: ENTRY_TYPE, string: A}, count: 1}
+            lprops: {dest: {kind: VAR, type: STRING, name: d}}
+entries:
+  - {peer: P1, container: PIC, type: A, eprops: {to: P1}}
+`
+	}
+	if errs := typeErrors(t, model("STRING")); nil != errs {
+		t.Fatalf("no type errors expected, got:\n%s", errs)
+	}
+	errs := typeErrors(t, model("INT"))
+	if nil == errs || !strings.Contains(errs.Error(), "link 0: vars: d") {
+		t.Fatalf("error of the var expected, got:\n%v", errs)
+	}
+}
+
+// ----------------------------------------
+// the args of the functions are checked against their signature; also a label in an arg
+func TestTypeCheckFunctions(t *testing.T) {
+	errs := typeErrors(t, `peers:
+  - id: P1
+    wirings:
+      - id: W1
+        links:
+          - type: guard
+            c: PIC
+            op: take
+            query:
+              typ: {subtype: ENTRY_TYPE, string: A}
+              count: 1
+              sel: {kind: FU, fu: contains(), args: [{kind: LABEL, type: STRING, name: x}, "a"]}
+          - type: action
+            c: POC
+            op: write
+            query: {typ: {subtype: ENTRY_TYPE, string: A}, count: 1}
+            eprops:
+              m: {kind: FU, fu: min(), args: ["a", 1]}
+              n: {kind: FU, type: INT, fu: if(), args: [1, 2, 3]}
+              o: {kind: FU, fu: max(), args: [{kind: LABEL, type: INT, name: x}, 1]}
+entries:
+  - {peer: P1, container: PIC, type: A, eprops: {x: 3}}
+`)
+	expected := []string{
+		"peer P1: wiring W1: link 0: sel: label x has type STRING, but property x of entry type A has type INT",
+		"peer P1: wiring W1: link 1: eprops: m: arg 1 of min() must be of type INT, but \"a\" has type STRING",
+		"peer P1: wiring W1: link 1: eprops: n: arg 1 of if() must be of type BOOL, but 1 has type INT",
+	}
+	if len(expected) != len(errs) {
+		t.Fatalf("%d type errors expected, got:\n%v", len(expected), errs)
+	}
+	for _, msg := range expected {
+		if !strings.Contains(errs.Error(), msg) {
+			t.Errorf("%q expected in:\n%s", msg, errs)
+		}
+	}
+}
+
+// ----------------------------------------
+func TestTypeCheckWellTypedModel(t *testing.T) {
+	if errs := typeErrors(t, TEST_MODEL_YAML); nil != errs {
+		t.Fatalf("no type errors expected, got:\n%s", errs)
+	}
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
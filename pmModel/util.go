@@ -33,6 +33,13 @@ func Uuid(prefix string) string {
 	return fmt.Sprintf("%s%d", prefix, UUID)
 }
 
+// ----------------------------------------
+// next entry version: unique, also across runs
+func NewEntryVersion() int {
+	ENTRY_VERSION++
+	return ENTRY_VERSION
+}
+
 ////////////////////////////////////////
 // EOF
 ////////////////////////////////////////
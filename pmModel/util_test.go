@@ -0,0 +1,100 @@
+////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+////////////////////////////////////////
+
+////////////////////////////////////////
+// DOCU:
+// test data and helpers shared by the tests of the package
+////////////////////////////////////////
+
+package pmModel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+////////////////////////////////////////
+// test data
+////////////////////////////////////////
+
+// ----------------------------------------
+// one peer that takes an A with x < 10 from its PIC and writes it into its POC
+const TEST_MODEL_YAML = `name: test
+system_peers:
+  - peer: Stop
+peers:
+  - id: P1
+    poc: {coordinator: fifo}
+    wirings:
+      - id: W1
+        wprops: {repeat_count: 1}
+        links:
+          - type: guard
+            c: PIC
+            op: take
+            query:
+              typ: {subtype: ENTRY_TYPE, string: A}
+              count: 1
+              sel: {kind: EXPR, op: LESS, left: {kind: LABEL, type: INT, name: x}, right: 10}
+          - type: action
+            c: POC
+            op: write
+            query: {typ: {subtype: ENTRY_TYPE, string: A}, count: 1}
+entries:
+  - {peer: P1, container: PIC, type: A, eprops: {x: 3}}
+`
+
+////////////////////////////////////////
+// helpers
+////////////////////////////////////////
+
+// ----------------------------------------
+// write the file into a fresh temp dir and return its path
+func writeTestFile(t *testing.T, name string, data string) string {
+	dir, err := ioutil.TempDir("", "pmModel_test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(data), 0644); nil != err {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// ----------------------------------------
+// load the model file from the data
+func loadTestModel(t *testing.T, name string, data string) *ModelFile {
+	t.Helper()
+	mf, err := LoadModelFile(writeTestFile(t, name, data))
+	if nil != err {
+		t.Fatal(err)
+	}
+	return mf
+}
+
+////////////////////////////////////////
+// EOF
+////////////////////////////////////////
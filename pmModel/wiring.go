@@ -250,6 +250,13 @@ func (w *Wiring) AddAction(subpid string, c2 string, op SpaceOpTypeEnum, q Query
 	w.AddLink(NewAction(subpid, c2, op, q, lprops, eprops, vars))
 }
 
+// --------------------------------------------
+// model checking: an alias into the meta model in the LVS of a machine is recomputed from
+// - its context at choice point recovery -> not part of the state
+func (w *Wiring) Fingerprint() string {
+	return "-"
+}
+
 ////////////////////////////////////////
 // empty test
 ////////////////////////////////////////
@@ -31,6 +31,7 @@ package runtime
 import (
 	. "github.com/peermodel/simulator/config"
 	. "github.com/peermodel/simulator/debug"
+	. "github.com/peermodel/simulator/eventLog"
 	. "github.com/peermodel/simulator/framework"
 	. "github.com/peermodel/simulator/latex"
 	. "github.com/peermodel/simulator/scheduler"
@@ -38,17 +39,140 @@ import (
 	"runtime"
 )
 
+//------------------------------------------------------------
+// run the test case with the currently applied config (see config.CurrentConfig)
+// - kept for generated use cases; the given system ttl of the use case applies if it is > 0 and no system ttl
+//   is configured (see Config.IsSet); a configured one wins, also if it is the default one
+// - returns the error of an invalid system ttl, or the error that stopped the run, if any (see RunWithConfig)
+func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, systemTtl int) error {
+	cfg, err := useCaseConfig(systemTtl)
+	if nil != err {
+		return err
+	}
+	_, err = RunWithConfig(s, testCaseName, testCaseLatexConfig, cfg)
+	return err
+}
+
+//------------------------------------------------------------
+// private fu:
+// the currently applied config with the system ttl of the use case (see Run)
+func useCaseConfig(systemTtl int) (*Config, error) {
+	cfg := CurrentConfig()
+	if 0 < systemTtl && !cfg.IsSet(SYSTEM_TTL_KEY) {
+		cfg.SystemTtl = systemTtl
+		if err := cfg.Validate(); nil != err {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+//------------------------------------------------------------
+// run the test case once along the path of the replay trace file (see framework: ReplayTrace)
+// - eg to reproduce a counterexample found by model checking under the normal tracer
+// - otherwise with the currently applied config
+func ReplayRun(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, replayFile string) (VerdictTypeEnum, error) {
+	cfg := CurrentConfig()
+	cfg.VerificationMode = REPLAY
+	cfg.ReplayFile = replayFile
+	return RunWithConfig(s, testCaseName, testCaseLatexConfig, cfg)
+}
+
 //------------------------------------------------------------
 // init and manage the test case run depending on execution and verification mode
+// - the config is validated and applied first; so several configs can be run one after the other in one process
+// -- nb: each run needs its own, freshly initialized status
 // - nb: if Run finishes, all machines have stopped (by user, or by system ttl);
 // - caution: initially, all machines must be created by the caller and reflected in the machine controls of status;
-// -- ie: call NewTestCase (via testPreparation that generates the status) before running it,
-// -- otherwise SIMULATION_COUNT, VERIFICATION_MODE etc. are not initialized;
-func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, systemTtl int) {
+// -- ie: call NewTestCase (via testPreparation that generates the status) before running it;
+// - returns the verdict (see VERDICT)
+// - returns the error, if the config is invalid or if the model raised an error (see debug: errors.go)
+// -- eg a ModelError, EvalError or InternalError with its context; the run is stopped then, and no verdict is reported
+// -- nb: so the caller can continue with the next model
+func RunWithConfig(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, cfg *Config) (verdict VerdictTypeEnum, err error) {
+	//------------------------------------------------------------
+	// errors raised outside of the machines, eg by the controller
+	// - an error leaves no globals of the run behind
+	// - the clocks are reset in any case, because the status of the next run is created before it starts
+	prevCfg := CurrentConfig()
+	defer func() {
+		if r := recover(); nil != r {
+			verdict, err = VERDICT, RecoveredError(r)
+		}
+		if nil != err {
+			resetRunGlobals(s, prevCfg)
+		}
+		CLOCK = 0
+		EVENT_CLOCK = 0
+	}()
+	//------------------------------------------------------------
+	// apply config
+	if nil == cfg {
+		cfg = CurrentConfig()
+	}
+	if err := cfg.Apply(); nil != err {
+		return VERDICT, err
+	}
+	// - the status might have been created with another system ttl
+	s.Scheduler = s.Scheduler.ResetSttlSlot(SYSTEM_TTL)
+	RUN_COUNT = 0
+	VERDICT = NO_VIOLATION
+	VERDICT_MSG = ""
+	COUNTEREXAMPLE = Path{}
+	COUNTEREXAMPLE_DRAWS = RandomDraws{}
+	//------------------------------------------------------------
+	// replay: read the trace
+	// - caution: before the debug init, which creates a new replay trace file
+	CUR_REPLAY_TRACE = nil
+	defer func() { CUR_REPLAY_TRACE = nil }()
+	if REPLAY == VERIFICATION_MODE {
+		trace, err := LoadReplayTrace(REPLAY_FILE)
+		if nil != err {
+			return VERDICT, err
+		}
+		CUR_REPLAY_TRACE = trace
+		s.Replay = trace
+	}
+	//------------------------------------------------------------
+	// seed the random generator
+	// - nb: simulation runs reseed it for every run
+	SeedRun(1)
+	//------------------------------------------------------------
+	// the status has made random draws when it was created, eg for the initial entries, ie before the seed
+	// (or the replay trace) applied -> create it again, so that they are made by the seeded and recorded generator
+	// - nb: without a use case function it cannot be created again
+	if 0 < len(s.Draws) {
+		if nil == s.InitAppUseCaseFu {
+			return VERDICT, fmt.Errorf("runtime: the status made %d random draws before the random generator was seeded", len(s.Draws))
+		}
+		s.StopMachines()
+		s = s.InitAppUseCaseFu()
+		s.Scheduler = s.Scheduler.ResetSttlSlot(SYSTEM_TTL)
+	}
 	//------------------------------------------------------------
 	// init debugging
 	DebugInit()
 	//------------------------------------------------------------
+	// open the event log (if configured)
+	// - nb: every run logs its records itself (see Status.Run)
+	if err := OpenEventLog(EVENT_LOG_FILE); nil != err {
+		return VERDICT, err
+	}
+	defer CloseEventLog()
+	//------------------------------------------------------------
+	// open the debugger (if configured)
+	// - nb: tcp: waits for the connection
+	if err := OpenDebugger(DEBUGGER); nil != err {
+		return VERDICT, err
+	}
+	defer CloseDebugger()
+	//------------------------------------------------------------
+	// open the http api (if configured)
+	if err := OpenHttpApi(HTTP_API); nil != err {
+		return VERDICT, err
+	}
+	defer CloseHttpApi()
+	//------------------------------------------------------------
 	// debug: print the entire status/space (only for the first run)
 	// - s.ModelPrint(TRACE0, IND, testCaseId)
 	// - s.SpacePrint(TRACE0, IND, false /* printAlsoEmptyContainersFlag */)
@@ -64,6 +188,21 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 	CLOCK = 0
 	EVENT_CLOCK = 0
 	//------------------------------------------------------------
+	// restore the snapshot (if configured)
+	// - the first run continues where the snapshot was taken, also with the random generator
+	// - nb: the restored status is the initial one for all modes
+	var snap *Snapshot
+	if "" != RESTORE_FILE {
+		if snap, err = LoadSnapshot(RESTORE_FILE); nil != err {
+			return VERDICT, err
+		}
+		if s, err = s.Restore(snap); nil != err {
+			return VERDICT, err
+		}
+		snap.FastForwardRandomGenerator()
+		s.SystemInfo(fmt.Sprintf("RESTORE: %d machines at t=%d, et=%d from %s", len(snap.Machines), CLOCK, EVENT_CLOCK, RESTORE_FILE))
+	}
+	//------------------------------------------------------------
 	// execute the test case depending on execution and verification mode
 	switch VERIFICATION_MODE {
 	//------------------------------------------------------------
@@ -73,11 +212,30 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 		//============================================================
 		// run the test case exactly once
 		//------------------------------------------------------------
+		RUN_COUNT = 1
+		//------------------------------------------------------------
 		// debug:
 		//   s.PrintMyGoRoutineId("RUN", "Runtime") // DEBUG
 		//------------------------------------------------------------
 		// run the test case:
 		s.Run()
+		err = s.Err
+		//------------------------------------------------------------
+		// debug:
+		s.PrintStatistics() // DEBUG
+
+	case REPLAY:
+		//============================================================
+		// REPLAY
+		//============================================================
+		// run the test case once, following the path of the replay trace
+		// - nb: the path starts at the beginning, also if it was recorded in a model checking run
+		//   that started at a choice point
+		//------------------------------------------------------------
+		RUN_COUNT = 1
+		s.SystemInfo(fmt.Sprintf("REPLAY: %d steps and %d random draws of run %d from %s", len(s.Replay.Path), len(s.Replay.Draws), s.Replay.Run, REPLAY_FILE))
+		s.Run()
+		err = s.Err
 		//------------------------------------------------------------
 		// debug:
 		s.PrintStatistics() // DEBUG
@@ -91,12 +249,15 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 		//------------------------------------------------------------
 		nextS := s
 		//------------------------------------------------------------
+		// observables of the runs
+		runStats := NewRunStatistics(s.MetaContext.ObservableNames())
+		//------------------------------------------------------------
 		for RUN_COUNT = 1; RUN_COUNT <= SIMULATION_COUNT; RUN_COUNT++ {
 			//------------------------------------------------------------
 			// debug:
 			//............................................................
 			if SIMULATION_TRACE.DoTrace() { // DEBUG
-				Banner2TraceFile("\n\n", fmt.Sprintf("%d. MODEL SIMULATION RUN:\n", RUN_COUNT), "\n") // DEBUG
+				Banner2TraceFile("\n\n", fmt.Sprintf("%d. MODEL SIMULATION RUN (seed=%d):\n", RUN_COUNT, RUN_SEED), "\n") // DEBUG
 			} // DEBUG
 			//............................................................
 			// print my go routine id:
@@ -113,17 +274,51 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 			// debug
 			nextS.PrintStatistics() // DEBUG
 			//------------------------------------------------------------
+			// error? -> no more runs
+			if err = nextS.Err; nil != err {
+				break
+			}
+			//------------------------------------------------------------
+			runStats.Add(nextS.MetaContext.ObservableValues())
+			//------------------------------------------------------------
+			// violation found, or quit in the debugger? -> no more runs
+			if NO_VIOLATION != VERDICT || DebuggerQuitted() {
+				break
+			}
+			//------------------------------------------------------------
+			// target precision of the observables reached? -> no more runs
+			if 0 < SIMULATION_PRECISION && runStats.PrecisionReached(SIMULATION_PRECISION) {
+				s.SystemInfo(fmt.Sprintf("SIMULATION: precision %g reached after %d runs", SIMULATION_PRECISION, RUN_COUNT))
+				break
+			}
+			//------------------------------------------------------------
 			// TBD: close all channels that still exist
+			// - nb: not needed for the sequential executor, which has no machine go routines
 			//------------------------------------------------------------
 			// prepare everything for the next run:
+			// - each run has its own seed; it is seeded before its status is created, which already draws, eg for the
+			//   initial entries (see NewStatus); a single run can be repeated with its run seed as seed (see RunSeed)
+			// -- nb: the first run is seeded above; from a snapshot, it continues with the random generator of the snapshot
 			// - generate a completely fresh new status (as a copy of the orig metacontext copied from s);
 			// - init the test case and start all machines (async) which also generates the machine controls;
+			SeedRun(RUN_COUNT + 1)
 			nextS = s.InitAppUseCaseFu()
 			//------------------------------------------------------------
 			// reset clocks
 			CLOCK = 0
 			EVENT_CLOCK = 0
+			//------------------------------------------------------------
+			// start at the snapshot again (if configured)
+			// - nb: sets the clocks
+			if nil != snap {
+				if nextS, err = nextS.Restore(snap); nil != err {
+					return VERDICT, err
+				}
+			}
 		}
+		//------------------------------------------------------------
+		// statistics of the observables over all runs
+		s.PrintRunStatistics(runStats)
 
 	case MODEL_CHECKING:
 		//============================================================
@@ -193,9 +388,28 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 			s.PrintStatistics() // DEBUG
 			//_._._._._._._._._._._._._._._._._._._._._._._._._._._._._._.
 			//------------------------------------------------------------
+			// ERROR? -> model checking ends
+			//_._._._._._._._._._._._._._._._._._._._._._._._._._._._._._.
+			if err = nextS.Err; nil != err {
+				break
+			}
+			//_._._._._._._._._._._._._._._._._._._._._._._._._._._._._._.
+			//------------------------------------------------------------
 			// DONE?
 			//_._._._._._._._._._._._._._._._._._._._._._._._._._._._._._.
 			//------------------------------------------------------------
+			// 0) has a violation been found?
+			if NO_VIOLATION != VERDICT {
+				//------------------------------------------------------------
+				// debug
+				if MODEL_CHECKING_TRACE.DoTrace() { // DEBUG
+					/**/ String2TraceFile(fmt.Sprintf("MODEL CHECKING END: %s found in run %d\n", VERDICT, RUN_COUNT)) // DEBUG
+				} // DEBUG
+				//------------------------------------------------------------
+				// finished -> counterexample found
+				break
+			}
+			//------------------------------------------------------------
 			// 1) have all choice points been tried?
 			if 0 == len(MC_VARS.ChoicePoints) {
 				//------------------------------------------------------------
@@ -224,11 +438,17 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 			// 3) do BACKTRACKING: there are still choices
 			//_._._._._._._._._._._._._._._._._._._._._._._._._._._._._._.
 			//------------------------------------------------------------
+			// the status of the run is discarded: release its scheduler, which may share its queue with the
+			// statuses of choice points
+			nextS.Scheduler = nextS.Scheduler.Release()
+			//------------------------------------------------------------
 			// get next choice (= machine key) and its updated CP (ie without the selected choice) from CP list:
 			// - possibly also removing the CP from the global CPs collection, if it was its last choice;
 			// - nb: there must exist a choice (because there is at least one choice point open -- see check 1) above);
 			// - nb: the chosen choice (and possibly also its CP) is removed from CP list;
-			MC_VARS.CurChoice, MC_VARS.CurChoicePoint = MC_VARS.ChoicePoints.EasyFetchChoice()
+			// - nb: a random choice is drawn by the status of the completed run, ie seeded and recorded; its replay
+			//   trace has already been written
+			MC_VARS.CurChoice, MC_VARS.CurChoicePoint = MC_VARS.ChoicePoints.EasyFetchChoice(nextS.RandomIntn)
 			//------------------------------------------------------------
 			// set flag that next run starts with a recovered choice
 			// - caution: flag must be set every new run, because Controller resets the variable!
@@ -330,8 +550,11 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 				}
 				//------------------------------------------------------------
 				// start async execution of machine
+				// - sequential executor: nothing to start; the controller executes the machine when it resumes it
 				if ASYNC == mc.M.StartType {
-					go mc.M.Execute(nextS, mc)
+					if SEQUENTIAL != EXECUTOR {
+						go mc.M.Execute(nextS, mc)
+					}
 				} else {
 					//------------------------------------------------------------
 					// assertion:
@@ -356,6 +579,9 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 			// TBD: do garbage collection
 			runtime.GC()
 		}
+		//------------------------------------------------------------
+		// report the state space statistics
+		s.SystemInfo(fmt.Sprintf("MODEL CHECKING: %d runs, %d states explored, %d states pruned", RUN_COUNT, MC_VARS.StatesExplored, MC_VARS.StatesPruned))
 
 	default:
 		//============================================================
@@ -363,6 +589,35 @@ func Run(s *Status, testCaseName string, testCaseLatexConfig LatexConfig, system
 		//============================================================
 		Panic("ill. execution mode")
 	}
+	//------------------------------------------------------------
+	// error: stopped the run
+	if nil != err {
+		s.SystemInfo(fmt.Sprintf("ERROR: %s", err))
+		return VERDICT, err
+	}
+	//------------------------------------------------------------
+	// report the violation and the path that led to it
+	// - nb: the replay trace of the counterexample is the last run in the replay trace file
+	if NO_VIOLATION != VERDICT {
+		s.SystemInfo(fmt.Sprintf("VERDICT: %s: %s", VERDICT, VERDICT_MSG))
+		String2TraceFile(fmt.Sprintf("COUNTEREXAMPLE (%d steps, * = choice):\n%s", len(COUNTEREXAMPLE), COUNTEREXAMPLE.ToString(TAB)))
+		WriteReplayTrace(RUN_COUNT, COUNTEREXAMPLE, COUNTEREXAMPLE_DRAWS, s.SelectsAtRandom())
+	}
+	//------------------------------------------------------------
+	return VERDICT, nil
+}
+
+//------------------------------------------------------------
+// private fu:
+// reset the globals of a run that stopped with an error, so that the next run in this process starts clean
+// - the config that was applied before, and the event sink of the metrics
+// - nb: the clocks are reset by the caller
+func resetRunGlobals(s *Status, prevCfg *Config) {
+	prevCfg.Apply()
+	if nil != s && nil != s.MetaContext {
+		s.MetaContext.StopMetrics()
+	}
+	SetEventSink(nil)
 }
 
 //////////////////////////////////////////////////////////////
@@ -0,0 +1,120 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the runtime
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package runtime
+
+import (
+	. "github.com/peermodel/simulator/config"
+	. "github.com/peermodel/simulator/framework"
+	. "github.com/peermodel/simulator/latex"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// the system ttl of a use case applies only if none is configured
+// - nb: a configured default system ttl is configured too
+func TestUseCaseConfigSystemTtl(t *testing.T) {
+	prev := CurrentConfig()
+	defer prev.Apply()
+	tests := []struct {
+		name       string
+		configured string // "" ... not configured
+		useCase    int
+		expected   int
+	}{
+		{"none configured", "", 30, 30},
+		{"configured wins", "50", 30, 50},
+		{"configured default wins", strconv.Itoa(DEFAULT_SYSTEM_TTL), 30, DEFAULT_SYSTEM_TTL},
+		{"no use case ttl", "50", 0, 50},
+		{"no ttl at all", "", 0, DEFAULT_SYSTEM_TTL},
+	}
+	for _, test := range tests {
+		cfg := NewConfig()
+		if "" != test.configured {
+			if err := cfg.Set(SYSTEM_TTL_KEY, test.configured); nil != err {
+				t.Fatal(err)
+			}
+		}
+		if err := cfg.Apply(); nil != err {
+			t.Fatal(err)
+		}
+		got, err := useCaseConfig(test.useCase)
+		if nil != err {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		if test.expected != got.SystemTtl {
+			t.Errorf("%s: system ttl %d expected, got %d", test.name, test.expected, got.SystemTtl)
+		}
+		// - the applied config is not changed
+		if cfg.SystemTtl != SYSTEM_TTL {
+			t.Errorf("%s: SYSTEM_TTL changed to %d", test.name, SYSTEM_TTL)
+		}
+	}
+}
+
+//------------------------------------------------------------
+// an invalid system ttl of a use case is an error, not a panic
+func TestRunInvalidSystemTtl(t *testing.T) {
+	prev := CurrentConfig()
+	defer prev.Apply()
+	if err := NewConfig().Apply(); nil != err {
+		t.Fatal(err)
+	}
+	// - nb: the status is not needed, because the config is checked first
+	err := Run(nil, "invalid", NewLatexConfig(), MAX_SYSTEM_TTL+1)
+	if nil == err || !strings.Contains(err.Error(), SYSTEM_TTL_KEY) {
+		t.Fatalf("system ttl error expected, got %v", err)
+	}
+}
+
+//------------------------------------------------------------
+// the draws of a status made before the seed cannot be made again without a use case function
+// - nb: they would not be reproducible with the seed
+func TestRunStatusDrewBeforeSeed(t *testing.T) {
+	prev := CurrentConfig()
+	defer prev.Apply()
+	if err := NewConfig().Apply(); nil != err {
+		t.Fatal(err)
+	}
+	s := NewStatus(10, nil)
+	s.RandomIntn(3)
+	s.RandomIntn(5)
+	_, err := RunWithConfig(s, "drawn", NewLatexConfig(), nil)
+	if expected := "runtime: the status made 2 random draws before the random generator was seeded"; nil == err || expected != err.Error() {
+		t.Fatalf("%q expected, got %v", expected, err)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
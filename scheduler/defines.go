@@ -29,6 +29,9 @@ package scheduler
 import (
 	. "github.com/peermodel/simulator/config"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 //////////////////////////////////////////////////////////////
@@ -37,9 +40,14 @@ import (
 
 //------------------------------------------------------------
 // INFINITE
-// - is set to system time to live (= SYSTEM_TTL)
+// - is set to the maximal system time to live (= MAX_SYSTEM_TTL)
+// - nb: must be a const sentinel; the configured SYSTEM_TTL may be smaller
 // - alternatively it can be set to MAX_INT
-const INFINITE int = SYSTEM_TTL
+const INFINITE int = MAX_SYSTEM_TTL
+
+//------------------------------------------------------------
+// marks a relative event time in a state fingerprint (see EventTimeFingerprint)
+const EVENT_TIME_MARK string = "\x00"
 
 //////////////////////////////////////////////////////////////
 // vars
@@ -58,6 +66,12 @@ var CLOCK int
 // EVENT CLOCK:
 var EVENT_CLOCK int
 
+//------------------------------------------------------------
+// model checking: are times written into state fingerprints relative to the current clocks?
+// - needed to recognize that a state is visited again, or that a path runs into a loop (lasso);
+//   see TimeFingerprint
+var RELATIVE_TIME_FINGERPRINT_FLAG bool = false
+
 //////////////////////////////////////////////////////////////
 // enums
 //////////////////////////////////////////////////////////////
@@ -123,6 +137,71 @@ func (t EventTypeEnum) String() string {
 	}
 }
 
+//////////////////////////////////////////////////////////////
+// functions
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// a system time in a state fingerprint
+// - relative: times at or after the system ttl never come -> "ttl"; past times are all the same -> "0"
+func TimeFingerprint(t int) string {
+	if !RELATIVE_TIME_FINGERPRINT_FLAG {
+		return strconv.Itoa(t)
+	}
+	if t >= SYSTEM_TTL {
+		return "ttl"
+	}
+	if t <= CLOCK {
+		return "0"
+	}
+	return strconv.Itoa(t - CLOCK)
+}
+
+//------------------------------------------------------------
+// an event time in a state fingerprint
+// - relative: "never" (< 0) stays "-"; otherwise a mark that is replaced by the rank of the event time
+//   (see RankEventTimes)
+// -- nb: event times are only compared with each other, ie their order is all that counts
+func EventTimeFingerprint(et int) string {
+	if !RELATIVE_TIME_FINGERPRINT_FLAG {
+		return strconv.Itoa(et)
+	}
+	if et < 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%s%d%s", EVENT_TIME_MARK, et, EVENT_TIME_MARK)
+}
+
+//------------------------------------------------------------
+// replaces the marked event times of a state fingerprint by their rank relative to the EVENT_CLOCK
+// - the EVENT_CLOCK is "e0", the next older event time is "e-1" etc.
+func RankEventTimes(fp string) string {
+	parts := strings.Split(fp, EVENT_TIME_MARK)
+	//------------------------------------------------------------
+	// collect the distinct event times (odd parts) together with the EVENT_CLOCK
+	ets := map[int]bool{EVENT_CLOCK: true}
+	for i := 1; i < len(parts); i += 2 {
+		et, err := strconv.Atoi(parts[i])
+		if nil != err {
+			panic(fmt.Sprintf("ill. event time mark %q in state fingerprint", parts[i]))
+		}
+		ets[et] = true
+	}
+	sorted := make([]int, 0, len(ets))
+	for et := range ets {
+		sorted = append(sorted, et)
+	}
+	sort.Ints(sorted)
+	clockRank := sort.SearchInts(sorted, EVENT_CLOCK)
+	//------------------------------------------------------------
+	// replace
+	for i := 1; i < len(parts); i += 2 {
+		et, _ := strconv.Atoi(parts[i])
+		parts[i] = fmt.Sprintf("e%d", sort.SearchInts(sorted, et)-clockRank)
+	}
+	return strings.Join(parts, "")
+}
+
 //////////////////////////////////////////////////////////////
 // EOF
 //////////////////////////////////////////////////////////////
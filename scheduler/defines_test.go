@@ -0,0 +1,98 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the times in state fingerprints
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package scheduler
+
+import (
+	. "github.com/peermodel/simulator/config"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// relative fingerprints at the given clocks; the previous values are restored by the returned fu
+func relativeClocks(clock int, eventClock int, systemTtl int) func() {
+	prevClock, prevEventClock, prevTtl, prevFlag := CLOCK, EVENT_CLOCK, SYSTEM_TTL, RELATIVE_TIME_FINGERPRINT_FLAG
+	CLOCK, EVENT_CLOCK, SYSTEM_TTL, RELATIVE_TIME_FINGERPRINT_FLAG = clock, eventClock, systemTtl, true
+	return func() {
+		CLOCK, EVENT_CLOCK, SYSTEM_TTL, RELATIVE_TIME_FINGERPRINT_FLAG = prevClock, prevEventClock, prevTtl, prevFlag
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+func TestTimeFingerprint(t *testing.T) {
+	defer relativeClocks(10, 0, 100)()
+	tests := []struct {
+		t        int
+		expected string
+	}{
+		{15, "5"},
+		{10, "0"},
+		{3, "0"},
+		{100, "ttl"},
+		{INFINITE, "ttl"},
+	}
+	for _, test := range tests {
+		if got := TimeFingerprint(test.t); test.expected != got {
+			t.Errorf("t=%d: %s expected, got %s", test.t, test.expected, got)
+		}
+	}
+	// - absolute outside of a state fingerprint
+	RELATIVE_TIME_FINGERPRINT_FLAG = false
+	if got := TimeFingerprint(15); "15" != got {
+		t.Errorf("absolute time 15 expected, got %s", got)
+	}
+}
+
+//------------------------------------------------------------
+// event times are replaced by their rank relative to the event clock
+func TestRankEventTimes(t *testing.T) {
+	restore := relativeClocks(0, 7, 100)
+	fp1 := RankEventTimes("c1@" + EventTimeFingerprint(7) + ", c2@" + EventTimeFingerprint(3) + ", c3@" + EventTimeFingerprint(-1))
+	restore()
+	if "c1@e0, c2@e-1, c3@-" != fp1 {
+		t.Fatalf("ranked event times expected, got %s", fp1)
+	}
+	// - the same order at other event times gives the same fingerprint
+	restore = relativeClocks(0, 20, 100)
+	fp2 := RankEventTimes("c1@" + EventTimeFingerprint(20) + ", c2@" + EventTimeFingerprint(11) + ", c3@" + EventTimeFingerprint(-1))
+	restore()
+	if fp1 != fp2 {
+		t.Fatalf("equal fingerprints expected:\n%s\n%s", fp1, fp2)
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
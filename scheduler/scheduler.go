@@ -21,6 +21,7 @@
 //------------------------------------------------------------
 // "Scheduler" data type & logic
 // - insert only slots whose time <= system ttl
+// - priority queue: insert, cancel and pick the next slot in O(log n)
 //------------------------------------------------------------
 //////////////////////////////////////////////////////////////
 // Code Review: 2021 Apr, Eva Maria Kuehn
@@ -32,7 +33,10 @@ import (
 	. "github.com/peermodel/simulator/config"
 	. "github.com/peermodel/simulator/debug"
 	. "github.com/peermodel/simulator/helpers"
+	"container/heap"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 //////////////////////////////////////////////////////////////
@@ -40,10 +44,43 @@ import (
 //////////////////////////////////////////////////////////////
 
 //------------------------------------------------------------
-// scheduler is a sorted slice of slots
-// - sorted by slot time
-// - nb: the index is *not* the slot time!
-type Scheduler []*Slot
+// scheduler is a priority queue of slots
+// - ordered by slot time; slots with the same time: the one inserted last comes first
+// - every slot has a key (see Slot.Key), by which it can be cancelled directly
+// - copy on write: copies share the queue until one of them changes it; a copy that is no longer used
+//   must be released (see Release)
+// - nb: all methods that change the scheduler return the changed scheduler (value semantics)
+// - migration from the former slice type ([]*Slot):
+// -- len(scheduler) -> scheduler.Len()
+// -- scheduler[0] -> scheduler.First()
+// -- range scheduler, scheduler[i] -> scheduler.Slots(), ie all slots in the order in which they will be treated
+// -- the slots must not be changed or re-sorted in place; use SortedInsert, Cancel and Reschedule
+type Scheduler struct {
+	q *slotQueue
+}
+
+//------------------------------------------------------------
+// binary heap of slot handles
+type slotQueue struct {
+	handles []*slotHandle
+	// handles per slot key
+	byKey map[string][]*slotHandle
+	// insertion counter
+	next int
+	// number of further schedulers that share this queue
+	refs int
+}
+
+//------------------------------------------------------------
+// position of a slot in the queue
+type slotHandle struct {
+	slot *Slot
+	key  string
+	// insertion number
+	seq int
+	// index in the heap
+	index int
+}
 
 //////////////////////////////////////////////////////////////
 // constructor
@@ -52,7 +89,16 @@ type Scheduler []*Slot
 //------------------------------------------------------------
 // create new scheduler
 func NewScheduler() Scheduler {
-	return Scheduler{}
+	return Scheduler{q: newSlotQueue()}
+}
+
+//------------------------------------------------------------
+// private fu
+func newSlotQueue() *slotQueue {
+	q := new(slotQueue)
+	q.handles = []*slotHandle{}
+	q.byKey = map[string][]*slotHandle{}
+	return q
 }
 
 //////////////////////////////////////////////////////////////
@@ -61,61 +107,150 @@ func NewScheduler() Scheduler {
 
 //------------------------------------------------------------
 // shallow copy
-// - nb: slots can be shared as they are never changed
+// - nb: the queue is shared until it is changed, slots can be shared as they are never changed
 func (scheduler Scheduler) Copy() Scheduler {
-	// create new slice of same length and capacity
-	newScheduler := make(Scheduler, len(scheduler), cap(scheduler))
-	// copy
-	copy(newScheduler, scheduler)
-	// return
-	return newScheduler
+	if nil != scheduler.q {
+		scheduler.q.refs++
+	}
+	return scheduler
+}
+
+//------------------------------------------------------------
+// the scheduler is no longer used, eg its status was discarded: it no longer shares its queue
+// - so that the other schedulers that share the queue need not copy it when they change it
+// - returns the empty scheduler, which is to be assigned to the released one
+func (scheduler Scheduler) Release() Scheduler {
+	if nil != scheduler.q && 0 < scheduler.q.refs {
+		scheduler.q.refs--
+	}
+	return Scheduler{}
+}
+
+//------------------------------------------------------------
+// number of slots
+func (scheduler Scheduler) Len() int {
+	if nil == scheduler.q {
+		return 0
+	}
+	return len(scheduler.q.handles)
+}
+
+//------------------------------------------------------------
+// next slot, or nil if the scheduler is empty
+func (scheduler Scheduler) First() *Slot {
+	if 0 == scheduler.Len() {
+		return nil
+	}
+	return scheduler.q.handles[0].slot
+}
+
+//------------------------------------------------------------
+// all slots in the order in which they will be treated
+func (scheduler Scheduler) Slots() []*Slot {
+	if 0 == scheduler.Len() {
+		return []*Slot{}
+	}
+	handles := append([]*slotHandle{}, scheduler.q.handles...)
+	sort.Slice(handles, func(i, j int) bool { return handles[i].before(handles[j]) })
+	slots := make([]*Slot, len(handles))
+	for i, h := range handles {
+		slots[i] = h.slot
+	}
+	return slots
 }
 
 //------------------------------------------------------------
 // sorted insert
-// - cf: https://play.golang.org/p/iFzojVHSpq
+// - nb: the slot comes before all slots with the same time
 func (scheduler Scheduler) SortedInsert(slot *Slot) Scheduler {
-	//------------------------------------------------------------
-	// search for index where element shall be inserted
-	i := 0
-	for _, nextSlot := range scheduler {
-		if slot.Time <= nextSlot.Time {
-			break
-		}
-		i++
+	scheduler = scheduler.writable()
+	q := scheduler.q
+	h := &slotHandle{slot: slot, key: slot.Key(), seq: q.next}
+	q.next++
+	q.byKey[h.key] = append(q.byKey[h.key], h)
+	heap.Push(q, h)
+	return scheduler
+}
+
+//------------------------------------------------------------
+// remove the first slot with the given key (ie the one that would be treated first)
+// - nothing is done, if there is none
+func (scheduler Scheduler) Cancel(key string) Scheduler {
+	h := scheduler.firstWithKey(key)
+	if nil == h {
+		return scheduler
 	}
+	scheduler = scheduler.writable()
+	scheduler.q.remove(scheduler.q.handles[h.index])
+	return scheduler
+}
+
+//------------------------------------------------------------
+// set the time of the first slot with the given key
+// - the slot keeps its rank among slots with the same time
+// - returns false, if there is no such slot
+func (scheduler Scheduler) Reschedule(key string, time int) (Scheduler, bool) {
+	h := scheduler.firstWithKey(key)
+	if nil == h {
+		return scheduler, false
+	}
+	scheduler = scheduler.writable()
+	h = scheduler.q.handles[h.index]
+	// slots are shared by copies -> replace it
+	newSlot := *h.slot
+	newSlot.Time = time
+	h.slot = &newSlot
+	heap.Fix(scheduler.q, h.index)
+	return scheduler, true
+}
+
+//------------------------------------------------------------
+// replace the system ttl slot by one with the given time
+// - eg used if the system ttl was configured after the status had been created
+func (scheduler Scheduler) ResetSttlSlot(systemTtl int) Scheduler {
 	//------------------------------------------------------------
-	// create one dummy place at the end
-	scheduler = append(scheduler, nil)
-	//------------------------------------------------------------
-	// shift everything to the right after the insert place
-	// - copy(dest, source)
-	copy(scheduler[i+1:], scheduler[i:])
-	//------------------------------------------------------------
-	// insert element
-	scheduler[i] = slot
+	// remove the old sttl slot(s)
+	for nil != scheduler.firstWithKey(STTL.String()) {
+		scheduler = scheduler.Cancel(STTL.String())
+	}
 	//------------------------------------------------------------
-	// return
-	return scheduler
+	// insert the new one
+	// - nb: sorted insert puts it before user slots with the same time
+	return scheduler.SortedInsert(NewSttlSlot(systemTtl))
+}
+
+//------------------------------------------------------------
+// canonical description of all slots for model checking
+// - slots with the same time may be in any order -> sort their fingerprints
+// - relative times: user slots at or after the system ttl never fire (the sttl slot comes first) -> skipped,
+//   otherwise every fired link with a ttl would make a loop state look new
+func (scheduler Scheduler) Fingerprint() string {
+	fps := []string{}
+	for i := 0; i < scheduler.Len(); i++ {
+		slot := scheduler.q.handles[i].slot
+		if RELATIVE_TIME_FINGERPRINT_FLAG && USER_SLOT == slot.Type && slot.Time >= SYSTEM_TTL {
+			continue
+		}
+		fps = append(fps, slot.Fingerprint())
+	}
+	sort.Strings(fps)
+	return strings.Join(fps, ";")
 }
 
 //------------------------------------------------------------
 // return next slot provided that it is "ripe" and remove it from scheduler
 // - return nil if no slot is there or no slot is ripe
-// - in any case return also the (changed) scheduler list
+// - in any case return also the (changed) scheduler
 // - private fu
 func (scheduler Scheduler) pickFirstSlotIfRipe() (Scheduler, *Slot) {
 	//------------------------------------------------------------
-	// is there a first slot (ie at index 0) whose time is ripe (ie has reached CLOCK)?
-	if 0 < len(scheduler) && CLOCK >= scheduler[0].Time {
-		//------------------------------------------------------------
-		// get the slot
-		slot := scheduler[0]
+	// is there a first slot whose time is ripe (ie has reached CLOCK)?
+	if slot := scheduler.First(); nil != slot && CLOCK >= slot.Time {
 		//------------------------------------------------------------
 		// remove the slot and return everything
-		// return append(scheduler[:0], scheduler[1:]...), slot
-		// fmt.Println(fmt.Sprintf("BEFORE = %d, AFTER = %d", len(scheduler), len(scheduler[1:]))) // DEBUG
-		return scheduler[1:], slot
+		scheduler = scheduler.writable()
+		scheduler.q.remove(scheduler.q.handles[0])
+		return scheduler, slot
 	}
 	//------------------------------------------------------------
 	// no slot found
@@ -129,7 +264,6 @@ func (scheduler Scheduler) pickFirstSlotIfRipe() (Scheduler, *Slot) {
 // - (changed) scheduler with ripe slot removed
 // - flag whether we shall stop, because STTL was reached
 func (scheduler Scheduler) GetAndRemoveNextRipeSlot() (*Slot, Scheduler, bool) {
-	// fmt.Println(fmt.Sprintf("XBEFORE = %d", len(scheduler))) // DEBUG
 	//------------------------------------------------------------
 	// return vars
 	stopFlag := false
@@ -168,6 +302,110 @@ func (scheduler Scheduler) GetAndRemoveNextRipeSlot() (*Slot, Scheduler, bool) {
 	return slot, scheduler, stopFlag
 }
 
+//------------------------------------------------------------
+// first slot with the given key, or nil
+// - private fu
+func (scheduler Scheduler) firstWithKey(key string) *slotHandle {
+	if nil == scheduler.q {
+		return nil
+	}
+	var first *slotHandle = nil
+	for _, h := range scheduler.q.byKey[key] {
+		if nil == first || h.before(first) {
+			first = h
+		}
+	}
+	return first
+}
+
+//------------------------------------------------------------
+// scheduler whose queue may be changed: copy the queue if it is shared
+// - private fu
+func (scheduler Scheduler) writable() Scheduler {
+	if nil == scheduler.q {
+		return NewScheduler()
+	}
+	if 0 < scheduler.q.refs {
+		scheduler.q.refs--
+		return Scheduler{q: scheduler.q.copy()}
+	}
+	return scheduler
+}
+
+//============================================================
+// slot queue
+//============================================================
+
+//------------------------------------------------------------
+// copy with new handles (the slots are shared)
+func (q *slotQueue) copy() *slotQueue {
+	newQ := newSlotQueue()
+	newQ.next = q.next
+	newQ.handles = make([]*slotHandle, len(q.handles), cap(q.handles))
+	for i, h := range q.handles {
+		newH := *h
+		newQ.handles[i] = &newH
+		newQ.byKey[h.key] = append(newQ.byKey[h.key], &newH)
+	}
+	return newQ
+}
+
+//------------------------------------------------------------
+func (q *slotQueue) remove(h *slotHandle) {
+	heap.Remove(q, h.index)
+	l := q.byKey[h.key]
+	for i, h2 := range l {
+		if h2 == h {
+			l = append(l[:i], l[i+1:]...)
+			break
+		}
+	}
+	if 0 == len(l) {
+		delete(q.byKey, h.key)
+	} else {
+		q.byKey[h.key] = l
+	}
+}
+
+//------------------------------------------------------------
+// heap.Interface
+func (q *slotQueue) Len() int {
+	return len(q.handles)
+}
+
+func (q *slotQueue) Less(i, j int) bool {
+	return q.handles[i].before(q.handles[j])
+}
+
+func (q *slotQueue) Swap(i, j int) {
+	q.handles[i], q.handles[j] = q.handles[j], q.handles[i]
+	q.handles[i].index = i
+	q.handles[j].index = j
+}
+
+func (q *slotQueue) Push(x interface{}) {
+	h := x.(*slotHandle)
+	h.index = len(q.handles)
+	q.handles = append(q.handles, h)
+}
+
+func (q *slotQueue) Pop() interface{} {
+	n := len(q.handles)
+	h := q.handles[n-1]
+	q.handles[n-1] = nil
+	q.handles = q.handles[:n-1]
+	return h
+}
+
+//------------------------------------------------------------
+// is h treated before h2?
+func (h *slotHandle) before(h2 *slotHandle) bool {
+	if h.slot.Time != h2.slot.Time {
+		return h.slot.Time < h2.slot.Time
+	}
+	return h.seq > h2.seq
+}
+
 //////////////////////////////////////////////////////////////
 // helpers
 //////////////////////////////////////////////////////////////
@@ -215,8 +453,8 @@ func (scheduler Scheduler) ToString(ind int) string {
 	// add header
 	s = fmt.Sprintf("%sScheduler Slots at t=%d:\n", s, CLOCK)
 	// add all slots:
-	for i := 0; i < len(scheduler); i++ {
-		s = fmt.Sprintf("%s%s\n", s, scheduler[i].ToString(ind+TAB))
+	for _, slot := range scheduler.Slots() {
+		s = fmt.Sprintf("%s%s\n", s, slot.ToString(ind+TAB))
 	}
 	// return
 	return s
@@ -0,0 +1,154 @@
+//////////////////////////////////////////////////////////////
+// Peer Model Tool Chain
+// Copyright (C) 2021 Eva Maria Kuehn
+//////////////////////////////////////////////////////////////
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+//////////////////////////////////////////////////////////////
+
+//////////////////////////////////////////////////////////////
+// System: Peer Model State Machine
+// Author: eva Kühn
+// Date: 2021
+//------------------------------------------------------------
+// tests of the scheduler: order of the slots, copy on write and release
+//------------------------------------------------------------
+//////////////////////////////////////////////////////////////
+
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//////////////////////////////////////////////////////////////
+// test data
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// user slot with a key
+type testSlot struct {
+	key string
+}
+
+func (s *testSlot) Copy() interface{}         { return &testSlot{key: s.key} }
+func (s *testSlot) String() string            { return s.key }
+func (s *testSlot) Fingerprint() string       { return s.key }
+func (s *testSlot) Key() string               { return s.key }
+func (s *testSlot) Snapshot() ([]byte, error) { return []byte(s.key), nil }
+func (s *testSlot) IsEmpty() bool             { return false }
+func (s *testSlot) Print(ind int)             {}
+func (s *testSlot) Println(ind int)           {}
+
+//////////////////////////////////////////////////////////////
+// helpers
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// the slots in the order in which they will be treated, eg "a@3 STTL@10"
+func slotOrder(scheduler Scheduler) string {
+	ss := []string{}
+	for _, slot := range scheduler.Slots() {
+		ss = append(ss, fmt.Sprintf("%s@%d", slot.Key(), slot.Time))
+	}
+	return strings.Join(ss, " ")
+}
+
+//------------------------------------------------------------
+func insertTestSlot(scheduler Scheduler, key string, time int) Scheduler {
+	return scheduler.SortedInsert(NewUserSlot(time, &testSlot{key: key}))
+}
+
+//////////////////////////////////////////////////////////////
+// tests
+//////////////////////////////////////////////////////////////
+
+//------------------------------------------------------------
+// by time; with the same time the one inserted last comes first
+func TestSchedulerOrder(t *testing.T) {
+	scheduler := NewScheduler().SortedInsert(NewSttlSlot(10))
+	for _, s := range []struct {
+		key  string
+		time int
+	}{{"a", 5}, {"b", 3}, {"c", 5}, {"d", 1}, {"e", 10}} {
+		scheduler = insertTestSlot(scheduler, s.key, s.time)
+	}
+	if expected, got := "d@1 b@3 c@5 a@5 e@10 STTL@10", slotOrder(scheduler); expected != got {
+		t.Fatalf("%s expected, got %s", expected, got)
+	}
+	scheduler = scheduler.Cancel("c")
+	scheduler, ok := scheduler.Reschedule("d", 4)
+	if !ok {
+		t.Fatalf("d expected")
+	}
+	if _, ok := scheduler.Reschedule("x", 4); ok {
+		t.Fatalf("no x expected")
+	}
+	if expected, got := "b@3 d@4 a@5 e@10 STTL@10", slotOrder(scheduler); expected != got {
+		t.Fatalf("%s expected, got %s", expected, got)
+	}
+	if 5 != scheduler.Len() || "b" != scheduler.First().Key() {
+		t.Fatalf("5 slots and b first expected, got %d", scheduler.Len())
+	}
+}
+
+//------------------------------------------------------------
+// a copy shares the queue until one of them changes it
+func TestSchedulerCopyOnWrite(t *testing.T) {
+	s1 := insertTestSlot(insertTestSlot(NewScheduler(), "a", 1), "b", 2)
+	s2 := s1.Copy()
+	if s1.q != s2.q {
+		t.Fatalf("shared queue expected")
+	}
+	s2 = insertTestSlot(s2, "c", 0)
+	s2, _ = s2.Reschedule("a", 5)
+	s2 = s2.Cancel("b")
+	if expected, got := "a@1 b@2", slotOrder(s1); expected != got {
+		t.Fatalf("the original must not change: %s expected, got %s", expected, got)
+	}
+	if expected, got := "c@0 a@5", slotOrder(s2); expected != got {
+		t.Fatalf("%s expected, got %s", expected, got)
+	}
+	// - the changes of the original do not affect the copy either
+	s3 := s1.Copy()
+	if s1 = s1.Cancel("a"); "b@2" != slotOrder(s1) {
+		t.Fatalf("b@2 expected, got %s", slotOrder(s1))
+	}
+	if expected, got := "a@1 b@2", slotOrder(s3); expected != got {
+		t.Fatalf("the copy must not change: %s expected, got %s", expected, got)
+	}
+}
+
+//------------------------------------------------------------
+// after the copy is released, the original changes its queue in place
+func TestSchedulerRelease(t *testing.T) {
+	s1 := insertTestSlot(NewScheduler(), "a", 1)
+	s2 := s1.Copy()
+	q := s1.q
+	if s2 = s2.Release(); nil != s2.q {
+		t.Fatalf("empty scheduler expected")
+	}
+	if s1 = insertTestSlot(s1, "b", 2); q != s1.q {
+		t.Fatalf("no copy of the queue expected")
+	}
+	// - a released scheduler is empty and can be used again
+	if s2 = insertTestSlot(s2, "c", 3); "c@3" != slotOrder(s2) || "a@1 b@2" != slotOrder(s1) {
+		t.Fatalf("independent schedulers expected: %s, %s", slotOrder(s1), slotOrder(s2))
+	}
+}
+
+//////////////////////////////////////////////////////////////
+// EOF
+//////////////////////////////////////////////////////////////
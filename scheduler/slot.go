@@ -125,6 +125,26 @@ func (slot *Slot) Copy() *Slot {
 	return newSlot
 }
 
+//------------------------------------------------------------
+// key for the direct cancellation of the slot (see Scheduler.Cancel)
+func (slot *Slot) Key() string {
+	if USER_SLOT == slot.Type {
+		return slot.UserSlot.Key()
+	}
+	return slot.Type.String()
+}
+
+//------------------------------------------------------------
+// canonical description of the slot for model checking
+// - the user slot must not contain volatile ids (see ISlot)
+func (slot *Slot) Fingerprint() string {
+	s := fmt.Sprintf("%s:%s", TimeFingerprint(slot.Time), slot.Type)
+	if USER_SLOT == slot.Type {
+		s = fmt.Sprintf("%s:%s", s, slot.UserSlot.Fingerprint())
+	}
+	return s
+}
+
 //////////////////////////////////////////////////////////////
 // debug
 //////////////////////////////////////////////////////////////
@@ -40,6 +40,12 @@ type ISlot interface {
 	Copy() interface{}
 	// for debug
 	String() string
+	// for model checking: canonical description without volatile ids (see Status.StateFingerprint)
+	Fingerprint() string
+	// for the scheduler: key by which the slot can be cancelled; several slots may have the same key
+	Key() string
+	// for the snapshot of the status (see IMetaContext)
+	Snapshot() ([]byte, error)
 	// require also the IPrint interface ...
 	IsEmpty() bool
 	Print(ind int)